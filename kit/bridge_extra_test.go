@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -189,9 +190,9 @@ func TestSouthBridgeCreateSenderConn(t *testing.T) {
 	}
 
 	carrier := newEnvelopeCarrier(outgoingCarrier, "s1", "origin", "target")
-	called := false
+	var called atomic.Bool
 	conn := sb.createSenderConn(carrier, 0, func(_ *envelopeCarrier) {
-		called = true
+		called.Store(true)
 	})
 
 	if err := conn.handleCarrier(sb, &envelopeCarrier{Kind: outgoingCarrier, Data: &carrierData{}}); err != nil {
@@ -203,7 +204,7 @@ func TestSouthBridgeCreateSenderConn(t *testing.T) {
 
 	deadline := time.After(200 * time.Millisecond)
 	for {
-		if called && sb.getConn("s1") == nil {
+		if called.Load() && sb.getConn("s1") == nil {
 			break
 		}
 		select {
@@ -282,9 +283,10 @@ func TestSouthBridgeOnMessage(t *testing.T) {
 	}
 
 	carrier := newEnvelopeCarrier(outgoingCarrier, "sid", "origin", "target")
-	called := false
+	// the carriers are handled by the goroutine of the session.
+	var called atomic.Bool
 	conn := sb.createSenderConn(carrier, 0, func(_ *envelopeCarrier) {
-		called = true
+		called.Store(true)
 	})
 
 	var buf bytes.Buffer
@@ -293,7 +295,7 @@ func TestSouthBridgeOnMessage(t *testing.T) {
 
 	deadline := time.After(200 * time.Millisecond)
 	for {
-		if called {
+		if called.Load() {
 			break
 		}
 		select {
@@ -64,16 +64,32 @@ type northBridge struct {
 
 	wg   *sync.WaitGroup
 	eh   ErrHandlerFunc
+	pm   ErrorMessage
 	c    map[string]Contract
 	gw   Gateway
 	sb   *southBridge
 	cd   ConnDelegate
 	elog *endpointLog
+	// name identifies the gateway in the health reports and the connection registry.
+	name string
+	// reg is nil if the connection registry is disabled.
+	reg *connRegistry
+	// rec is nil if the recorder is disabled.
+	rec *recorder
+
+	// streamsMtx protects streams, which keeps the in-flight contexts of the stream
+	// connections, so we can cancel them when the connection is closed.
+	streamsMtx sync.Mutex
+	streams    map[uint64]map[*Context]struct{}
 }
 
 var _ GatewayDelegate = (*northBridge)(nil)
 
 func (n *northBridge) OnOpen(c Conn) {
+	if n.reg != nil {
+		n.reg.register(n, c)
+	}
+
 	if n.cd == nil {
 		return
 	}
@@ -82,6 +98,20 @@ func (n *northBridge) OnOpen(c Conn) {
 }
 
 func (n *northBridge) OnClose(connID uint64) {
+	n.cancelStream(connID)
+
+	if n.tr != nil {
+		n.tr.unsubscribeConn(n, connID)
+	}
+
+	if n.cr != nil {
+		n.cr.unbindConn(n, connID)
+	}
+
+	if n.reg != nil {
+		n.reg.unregister(n, connID)
+	}
+
 	if n.cd == nil {
 		return
 	}
@@ -89,9 +119,89 @@ func (n *northBridge) OnClose(connID uint64) {
 	n.cd.OnClose(connID)
 }
 
+func (n *northBridge) trackStream(ctx *Context) {
+	connID := ctx.conn.ConnID()
+
+	n.streamsMtx.Lock()
+	if n.streams == nil {
+		n.streams = map[uint64]map[*Context]struct{}{}
+	}
+
+	inFlight, ok := n.streams[connID]
+	if !ok {
+		inFlight = map[*Context]struct{}{}
+		n.streams[connID] = inFlight
+	}
+
+	inFlight[ctx] = struct{}{}
+	n.streamsMtx.Unlock()
+}
+
+func (n *northBridge) untrackStream(ctx *Context) {
+	connID := ctx.conn.ConnID()
+
+	n.streamsMtx.Lock()
+	inFlight := n.streams[connID]
+	delete(inFlight, ctx)
+
+	if len(inFlight) == 0 {
+		delete(n.streams, connID)
+	}
+	n.streamsMtx.Unlock()
+}
+
+// cancelStream cancels the context of all the in-flight requests of the connection.
+func (n *northBridge) cancelStream(connID uint64) {
+	n.streamsMtx.Lock()
+	for ctx := range n.streams[connID] {
+		ctx.cancel()
+	}
+
+	delete(n.streams, connID)
+	n.streamsMtx.Unlock()
+}
+
+// cancelStreams cancels the context of all the in-flight requests of all the stream
+// connections. This is called when the EdgeServer is draining.
+func (n *northBridge) cancelStreams() {
+	n.streamsMtx.Lock()
+	for connID, inFlight := range n.streams {
+		for ctx := range inFlight {
+			ctx.cancel()
+		}
+
+		delete(n.streams, connID)
+	}
+	n.streamsMtx.Unlock()
+}
+
 func (n *northBridge) OnMessage(conn Conn, msg []byte) {
 	n.wg.Add(1)
 
+	ctx := n.acquireCtx(conn)
+	ctx.sb = n.sb
+	ctx.nb = n
+	ctx.rawData = msg
+
+	// Only stream connections could be closed while their requests are in-flight.
+	stream := conn.Stream()
+	if stream {
+		n.trackStream(ctx)
+	}
+
+	n.handle(ctx, msg)
+
+	if stream {
+		n.untrackStream(ctx)
+	}
+
+	n.releaseCtx(ctx)
+	n.wg.Done()
+}
+
+func (n *northBridge) handle(ctx *Context, msg []byte) {
+	defer n.recoverPanic(ctx)
+
 	logEnabled := n.elog != nil && n.elog.w != nil
 
 	var start int64
@@ -99,10 +209,6 @@ func (n *northBridge) OnMessage(conn Conn, msg []byte) {
 		start = utils.NanoTime()
 	}
 
-	ctx := n.acquireCtx(conn)
-	ctx.sb = n.sb
-	ctx.rawData = msg
-
 	arg, err := n.gw.Dispatch(ctx, msg)
 	switch {
 	default:
@@ -118,15 +224,55 @@ func (n *northBridge) OnMessage(conn Conn, msg []byte) {
 			break
 		}
 
-		ctx.execute(arg, c)
+		if n.rec != nil {
+			n.record(ctx, arg, c)
+		} else {
+			ctx.execute(arg, c)
+		}
 
 		if logEnabled {
 			writeEndpointLog(n.elog, ctx, time.Duration(utils.NanoTime()-start))
 		}
 	}
+}
 
-	n.releaseCtx(ctx)
-	n.wg.Done()
+// record executes the contract while the recorder records the request.
+func (n *northBridge) record(ctx *Context, arg ExecuteArg, c Contract) {
+	ctx.
+		setRoute(arg.Route).
+		setServiceName(arg.ServiceName).
+		setContractID(arg.ContractID)
+
+	ctx.rec = n.rec.start(ctx, n.name)
+	if ctx.rec == nil {
+		ctx.execute(arg, c)
+
+		return
+	}
+
+	defer func() {
+		err := n.rec.finish(ctx, ctx.rec)
+		if err != nil {
+			n.eh(ctx, err)
+		}
+	}()
+
+	// the panics are recovered before the recording is finished, so it is not lost.
+	defer n.recoverPanic(ctx)
+
+	ctx.execute(arg, c)
+}
+
+// recoverPanic recovers the panic of the handlers. The panic is reported to the ErrHandlerFunc
+// and is set as the error of the Context, and the client receives the panic message.
+// It MUST be called directly by defer.
+func (n *northBridge) recoverPanic(ctx *Context) {
+	if r := recover(); r != nil {
+		err := newPanicError(r)
+		n.eh(ctx, err)
+		ctx.replyPanic(n.pm)
+		ctx.Error(err)
+	}
 }
 
 var (
@@ -138,6 +284,8 @@ var (
 	ErrDecodeIncomingContainerFailed = errors.New("decoding the incoming container failed")
 	ErrDispatchFailed                = errors.New("dispatch failed")
 	ErrPreflight                     = errors.New("preflight request")
+	ErrRequestTimeout                = errors.New("request timeout")
+	ErrHandlerPanic                  = errors.New("handler panic")
 )
 
 // These are just to silence the linter.
@@ -41,14 +41,21 @@ type southBridge struct {
 	id string
 	wg *sync.WaitGroup
 	eh ErrHandlerFunc
+	pm ErrorMessage
 	c  map[string]Contract
 	cb Cluster
 	tp TracePropagator
+	cc CarrierCodec
 	l  Logger
+	// reg is nil if the connection registry is disabled.
+	reg *connRegistry
 
 	inProgressMtx utils.SpinLock
 	inProgress    map[string]*clusterConn
 	msgFactories  map[string]MessageFactoryFunc
+
+	// pushes keeps the order of the pushed messages of each local connection.
+	pushes serialQueues[connOwner]
 }
 
 var _ ClusterDelegate = (*southBridge)(nil)
@@ -67,36 +74,49 @@ func (sb *southBridge) Shutdown(ctx context.Context) error {
 }
 
 func (sb *southBridge) OnMessage(data []byte) {
-	carrier := &envelopeCarrier{}
-
-	err := carrier.FromJSON(data)
+	carrier, err := decodeEnvelopeCarrier(data)
 	if err != nil {
 		sb.eh(nil, fmt.Errorf("failed to decode envelope carrier: %w", err))
 
 		return
 	}
 
-	sb.wg.Add(1)
+	switch carrier.Kind {
+	case outgoingCarrier, eofCarrier:
+		conn := sb.getConn(carrier.SessionID)
+		if conn == nil {
+			return
+		}
 
-	go sb.handleCarrierMessage(carrier)
+		// The replies of a session must be handled in the same order the Cluster delivers
+		// them, otherwise the EOF carrier could close the session before the last reply.
+		// They are queued per session, so a slow consumer does not block the Cluster.
+		sb.wg.Add(1)
+		conn.enqueue(sb, carrier)
+	case pushCarrier:
+		// The pushes are queued per connection, in the order the Cluster delivers them.
+		sb.onPushMessage(carrier)
+	default:
+		sb.wg.Add(1)
+		go sb.handleCarrierMessage(carrier)
+	}
 }
 
 func (sb *southBridge) handleCarrierMessage(carrier *envelopeCarrier) {
 	defer sb.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			sb.eh(nil, newPanicError(r))
+		}
+	}()
 
 	switch carrier.Kind {
 	case incomingCarrier:
 		sb.onIncomingMessage(carrier)
-	default:
-		conn := sb.getConn(carrier.SessionID)
-		if conn == nil {
-			return
-		}
-
-		err := conn.handleCarrier(sb, carrier)
-		if err != nil {
-			sb.eh(nil, err)
-		}
+	case topicCarrier:
+		sb.onTopicMessage(carrier)
+	case kickCarrier, connPushCarrier:
+		sb.onConnMessage(carrier)
 	}
 }
 
@@ -150,6 +170,12 @@ func (sb *southBridge) getConn(sessionID string) *clusterConn {
 	return conn
 }
 
+func (sb *southBridge) deleteConn(sessionID string) {
+	sb.inProgressMtx.Lock()
+	delete(sb.inProgress, sessionID)
+	sb.inProgressMtx.Unlock()
+}
+
 func (sb *southBridge) onIncomingMessage(carrier *envelopeCarrier) {
 	conn := sb.createTargetConn(carrier)
 	ctx := sb.acquireCtx(conn)
@@ -188,7 +214,7 @@ func (sb *southBridge) onIncomingMessage(carrier *envelopeCarrier) {
 	if err != nil {
 		sb.eh(ctx, err)
 	} else {
-		ctx.execute(arg, c)
+		sb.execute(ctx, arg, c)
 	}
 
 	ec := newEnvelopeCarrier(
@@ -200,7 +226,7 @@ func (sb *southBridge) onIncomingMessage(carrier *envelopeCarrier) {
 
 	ecBuf := buf.GetCap(CodecDefaultBufferSize)
 
-	err = defaultMessageCodec.Encode(ec, ecBuf)
+	err = sb.encodeCarrier(ec, ecBuf)
 	if err != nil {
 		sb.eh(ctx, err)
 	} else {
@@ -214,20 +240,130 @@ func (sb *southBridge) onIncomingMessage(carrier *envelopeCarrier) {
 	sb.releaseCtx(ctx)
 }
 
+// onPushMessage queues the message for the local connections which are bound to the keys
+// of the carrier. The writes to each connection are in the order of the carriers.
+func (sb *southBridge) onPushMessage(carrier *envelopeCarrier) {
+	if sb.cr == nil || carrier.Data == nil {
+		return
+	}
+
+	for _, key := range carrier.Data.ConnKeys {
+		route, ok := sb.cr.route(key)
+		if !ok {
+			continue
+		}
+
+		sb.wg.Add(1)
+		sb.pushes.push(route.owner, func() {
+			defer sb.wg.Done()
+
+			sb.push(route.conn, carrier.Data)
+		})
+	}
+}
+
+func (sb *southBridge) push(conn Conn, data *carrierData) {
+	ctx := sb.acquireCtx(conn)
+	defer sb.releaseCtx(ctx)
+
+	defer func() {
+		if r := recover(); r != nil {
+			sb.eh(ctx, newPanicError(r))
+		}
+	}()
+
+	ctx.Out().
+		SetHdrMap(data.Hdr).
+		SetMsg(sb.carrierMessage(data)).
+		Send()
+
+	if ctx.err != nil {
+		sb.eh(ctx, ctx.err)
+	}
+}
+
+// carrierMessage decodes the message of the carrier into its type, if this instance knows
+// the type, e.g., it is the input or output of a contract, so the gateway encodes it for the
+// connection. Otherwise, the message is sent as it is encoded in the carrier.
+func (sb *southBridge) carrierMessage(data *carrierData) Message {
+	f, ok := sb.msgFactories[data.MsgType]
+	if !ok {
+		return RawMessage(data.Msg)
+	}
+
+	msg := f()
+	if _, ok = msg.(RawMessage); ok {
+		return RawMessage(data.Msg)
+	}
+
+	if err := UnmarshalMessageAs(carrierEncoding(msg), data.Msg, msg); err != nil {
+		return RawMessage(data.Msg)
+	}
+
+	return msg
+}
+
+// onTopicMessage delivers the message published by another instance to the local
+// subscribers of the topic.
+func (sb *southBridge) onTopicMessage(carrier *envelopeCarrier) {
+	if sb.tr == nil || carrier.Data == nil || carrier.Data.Topic == "" {
+		return
+	}
+
+	for _, conn := range sb.tr.subscribers(carrier.Data.Topic) {
+		ctx := sb.acquireCtx(conn)
+		ctx.Out().
+			SetHdrMap(carrier.Data.Hdr).
+			SetMsg(sb.carrierMessage(carrier.Data)).
+			Send()
+
+		if ctx.err != nil {
+			sb.eh(ctx, ctx.err)
+		}
+
+		sb.releaseCtx(ctx)
+	}
+}
+
+// execute runs the contract on behalf of the origin. Panics are recovered here, so
+// we can still send the EOF carrier to the origin and release the context.
+func (sb *southBridge) execute(ctx *Context, arg ExecuteArg, c Contract) {
+	defer func() {
+		if r := recover(); r != nil {
+			sb.eh(ctx, newPanicError(r))
+			ctx.replyPanic(sb.pm)
+		}
+	}()
+
+	ctx.execute(arg, c)
+}
+
+func (sb *southBridge) encodeCarrier(ec *envelopeCarrier, w *buf.Bytes) error {
+	if sb.cc == nil {
+		return JSONCarrierCodec.EncodeCarrier(ec, w)
+	}
+
+	return sb.cc.EncodeCarrier(ec, w)
+}
+
 func (sb *southBridge) sendMessage(carrier *envelopeCarrier) error {
+	err := sb.publish(carrier)
+	if err != nil {
+		sb.deleteConn(carrier.SessionID)
+	}
+
+	return err
+}
+
+// publish sends the carrier to its target, and it does not expect any reply.
+func (sb *southBridge) publish(carrier *envelopeCarrier) error {
 	ecBuf := buf.GetCap(CodecDefaultBufferSize)
 
-	err := defaultMessageCodec.Encode(carrier, ecBuf)
+	err := sb.encodeCarrier(carrier, ecBuf)
 	if err == nil {
 		err = sb.cb.Publish(carrier.TargetID, *ecBuf.Bytes())
 	}
 
-	if err != nil {
-		sb.inProgressMtx.Lock()
-		delete(sb.inProgress, carrier.SessionID)
-		sb.inProgressMtx.Unlock()
-	}
-
 	ecBuf.Release()
 
 	return err
@@ -272,15 +408,19 @@ func (sb *southBridge) genForwarderHandler(sel EdgeSelectorFunc) HandlerFunc {
 			target,
 		).FillWithContext(ctx)
 
+		// We create the connection before sending the carrier, otherwise the replies could
+		// arrive before we can route them to this context.
+		conn := sb.createSenderConn(carrier, ctx.rxt, sb.genCallback(ctx))
+
 		err = ctx.sb.sendMessage(carrier)
 		if err != nil {
+			conn.Cancel()
 			ctx.Error(err)
 			ctx.StopExecution()
 
 			return
 		}
 
-		conn := sb.createSenderConn(carrier, ctx.rxt, sb.genCallback(ctx))
 		select {
 		case <-conn.Done():
 			ctx.Error(conn.Err())
@@ -309,11 +449,24 @@ func (sb *southBridge) genCallback(ctx *Context) func(carrier *envelopeCarrier)
 		ctx.Out().
 			SetID(carrier.Data.EnvelopeID).
 			SetHdrMap(carrier.Data.Hdr).
-			SetMsg(RawMessage(carrier.Data.Msg)).
+			SetMsg(sb.replyMessage(carrier.Data)).
 			Send()
 	}
 }
 
+// replyMessage returns the message of the reply carrier. The proto messages are decoded,
+// so the gateway could encode them by the encoding negotiated with the client, and the
+// others are sent as they are.
+func (sb *southBridge) replyMessage(d *carrierData) Message {
+	if f, ok := sb.msgFactories[d.MsgType]; ok {
+		if m := f(); IsProtoMessage(m) && UnmarshalMessageAs(Proto, d.Msg, m) == nil {
+			return m
+		}
+	}
+
+	return RawMessage(d.Msg)
+}
+
 func (sb *southBridge) writeFunc(c *clusterConn, e *Envelope) error {
 	ec := newEnvelopeCarrier(
 		outgoingCarrier,
@@ -329,7 +482,7 @@ func (sb *southBridge) writeFunc(c *clusterConn, e *Envelope) error {
 
 	ecBuf := buf.GetCap(CodecDefaultBufferSize)
 
-	err := defaultMessageCodec.Encode(ec, ecBuf)
+	err := sb.encodeCarrier(ec, ecBuf)
 	if err != nil {
 		return err
 	}
@@ -355,6 +508,10 @@ type clusterConn struct {
 
 	carrierMtx sync.Mutex // serializes callback/eof handling
 
+	queueMtx sync.Mutex // protects queue and draining
+	queue    []*envelopeCarrier
+	draining bool
+
 	// target
 	serverID  string
 	sessionID string
@@ -368,6 +525,56 @@ type clusterConn struct {
 	callbackFn func(carrier *envelopeCarrier)
 }
 
+// enqueue appends the carrier to the queue of the session, and starts draining it if it is
+// not being drained yet. The carriers are handled in order, one at a time.
+func (c *clusterConn) enqueue(sb *southBridge, carrier *envelopeCarrier) {
+	c.queueMtx.Lock()
+	c.queue = append(c.queue, carrier)
+	if c.draining {
+		c.queueMtx.Unlock()
+
+		return
+	}
+
+	c.draining = true
+	c.queueMtx.Unlock()
+
+	go c.drain(sb)
+}
+
+func (c *clusterConn) drain(sb *southBridge) {
+	for {
+		c.queueMtx.Lock()
+		if len(c.queue) == 0 {
+			c.draining = false
+			c.queueMtx.Unlock()
+
+			return
+		}
+
+		carrier := c.queue[0]
+		c.queue[0] = nil
+		c.queue = c.queue[1:]
+		c.queueMtx.Unlock()
+
+		c.handleQueued(sb, carrier)
+	}
+}
+
+func (c *clusterConn) handleQueued(sb *southBridge, carrier *envelopeCarrier) {
+	defer sb.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			sb.eh(nil, newPanicError(r))
+		}
+	}()
+
+	err := c.handleCarrier(sb, carrier)
+	if err != nil {
+		sb.eh(nil, err)
+	}
+}
+
 func (c *clusterConn) handleCarrier(sb *southBridge, carrier *envelopeCarrier) error {
 	c.carrierMtx.Lock()
 	defer c.carrierMtx.Unlock()
@@ -389,9 +596,7 @@ func (c *clusterConn) handleCarrier(sb *southBridge, carrier *envelopeCarrier) e
 		}
 	case eofCarrier:
 		if sb != nil {
-			sb.inProgressMtx.Lock()
-			delete(sb.inProgress, c.sessionID)
-			sb.inProgressMtx.Unlock()
+			sb.deleteConn(c.sessionID)
 		}
 
 		if c.cf != nil {
@@ -473,4 +678,6 @@ func (c *clusterConn) Cancel() {
 var (
 	ErrSouthBridgeDisabled        = errors.New("south bridge is disabled")
 	ErrWritingToClusterConnection = errors.New("writing to cluster connection is not possible")
+	ErrInvalidCarrier             = errors.New("invalid envelope carrier")
+	ErrUnsupportedCarrierVersion  = errors.New("unsupported envelope carrier version")
 )
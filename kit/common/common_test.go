@@ -5,6 +5,11 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/errors"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 type testPayload struct {
@@ -102,3 +107,277 @@ func TestSimpleRPCContainers(t *testing.T) {
 		t.Fatalf("expected released outgoing payload to be nil, got %v", out.Payload)
 	}
 }
+
+func TestSimpleProtoRPCContainers(t *testing.T) {
+	out := SimpleOutgoingProtoRPC()
+	out.SetID("req-1")
+	out.SetHdr("x-trace", "trace-1")
+	out.InjectMessage(wrapperspb.String("alpha"))
+
+	data, err := out.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Release()
+
+	in := SimpleIncomingProtoRPC()
+	if err := in.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if in.GetID() != "req-1" || in.GetHdr("x-trace") != "trace-1" {
+		t.Fatalf("unexpected container: %q, %v", in.GetID(), in.GetHdrMap())
+	}
+
+	payload := &wrapperspb.StringValue{}
+	if err := in.ExtractMessage(payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.GetValue() != "alpha" {
+		t.Fatalf("unexpected payload: %v", payload)
+	}
+	in.Release()
+
+	// the messages which are not proto messages fall back to JSON
+	out = SimpleOutgoingProtoRPC()
+	out.InjectMessage(&testPayload{Name: "beta", Count: 3})
+
+	data, err = out.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Release()
+
+	in = SimpleIncomingProtoRPC()
+	if err := in.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+
+	var tp testPayload
+	if err := in.ExtractMessage(&tp); err != nil {
+		t.Fatal(err)
+	}
+	if in.GetHdr("Content-Type") != kit.ContentTypeJSON || tp.Name != "beta" || tp.Count != 3 {
+		t.Fatalf("unexpected payload: %v, %+v", in.GetHdrMap(), tp)
+	}
+	in.Release()
+}
+
+func TestSimpleMsgpackRPCContainers(t *testing.T) {
+	out := SimpleOutgoingMsgpackRPC()
+	out.SetID("req-1")
+	out.SetHdr("x-trace", "trace-1")
+	out.InjectMessage(&testPayload{Name: "alpha", Count: 2})
+
+	data, err := out.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Release()
+
+	in := SimpleIncomingMsgpackRPC()
+	if err := in.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if in.GetID() != "req-1" || in.GetHdr("x-trace") != "trace-1" {
+		t.Fatalf("unexpected container: %q, %v", in.GetID(), in.GetHdrMap())
+	}
+
+	var payload testPayload
+	if err := in.ExtractMessage(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Name != "alpha" || payload.Count != 2 {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+
+	// the payload is named by the json tags, so it decodes as a map with the same keys
+	var raw map[string]any
+	if err := in.ExtractMessage(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw["name"] != "alpha" {
+		t.Fatalf("unexpected payload keys: %v", raw)
+	}
+	in.Release()
+}
+
+func TestOutgoingJSONRPC2Container(t *testing.T) {
+	for _, tc := range []struct {
+		id       string
+		hdr      map[string]string
+		msg      kit.Message
+		expected string
+	}{
+		{`1`, nil, &testPayload{Name: "alpha"}, `{"jsonrpc":"2.0","id":1,"result":{"name":"alpha","count":0}}`},
+		{`abc`, nil, nil, `{"jsonrpc":"2.0","id":"abc","result":null}`},
+		{
+			`"a"`, nil, kit.NewError(404, "NOT_FOUND"),
+			`{"jsonrpc":"2.0","id":"a","error":{"code":-32004,"message":"NOT_FOUND","data":{"code":404,"item":"NOT_FOUND"}}}`,
+		},
+		{
+			"", map[string]string{JSONRPC2MethodKey: "news", "x-trace": "1"}, &testPayload{Count: 1},
+			`{"jsonrpc":"2.0","method":"news","params":{"name":"","count":1}}`,
+		},
+	} {
+		out := OutgoingJSONRPC2()
+		out.SetID(tc.id)
+		for k, v := range tc.hdr {
+			out.SetHdr(k, v)
+		}
+		out.InjectMessage(tc.msg)
+
+		data, err := out.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.expected {
+			t.Fatalf("unexpected container: %s", data)
+		}
+		out.Release()
+	}
+}
+
+// jsonrpc2Delegate echoes the params of the "echo" method, and replies the "fail" method
+// with an error.
+type jsonrpc2Delegate struct{}
+
+func (jsonrpc2Delegate) OnOpen(kit.Conn) {}
+
+func (jsonrpc2Delegate) OnClose(uint64) {}
+
+func (jsonrpc2Delegate) OnMessage(c kit.Conn, _ []byte) {
+	jc := c.(*JSONRPC2Conn) //nolint:forcetypeassert
+
+	in := &testPayload{}
+	if err := jc.Container().ExtractMessage(in); err != nil {
+		jc.SetDispatchError(errors.Wrap(kit.ErrDecodeIncomingMessageFailed, err))
+
+		return
+	}
+
+	_ = kit.NewTestContext().
+		SetHandler(func(ctx *kit.Context) {
+			switch jc.Container().GetHdr(JSONRPC2MethodKey) {
+			case "echo":
+				ctx.Out().SetMsg(in).Send()
+			case "fail":
+				ctx.Out().SetMsg(kit.NewError(404, "NOT_FOUND")).Send()
+			case "twice":
+				// the replies carry the id of the request, as they do in the gateways.
+				ctx.In().SetID(jc.Container().GetID())
+				ctx.In().Reply().SetMsg(in).Send()
+				ctx.In().Reply().SetMsg(in).Send()
+			}
+		}).
+		RunWithConn(jc)
+}
+
+func TestServeJSONRPC2(t *testing.T) {
+	hasMethod := func(method string) bool { return method == "echo" || method == "fail" || method == "twice" }
+
+	for _, tc := range []struct {
+		name, in, expected string
+	}{
+		{
+			"request",
+			`{"jsonrpc":"2.0","method":"echo","params":{"name":"a","count":1},"id":1}`,
+			`{"jsonrpc":"2.0","id":1,"result":{"name":"a","count":1}}`,
+		},
+		{
+			"parse error",
+			`{"jsonrpc":"2.0","method"`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error"}}`,
+		},
+		{
+			"invalid request",
+			`{"jsonrpc":"1.0","method":"echo","id":"x"}`,
+			`{"jsonrpc":"2.0","id":"x","error":{"code":-32600,"message":"Invalid Request"}}`,
+		},
+		{
+			"empty batch",
+			`[]`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request"}}`,
+		},
+		{
+			"notifications",
+			`[{"jsonrpc":"2.0","method":"echo"},{"jsonrpc":"2.0","method":"unknown"}]`,
+			``,
+		},
+		{
+			"batch",
+			`[
+				{"jsonrpc":"2.0","method":"echo","params":{"name":"b"},"id":"x"},
+				{"jsonrpc":"2.0","method":"echo","params":{"name":"c"}},
+				{"jsonrpc":"2.0","method":"unknown","id":2},
+				{"jsonrpc":"2.0","method":"fail","id":3},
+				1,
+				{"jsonrpc":"2.0","method":"echo","params":{"count":"x"},"id":4}
+			]`,
+			`[{"jsonrpc":"2.0","id":"x","result":{"name":"b","count":0}},` +
+				`{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"Method not found"}},` +
+				`{"jsonrpc":"2.0","id":3,"error":{"code":-32004,"message":"NOT_FOUND","data":{"code":404,"item":"NOT_FOUND"}}},` +
+				`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request"}},` +
+				`{"jsonrpc":"2.0","id":4,"error":{"code":-32602,"message":"Invalid params"}}]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res := ServeJSONRPC2(jsonrpc2Delegate{}, nil, []byte(tc.in), hasMethod)
+			if string(res) != tc.expected {
+				t.Fatalf("unexpected response: %s", res)
+			}
+		})
+	}
+}
+
+// jsonrpc2StreamConn records the ids of the envelopes written to the stream.
+type jsonrpc2StreamConn struct {
+	kit.Conn
+
+	ids []string
+}
+
+func (c *jsonrpc2StreamConn) Stream() bool { return true }
+
+func (c *jsonrpc2StreamConn) WriteEnvelope(e *kit.Envelope) error {
+	c.ids = append(c.ids, e.GetID())
+
+	return nil
+}
+
+func TestServeJSONRPC2SingleResponse(t *testing.T) {
+	conn := &jsonrpc2StreamConn{}
+	res := ServeJSONRPC2(
+		jsonrpc2Delegate{}, conn,
+		[]byte(`{"jsonrpc":"2.0","method":"twice","params":{"name":"a"},"id":1}`),
+		func(string) bool { return true },
+	)
+	if string(res) != `{"jsonrpc":"2.0","id":1,"result":{"name":"a","count":0}}` {
+		t.Fatalf("unexpected response: %s", res)
+	}
+
+	// the second envelope is sent as a notification, not as another response of the call.
+	if len(conn.ids) != 1 || conn.ids[0] != "" {
+		t.Fatalf("unexpected envelopes on the stream: %q", conn.ids)
+	}
+}
+
+func TestJSONRPC2Code(t *testing.T) {
+	for code, expected := range map[int]int{
+		400:                    JSONRPC2InvalidRequest,
+		401:                    -32001,
+		404:                    -32004,
+		499:                    -32099,
+		500:                    JSONRPC2InternalError,
+		503:                    JSONRPC2InternalError,
+		0:                      JSONRPC2ServerError,
+		302:                    JSONRPC2ServerError,
+		JSONRPC2MethodNotFound: JSONRPC2MethodNotFound,
+		-32050:                 -32050,
+	} {
+		if got := JSONRPC2Code(code); got != expected {
+			t.Fatalf("unexpected code for %d: %d", code, got)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package common
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/errors"
+
+	"github.com/goccy/go-json"
+)
+
+// JSONRPC2Conn is the connection of a single JSON-RPC 2.0 call. ServeJSONRPC2 passes it to
+// kit.GatewayDelegate.OnMessage instead of the connection of the request, and the gateways
+// dispatch the call by its Container.
+//
+// The first envelope sent while the call is in progress is the response of the call, which
+// is dropped if the call is a notification. Each call has only one response, hence the
+// other envelopes are written to the wrapped connection without their id if it is a stream
+// connection, e.g., a websocket, which sends them as notifications.
+type JSONRPC2Conn struct {
+	kit.Conn
+
+	mtx         sync.Mutex
+	in          *incomingJSONRPC2
+	id          json.RawMessage
+	res         []byte
+	responded   bool
+	done        bool
+	dispatchErr error
+}
+
+var _ kit.RPCConn = (*JSONRPC2Conn)(nil)
+
+// Container returns the container of the call. It is released by ServeJSONRPC2, hence the
+// gateways must not release it.
+func (c *JSONRPC2Conn) Container() kit.IncomingRPCContainer {
+	return c.in
+}
+
+// SetDispatchError sets the error of dispatching the call, which is converted to the error
+// response of the call if no response is sent.
+func (c *JSONRPC2Conn) SetDispatchError(err error) {
+	c.mtx.Lock()
+	c.dispatchErr = err
+	c.mtx.Unlock()
+}
+
+func (c *JSONRPC2Conn) WriteEnvelope(e *kit.Envelope) error {
+	c.mtx.Lock()
+	if c.done || c.responded {
+		c.mtx.Unlock()
+
+		if !c.Conn.Stream() {
+			return nil
+		}
+
+		return c.Conn.WriteEnvelope(e.SetID(""))
+	}
+	defer c.mtx.Unlock()
+
+	c.responded = true
+	if len(c.id) == 0 {
+		return nil
+	}
+
+	res, err := marshalJSONRPC2Response(c.id, e.GetMsg())
+	if err != nil {
+		return err
+	}
+
+	c.res = res
+
+	return nil
+}
+
+func (c *JSONRPC2Conn) Write(data []byte) (int, error) {
+	w, ok := c.Conn.(io.Writer)
+	if !ok {
+		return 0, kit.ErrWriteToClosedConn
+	}
+
+	return w.Write(data)
+}
+
+func (c *JSONRPC2Conn) Close() {
+	if rc, ok := c.Conn.(kit.RPCConn); ok {
+		rc.Close()
+	}
+}
+
+// response finishes the call and returns its response.
+func (c *JSONRPC2Conn) response() []byte {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.done = true
+
+	switch {
+	case len(c.id) == 0:
+		return nil
+	case c.res != nil:
+		return c.res
+	case c.dispatchErr != nil:
+		return jsonrpc2ErrorResponse(c.id, dispatchJSONRPC2Error(c.dispatchErr))
+	default:
+		// every request must be answered, even if the handler does not send anything.
+		res, _ := marshalJSONRPC2Response(c.id, nil)
+
+		return res
+	}
+}
+
+func dispatchJSONRPC2Error(err error) *JSONRPC2Error {
+	switch {
+	case errors.Is(err, kit.ErrDecodeIncomingMessageFailed):
+		return NewJSONRPC2Error(JSONRPC2InvalidParams, "Invalid params")
+	case errors.Is(err, kit.ErrNoHandler):
+		return NewJSONRPC2Error(JSONRPC2MethodNotFound, "Method not found")
+	default:
+		return NewJSONRPC2Error(JSONRPC2InternalError, "Internal error")
+	}
+}
+
+func jsonrpc2ErrorResponse(id json.RawMessage, err *JSONRPC2Error) []byte {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+
+	res, _ := marshalJSONRPC2Response(id, err)
+
+	return res
+}
+
+// ServeJSONRPC2 serves the JSON-RPC 2.0 request, or the batch of requests, in data which is
+// received by conn. The calls are dispatched one by one by d.OnMessage with a JSONRPC2Conn
+// wrapping conn. hasMethod reports if there is a handler for the method, and the calls
+// of the other methods are answered by the "Method not found" error without dispatching.
+//
+// It returns the response, or the batch of responses, which must be written to conn by the
+// gateway. It returns nil if there is nothing to reply, e.g., all the calls are notifications.
+func ServeJSONRPC2(d kit.GatewayDelegate, conn kit.Conn, data []byte, hasMethod func(method string) bool) []byte {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		return serveJSONRPC2Call(d, conn, data, hasMethod)
+	}
+
+	var calls []json.RawMessage
+
+	err := json.Unmarshal(data, &calls)
+	if err != nil {
+		return jsonrpc2ErrorResponse(nil, NewJSONRPC2Error(JSONRPC2ParseError, "Parse error"))
+	}
+
+	if len(calls) == 0 {
+		return jsonrpc2ErrorResponse(nil, NewJSONRPC2Error(JSONRPC2InvalidRequest, "Invalid Request"))
+	}
+
+	var res []byte
+	for _, call := range calls {
+		r := serveJSONRPC2Call(d, conn, call, hasMethod)
+		if r == nil {
+			continue
+		}
+
+		if res == nil {
+			res = append(res, '[')
+		} else {
+			res = append(res, ',')
+		}
+
+		res = append(res, r...)
+	}
+
+	if res != nil {
+		res = append(res, ']')
+	}
+
+	return res
+}
+
+func serveJSONRPC2Call(d kit.GatewayDelegate, conn kit.Conn, data []byte, hasMethod func(string) bool) []byte {
+	if !json.Valid(data) {
+		return jsonrpc2ErrorResponse(nil, NewJSONRPC2Error(JSONRPC2ParseError, "Parse error"))
+	}
+
+	in := IncomingJSONRPC2().(*incomingJSONRPC2) //nolint:forcetypeassert
+	defer in.Release()
+
+	err := in.Unmarshal(data)
+	if err != nil {
+		// the id is only trusted if the request is parsed.
+		var id json.RawMessage
+		if errors.Is(err, errInvalidJSONRPC2Request) && validJSONRPC2ID(in.ID) {
+			id = in.ID
+		}
+
+		return jsonrpc2ErrorResponse(id, NewJSONRPC2Error(JSONRPC2InvalidRequest, "Invalid Request"))
+	}
+
+	if !hasMethod(in.Method) {
+		if in.notification() {
+			return nil
+		}
+
+		return jsonrpc2ErrorResponse(in.ID, NewJSONRPC2Error(JSONRPC2MethodNotFound, "Method not found"))
+	}
+
+	c := &JSONRPC2Conn{
+		Conn: conn,
+		in:   in,
+		id:   append(json.RawMessage(nil), in.ID...),
+	}
+	d.OnMessage(c, data)
+
+	return c.response()
+}
@@ -0,0 +1,318 @@
+package common
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/errors"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	// JSONRPC2Version is the value of the "jsonrpc" member of the JSON-RPC 2.0 messages.
+	JSONRPC2Version = "2.0"
+	// JSONRPC2MethodKey is the header which the JSON-RPC 2.0 containers map the "method"
+	// member to. The gateways use it as the predicate key in the JSON-RPC 2.0 mode, and the
+	// outgoing container uses it as the method of the notifications.
+	JSONRPC2MethodKey = "method"
+	// JSONRPC2NotificationMethod is the method of the notifications which do not have the
+	// JSONRPC2MethodKey header.
+	JSONRPC2NotificationMethod = "notify"
+)
+
+// The error codes defined by the JSON-RPC 2.0 specification. The codes from
+// JSONRPC2ServerErrorMin to JSONRPC2ServerError are reserved for the implementation-defined
+// server errors.
+const (
+	JSONRPC2ParseError     = -32700
+	JSONRPC2InvalidRequest = -32600
+	JSONRPC2MethodNotFound = -32601
+	JSONRPC2InvalidParams  = -32602
+	JSONRPC2InternalError  = -32603
+	JSONRPC2ServerError    = -32000
+	JSONRPC2ServerErrorMin = -32099
+)
+
+var errInvalidJSONRPC2Request = errors.New("invalid json-rpc 2.0 request")
+
+// JSONRPC2Error is the error object of the JSON-RPC 2.0 responses. The outgoing container
+// converts the other kit.ErrorMessage to it: the code of the error is mapped to the codes
+// of the specification by JSONRPC2Code, and the error itself, with its original code, is
+// sent as the data.
+type JSONRPC2Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+var _ kit.ErrorMessage = (*JSONRPC2Error)(nil)
+
+func NewJSONRPC2Error(code int, message string) *JSONRPC2Error {
+	return &JSONRPC2Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *JSONRPC2Error) GetCode() int {
+	return e.Code
+}
+
+func (e *JSONRPC2Error) GetItem() string {
+	return e.Message
+}
+
+func (e *JSONRPC2Error) Error() string {
+	return e.Message
+}
+
+func toJSONRPC2Error(m kit.ErrorMessage) *JSONRPC2Error {
+	if v, ok := m.(*JSONRPC2Error); ok {
+		return v
+	}
+
+	msg := m.GetItem()
+	if msg == "" {
+		msg = m.Error()
+	}
+
+	return &JSONRPC2Error{
+		Code:    JSONRPC2Code(m.GetCode()),
+		Message: msg,
+		Data:    m,
+	}
+}
+
+// JSONRPC2Code maps the code of a kit.ErrorMessage, which is an HTTP status code, to the
+// error codes of JSON-RPC 2.0:
+//   - 400 is JSONRPC2InvalidRequest.
+//   - The other 4xx codes are the server errors from -32001 to -32099, e.g., 404 is -32004.
+//   - 5xx codes are JSONRPC2InternalError.
+//   - The codes which are already JSON-RPC 2.0 codes are kept, and the others are
+//     JSONRPC2ServerError.
+func JSONRPC2Code(code int) int {
+	switch {
+	case code >= -32768 && code <= -32000:
+		return code
+	case code == 400:
+		return JSONRPC2InvalidRequest
+	case code > 400 && code < 500:
+		return JSONRPC2ServerError - (code - 400)
+	case code >= 500 && code < 600:
+		return JSONRPC2InternalError
+	default:
+		return JSONRPC2ServerError
+	}
+}
+
+var inJSONRPC2Pool sync.Pool
+
+// incomingJSONRPC2 implements kit.IncomingRPCContainer for the JSON-RPC 2.0 requests.
+// The ID is the raw JSON of the "id" member, so the type of the id is kept in the response,
+// and it is empty for the notifications.
+type incomingJSONRPC2 struct {
+	Version string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  json.RawMessage   `json:"params"`
+	ID      json.RawMessage   `json:"id"`
+	Header  map[string]string `json:"-"`
+}
+
+// IncomingJSONRPC2 returns the container of the JSON-RPC 2.0 requests. The method of the
+// request is available as the JSONRPC2MethodKey header, and the params, which must be an
+// object, are extracted as the message.
+func IncomingJSONRPC2() kit.IncomingRPCContainer {
+	v, ok := inJSONRPC2Pool.Get().(*incomingJSONRPC2)
+	if !ok {
+		v = &incomingJSONRPC2{
+			Header: make(map[string]string, 1),
+		}
+	}
+
+	return v
+}
+
+func (e *incomingJSONRPC2) GetID() string {
+	return string(e.ID)
+}
+
+func (e *incomingJSONRPC2) notification() bool {
+	return len(e.ID) == 0
+}
+
+func (e *incomingJSONRPC2) Unmarshal(data []byte) error {
+	err := json.Unmarshal(data, e)
+	if err != nil {
+		return err
+	}
+
+	if e.Version != JSONRPC2Version || e.Method == "" {
+		return errInvalidJSONRPC2Request
+	}
+
+	if !validJSONRPC2ID(e.ID) {
+		return errInvalidJSONRPC2Request
+	}
+
+	if len(e.Params) > 0 && e.Params[0] != '{' && e.Params[0] != '[' && !isJSONNull(e.Params) {
+		return errInvalidJSONRPC2Request
+	}
+
+	e.Header[JSONRPC2MethodKey] = e.Method
+
+	return nil
+}
+
+func (e *incomingJSONRPC2) ExtractMessage(m kit.Message) error {
+	if len(e.Params) == 0 || isJSONNull(e.Params) {
+		return nil
+	}
+
+	return json.Unmarshal(e.Params, m)
+}
+
+func (e *incomingJSONRPC2) GetHdr(key string) string {
+	return e.Header[key]
+}
+
+func (e *incomingJSONRPC2) GetHdrMap() map[string]string {
+	return e.Header
+}
+
+func (e *incomingJSONRPC2) Release() {
+	for k := range e.Header {
+		delete(e.Header, k)
+	}
+
+	e.Version = ""
+	e.Method = ""
+	e.Params = e.Params[:0]
+	e.ID = e.ID[:0]
+
+	inJSONRPC2Pool.Put(e)
+}
+
+var outJSONRPC2Pool sync.Pool
+
+// outgoingJSONRPC2 implements kit.OutgoingRPCContainer for the JSON-RPC 2.0 responses.
+type outgoingJSONRPC2 struct {
+	ID      string
+	Header  map[string]string
+	Payload kit.Message
+}
+
+// OutgoingJSONRPC2 returns the container of the JSON-RPC 2.0 responses. The id must be the
+// raw JSON of the request's id, and the ids which are not valid JSON are sent as strings.
+// A kit.ErrorMessage is sent as the error object, and the other messages as the result.
+// If the id is empty, the message is sent as a notification with the JSONRPC2MethodKey
+// header as its method. The other headers are dropped, since JSON-RPC 2.0 has no place
+// for them.
+func OutgoingJSONRPC2() kit.OutgoingRPCContainer {
+	v, ok := outJSONRPC2Pool.Get().(*outgoingJSONRPC2)
+	if !ok {
+		v = &outgoingJSONRPC2{
+			Header: make(map[string]string, 4),
+		}
+	}
+
+	return v
+}
+
+func (e *outgoingJSONRPC2) SetID(id string) {
+	e.ID = id
+}
+
+func (e *outgoingJSONRPC2) SetHdr(k, v string) {
+	e.Header[k] = v
+}
+
+func (e *outgoingJSONRPC2) InjectMessage(m kit.Message) {
+	e.Payload = m
+}
+
+func (e *outgoingJSONRPC2) Marshal() ([]byte, error) {
+	if e.ID == "" {
+		method := e.Header[JSONRPC2MethodKey]
+		if method == "" {
+			method = JSONRPC2NotificationMethod
+		}
+
+		return json.Marshal(
+			struct {
+				Version string      `json:"jsonrpc"`
+				Method  string      `json:"method"`
+				Params  kit.Message `json:"params,omitempty"`
+			}{JSONRPC2Version, method, e.Payload},
+		)
+	}
+
+	return marshalJSONRPC2Response(jsonrpc2ID(e.ID), e.Payload)
+}
+
+func (e *outgoingJSONRPC2) Release() {
+	for k := range e.Header {
+		delete(e.Header, k)
+	}
+
+	e.Payload = nil
+	e.ID = e.ID[:0]
+
+	outJSONRPC2Pool.Put(e)
+}
+
+func marshalJSONRPC2Response(id json.RawMessage, m kit.Message) ([]byte, error) {
+	if em, ok := m.(kit.ErrorMessage); ok {
+		return json.Marshal(
+			struct {
+				Version string          `json:"jsonrpc"`
+				ID      json.RawMessage `json:"id"`
+				Error   *JSONRPC2Error  `json:"error"`
+			}{JSONRPC2Version, id, toJSONRPC2Error(em)},
+		)
+	}
+
+	return json.Marshal(
+		struct {
+			Version string          `json:"jsonrpc"`
+			ID      json.RawMessage `json:"id"`
+			Result  kit.Message     `json:"result"`
+		}{JSONRPC2Version, id, m},
+	)
+}
+
+// jsonrpc2ID returns the JSON of the id. The ids which are not valid JSON, e.g., the ones
+// set by the handlers, are quoted.
+func jsonrpc2ID(id string) json.RawMessage {
+	if id == "" {
+		return json.RawMessage("null")
+	}
+
+	if json.Valid([]byte(id)) {
+		return json.RawMessage(id)
+	}
+
+	data, _ := json.Marshal(id)
+
+	return data
+}
+
+// validJSONRPC2ID reports if the id is a string, a number, null, or empty, i.e., the id of
+// a notification.
+func validJSONRPC2ID(id json.RawMessage) bool {
+	if len(id) == 0 {
+		return true
+	}
+
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	default:
+		return false
+	}
+}
+
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
@@ -0,0 +1,109 @@
+package common
+
+import (
+	"sync"
+
+	"github.com/clubpay/ronykit/kit"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var inMsgpackPool sync.Pool
+
+// simpleIncomingMsgpackRPC implements kit.IncomingRPCContainer
+type simpleIncomingMsgpackRPC struct {
+	ID      string             `msgpack:"id"`
+	Header  map[string]string  `msgpack:"hdr"`
+	Payload msgpack.RawMessage `msgpack:"payload"`
+}
+
+func SimpleIncomingMsgpackRPC() kit.IncomingRPCContainer {
+	v, ok := inMsgpackPool.Get().(*simpleIncomingMsgpackRPC)
+	if !ok {
+		v = &simpleIncomingMsgpackRPC{
+			Header: make(map[string]string, 4),
+		}
+	}
+
+	return v
+}
+
+func (e *simpleIncomingMsgpackRPC) GetID() string {
+	return e.ID
+}
+
+func (e *simpleIncomingMsgpackRPC) Unmarshal(data []byte) error {
+	return msgpack.Unmarshal(data, e)
+}
+
+func (e *simpleIncomingMsgpackRPC) ExtractMessage(m kit.Message) error {
+	return kit.UnmarshalMessageAs(kit.MSG, e.Payload, m)
+}
+
+func (e *simpleIncomingMsgpackRPC) GetHdr(key string) string {
+	return e.Header[key]
+}
+
+func (e *simpleIncomingMsgpackRPC) GetHdrMap() map[string]string {
+	return e.Header
+}
+
+func (e *simpleIncomingMsgpackRPC) Release() {
+	for k := range e.Header {
+		delete(e.Header, k)
+	}
+
+	e.Payload = e.Payload[:0]
+	e.ID = e.ID[:0]
+
+	inMsgpackPool.Put(e)
+}
+
+var outMsgpackPool = sync.Pool{}
+
+// simpleOutgoingMsgpackRPC implements kit.OutgoingRPCContainer
+type simpleOutgoingMsgpackRPC struct {
+	ID      string            `msgpack:"id"`
+	Header  map[string]string `msgpack:"hdr"`
+	Payload kit.Message       `msgpack:"payload"`
+}
+
+func SimpleOutgoingMsgpackRPC() kit.OutgoingRPCContainer {
+	v, ok := outMsgpackPool.Get().(*simpleOutgoingMsgpackRPC)
+	if !ok {
+		v = &simpleOutgoingMsgpackRPC{
+			Header: make(map[string]string, 4),
+		}
+	}
+
+	return v
+}
+
+func (e *simpleOutgoingMsgpackRPC) SetID(id string) {
+	e.ID = id
+}
+
+func (e *simpleOutgoingMsgpackRPC) SetHdr(k, v string) {
+	e.Header[k] = v
+}
+
+func (e *simpleOutgoingMsgpackRPC) InjectMessage(m kit.Message) {
+	e.Payload = m
+}
+
+func (e *simpleOutgoingMsgpackRPC) Marshal() ([]byte, error) {
+	data, _, err := kit.MarshalMessageAs(kit.MSG, e)
+
+	return data, err
+}
+
+func (e *simpleOutgoingMsgpackRPC) Release() {
+	for k := range e.Header {
+		delete(e.Header, k)
+	}
+
+	e.Payload = nil
+	e.ID = e.ID[:0]
+
+	outMsgpackPool.Put(e)
+}
@@ -0,0 +1,244 @@
+package common
+
+import (
+	"sync"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/errors"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The containers of the Proto encoding are encoded as the following message:
+//
+//	message RPCContainer {
+//		string id = 1;
+//		map<string, string> hdr = 2;
+//		bytes payload = 3;
+//	}
+//
+// The payload is encoded by the Proto encoding. The messages which are not supported by
+// it, e.g., kit.ErrorMessage, are encoded by JSON, and the "Content-Type" header of the
+// container is set to application/json.
+const (
+	protoFieldID      protowire.Number = 1
+	protoFieldHdr     protowire.Number = 2
+	protoFieldPayload protowire.Number = 3
+
+	protoFieldKey   protowire.Number = 1
+	protoFieldValue protowire.Number = 2
+
+	hdrContentType = "Content-Type"
+)
+
+var errInvalidProtoRPC = errors.New("invalid proto rpc container")
+
+var inProtoPool sync.Pool
+
+// simpleIncomingProtoRPC implements kit.IncomingRPCContainer
+type simpleIncomingProtoRPC struct {
+	ID      string
+	Header  map[string]string
+	Payload []byte
+}
+
+func SimpleIncomingProtoRPC() kit.IncomingRPCContainer {
+	v, ok := inProtoPool.Get().(*simpleIncomingProtoRPC)
+	if !ok {
+		v = &simpleIncomingProtoRPC{
+			Header: make(map[string]string, 4),
+		}
+	}
+
+	return v
+}
+
+func (e *simpleIncomingProtoRPC) GetID() string {
+	return e.ID
+}
+
+func (e *simpleIncomingProtoRPC) Unmarshal(data []byte) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errors.Wrap(errInvalidProtoRPC, protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		switch {
+		case num == protoFieldID && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return errors.Wrap(errInvalidProtoRPC, protowire.ParseError(n))
+			}
+			e.ID = v
+			data = data[n:]
+		case num == protoFieldHdr && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return errors.Wrap(errInvalidProtoRPC, protowire.ParseError(n))
+			}
+			if err := e.unmarshalHdr(v); err != nil {
+				return err
+			}
+			data = data[n:]
+		case num == protoFieldPayload && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return errors.Wrap(errInvalidProtoRPC, protowire.ParseError(n))
+			}
+			e.Payload = append(e.Payload[:0], v...)
+			data = data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return errors.Wrap(errInvalidProtoRPC, protowire.ParseError(n))
+			}
+			data = data[n:]
+		}
+	}
+
+	return nil
+}
+
+func (e *simpleIncomingProtoRPC) unmarshalHdr(data []byte) error {
+	var k, v string
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errors.Wrap(errInvalidProtoRPC, protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		switch {
+		case num == protoFieldKey && typ == protowire.BytesType:
+			k, n = protowire.ConsumeString(data)
+		case num == protoFieldValue && typ == protowire.BytesType:
+			v, n = protowire.ConsumeString(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return errors.Wrap(errInvalidProtoRPC, protowire.ParseError(n))
+		}
+		data = data[n:]
+	}
+
+	e.Header[k] = v
+
+	return nil
+}
+
+func (e *simpleIncomingProtoRPC) ExtractMessage(m kit.Message) error {
+	enc := kit.Proto
+	if e.Header[hdrContentType] == kit.ContentTypeJSON {
+		enc = kit.JSON
+	}
+
+	return kit.UnmarshalMessageAs(enc, e.Payload, m)
+}
+
+func (e *simpleIncomingProtoRPC) GetHdr(key string) string {
+	return e.Header[key]
+}
+
+func (e *simpleIncomingProtoRPC) GetHdrMap() map[string]string {
+	return e.Header
+}
+
+func (e *simpleIncomingProtoRPC) Release() {
+	for k := range e.Header {
+		delete(e.Header, k)
+	}
+
+	e.Payload = e.Payload[:0]
+	e.ID = e.ID[:0]
+
+	inProtoPool.Put(e)
+}
+
+var outProtoPool = sync.Pool{}
+
+// simpleOutgoingProtoRPC implements kit.OutgoingRPCContainer
+type simpleOutgoingProtoRPC struct {
+	ID      string
+	Header  map[string]string
+	Payload kit.Message
+}
+
+func SimpleOutgoingProtoRPC() kit.OutgoingRPCContainer {
+	v, ok := outProtoPool.Get().(*simpleOutgoingProtoRPC)
+	if !ok {
+		v = &simpleOutgoingProtoRPC{
+			Header: make(map[string]string, 4),
+		}
+	}
+
+	return v
+}
+
+func (e *simpleOutgoingProtoRPC) SetID(id string) {
+	e.ID = id
+}
+
+func (e *simpleOutgoingProtoRPC) SetHdr(k, v string) {
+	e.Header[k] = v
+}
+
+func (e *simpleOutgoingProtoRPC) InjectMessage(m kit.Message) {
+	e.Payload = m
+}
+
+func (e *simpleOutgoingProtoRPC) Marshal() ([]byte, error) {
+	var (
+		payload []byte
+		err     error
+	)
+
+	if e.Payload != nil {
+		var enc kit.Encoding
+		payload, enc, err = kit.MarshalMessageAs(kit.Proto, e.Payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if enc == kit.JSON {
+			e.Header[hdrContentType] = kit.ContentTypeJSON
+		}
+	}
+
+	var data []byte
+	if e.ID != "" {
+		data = protowire.AppendTag(data, protoFieldID, protowire.BytesType)
+		data = protowire.AppendString(data, e.ID)
+	}
+
+	for k, v := range e.Header {
+		var entry []byte
+		entry = protowire.AppendTag(entry, protoFieldKey, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, protoFieldValue, protowire.BytesType)
+		entry = protowire.AppendString(entry, v)
+
+		data = protowire.AppendTag(data, protoFieldHdr, protowire.BytesType)
+		data = protowire.AppendBytes(data, entry)
+	}
+
+	if len(payload) > 0 {
+		data = protowire.AppendTag(data, protoFieldPayload, protowire.BytesType)
+		data = protowire.AppendBytes(data, payload)
+	}
+
+	return data, nil
+}
+
+func (e *simpleOutgoingProtoRPC) Release() {
+	for k := range e.Header {
+		delete(e.Header, k)
+	}
+
+	e.Payload = nil
+	e.ID = e.ID[:0]
+
+	outProtoPool.Put(e)
+}
@@ -0,0 +1,259 @@
+package kit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/clubpay/ronykit/kit/errors"
+)
+
+// ErrConnAdminAuthRequired is the panic of NewServer if ConnAdmin is set without an auth
+// handler.
+var ErrConnAdminAuthRequired = errors.New("connection admin requires an auth handler")
+
+// ConnAdminServiceName is the name of the Service which is registered by ConnAdmin.
+const ConnAdminServiceName = "conns"
+
+// ConnList is the output message of the list contract of the connection admin service.
+type ConnList struct {
+	Conns []ConnInfo `json:"conns"`
+}
+
+// ConnPush is the input message of the push contract of the connection admin service.
+// Msg is sent to the connection as is, with the headers in Hdr.
+type ConnPush struct {
+	ConnRef
+
+	Hdr map[string]string `json:"hdr,omitempty"`
+	Msg json.RawMessage   `json:"msg"`
+}
+
+type connAdminConfig struct {
+	path      string
+	predicate string
+	auth      HandlerFunc
+	handlers  []HandlerFunc
+}
+
+// ConnAdmin registers a Service, named ConnAdminServiceName, with three contracts:
+//
+//   - list: "GET path" or "predicate.list" returns the connections as ConnList. The input
+//     is a ConnFilter, which REST clients could also send as the query parameters, e.g.,
+//     "?gateway=gateway.0&kv.userID=1".
+//   - kick: "POST path/kick" or "predicate.kick" kicks the connection of the input ConnRef.
+//   - push: "POST path/push" or "predicate.push" pushes the message of the input ConnPush.
+//
+// An empty path disables the REST routes, and an empty predicate disables the RPC routes.
+// Since the service could close or write to any connection, auth is required, and NewServer
+// panics with ErrConnAdminAuthRequired if it is nil. auth must stop the unauthorized requests,
+// e.g., by ctx.StopExecution. It runs first, then the handlers, and then the handlers of
+// the service.
+func ConnAdmin(path, predicate string, auth HandlerFunc, handlers ...HandlerFunc) ConnRegistryOption {
+	return func(cfg *connRegistryConfig) {
+		cfg.admin = &connAdminConfig{
+			path:      strings.TrimSuffix(path, "/"),
+			predicate: predicate,
+			auth:      auth,
+			handlers:  handlers,
+		}
+	}
+}
+
+type connAdminService struct {
+	s   *EdgeServer
+	cfg *connAdminConfig
+}
+
+var _ Service = (*connAdminService)(nil)
+
+func (cs *connAdminService) Name() string {
+	return ConnAdminServiceName
+}
+
+func (cs *connAdminService) Contracts() []Contract {
+	return []Contract{
+		&connAdminContract{
+			id:  "list",
+			sel: cs.selector(http.MethodGet, "", "list"),
+			out: &ConnList{},
+			h:   cs.handlers(cs.list),
+		},
+		&connAdminContract{
+			id:  "kick",
+			sel: cs.selector(http.MethodPost, "/kick", "kick"),
+			out: &ConnRef{},
+			h:   cs.handlers(cs.kick),
+		},
+		&connAdminContract{
+			id:  "push",
+			sel: cs.selector(http.MethodPost, "/push", "push"),
+			out: &ConnRef{},
+			h:   cs.handlers(cs.push),
+		},
+	}
+}
+
+// handlers returns the handlers of the contract, which runs h after the handlers of ConnAdmin.
+func (cs *connAdminService) handlers(h HandlerFunc) []HandlerFunc {
+	hs := make([]HandlerFunc, 0, len(cs.cfg.handlers)+2)
+	hs = append(hs, cs.cfg.auth)
+	hs = append(hs, cs.cfg.handlers...)
+
+	return append(hs, h)
+}
+
+func (cs *connAdminService) selector(method, subPath, op string) connAdminSelector {
+	sel := connAdminSelector{}
+	if cs.cfg.path != "" {
+		sel.method = method
+		sel.path = cs.cfg.path + subPath
+	}
+
+	if cs.cfg.predicate != "" {
+		sel.predicate = cs.cfg.predicate + "." + op
+	}
+
+	return sel
+}
+
+func (cs *connAdminService) list(ctx *Context) {
+	filter := ConnFilter{}
+	if !cs.decode(ctx, &filter) {
+		return
+	}
+
+	if ctx.IsREST() {
+		ctx.RESTConn().WalkQueryParams(
+			func(key string, val string) bool {
+				switch {
+				case key == "serverID":
+					filter.ServerID = val
+				case key == "gateway":
+					filter.Gateway = val
+				case key == "clientIP":
+					filter.ClientIP = val
+				case key == "local":
+					filter.Local = val == "true" || val == "1"
+				case strings.HasPrefix(key, "kv."):
+					if filter.KV == nil {
+						filter.KV = map[string]string{}
+					}
+
+					filter.KV[strings.TrimPrefix(key, "kv.")] = val
+				}
+
+				return true
+			},
+		)
+	}
+
+	conns, err := cs.s.ListConns(ctx.Context(), filter)
+	if err != nil {
+		cs.reject(ctx, http.StatusInternalServerError, "LIST_CONNS_FAILED", err)
+
+		return
+	}
+
+	ctx.Out().SetMsg(&ConnList{Conns: conns}).Send()
+}
+
+func (cs *connAdminService) kick(ctx *Context) {
+	ref := ConnRef{}
+	if !cs.decode(ctx, &ref) {
+		return
+	}
+
+	cs.reply(ctx, ref, cs.s.KickConn(ctx.Context(), ref))
+}
+
+func (cs *connAdminService) push(ctx *Context) {
+	in := ConnPush{}
+	if !cs.decode(ctx, &in) {
+		return
+	}
+
+	if len(in.Msg) == 0 {
+		cs.reject(ctx, http.StatusBadRequest, "MESSAGE_MISSING", nil)
+
+		return
+	}
+
+	cs.reply(ctx, in.ConnRef, cs.s.PushToConn(ctx.Context(), in.ConnRef, RawMessage(in.Msg), in.Hdr))
+}
+
+// decode unmarshals the input of the request into m. The empty inputs are valid, hence
+// the GET requests do not need a body.
+func (cs *connAdminService) decode(ctx *Context, m Message) bool {
+	data, _ := ctx.In().GetMsg().(RawMessage)
+	if len(data) == 0 {
+		return true
+	}
+
+	err := json.Unmarshal(data, m)
+	if err != nil {
+		cs.reject(ctx, http.StatusBadRequest, "INVALID_INPUT", err)
+
+		return false
+	}
+
+	return true
+}
+
+func (cs *connAdminService) reply(ctx *Context, ref ConnRef, err error) {
+	switch {
+	case err == nil:
+		ctx.Out().SetMsg(&ref).Send()
+	case errors.Is(err, ErrConnNotFound):
+		cs.reject(ctx, http.StatusNotFound, "CONN_NOT_FOUND", err)
+	default:
+		cs.reject(ctx, http.StatusInternalServerError, "CONN_OPERATION_FAILED", err)
+	}
+}
+
+func (cs *connAdminService) reject(ctx *Context, code int, item string, err error) {
+	if err != nil {
+		ctx.Error(err)
+	}
+
+	ctx.SetStatusCode(code)
+	ctx.Out().SetMsg(NewError(code, item)).Send()
+}
+
+type connAdminContract struct {
+	id  string
+	sel RouteSelector
+	out Message
+	h   []HandlerFunc
+}
+
+var _ Contract = (*connAdminContract)(nil)
+
+func (c *connAdminContract) ID() string                     { return c.id }
+func (c *connAdminContract) RouteSelector() RouteSelector   { return c.sel }
+func (c *connAdminContract) EdgeSelector() EdgeSelectorFunc { return nil }
+func (c *connAdminContract) Encoding() Encoding             { return JSON }
+func (c *connAdminContract) Input() Message                 { return RawMessage{} }
+func (c *connAdminContract) Output() Message                { return c.out }
+func (c *connAdminContract) Handlers() []HandlerFunc        { return c.h }
+func (c *connAdminContract) Modifiers() []ModifierFunc      { return nil }
+
+// connAdminSelector is a gateway-agnostic selector, like healthSelector, which also
+// carries the method of the REST route.
+type connAdminSelector struct {
+	method    string
+	path      string
+	predicate string
+}
+
+var (
+	_ RESTRouteSelector = connAdminSelector{}
+	_ RPCRouteSelector  = connAdminSelector{}
+)
+
+func (r connAdminSelector) Query(string) any      { return nil }
+func (r connAdminSelector) GetEncoding() Encoding { return JSON }
+func (r connAdminSelector) GetPredicate() string  { return r.predicate }
+func (r connAdminSelector) GetPath() string       { return r.path }
+func (r connAdminSelector) GetMethod() string     { return r.method }
+func (r connAdminSelector) String() string        { return r.method + " " + r.path }
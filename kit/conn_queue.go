@@ -0,0 +1,273 @@
+package kit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/clubpay/ronykit/kit/errors"
+)
+
+var ErrOutboundQueueFull = errors.New("outbound queue is full")
+
+// OverflowPolicy defines what an OutboundQueue does when it is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDefault uses the policy configured in the gateway.
+	OverflowDefault OverflowPolicy = iota
+	// OverflowBlock blocks the sender until there is room in the queue.
+	OverflowBlock
+	// OverflowDropOldest drops the oldest queued message to make room for the new one.
+	OverflowDropOldest
+	// OverflowDropNewest drops the new message.
+	OverflowDropNewest
+	// OverflowDisconnect closes the connection, and the sender gets ErrOutboundQueueFull.
+	OverflowDisconnect
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowDisconnect:
+		return "disconnect"
+	default:
+		return "default"
+	}
+}
+
+// OutboundQueueStats is the snapshot of the metrics of an OutboundQueue.
+type OutboundQueueStats struct {
+	// Len is the number of messages waiting to be written.
+	Len int
+	// Dropped is the number of messages dropped by OverflowDropOldest and OverflowDropNewest.
+	Dropped uint64
+}
+
+// QueuedConn is implemented by the stream connections which write the envelopes through
+// an OutboundQueue.
+type QueuedConn interface {
+	Conn
+	OutboundQueueStats() OutboundQueueStats
+}
+
+// OutboundQueue is a bounded queue of the encoded messages of a stream connection. A
+// background goroutine writes them to the connection, hence a slow consumer does not
+// block the handlers pushing to it, unless the policy is OverflowBlock.
+// The gateways create one OutboundQueue per connection.
+type OutboundQueue struct {
+	mtx      sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	items    [][]byte
+	size     int
+	policy   OverflowPolicy
+	closed   bool
+	draining bool
+	done     chan struct{}
+	dropped  atomic.Uint64
+
+	writeFn      func(data []byte) error
+	disconnectFn func()
+}
+
+// NewOutboundQueue creates the queue and starts its writer. writeFn writes the message to
+// the connection, and disconnectFn closes the connection. disconnectFn is called if writeFn
+// fails, or the queue is full and the policy is OverflowDisconnect.
+// If policy is OverflowDefault, OverflowBlock is used.
+func NewOutboundQueue(
+	size int, policy OverflowPolicy,
+	writeFn func(data []byte) error, disconnectFn func(),
+) *OutboundQueue {
+	if size <= 0 {
+		size = 1
+	}
+
+	if policy == OverflowDefault {
+		policy = OverflowBlock
+	}
+
+	q := &OutboundQueue{
+		items:        make([][]byte, 0, size),
+		size:         size,
+		policy:       policy,
+		writeFn:      writeFn,
+		disconnectFn: disconnectFn,
+		done:         make(chan struct{}),
+	}
+	q.notEmpty = sync.NewCond(&q.mtx)
+	q.notFull = sync.NewCond(&q.mtx)
+
+	go q.run()
+
+	return q
+}
+
+// Push copies data into the queue. If the queue is full, it acts based on policy, or the
+// policy of the queue if it is OverflowDefault.
+func (q *OutboundQueue) Push(data []byte, policy OverflowPolicy) error {
+	if policy == OverflowDefault {
+		policy = q.policy
+	}
+
+	data = append(make([]byte, 0, len(data)), data...)
+
+	q.mtx.Lock()
+	if q.closed || q.draining {
+		q.mtx.Unlock()
+
+		return ErrWriteToClosedConn
+	}
+
+	if len(q.items) >= q.size {
+		switch policy {
+		default:
+			for len(q.items) >= q.size && !q.closed && !q.draining {
+				q.notFull.Wait()
+			}
+
+			if q.closed || q.draining {
+				q.mtx.Unlock()
+
+				return ErrWriteToClosedConn
+			}
+		case OverflowDropOldest:
+			q.items[0] = nil
+			q.items = append(q.items[:0], q.items[1:]...)
+			q.dropped.Add(1)
+		case OverflowDropNewest:
+			q.mtx.Unlock()
+			q.dropped.Add(1)
+
+			return nil
+		case OverflowDisconnect:
+			q.mtx.Unlock()
+			q.disconnect()
+
+			return ErrOutboundQueueFull
+		}
+	}
+
+	q.items = append(q.items, data)
+	q.notEmpty.Signal()
+	q.mtx.Unlock()
+
+	return nil
+}
+
+// Stats returns the current metrics of the queue.
+func (q *OutboundQueue) Stats() OutboundQueueStats {
+	q.mtx.Lock()
+	n := len(q.items)
+	q.mtx.Unlock()
+
+	return OutboundQueueStats{
+		Len:     n,
+		Dropped: q.dropped.Load(),
+	}
+}
+
+// Close stops the writer and drops the queued messages. The blocked senders get
+// ErrWriteToClosedConn.
+func (q *OutboundQueue) Close() {
+	q.mtx.Lock()
+	q.closed = true
+	q.items = nil
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.mtx.Unlock()
+}
+
+// Drain stops accepting new messages, and waits until the queued messages are written.
+// The stream connections which are closed by the server, e.g., SSE, call it before closing
+// the connection. If ctx is done before that, e.g., the client does not read, the queue is
+// closed, disconnectFn is called and ctx.Err() is returned.
+func (q *OutboundQueue) Drain(ctx context.Context) error {
+	q.mtx.Lock()
+	q.draining = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.mtx.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		q.disconnect()
+
+		return ctx.Err()
+	}
+}
+
+func (q *OutboundQueue) disconnect() {
+	q.Close()
+
+	if q.disconnectFn != nil {
+		q.disconnectFn()
+	}
+}
+
+func (q *OutboundQueue) run() {
+	defer close(q.done)
+
+	for {
+		q.mtx.Lock()
+		for len(q.items) == 0 && !q.closed && !q.draining {
+			q.notEmpty.Wait()
+		}
+
+		if q.closed || len(q.items) == 0 {
+			q.mtx.Unlock()
+
+			return
+		}
+
+		data := q.items[0]
+		q.items[0] = nil
+		q.items = q.items[1:]
+		q.notFull.Signal()
+		q.mtx.Unlock()
+
+		if err := q.writeFn(data); err != nil {
+			q.disconnect()
+
+			return
+		}
+	}
+}
+
+// SetOverflowPolicy sets the policy of the outbound queues for the envelopes sent by this
+// Context. It overrides the policy of the gateway, if the gateway uses OutboundQueue.
+func (ctx *Context) SetOverflowPolicy(p OverflowPolicy) {
+	ctx.overflow = p
+}
+
+// OverflowPolicy returns the policy of the outbound queue for this envelope, which is
+// OverflowDefault unless the Context overrides it by SetOverflowPolicy.
+func (e *Envelope) OverflowPolicy() OverflowPolicy {
+	if e.ctx == nil {
+		return OverflowDefault
+	}
+
+	return e.ctx.overflow
+}
+
+// Overflow returns a ContractWrapper which sets the overflow policy of the outbound
+// queues for the envelopes sent by the contract's handlers.
+func Overflow(p OverflowPolicy) ContractWrapper {
+	return ContractWrapperFunc(func(c Contract) Contract {
+		return &contractWrap{
+			Contract: c,
+			h: []HandlerFunc{
+				func(ctx *Context) {
+					ctx.SetOverflowPolicy(p)
+				},
+			},
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks the writer of the OutboundQueue until release is called.
+type blockingWriter struct {
+	mtx     sync.Mutex
+	written []string
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{
+		started: make(chan struct{}, 16),
+		release: make(chan struct{}),
+	}
+}
+
+func (w *blockingWriter) write(data []byte) error {
+	w.started <- struct{}{}
+	<-w.release
+
+	w.mtx.Lock()
+	w.written = append(w.written, string(data))
+	w.mtx.Unlock()
+
+	return nil
+}
+
+func (w *blockingWriter) result() []string {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	return append([]string(nil), w.written...)
+}
+
+// fillQueue pushes the first message, which blocks the writer, and then fills the queue.
+func fillQueue(t *testing.T, q *OutboundQueue, w *blockingWriter, msgs ...string) {
+	t.Helper()
+
+	if err := q.Push([]byte(msgs[0]), OverflowDefault); err != nil {
+		t.Fatal(err)
+	}
+	<-w.started
+
+	for _, m := range msgs[1:] {
+		if err := q.Push([]byte(m), OverflowDefault); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOutboundQueueDropPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy   OverflowPolicy
+		expected []string
+	}{
+		{OverflowDropOldest, []string{"0", "2", "3"}},
+		{OverflowDropNewest, []string{"0", "1", "2"}},
+	} {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			w := newBlockingWriter()
+			q := NewOutboundQueue(2, tc.policy, w.write, nil)
+			fillQueue(t, q, w, "0", "1", "2")
+
+			if err := q.Push([]byte("3"), OverflowDefault); err != nil {
+				t.Fatal(err)
+			}
+			if st := q.Stats(); st.Len != 2 || st.Dropped != 1 {
+				t.Fatalf("unexpected stats: %+v", st)
+			}
+
+			close(w.release)
+			if err := q.Drain(t.Context()); err != nil {
+				t.Fatal(err)
+			}
+
+			got := w.result()
+			if len(got) != len(tc.expected) {
+				t.Fatalf("unexpected messages: %v", got)
+			}
+			for idx := range got {
+				if got[idx] != tc.expected[idx] {
+					t.Fatalf("unexpected messages: %v", got)
+				}
+			}
+
+			if err := q.Push([]byte("4"), OverflowDefault); !errors.Is(err, ErrWriteToClosedConn) {
+				t.Fatalf("expected ErrWriteToClosedConn, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestOutboundQueueBlock(t *testing.T) {
+	w := newBlockingWriter()
+	q := NewOutboundQueue(1, OverflowDefault, w.write, nil)
+	fillQueue(t, q, w, "0", "1")
+
+	pushed := make(chan error, 1)
+	go func() {
+		pushed <- q.Push([]byte("2"), OverflowDefault)
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("expected the sender to be blocked")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(w.release)
+	if err := <-pushed; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Drain(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.result(); len(got) != 3 {
+		t.Fatalf("unexpected messages: %v", got)
+	}
+}
+
+func TestOutboundQueueDisconnect(t *testing.T) {
+	w := newBlockingWriter()
+	disconnected := make(chan struct{})
+	q := NewOutboundQueue(1, OverflowBlock, w.write, func() { close(disconnected) })
+	fillQueue(t, q, w, "0", "1")
+
+	// the policy of the envelope overrides the policy of the queue
+	err := q.Push([]byte("2"), OverflowDisconnect)
+	if !errors.Is(err, ErrOutboundQueueFull) {
+		t.Fatalf("expected ErrOutboundQueueFull, got: %v", err)
+	}
+
+	<-disconnected
+	close(w.release)
+	if err := q.Drain(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if st := q.Stats(); st.Len != 0 {
+		t.Fatalf("expected the queue to be dropped: %+v", st)
+	}
+}
+
+func TestOutboundQueueDrainTimeout(t *testing.T) {
+	w := newBlockingWriter()
+	disconnected := make(chan struct{})
+	q := NewOutboundQueue(2, OverflowBlock, w.write, func() {
+		close(disconnected)
+		// the connection is closed, hence the blocked write returns.
+		close(w.release)
+	})
+	fillQueue(t, q, w, "0", "1")
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := q.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got: %v", err)
+	}
+
+	<-disconnected
+	if st := q.Stats(); st.Len != 0 {
+		t.Fatalf("expected the queue to be dropped: %+v", st)
+	}
+	if err := q.Push([]byte("2"), OverflowDefault); !errors.Is(err, ErrWriteToClosedConn) {
+		t.Fatalf("expected ErrWriteToClosedConn, got: %v", err)
+	}
+}
+
+func TestOverflowWrapper(t *testing.T) {
+	var policy OverflowPolicy
+
+	err := NewTestContext().
+		SetHandler(
+			Overflow(OverflowDropNewest).Wrap(&testContract{}).Handlers()[0],
+			func(ctx *Context) {
+				policy = ctx.Out().OverflowPolicy()
+			},
+		).
+		Run(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy != OverflowDropNewest {
+		t.Fatalf("unexpected policy: %s", policy)
+	}
+}
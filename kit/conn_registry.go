@@ -0,0 +1,489 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/utils"
+
+	"github.com/goccy/go-reflect"
+)
+
+const (
+	connRegistryKeyPrefix  = "kit:connreg:"
+	defaultConnRegistryTTL = time.Minute
+)
+
+var ErrConnRegistryDisabled = errors.New("connection registry is disabled")
+
+// ConnRef identifies a live connection in the cluster. ServerID is the id of the instance
+// which holds the connection, and it is empty if the EdgeServer has no Cluster. Gateway is
+// the name of the gateway, e.g., "gateway.0" for the first registered gateway.
+type ConnRef struct {
+	ServerID string `json:"serverID,omitempty"`
+	Gateway  string `json:"gateway"`
+	ID       uint64 `json:"id"`
+}
+
+func (ref ConnRef) storeKey() string {
+	return connRegistryKeyPrefix + ref.ServerID + "/" + ref.Gateway + "/" + strconv.FormatUint(ref.ID, 10)
+}
+
+// ConnInfo is the information of a live connection which is recorded by the connection
+// registry. KV is the key-values of the connection (Conn.Walk); for the connections of the
+// other instances, it is the snapshot which was taken at their last refresh.
+type ConnInfo struct {
+	ConnRef
+
+	ClientIP    string            `json:"clientIP"`
+	ConnectedAt time.Time         `json:"connectedAt"`
+	KV          map[string]string `json:"kv,omitempty"`
+}
+
+// ConnFilter selects the connections in ListConns. The empty fields match any connection.
+type ConnFilter struct {
+	ServerID string `json:"serverID,omitempty"`
+	Gateway  string `json:"gateway,omitempty"`
+	ClientIP string `json:"clientIP,omitempty"`
+	// KV matches the connections which have all these key-values.
+	KV map[string]string `json:"kv,omitempty"`
+	// Local limits the result to the connections of this instance.
+	Local bool `json:"local,omitempty"`
+}
+
+func (f ConnFilter) match(info ConnInfo) bool {
+	if f.ServerID != "" && f.ServerID != info.ServerID ||
+		f.Gateway != "" && f.Gateway != info.Gateway ||
+		f.ClientIP != "" && f.ClientIP != info.ClientIP {
+		return false
+	}
+
+	for k, v := range f.KV {
+		if info.KV[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+type connRegistryConfig struct {
+	admin *connAdminConfig
+	ttl   time.Duration
+}
+
+type ConnRegistryOption func(cfg *connRegistryConfig)
+
+// ConnRegistryTTL sets the time-to-live of the connections in the ClusterStore. The entries
+// of the live connections are refreshed every third of it, hence the entries of a crashed
+// instance expire after at most ttl. Default is 1 minute.
+func ConnRegistryTTL(ttl time.Duration) ConnRegistryOption {
+	return func(cfg *connRegistryConfig) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithConnRegistry records the live stream connections (e.g., websocket and SSE) of the
+// gateways, so they can be listed by EdgeServer.ListConns, closed by EdgeServer.KickConn,
+// and receive messages by EdgeServer.PushToConn. If the Cluster supports ClusterStore, the
+// connections are published there, and these operations work on the connections of any
+// instance of the cluster. The published entries, including the key-values of the
+// connections, are refreshed periodically, and expire if the instance crashes. Check
+// ConnRegistryTTL.
+func WithConnRegistry(opts ...ConnRegistryOption) Option {
+	return func(s *edgeConfig) {
+		cfg := &connRegistryConfig{ttl: defaultConnRegistryTTL}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+
+		s.connRegistry = cfg
+	}
+}
+
+type connEntry struct {
+	nb   *northBridge
+	conn Conn
+	info ConnInfo
+}
+
+// connKey identifies a local connection by the name of its gateway and its id.
+type connKey struct {
+	gateway string
+	id      uint64
+}
+
+// connRegistry keeps the live stream connections of all the gateways.
+type connRegistry struct {
+	id    string
+	store ClusterStore
+	ttl   time.Duration
+	q     *storeQueue
+
+	mtx   sync.RWMutex
+	conns map[connKey]*connEntry
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newConnRegistry(cfg *connRegistryConfig, eh ErrHandlerFunc) *connRegistry {
+	ttl := cfg.ttl
+	if ttl <= 0 {
+		ttl = defaultConnRegistryTTL
+	}
+
+	return &connRegistry{
+		ttl:   ttl,
+		q:     newStoreQueue(eh),
+		conns: map[connKey]*connEntry{},
+		done:  make(chan struct{}),
+	}
+}
+
+// start refreshes the entries of the local connections in the ClusterStore, until stop
+// is called.
+func (r *connRegistry) start() {
+	if r.store == nil {
+		return
+	}
+
+	go r.heartbeat()
+}
+
+func (r *connRegistry) stop() {
+	r.stopOnce.Do(func() { close(r.done) })
+	r.q.flush()
+}
+
+func (r *connRegistry) heartbeat() {
+	t := time.NewTicker(r.ttl / 3)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-t.C:
+			r.refresh()
+		}
+	}
+}
+
+func (r *connRegistry) register(nb *northBridge, conn Conn) {
+	if !conn.Stream() {
+		return
+	}
+
+	entry := &connEntry{
+		nb:   nb,
+		conn: conn,
+		info: ConnInfo{
+			ConnRef: ConnRef{
+				ServerID: r.id,
+				Gateway:  nb.name,
+				ID:       conn.ConnID(),
+			},
+			ClientIP:    conn.ClientIP(),
+			ConnectedAt: time.Now().UTC(),
+			KV:          connKV(conn),
+		},
+	}
+
+	r.mtx.Lock()
+	r.conns[connKey{gateway: nb.name, id: entry.info.ID}] = entry
+	r.mtx.Unlock()
+
+	if r.store == nil {
+		return
+	}
+
+	info := entry.info
+	r.q.push(func(ctx context.Context) error {
+		data, err := json.Marshal(info)
+		if err != nil {
+			return err
+		}
+
+		return r.store.Set(ctx, info.storeKey(), utils.B2S(data), r.ttl)
+	})
+}
+
+func (r *connRegistry) unregister(nb *northBridge, connID uint64) {
+	key := connKey{gateway: nb.name, id: connID}
+
+	r.mtx.Lock()
+	entry, ok := r.conns[key]
+	delete(r.conns, key)
+	r.mtx.Unlock()
+
+	if !ok || r.store == nil {
+		return
+	}
+
+	storeKey := entry.info.storeKey()
+	r.q.push(func(ctx context.Context) error {
+		return r.store.Delete(ctx, storeKey)
+	})
+}
+
+// refresh publishes the entries of the local connections with their current key-values,
+// which also extends their time-to-live.
+func (r *connRegistry) refresh() {
+	r.mtx.RLock()
+	kv := make(map[string]string, len(r.conns))
+	for _, entry := range r.conns {
+		info := entry.info
+		info.KV = connKV(entry.conn)
+
+		data, err := json.Marshal(info)
+		if err == nil {
+			kv[info.storeKey()] = string(data)
+		}
+	}
+	r.mtx.RUnlock()
+
+	if len(kv) == 0 {
+		return
+	}
+
+	r.q.push(func(ctx context.Context) error {
+		return r.store.SetMulti(ctx, kv, r.ttl)
+	})
+}
+
+func (r *connRegistry) local(gateway string, connID uint64) (*connEntry, bool) {
+	r.mtx.RLock()
+	entry, ok := r.conns[connKey{gateway: gateway, id: connID}]
+	r.mtx.RUnlock()
+
+	return entry, ok
+}
+
+func (r *connRegistry) list(ctx context.Context, filter ConnFilter) ([]ConnInfo, error) {
+	var conns []ConnInfo
+
+	r.mtx.RLock()
+	for _, entry := range r.conns {
+		info := entry.info
+		info.KV = connKV(entry.conn)
+
+		if filter.match(info) {
+			conns = append(conns, info)
+		}
+	}
+	r.mtx.RUnlock()
+
+	if r.store != nil && !filter.Local {
+		err := r.store.ScanWithValue(
+			ctx, connRegistryKeyPrefix,
+			func(key, val string) bool {
+				// the keys of this instance are skipped, since the local view is more recent.
+				if strings.HasPrefix(key, connRegistryKeyPrefix+r.id+"/") {
+					return true
+				}
+
+				var info ConnInfo
+				if json.Unmarshal(utils.S2B(val), &info) == nil && filter.match(info) {
+					conns = append(conns, info)
+				}
+
+				return true
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(conns, func(i, j int) bool {
+		if conns[i].ServerID != conns[j].ServerID {
+			return conns[i].ServerID < conns[j].ServerID
+		}
+
+		if conns[i].Gateway != conns[j].Gateway {
+			return conns[i].Gateway < conns[j].Gateway
+		}
+
+		return conns[i].ID < conns[j].ID
+	})
+
+	return conns, nil
+}
+
+// exists reports if the connection of another instance is in the ClusterStore. If there
+// is no ClusterStore, we cannot know, hence it returns true.
+func (r *connRegistry) exists(ctx context.Context, ref ConnRef) bool {
+	if r.store == nil {
+		return true
+	}
+
+	// some stores return an error for the missing keys, hence we consider any error
+	// as a missing key.
+	val, err := r.store.Get(ctx, ref.storeKey())
+
+	return err == nil && val != ""
+}
+
+// kick cancels the in-flight requests of the connection, which ends the SSE streams, and
+// closes the connection if it supports it, e.g., websocket.
+func (e *connEntry) kick() {
+	e.nb.cancelStream(e.info.ID)
+
+	if c, ok := e.conn.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
+func (e *connEntry) push(hdr map[string]string, msg Message) error {
+	ctx := e.nb.acquireCtx(e.conn)
+	ctx.Out().
+		SetHdrMap(hdr).
+		SetMsg(msg).
+		Send()
+
+	err := ctx.err
+	e.nb.releaseCtx(ctx)
+
+	return err
+}
+
+func connKV(conn Conn) map[string]string {
+	var kv map[string]string
+
+	conn.Walk(func(key string, val string) bool {
+		if kv == nil {
+			kv = make(map[string]string, 4)
+		}
+
+		kv[key] = val
+
+		return true
+	})
+
+	return kv
+}
+
+// ListConns returns the live stream connections which match the filter. If the Cluster
+// supports ClusterStore, the connections of the other instances are included too.
+// It returns ErrConnRegistryDisabled if the EdgeServer is not configured by WithConnRegistry.
+func (s *EdgeServer) ListConns(ctx context.Context, filter ConnFilter) ([]ConnInfo, error) {
+	if s.reg == nil {
+		return nil, ErrConnRegistryDisabled
+	}
+
+	return s.reg.list(ctx, filter)
+}
+
+// KickConn closes the connection, if the gateway supports it (e.g., websocket), and
+// cancels its in-flight requests, which ends the SSE streams. The connections of the other
+// instances are kicked through the Cluster.
+// If the connection is not found, ErrConnNotFound is returned.
+func (s *EdgeServer) KickConn(ctx context.Context, ref ConnRef) error {
+	if s.reg == nil {
+		return ErrConnRegistryDisabled
+	}
+
+	if s.isRemoteConn(ref) {
+		return s.sendConnCarrier(ctx, kickCarrier, ref, nil)
+	}
+
+	entry, ok := s.reg.local(ref.Gateway, ref.ID)
+	if !ok {
+		return ErrConnNotFound
+	}
+
+	entry.kick()
+
+	return nil
+}
+
+// PushToConn sends the message with the headers to the connection. The message is carried
+// to the other instances in its encoding, and they send it by its type if they know the
+// type, otherwise as it is encoded.
+// If the connection is not found, ErrConnNotFound is returned.
+func (s *EdgeServer) PushToConn(ctx context.Context, ref ConnRef, msg Message, hdr map[string]string) error {
+	if s.reg == nil {
+		return ErrConnRegistryDisabled
+	}
+
+	if s.isRemoteConn(ref) {
+		data, _, err := MarshalMessageAs(carrierEncoding(msg), msg)
+		if err != nil {
+			return err
+		}
+
+		return s.sendConnCarrier(
+			ctx, connPushCarrier, ref,
+			&carrierData{
+				Hdr:     hdr,
+				MsgType: reflect.TypeOf(msg).String(),
+				Msg:     data,
+			},
+		)
+	}
+
+	entry, ok := s.reg.local(ref.Gateway, ref.ID)
+	if !ok {
+		return ErrConnNotFound
+	}
+
+	return entry.push(hdr, msg)
+}
+
+func (s *EdgeServer) isRemoteConn(ref ConnRef) bool {
+	return ref.ServerID != "" && ref.ServerID != s.reg.id
+}
+
+// sendConnCarrier sends the carrier of the kick or push operation to the instance which
+// holds the connection. The connection is carried in ConnKeys as its gateway and id.
+func (s *EdgeServer) sendConnCarrier(ctx context.Context, kind carrierKind, ref ConnRef, data *carrierData) error {
+	if s.sb == nil || !s.reg.exists(ctx, ref) {
+		return ErrConnNotFound
+	}
+
+	if data == nil {
+		data = &carrierData{}
+	}
+
+	data.ConnKeys = []string{ref.Gateway, strconv.FormatUint(ref.ID, 10)}
+
+	carrier := newEnvelopeCarrier(kind, utils.RandomID(32), s.sb.id, ref.ServerID)
+	carrier.Data = data
+
+	return s.sb.publish(carrier)
+}
+
+// onConnMessage runs the kick or push operation which is sent by another instance.
+func (sb *southBridge) onConnMessage(carrier *envelopeCarrier) {
+	if sb.reg == nil || carrier.Data == nil || len(carrier.Data.ConnKeys) != 2 {
+		return
+	}
+
+	connID, err := strconv.ParseUint(carrier.Data.ConnKeys[1], 10, 64)
+	if err != nil {
+		return
+	}
+
+	entry, ok := sb.reg.local(carrier.Data.ConnKeys[0], connID)
+	if !ok {
+		return
+	}
+
+	switch carrier.Kind {
+	case kickCarrier:
+		entry.kick()
+	case connPushCarrier:
+		err = entry.push(carrier.Data.Hdr, sb.carrierMessage(carrier.Data))
+		if err != nil {
+			sb.eh(nil, err)
+		}
+	default:
+	}
+}
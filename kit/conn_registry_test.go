@@ -0,0 +1,282 @@
+package kit
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type closableTestConn struct {
+	*testConn
+
+	closed atomic.Bool
+}
+
+func (c *closableTestConn) Close() {
+	c.closed.Store(true)
+}
+
+func newRegistryServer(cluster Cluster, opts ...ConnRegistryOption) (*EdgeServer, *testGateway) {
+	gw := &testGateway{
+		dispatchFn: func(_ *Context, _ []byte) (ExecuteArg, error) {
+			return ExecuteArg{}, ErrNoHandler
+		},
+	}
+
+	return NewServer(WithCluster(cluster), WithGateway(gw), WithConnRegistry(opts...)), gw
+}
+
+func openStreamConn(s *EdgeServer, clientIP string, kv map[string]string) *closableTestConn {
+	conn := &closableTestConn{testConn: newTestConn()}
+	conn.stream = true
+	conn.clientIP = clientIP
+
+	for k, v := range kv {
+		conn.kv[k] = v
+	}
+
+	s.nb[0].OnOpen(conn)
+	s.reg.q.flush()
+
+	return conn
+}
+
+func TestConnRegistry(t *testing.T) {
+	store := &memStore{kv: map[string]string{}}
+	cluster := memClusterWithStore{memCluster: newMemCluster(), store: store}
+	s1, _ := newRegistryServer(cluster)
+	s2, _ := newRegistryServer(cluster)
+
+	local := openStreamConn(s1, "10.0.0.1", map[string]string{"userID": "1"})
+	remote := openStreamConn(s2, "10.0.0.2", nil)
+
+	// only the stream connections are registered
+	s1.nb[0].OnOpen(newTestConn())
+
+	conns, err := s1.ListConns(t.Context(), ConnFilter{})
+	if err != nil || len(conns) != 2 {
+		t.Fatalf("expected 2 connections, got: %#v, %v", conns, err)
+	}
+
+	// the key-values of the remote connection are published by the next heartbeat
+	remote.Set("userID", "2")
+	s2.reg.refresh()
+	s2.reg.q.flush()
+
+	conns, err = s1.ListConns(t.Context(), ConnFilter{KV: map[string]string{"userID": "2"}})
+	if err != nil || len(conns) != 1 {
+		t.Fatalf("expected 1 connection, got: %#v, %v", conns, err)
+	}
+
+	info := conns[0]
+	if info.ServerID != s2.sb.id || info.Gateway != "gateway.0" || info.ID != remote.ConnID() ||
+		info.ClientIP != "10.0.0.2" || info.ConnectedAt.IsZero() {
+		t.Fatalf("unexpected connection: %#v", info)
+	}
+
+	conns, _ = s1.ListConns(t.Context(), ConnFilter{Local: true})
+	if len(conns) != 1 || conns[0].ID != local.ConnID() || conns[0].KV["userID"] != "1" {
+		t.Fatalf("unexpected local connections: %#v", conns)
+	}
+
+	s2.nb[0].OnClose(remote.ConnID())
+	s2.reg.q.flush()
+
+	conns, _ = s1.ListConns(t.Context(), ConnFilter{})
+	if len(conns) != 1 || len(store.kv) != 1 {
+		t.Fatalf("expected the closed connection to be removed: %#v, %v", conns, store.kv)
+	}
+}
+
+func TestConnRegistryHeartbeat(t *testing.T) {
+	store := &memStore{kv: map[string]string{}}
+	cluster := memClusterWithStore{memCluster: newMemCluster(), store: store}
+	s, _ := newRegistryServer(cluster, ConnRegistryTTL(30*time.Millisecond))
+
+	s.Start(t.Context())
+	defer s.Shutdown(t.Context())
+
+	conn := openStreamConn(s, "10.0.0.1", nil)
+	key := ConnRef{ServerID: s.sb.id, Gateway: "gateway.0", ID: conn.ConnID()}.storeKey()
+
+	store.mtx.Lock()
+	ttl := store.ttls[key]
+	store.mtx.Unlock()
+
+	if ttl != 30*time.Millisecond {
+		t.Fatalf("unexpected ttl: %v", ttl)
+	}
+
+	// the heartbeat publishes the key-values without any request of the connection
+	conn.Set("userID", "1")
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		conns, _ := s.ListConns(t.Context(), ConnFilter{ServerID: s.sb.id})
+		val, _ := store.Get(t.Context(), key)
+		if len(conns) == 1 && strings.Contains(val, `"userID":"1"`) {
+			return
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatal("expected the heartbeat to refresh the connection")
+}
+
+func TestConnRegistryKickAndPush(t *testing.T) {
+	store := &memStore{kv: map[string]string{}}
+	cluster := memClusterWithStore{memCluster: newMemCluster(), store: store}
+	s1, _ := newRegistryServer(cluster)
+	s2, _ := newRegistryServer(cluster)
+
+	local := openStreamConn(s1, "", nil)
+	remote := openStreamConn(s2, "", nil)
+	remoteRef := ConnRef{ServerID: s2.sb.id, Gateway: "gateway.0", ID: remote.ConnID()}
+
+	err := s1.PushToConn(t.Context(), ConnRef{ServerID: s1.sb.id, Gateway: "gateway.0", ID: local.ConnID()},
+		&callOut{N: 1}, map[string]string{"k": "v"})
+	if err != nil {
+		t.Fatalf("local push failed: %v", err)
+	}
+
+	if err = s1.PushToConn(t.Context(), remoteRef, &callOut{N: 2}, map[string]string{"k": "v"}); err != nil {
+		t.Fatalf("remote push failed: %v", err)
+	}
+
+	waitForOut(t, local.testConn, 1)
+	waitForOut(t, remote.testConn, 1)
+
+	remote.Lock()
+	if string(remote.out[0].GetMsg().(RawMessage)) != `{"n":2}` || remote.out[0].GetHdr("k") != "v" { //nolint:forcetypeassert
+		t.Fatalf("unexpected remote envelope: %s", remote.out[0].GetMsg())
+	}
+	remote.Unlock()
+
+	// the messages of the known types are decoded by their encoding
+	s2.sb.registerContract(&wrapperspb.StringValue{}, &wrapperspb.StringValue{})
+	if err = s1.PushToConn(t.Context(), remoteRef, wrapperspb.String("hello"), nil); err != nil {
+		t.Fatalf("remote proto push failed: %v", err)
+	}
+
+	waitForOut(t, remote.testConn, 2)
+
+	remote.Lock()
+	if msg, ok := remote.out[1].GetMsg().(*wrapperspb.StringValue); !ok || msg.GetValue() != "hello" {
+		t.Fatalf("unexpected remote proto envelope: %v", remote.out[1].GetMsg())
+	}
+	remote.Unlock()
+
+	if err = s1.KickConn(t.Context(), remoteRef); err != nil {
+		t.Fatalf("remote kick failed: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !remote.closed.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if !remote.closed.Load() {
+		t.Fatal("expected the remote connection to be closed")
+	}
+
+	err = s1.KickConn(t.Context(), ConnRef{ServerID: s2.sb.id, Gateway: "gateway.0", ID: 1})
+	if !errors.Is(err, ErrConnNotFound) {
+		t.Fatalf("expected ErrConnNotFound, got: %v", err)
+	}
+
+	err = s1.KickConn(t.Context(), ConnRef{Gateway: "gateway.1", ID: local.ConnID()})
+	if !errors.Is(err, ErrConnNotFound) {
+		t.Fatalf("expected ErrConnNotFound, got: %v", err)
+	}
+
+	_, err = NewServer().ListConns(t.Context(), ConnFilter{})
+	if !errors.Is(err, ErrConnRegistryDisabled) {
+		t.Fatalf("expected ErrConnRegistryDisabled, got: %v", err)
+	}
+}
+
+func TestConnAdminRequiresAuth(t *testing.T) {
+	defer func() {
+		if r := recover(); r != ErrConnAdminAuthRequired { //nolint:errorlint
+			t.Fatalf("expected ErrConnAdminAuthRequired, got: %v", r)
+		}
+	}()
+
+	newRegistryServer(newMemCluster(), ConnAdmin("/admin/conns", "", nil))
+}
+
+func TestConnAdminService(t *testing.T) {
+	var authorized atomic.Int32
+
+	s, gw := newRegistryServer(
+		newMemCluster(),
+		ConnAdmin("/admin/conns/", "conns", func(ctx *Context) { authorized.Add(1) }),
+	)
+	conn := openStreamConn(s, "10.0.0.1", nil)
+
+	s.Start(t.Context())
+	defer s.Shutdown(t.Context())
+
+	routes := map[string]connAdminSelector{}
+	for _, reg := range gw.regs {
+		if reg.svc == ConnAdminServiceName {
+			routes[reg.cid] = reg.sel.(connAdminSelector) //nolint:forcetypeassert
+		}
+	}
+
+	if sel := routes["kick"]; sel.GetMethod() != "POST" || sel.GetPath() != "/admin/conns/kick" ||
+		sel.GetPredicate() != "conns.kick" {
+		t.Fatalf("unexpected selector: %#v", sel)
+	}
+
+	exec := func(contractID, in string) *Envelope {
+		t.Helper()
+
+		gw.dispatchFn = func(ctx *Context, _ []byte) (ExecuteArg, error) {
+			ctx.In().SetMsg(RawMessage(in))
+
+			return ExecuteArg{ServiceName: ConnAdminServiceName, ContractID: contractID}, nil
+		}
+
+		caller := newTestConn()
+		gw.delegate.OnMessage(caller, []byte(in))
+
+		if len(caller.out) != 1 {
+			t.Fatalf("expected one envelope, got: %d", len(caller.out))
+		}
+
+		return caller.out[0]
+	}
+
+	list, ok := exec("list", `{"clientIP":"10.0.0.1"}`).GetMsg().(*ConnList)
+	if !ok || len(list.Conns) != 1 || list.Conns[0].ID != conn.ConnID() {
+		t.Fatalf("unexpected list: %#v", list)
+	}
+
+	ref := `{"serverID":"` + s.sb.id + `","gateway":"gateway.0","id":` + strconv.FormatUint(conn.ConnID(), 10)
+	if _, ok = exec("push", ref+`,"msg":{"n":1}}`).GetMsg().(*ConnRef); !ok {
+		t.Fatal("expected the push to succeed")
+	}
+	if _, ok = exec("kick", ref+`}`).GetMsg().(*ConnRef); !ok || !conn.closed.Load() {
+		t.Fatal("expected the kick to succeed")
+	}
+
+	if e, _ := exec("kick", `{"gateway":"gateway.0","id":1}`).GetMsg().(*Error); e == nil || e.Code != 404 {
+		t.Fatalf("expected not found error, got: %#v", e)
+	}
+	if e, _ := exec("push", `{"id":`).GetMsg().(*Error); e == nil || e.Code != 400 {
+		t.Fatalf("expected bad request error, got: %#v", e)
+	}
+
+	if authorized.Load() != 5 {
+		t.Fatalf("expected the admin handlers to run 5 times, got: %d", authorized.Load())
+	}
+
+	waitForOut(t, conn.testConn, 1)
+}
@@ -0,0 +1,167 @@
+package kit
+
+import (
+	"context"
+	"sync"
+)
+
+const connRouteKeyPrefix = "kit:conn:"
+
+type connOwner struct {
+	nb     *northBridge
+	connID uint64
+}
+
+type connRoute struct {
+	owner connOwner
+	conn  Conn
+}
+
+// connRouter keeps the connections which are bound to application level keys
+// (e.g., user ids). If the Cluster supports ClusterStore, then the ownership of each key
+// is published there, so other instances could route their envelopes to this instance.
+//
+// NOTE: the keys are removed from the ClusterStore when the connection is closed, however,
+// if the instance crashes, the stale keys remain until they are bound again.
+type connRouter struct {
+	id    string
+	store ClusterStore
+	// q runs the store operations of the closed connections in the background.
+	q *storeQueue
+
+	mtx    sync.RWMutex
+	routes map[string]connRoute
+	keys   map[connOwner]map[string]struct{}
+}
+
+func newConnRouter() *connRouter {
+	return &connRouter{
+		q:      newStoreQueue(nil),
+		routes: map[string]connRoute{},
+		keys:   map[connOwner]map[string]struct{}{},
+	}
+}
+
+func (r *connRouter) bind(ctx context.Context, nb *northBridge, conn Conn, key string) error {
+	owner := connOwner{nb: nb, connID: conn.ConnID()}
+
+	r.mtx.Lock()
+	if old, ok := r.routes[key]; ok {
+		r.deleteKey(old.owner, key)
+	}
+
+	r.routes[key] = connRoute{owner: owner, conn: conn}
+
+	keys, ok := r.keys[owner]
+	if !ok {
+		keys = map[string]struct{}{}
+		r.keys[owner] = keys
+	}
+
+	keys[key] = struct{}{}
+	r.mtx.Unlock()
+
+	if r.store == nil {
+		return nil
+	}
+
+	return r.store.Set(ctx, connRouteKeyPrefix+key, r.id, 0)
+}
+
+func (r *connRouter) unbind(ctx context.Context, key string) error {
+	r.mtx.Lock()
+	route, ok := r.routes[key]
+	if ok {
+		delete(r.routes, key)
+		r.deleteKey(route.owner, key)
+	}
+	r.mtx.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	return r.release(ctx, key)
+}
+
+// unbindConn removes all the keys bound to the connection. They are released from the
+// ClusterStore in the background, since it is called by the gateways on close.
+func (r *connRouter) unbindConn(nb *northBridge, connID uint64) {
+	owner := connOwner{nb: nb, connID: connID}
+
+	r.mtx.Lock()
+	keys := r.keys[owner]
+	for key := range keys {
+		delete(r.routes, key)
+	}
+
+	delete(r.keys, owner)
+	r.mtx.Unlock()
+
+	if len(keys) == 0 || r.store == nil {
+		return
+	}
+
+	r.q.push(func(ctx context.Context) error {
+		var err error
+		for key := range keys {
+			if rErr := r.release(ctx, key); rErr != nil && err == nil {
+				err = rErr
+			}
+		}
+
+		return err
+	})
+}
+
+func (r *connRouter) deleteKey(owner connOwner, key string) {
+	delete(r.keys[owner], key)
+
+	if len(r.keys[owner]) == 0 {
+		delete(r.keys, owner)
+	}
+}
+
+// release removes the key from the ClusterStore, if it is still owned by this instance.
+func (r *connRouter) release(ctx context.Context, key string) error {
+	if r.store == nil {
+		return nil
+	}
+
+	return compareAndDelete(ctx, r.store, connRouteKeyPrefix+key, r.id)
+}
+
+func (r *connRouter) local(key string) (Conn, bool) {
+	route, ok := r.route(key)
+
+	return route.conn, ok
+}
+
+func (r *connRouter) route(key string) (connRoute, bool) {
+	r.mtx.RLock()
+	route, ok := r.routes[key]
+	r.mtx.RUnlock()
+
+	return route, ok
+}
+
+// owner returns the id of the instance which owns the key. It returns an empty string
+// if the key is not bound to any connection.
+func (r *connRouter) owner(ctx context.Context, key string) string {
+	if _, ok := r.local(key); ok {
+		return r.id
+	}
+
+	if r.store == nil {
+		return ""
+	}
+
+	// some stores return an error for the missing keys, hence we consider any error
+	// as a missing key.
+	owner, err := r.store.Get(ctx, connRouteKeyPrefix+key)
+	if err != nil {
+		return ""
+	}
+
+	return owner
+}
@@ -1,5 +1,7 @@
 package kit
 
+import "time"
+
 // RouteSelector holds information about how this Contract is going to be selected. Each
 // Gateway may need different information to route the request to the right Contract.
 // RouteSelector is actually a base interface, and Gateway implementors usually implement
@@ -75,6 +77,26 @@ type Contract interface {
 	Modifiers() []ModifierFunc
 }
 
+// TimeoutContract is implemented by the Contracts which limit the duration of their handlers.
+// It is optional, hence the Contracts which do not implement it run with the default timeout
+// of the EdgeServer, if any.
+type TimeoutContract interface {
+	Contract
+	// Timeout returns the maximum duration that the handlers of this Contract are allowed
+	// to run. Zero means there is no deadline, unless the EdgeServer has a default one.
+	Timeout() time.Duration
+}
+
+// ContractTimeout returns the timeout of the contract, or zero if it does not implement
+// TimeoutContract.
+func ContractTimeout(c Contract) time.Duration {
+	if tc, ok := c.(TimeoutContract); ok {
+		return tc.Timeout()
+	}
+
+	return 0
+}
+
 // ContractWrapper is like an interceptor which can add Pre- and Post- handlers to all
 // the Contracts of the Contract.
 type ContractWrapper interface {
@@ -93,20 +115,44 @@ func (sw ContractWrapperFunc) Wrap(svc Contract) Contract {
 // this function.
 func WrapContract(c Contract, wrappers ...ContractWrapper) Contract {
 	for _, w := range wrappers {
+		timeout := ContractTimeout(c)
 		c = w.Wrap(c)
+
+		// the wrappers which embed the Contract hide its timeout, so it is kept here.
+		if timeout > 0 && ContractTimeout(c) == 0 {
+			c = &contractWrap{Contract: c, timeout: timeout}
+		}
 	}
 
 	return c
 }
 
+// InnerHandlers returns the handlers of the contract with h inserted before its last
+// handler, which is the main handler of the contracts built by the desc package. The wrappers
+// which could skip the main handler, e.g., to reply from a cache, use it, so the preceding
+// handlers, e.g., authentication, still run.
+func InnerHandlers(c Contract, h ...HandlerFunc) []HandlerFunc {
+	ch := c.Handlers()
+	if len(ch) == 0 {
+		return append([]HandlerFunc(nil), h...)
+	}
+
+	hs := make([]HandlerFunc, 0, len(ch)+len(h))
+	hs = append(hs, ch[:len(ch)-1]...)
+	hs = append(hs, h...)
+
+	return append(hs, ch[len(ch)-1])
+}
+
 // contractWrap implements the Contract interface and is useful when we need to wrap another
 // contract.
 type contractWrap struct {
 	Contract
 
-	h     []HandlerFunc
-	preM  []ModifierFunc
-	postM []ModifierFunc
+	h       []HandlerFunc
+	preM    []ModifierFunc
+	postM   []ModifierFunc
+	timeout time.Duration
 }
 
 var _ Contract = (*contractWrap)(nil)
@@ -127,3 +173,11 @@ func (c contractWrap) Modifiers() []ModifierFunc {
 
 	return m
 }
+
+func (c contractWrap) Timeout() time.Duration {
+	if c.timeout > 0 {
+		return c.timeout
+	}
+
+	return ContractTimeout(c.Contract)
+}
@@ -5,9 +5,12 @@ import (
 	"maps"
 	"math"
 	"net/http"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/clubpay/ronykit/kit/errors"
 	"github.com/clubpay/ronykit/kit/utils"
 )
 
@@ -31,6 +34,9 @@ type ctxPool struct {
 	sync.Pool
 
 	ls *localStore
+	cr *connRouter
+	tr *topicRouter
+	st *serverState
 	th HandlerFunc // trace handler
 }
 
@@ -41,6 +47,10 @@ func (p *ctxPool) acquireCtx(c Conn) *Context {
 	}
 
 	ctx.conn = c
+	ctx.cr = p.cr
+	ctx.tr = p.tr
+	ctx.st = p.st
+	ctx.ctx, ctx.cf = context.WithCancel(ctx.ctx)
 
 	ctx.in = newEnvelope(ctx, c, false)
 	if p.th != nil {
@@ -59,9 +69,15 @@ type Context struct {
 	utils.SpinLock
 
 	ctx       context.Context //nolint:containedctx
+	cf        context.CancelFunc
 	sb        *southBridge
+	nb        *northBridge
+	cr        *connRouter
+	tr        *topicRouter
+	st        *serverState
 	ls        *localStore
 	forwarded bool
+	overflow  OverflowPolicy
 	rxt       time.Duration // remote execution timeout
 
 	serviceName []byte
@@ -70,12 +86,22 @@ type Context struct {
 	rawData     []byte
 
 	kv         map[string]any
+	deps       map[reflect.Type]*dependency
 	hdr        map[string]string
 	conn       Conn
 	in         *Envelope
 	modifiers  []ModifierFunc
 	err        error
 	statusCode int
+	responded  atomic.Bool
+	rec        *recording
+
+	// sendMtx serializes the envelopes sent by the handlers and the timeout reply, which is
+	// sent from the timer's goroutine when the deadline fires. The state which the reply
+	// reads, i.e., the modifiers, the preset headers and the status code, is also written
+	// under it.
+	sendMtx  sync.Mutex
+	timedOut atomic.Bool
 
 	handlers     HandlerFuncChain
 	handlerIndex int
@@ -102,7 +128,21 @@ func (ctx *Context) reset() {
 		delete(ctx.hdr, k)
 	}
 
+	ctx.releaseDependencies()
+
+	if ctx.cf != nil {
+		ctx.cf()
+		ctx.cf = nil
+	}
+
+	ctx.nb = nil
+	ctx.rec = nil
 	ctx.forwarded = false
+	ctx.overflow = OverflowDefault
+	ctx.rxt = 0
+	ctx.err = nil
+	ctx.responded.Store(false)
+	ctx.timedOut.Store(false)
 	ctx.serviceName = ctx.serviceName[:0]
 	ctx.contractID = ctx.contractID[:0]
 	ctx.route = ctx.route[:0]
@@ -135,10 +175,74 @@ func (ctx *Context) execute(arg ExecuteArg, c Contract) {
 		setContractID(arg.ContractID).
 		AddModifier(c.Modifiers()...)
 
+	timeout := ContractTimeout(c)
+	if timeout <= 0 {
+		ctx.handlers = append(ctx.handlers, c.Handlers()...)
+		ctx.Next()
+
+		return
+	}
+
+	deadlineCtx, cf := context.WithTimeout(ctx.ctx, timeout)
+	defer cf()
+
+	ctx.ctx = deadlineCtx
+	if ctx.rxt == 0 {
+		ctx.rxt = timeout
+	}
+
+	// the timeout is replied as soon as the deadline fires, but the handlers are still
+	// awaited, since the Context and the connection are reused once they return.
+	fired := make(chan struct{})
+	stop := context.AfterFunc(deadlineCtx, func() {
+		defer close(fired)
+
+		if errors.Is(deadlineCtx.Err(), context.DeadlineExceeded) {
+			ctx.timeout()
+		}
+	})
+	defer func() {
+		if !stop() {
+			<-fired
+		}
+
+		if ctx.timedOut.Load() {
+			ctx.Error(ErrRequestTimeout)
+		}
+	}()
+
 	ctx.handlers = append(ctx.handlers, c.Handlers()...)
 	ctx.Next()
 }
 
+// timeout is called when the contract's deadline is exceeded. If no envelope has been sent
+// to the connection yet, a timeout error is sent to the client. The envelopes which the
+// handlers send afterward are dropped.
+func (ctx *Context) timeout() {
+	ctx.sendMtx.Lock()
+	defer ctx.sendMtx.Unlock()
+
+	ctx.timedOut.Store(true)
+	if ctx.responded.Load() {
+		return
+	}
+
+	if rc, ok := ctx.conn.(RESTConn); ok {
+		rc.SetStatusCode(http.StatusGatewayTimeout)
+	}
+
+	e := ctx.In().Reply().SetMsg(NewError(http.StatusGatewayTimeout, "TIMEOUT"))
+	_ = e.send()
+}
+
+// cancel cancels the context.Context of this Context. Any deadline set by the contract is
+// also canceled.
+func (ctx *Context) cancel() {
+	if ctx.cf != nil {
+		ctx.cf()
+	}
+}
+
 // Next sets the next handler which will be called after the current handler.
 /*
 	Here's a brief explanation of the Next() method:
@@ -173,15 +277,21 @@ func (ctx *Context) StopExecution() {
 // AddModifier adds one or more modifiers to the context which will be executed on each outgoing
 // Envelope before writing it to the wire.
 func (ctx *Context) AddModifier(modifiers ...ModifierFunc) {
+	ctx.sendMtx.Lock()
 	ctx.modifiers = append(ctx.modifiers, modifiers...)
+	ctx.sendMtx.Unlock()
 }
 
+// SetUserContext replaces the context.Context returned by Context method.
+// NOTE: userCtx should be derived from Context(), otherwise the contract's deadline and
+// the cancellation on connection close will not be propagated.
 func (ctx *Context) SetUserContext(userCtx context.Context) {
 	ctx.ctx = context.WithValue(userCtx, kitCtxKey, ctx)
 }
 
-// Context returns a context.Background which can be used a reference context for
-// other context-aware function calls.
+// Context returns a context.Context which can be used a reference context for
+// other context-aware function calls. It is canceled when the request is finished, the
+// contract's deadline is exceeded, or the underlying stream connection is closed.
 func (ctx *Context) Context() context.Context {
 	return ctx.ctx
 }
@@ -189,6 +299,14 @@ func (ctx *Context) Context() context.Context {
 // SetStatusCode set the connection status. It **ONLY** works if the underlying connection
 // is a RESTConn connection.
 func (ctx *Context) SetStatusCode(code int) {
+	ctx.sendMtx.Lock()
+	defer ctx.sendMtx.Unlock()
+
+	// the status of the timeout reply is kept.
+	if ctx.timedOut.Load() {
+		return
+	}
+
 	ctx.statusCode = code
 
 	rc, ok := ctx.Conn().(RESTConn)
@@ -235,13 +353,17 @@ func (ctx *Context) IsREST() bool {
 // header in some middleware before the actual response is prepared.
 // If you only want to set the header for an envelope, you can use Envelope.SetHdr method instead.
 func (ctx *Context) PresetHdr(k, v string) {
+	ctx.sendMtx.Lock()
 	ctx.hdr[k] = v
+	ctx.sendMtx.Unlock()
 }
 
 // PresetHdrMap sets the common header key-value pairs, so in the Out method we do not need to
 // repeatedly set those. Please refer to PresetHdr for more details
 func (ctx *Context) PresetHdrMap(hdr map[string]string) {
+	ctx.sendMtx.Lock()
 	maps.Copy(ctx.hdr, hdr)
+	ctx.sendMtx.Unlock()
 }
 
 // In returns the incoming Envelope which received from the connection.
@@ -1,11 +1,21 @@
 package kit
 
-import "github.com/clubpay/ronykit/kit/errors"
+import (
+	"context"
+	"time"
+
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/utils"
+
+	"github.com/goccy/go-reflect"
+)
 
 var (
 	ErrClusterNotSet          = errors.New("cluster is not set")
 	ErrClusterMemberNotFound  = errors.New("cluster member not found")
 	ErrClusterMemberNotActive = errors.New("cluster member not active")
+	ErrClusterCallTimeout     = errors.New("cluster call timeout")
+	ErrClusterCallNoReply     = errors.New("cluster call got no reply")
 )
 
 // ClusterStore returns a key-value store which is shared between different instances of the cluster.
@@ -45,3 +55,201 @@ func (ctx *Context) ClusterMembers() ([]string, error) {
 
 	return ctx.sb.cb.Subscribers()
 }
+
+// ClusterReply is an envelope which is sent back by the cluster member which executed
+// the contract of a ClusterCall.
+type ClusterReply struct {
+	ID         string
+	StatusCode int
+	Hdr        map[string]string
+	MsgType    string
+	Msg        RawMessage
+}
+
+// Unmarshal decodes the message of the reply into m.
+func (r *ClusterReply) Unmarshal(m Message) error {
+	if raw, ok := m.(*RawMessage); ok {
+		raw.CopyFrom(r.Msg)
+
+		return nil
+	}
+
+	// the replies are encoded as the carriers, i.e., the proto messages in their binary
+	// form.
+	return UnmarshalMessageAs(carrierEncoding(m), r.Msg, m)
+}
+
+type clusterCallConfig struct {
+	timeout time.Duration
+	hdr     map[string]string
+}
+
+type ClusterCallOption func(cfg *clusterCallConfig)
+
+// ClusterCallTimeout sets the maximum time to wait for the cluster member to finish
+// executing the contract. Default is the remote execution timeout of the Context, which
+// is set by LimitedContext.SetRemoteExecutionTimeout or the contract's timeout.
+func ClusterCallTimeout(timeout time.Duration) ClusterCallOption {
+	return func(cfg *clusterCallConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+// ClusterCallHdr sets a header on the envelope which is delivered to the contract's handlers.
+func ClusterCallHdr(key, val string) ClusterCallOption {
+	return func(cfg *clusterCallConfig) {
+		if cfg.hdr == nil {
+			cfg.hdr = make(map[string]string, 4)
+		}
+
+		cfg.hdr[key] = val
+	}
+}
+
+// ClusterCall executes the contract identified by serviceName and contractID on the
+// cluster member targetID, and unmarshals the first reply into out. The call returns
+// when the remote handlers are finished. If the handlers did not send any envelope,
+// ErrClusterCallNoReply is returned.
+//
+// NOTE: in and out must be the types the contract is registered with.
+func (ctx *Context) ClusterCall(
+	targetID, serviceName, contractID string, in, out Message, opts ...ClusterCallOption,
+) error {
+	var (
+		replied bool
+		err     error
+	)
+
+	callErr := ctx.ClusterCallStream(
+		targetID, serviceName, contractID, in,
+		func(r *ClusterReply) bool {
+			if !replied {
+				replied = true
+				err = r.Unmarshal(out)
+			}
+
+			return true
+		},
+		opts...,
+	)
+
+	switch {
+	case callErr != nil:
+		return callErr
+	case !replied:
+		return ErrClusterCallNoReply
+	}
+
+	return err
+}
+
+// ClusterCallStream executes the contract identified by serviceName and contractID on the
+// cluster member targetID, and calls f for each envelope sent back by the contract's
+// handlers, in the same order they were sent. If f returns false, the call is canceled
+// and no more replies will be delivered.
+func (ctx *Context) ClusterCallStream(
+	targetID, serviceName, contractID string, in Message, f func(r *ClusterReply) bool,
+	opts ...ClusterCallOption,
+) error {
+	if ctx.sb == nil {
+		return ErrClusterNotSet
+	}
+
+	cfg := clusterCallConfig{
+		timeout: ctx.rxt,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// the remote member decodes the message as the other carriers.
+	msg, _, err := MarshalMessageAs(carrierEncoding(in), in)
+	if err != nil {
+		return err
+	}
+
+	carrier := newEnvelopeCarrier(incomingCarrier, utils.RandomID(32), ctx.sb.id, targetID)
+	carrier.Data = &carrierData{
+		EnvelopeID:  utils.RandomID(16),
+		Hdr:         cfg.hdr,
+		MsgType:     reflect.TypeOf(in).String(),
+		Msg:         msg,
+		ContractID:  contractID,
+		ServiceName: serviceName,
+	}
+
+	if tp := ctx.sb.tp; tp != nil {
+		tp.Inject(ctx.ctx, carrier.Data)
+	}
+
+	var (
+		conn    *clusterConn
+		replies = make(chan *envelopeCarrier, clusterCallBufferSize)
+	)
+
+	// We create the connection before sending the carrier, otherwise the replies could
+	// arrive before we can route them to this call.
+	conn = ctx.sb.createSenderConn(
+		carrier, cfg.timeout,
+		func(c *envelopeCarrier) {
+			select {
+			case replies <- c:
+			case <-conn.Done():
+			}
+		},
+	)
+	defer ctx.sb.deleteConn(carrier.SessionID)
+
+	err = ctx.sb.sendMessage(carrier)
+	if err != nil {
+		conn.Cancel()
+
+		return err
+	}
+
+	for {
+		select {
+		case c := <-replies:
+			if !f(newClusterReply(c)) {
+				conn.Cancel()
+
+				return nil
+			}
+		case <-conn.Done():
+			// The replies are queued before the EOF carrier cancels the connection,
+			// so we need to deliver the remaining ones.
+			for drained := false; !drained; {
+				select {
+				case c := <-replies:
+					if !f(newClusterReply(c)) {
+						return nil
+					}
+				default:
+					drained = true
+				}
+			}
+
+			if errors.Is(conn.Err(), context.DeadlineExceeded) {
+				return ErrClusterCallTimeout
+			}
+
+			return nil
+		case <-ctx.ctx.Done():
+			conn.Cancel()
+
+			return ctx.ctx.Err()
+		}
+	}
+}
+
+const clusterCallBufferSize = 16
+
+func newClusterReply(c *envelopeCarrier) *ClusterReply {
+	return &ClusterReply{
+		ID:         c.Data.EnvelopeID,
+		StatusCode: c.Data.StatusCode,
+		Hdr:        c.Data.Hdr,
+		MsgType:    c.Data.MsgType,
+		Msg:        c.Data.Msg,
+	}
+}
@@ -0,0 +1,325 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// memCluster delivers the published messages to the subscribers in the same order.
+type memCluster struct {
+	mtx       sync.Mutex
+	delegates map[string]chan []byte
+}
+
+func newMemCluster() *memCluster {
+	return &memCluster{delegates: map[string]chan []byte{}}
+}
+
+func (c *memCluster) Start(context.Context) error    { return nil }
+func (c *memCluster) Shutdown(context.Context) error { return nil }
+
+func (c *memCluster) Subscribe(id string, d ClusterDelegate) {
+	ch := make(chan []byte, 64)
+
+	c.mtx.Lock()
+	c.delegates[id] = ch
+	c.mtx.Unlock()
+
+	go func() {
+		for data := range ch {
+			d.OnMessage(data)
+		}
+	}()
+}
+
+func (c *memCluster) Publish(id string, data []byte) error {
+	c.mtx.Lock()
+	ch, ok := c.delegates[id]
+	c.mtx.Unlock()
+
+	if !ok {
+		return ErrClusterMemberNotFound
+	}
+
+	ch <- append([]byte(nil), data...)
+
+	return nil
+}
+
+func (c *memCluster) Subscribers() ([]string, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	members := make([]string, 0, len(c.delegates))
+	for id := range c.delegates {
+		members = append(members, id)
+	}
+
+	return members, nil
+}
+
+type callIn struct {
+	N int `json:"n"`
+}
+
+type callOut struct {
+	N int `json:"n"`
+}
+
+func newCallBridge(id string, cluster Cluster, contracts ...Contract) *southBridge {
+	sb := &southBridge{
+		ctxPool:      ctxPool{ls: &localStore{kv: map[string]any{}}},
+		id:           id,
+		wg:           &sync.WaitGroup{},
+		eh:           func(_ *Context, _ error) {},
+		c:            map[string]Contract{},
+		cb:           cluster,
+		cc:           BinaryCarrierCodec,
+		inProgress:   map[string]*clusterConn{},
+		msgFactories: map[string]MessageFactoryFunc{},
+	}
+
+	for _, c := range contracts {
+		sb.c[contractLookupKey("svc", c.ID())] = c
+		sb.registerContract(c.Input(), c.Output())
+	}
+
+	cluster.Subscribe(id, sb)
+
+	return sb
+}
+
+func newCallContext(sb *southBridge) *Context {
+	ctx := newContext(sb.ls)
+	ctx.sb = sb
+	ctx.conn = newTestConn()
+	ctx.in = newEnvelope(ctx, ctx.conn, false)
+
+	return ctx
+}
+
+func TestContextClusterCall(t *testing.T) {
+	cluster := newMemCluster()
+	caller := newCallBridge("n1", cluster)
+	newCallBridge(
+		"n2", cluster,
+		&testContract{
+			id:     "double",
+			input:  &callIn{},
+			output: &callOut{},
+			handlers: []HandlerFunc{
+				func(ctx *Context) {
+					in := ctx.In().GetMsg().(*callIn) //nolint:forcetypeassert
+					ctx.Out().
+						SetHdr("node", ctx.ClusterID()).
+						SetMsg(&callOut{N: in.N * 2}).
+						Send()
+				},
+			},
+		},
+		&testContract{
+			id:     "count",
+			input:  &callIn{},
+			output: &callOut{},
+			handlers: []HandlerFunc{
+				func(ctx *Context) {
+					in := ctx.In().GetMsg().(*callIn) //nolint:forcetypeassert
+					for i := range in.N {
+						ctx.Out().SetMsg(&callOut{N: i}).Send()
+					}
+				},
+			},
+		},
+		&testContract{
+			id:     "upper",
+			input:  &wrapperspb.StringValue{},
+			output: &wrapperspb.StringValue{},
+			handlers: []HandlerFunc{
+				func(ctx *Context) {
+					in := ctx.In().GetMsg().(*wrapperspb.StringValue) //nolint:forcetypeassert
+					ctx.Out().SetMsg(wrapperspb.String(strings.ToUpper(in.GetValue()))).Send()
+				},
+			},
+		},
+		&testContract{
+			id:       "silent",
+			input:    &callIn{},
+			output:   &callOut{},
+			handlers: []HandlerFunc{func(_ *Context) {}},
+		},
+		&testContract{
+			id:     "slow",
+			input:  &callIn{},
+			output: &callOut{},
+			handlers: []HandlerFunc{
+				func(_ *Context) { time.Sleep(200 * time.Millisecond) },
+			},
+		},
+	)
+
+	t.Run("single reply", func(t *testing.T) {
+		out := &callOut{}
+
+		err := newCallContext(caller).ClusterCall("n2", "svc", "double", &callIn{N: 21}, out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.N != 42 {
+			t.Fatalf("unexpected reply: %d", out.N)
+		}
+	})
+
+	t.Run("proto messages", func(t *testing.T) {
+		out := &wrapperspb.StringValue{}
+
+		err := newCallContext(caller).ClusterCall(
+			"n2", "svc", "upper", wrapperspb.String("hi"), out,
+			ClusterCallTimeout(time.Second),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.GetValue() != "HI" {
+			t.Fatalf("unexpected reply: %q", out.GetValue())
+		}
+	})
+
+	t.Run("stream replies in order", func(t *testing.T) {
+		var got []int
+
+		err := newCallContext(caller).ClusterCallStream(
+			"n2", "svc", "count", &callIn{N: 50},
+			func(r *ClusterReply) bool {
+				out := &callOut{}
+				if err := r.Unmarshal(out); err != nil {
+					t.Errorf("unmarshal failed: %v", err)
+				}
+
+				got = append(got, out.N)
+
+				return true
+			},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 50 {
+			t.Fatalf("expected 50 replies, got: %d", len(got))
+		}
+
+		for i, n := range got {
+			if n != i {
+				t.Fatalf("unexpected order: %v", got)
+			}
+		}
+	})
+
+	t.Run("stop streaming", func(t *testing.T) {
+		calls := 0
+
+		err := newCallContext(caller).ClusterCallStream(
+			"n2", "svc", "count", &callIn{N: 10},
+			func(_ *ClusterReply) bool {
+				calls++
+
+				return false
+			},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls != 1 {
+			t.Fatalf("expected one reply, got: %d", calls)
+		}
+	})
+
+	t.Run("slow consumer with nested call", func(t *testing.T) {
+		var got []int
+
+		ctx := newCallContext(caller)
+		err := ctx.ClusterCallStream(
+			"n2", "svc", "count", &callIn{N: 3 * clusterCallBufferSize},
+			func(r *ClusterReply) bool {
+				out := &callOut{}
+				if err := r.Unmarshal(out); err != nil {
+					t.Errorf("unmarshal failed: %v", err)
+				}
+
+				got = append(got, out.N)
+
+				// the replies of the outer call fill its buffer meanwhile, but the reply of the
+				// nested call is still delivered.
+				if out.N == 0 {
+					time.Sleep(20 * time.Millisecond)
+
+					nested := &callOut{}
+					err := ctx.ClusterCall(
+						"n2", "svc", "double", &callIn{N: 2}, nested,
+						ClusterCallTimeout(time.Second),
+					)
+					if err != nil || nested.N != 4 {
+						t.Errorf("unexpected nested reply: %d, %v", nested.N, err)
+					}
+				}
+
+				return true
+			},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 3*clusterCallBufferSize {
+			t.Fatalf("expected %d replies, got: %d", 3*clusterCallBufferSize, len(got))
+		}
+
+		for i, n := range got {
+			if n != i {
+				t.Fatalf("unexpected order: %v", got)
+			}
+		}
+	})
+
+	t.Run("no reply", func(t *testing.T) {
+		err := newCallContext(caller).ClusterCall("n2", "svc", "silent", &callIn{}, &callOut{})
+		if !errors.Is(err, ErrClusterCallNoReply) {
+			t.Fatalf("expected ErrClusterCallNoReply, got: %v", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		err := newCallContext(caller).ClusterCall(
+			"n2", "svc", "slow", &callIn{}, &callOut{},
+			ClusterCallTimeout(20*time.Millisecond),
+		)
+		if !errors.Is(err, ErrClusterCallTimeout) {
+			t.Fatalf("expected ErrClusterCallTimeout, got: %v", err)
+		}
+	})
+
+	t.Run("unknown member", func(t *testing.T) {
+		err := newCallContext(caller).ClusterCall("n3", "svc", "double", &callIn{}, &callOut{})
+		if !errors.Is(err, ErrClusterMemberNotFound) {
+			t.Fatalf("expected ErrClusterMemberNotFound, got: %v", err)
+		}
+	})
+
+	caller.inProgressMtx.Lock()
+	defer caller.inProgressMtx.Unlock()
+
+	if len(caller.inProgress) != 0 {
+		t.Fatalf("expected no sessions in progress, got: %d", len(caller.inProgress))
+	}
+}
+
+func TestContextClusterCallWithoutCluster(t *testing.T) {
+	err := NewContext(nil).ClusterCall("n2", "svc", "c1", &callIn{}, &callOut{})
+	if !errors.Is(err, ErrClusterNotSet) {
+		t.Fatalf("expected ErrClusterNotSet, got: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package kit
+
+import (
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/utils"
+
+	"github.com/goccy/go-reflect"
+)
+
+var (
+	ErrConnNotFound    = errors.New("connection not found")
+	ErrConnNotBindable = errors.New("connection is not bindable")
+)
+
+// BindConn binds the connection of this Context to connKey (e.g., a user id), so
+// the handlers on any instance of the cluster could send envelopes to this connection
+// by SendToRemoteConn. If the Cluster supports ClusterStore, the ownership of the key
+// is published there. Each key is bound to one connection, and binding it again replaces
+// the previous connection. The keys are unbound when the connection is closed.
+func (ctx *Context) BindConn(connKey string) error {
+	if ctx.cr == nil || ctx.nb == nil {
+		return ErrConnNotBindable
+	}
+
+	return ctx.cr.bind(ctx.ctx, ctx.nb, ctx.conn, connKey)
+}
+
+// UnbindConn removes the binding of connKey.
+func (ctx *Context) UnbindConn(connKey string) error {
+	if ctx.cr == nil {
+		return ErrConnNotBindable
+	}
+
+	return ctx.cr.unbind(ctx.ctx, connKey)
+}
+
+// SendToRemoteConn sends the message and headers of the envelope to the connection bound
+// to connKey, which could be held by any instance of the cluster. You can create the
+// envelope by Out method, and you MUST NOT use it after this call.
+// The gateway of the connection encodes the message, if the holding instance knows its
+// type, e.g., it is the input or output of a contract; otherwise, the message is written
+// as JSON, or Proto for the proto messages.
+// If connKey is not bound to any connection, ErrConnNotFound is returned.
+func (ctx *Context) SendToRemoteConn(connKey string, e *Envelope) error {
+	return ctx.SendToRemoteConns([]string{connKey}, e)
+}
+
+// SendToRemoteConns is similar to SendToRemoteConn, except that it sends the envelope to
+// the connections of all the connKeys. The envelope is published to each instance only
+// once, no matter how many of its connections are targeted.
+// If some keys are not bound to any connection, ErrConnNotFound is returned after
+// sending the envelope to the rest of them.
+func (ctx *Context) SendToRemoteConns(connKeys []string, e *Envelope) error {
+	defer e.release()
+
+	if ctx.cr == nil {
+		return ErrConnNotBindable
+	}
+
+	var (
+		missing []string
+		remotes = map[string][]string{}
+	)
+
+	for _, key := range connKeys {
+		if conn, ok := ctx.cr.local(key); ok {
+			ctx.OutTo(conn).
+				SetHdrMap(e.kv).
+				SetMsg(e.GetMsg()).
+				Send()
+
+			continue
+		}
+
+		owner := ctx.cr.owner(ctx.ctx, key)
+
+		// the key could be stale, if this instance has been restarted.
+		if owner == "" || owner == ctx.cr.id || ctx.sb == nil {
+			missing = append(missing, key)
+
+			continue
+		}
+
+		remotes[owner] = append(remotes[owner], key)
+	}
+
+	if len(remotes) > 0 {
+		// the message is carried by its type, so the owners encode it for their connections.
+		msg, _, err := MarshalMessageAs(carrierEncoding(e.GetMsg()), e.GetMsg())
+		if err != nil {
+			return err
+		}
+
+		for owner, keys := range remotes {
+			carrier := newEnvelopeCarrier(pushCarrier, utils.RandomID(32), ctx.sb.id, owner)
+			carrier.Data = &carrierData{
+				Hdr:      e.kv,
+				MsgType:  reflect.TypeOf(e.GetMsg()).String(),
+				Msg:      msg,
+				ConnKeys: keys,
+			}
+
+			err = ctx.sb.publish(carrier)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return errors.Wrap(ErrConnNotFound, errors.New("keys=%v", missing))
+	}
+
+	return nil
+}
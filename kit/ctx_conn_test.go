@@ -0,0 +1,312 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memStore struct {
+	mtx  sync.Mutex
+	kv   map[string]string
+	ttls map[string]time.Duration
+}
+
+var errMemStoreMissingKey = errors.New("missing key")
+
+func (s *memStore) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	s.mtx.Lock()
+	s.kv[key] = value
+	if s.ttls == nil {
+		s.ttls = map[string]time.Duration{}
+	}
+	s.ttls[key] = ttl
+	s.mtx.Unlock()
+
+	return nil
+}
+
+func (s *memStore) SetMulti(ctx context.Context, kv map[string]string, ttl time.Duration) error {
+	for k, v := range kv {
+		_ = s.Set(ctx, k, v, ttl)
+	}
+
+	return nil
+}
+
+func (s *memStore) Delete(_ context.Context, key string) error {
+	s.mtx.Lock()
+	delete(s.kv, key)
+	s.mtx.Unlock()
+
+	return nil
+}
+
+func (s *memStore) Get(_ context.Context, key string) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	v, ok := s.kv[key]
+	if !ok {
+		return "", errMemStoreMissingKey
+	}
+
+	return v, nil
+}
+
+func (s *memStore) Scan(context.Context, string, func(string) bool) error { return nil }
+
+func (s *memStore) ScanWithValue(_ context.Context, prefix string, cb func(string, string) bool) error {
+	s.mtx.Lock()
+	kv := make(map[string]string, len(s.kv))
+	for k, v := range s.kv {
+		kv[k] = v
+	}
+	s.mtx.Unlock()
+
+	for k, v := range kv {
+		if strings.HasPrefix(k, prefix) && !cb(k, v) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+type memClusterWithStore struct {
+	*memCluster
+	store *memStore
+}
+
+func (c memClusterWithStore) Store() ClusterStore { return c.store }
+
+func newRoutedServer(cluster Cluster) *EdgeServer {
+	return NewServer(WithCluster(cluster), WithGateway(&testGateway{}))
+}
+
+func bindTestConn(t *testing.T, s *EdgeServer, keys ...string) *testConn {
+	t.Helper()
+
+	conn := newTestConn()
+	ctx := s.nb[0].acquireCtx(conn)
+	ctx.nb = s.nb[0]
+
+	for _, key := range keys {
+		if err := ctx.BindConn(key); err != nil {
+			t.Fatalf("bind failed: %v", err)
+		}
+	}
+
+	s.nb[0].releaseCtx(ctx)
+
+	return conn
+}
+
+func waitForOut(t *testing.T, conn *testConn, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		conn.Lock()
+		got := len(conn.out)
+		conn.Unlock()
+
+		if got >= n {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("expected %d envelope(s), got: %d", n, len(conn.out))
+}
+
+func TestContextSendToRemoteConns(t *testing.T) {
+	store := &memStore{kv: map[string]string{}}
+	cluster := memClusterWithStore{memCluster: newMemCluster(), store: store}
+	s1 := newRoutedServer(cluster)
+	s2 := newRoutedServer(cluster)
+
+	local := bindTestConn(t, s1, "user:1")
+	remote1 := bindTestConn(t, s2, "user:2", "user:2:web")
+	remote2 := bindTestConn(t, s2, "user:3")
+
+	if owner := store.kv[connRouteKeyPrefix+"user:2"]; owner != s2.sb.id {
+		t.Fatalf("unexpected owner: %s", owner)
+	}
+
+	ctx := s1.nb[0].acquireCtx(newTestConn())
+	ctx.sb = s1.sb
+
+	err := ctx.SendToRemoteConns(
+		[]string{"user:1", "user:2", "user:2:web", "user:3", "user:4"},
+		ctx.Out().SetHdr("k", "v").SetMsg(&callOut{N: 7}),
+	)
+	if !errors.Is(err, ErrConnNotFound) || !strings.Contains(err.Error(), "user:4") {
+		t.Fatalf("expected ErrConnNotFound for user:4, got: %v", err)
+	}
+
+	waitForOut(t, local, 1)
+	waitForOut(t, remote1, 2)
+	waitForOut(t, remote2, 1)
+
+	if msg, ok := local.out[0].GetMsg().(*callOut); !ok || msg.N != 7 {
+		t.Fatalf("unexpected local message: %#v", local.out[0].GetMsg())
+	}
+
+	remote1.Lock()
+	defer remote1.Unlock()
+
+	for _, e := range remote1.out {
+		if string(e.GetMsg().(RawMessage)) != `{"n":7}` { //nolint:forcetypeassert
+			t.Fatalf("unexpected remote message: %s", e.GetMsg())
+		}
+		if e.GetHdr("k") != "v" {
+			t.Fatalf("unexpected remote header: %s", e.GetHdr("k"))
+		}
+	}
+}
+
+func TestContextSendToRemoteConnOrder(t *testing.T) {
+	cluster := memClusterWithStore{memCluster: newMemCluster(), store: &memStore{kv: map[string]string{}}}
+	s1 := newRoutedServer(cluster)
+	s2 := newRoutedServer(cluster)
+
+	// the type is known by the owner, hence its gateway encodes the message.
+	s2.sb.registerContract(&callIn{}, &callOut{})
+
+	remote := bindTestConn(t, s2, "user:1")
+
+	ctx := s1.nb[0].acquireCtx(newTestConn())
+	ctx.sb = s1.sb
+
+	const count = 100
+	for i := range count {
+		if err := ctx.SendToRemoteConn("user:1", ctx.Out().SetMsg(&callOut{N: i})); err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+	}
+
+	waitForOut(t, remote, count)
+
+	remote.Lock()
+	defer remote.Unlock()
+
+	for i, e := range remote.out {
+		if msg, ok := e.GetMsg().(*callOut); !ok || msg.N != i {
+			t.Fatalf("unexpected message #%d: %#v", i, e.GetMsg())
+		}
+	}
+}
+
+type casMemStore struct {
+	*memStore
+
+	calls int
+}
+
+func (s *casMemStore) CompareAndDelete(_ context.Context, key, value string) (bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.calls++
+	if s.kv[key] != value {
+		return false, nil
+	}
+
+	delete(s.kv, key)
+
+	return true, nil
+}
+
+func TestConnRouterCompareAndDelete(t *testing.T) {
+	store := &casMemStore{memStore: &memStore{kv: map[string]string{}}}
+	s := newRoutedServer(struct {
+		*memCluster
+		casClusterStore
+	}{newMemCluster(), casClusterStore{store}})
+
+	conn := bindTestConn(t, s, "user:1")
+
+	// another instance has taken the key meanwhile
+	_ = store.Set(t.Context(), connRouteKeyPrefix+"user:1", "other", 0)
+
+	s.nb[0].OnClose(conn.ConnID())
+	s.cr.q.flush()
+
+	if store.calls != 1 || store.kv[connRouteKeyPrefix+"user:1"] != "other" {
+		t.Fatalf("unexpected store: %d, %v", store.calls, store.kv)
+	}
+}
+
+type casClusterStore struct {
+	store *casMemStore
+}
+
+func (c casClusterStore) Store() ClusterStore { return c.store }
+
+func TestConnRouterUnbindOnClose(t *testing.T) {
+	store := &memStore{kv: map[string]string{}}
+	cluster := memClusterWithStore{memCluster: newMemCluster(), store: store}
+	s1 := newRoutedServer(cluster)
+	s2 := newRoutedServer(cluster)
+
+	conn := bindTestConn(t, s2, "user:1", "user:2")
+	s2.nb[0].OnClose(conn.ConnID())
+	s2.cr.q.flush()
+
+	if len(store.kv) != 0 {
+		t.Fatalf("expected the keys to be released, got: %v", store.kv)
+	}
+	if _, ok := s2.cr.local("user:1"); ok {
+		t.Fatal("expected user:1 to be unbound")
+	}
+
+	ctx := s1.nb[0].acquireCtx(newTestConn())
+	ctx.sb = s1.sb
+
+	err := ctx.SendToRemoteConn("user:1", ctx.Out().SetMsg(&callOut{}))
+	if !errors.Is(err, ErrConnNotFound) {
+		t.Fatalf("expected ErrConnNotFound, got: %v", err)
+	}
+}
+
+func TestConnRouterRebind(t *testing.T) {
+	store := &memStore{kv: map[string]string{}}
+	s := newRoutedServer(memClusterWithStore{memCluster: newMemCluster(), store: store})
+
+	oldConn := bindTestConn(t, s, "user:1")
+	newConn := bindTestConn(t, s, "user:1")
+
+	// closing the old connection must not release the key owned by the new one
+	s.nb[0].OnClose(oldConn.ConnID())
+	s.cr.q.flush()
+
+	conn, ok := s.cr.local("user:1")
+	if !ok || conn != newConn {
+		t.Fatal("expected user:1 to be bound to the new connection")
+	}
+	if store.kv[connRouteKeyPrefix+"user:1"] != s.sb.id {
+		t.Fatal("expected user:1 to remain in the store")
+	}
+
+	ctx := s.nb[0].acquireCtx(newConn)
+	ctx.nb = s.nb[0]
+
+	if err := ctx.UnbindConn("user:1"); err != nil {
+		t.Fatalf("unbind failed: %v", err)
+	}
+	if len(store.kv) != 0 || len(s.cr.keys) != 0 {
+		t.Fatalf("expected no keys, got: %v", store.kv)
+	}
+}
+
+func TestContextBindConnNotBindable(t *testing.T) {
+	ctx := NewContext(nil)
+	if err := ctx.BindConn("user:1"); !errors.Is(err, ErrConnNotBindable) {
+		t.Fatalf("expected ErrConnNotBindable, got: %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newDeadlineBridge(c Contract) (*northBridge, *testGateway) {
+	gw := &testGateway{
+		dispatchFn: func(_ *Context, _ []byte) (ExecuteArg, error) {
+			return ExecuteArg{ServiceName: "svc", ContractID: "c1"}, nil
+		},
+	}
+
+	b := &northBridge{
+		ctxPool: ctxPool{ls: &localStore{kv: map[string]any{}}},
+		wg:      &sync.WaitGroup{},
+		eh:      func(_ *Context, _ error) {},
+		c:       map[string]Contract{contractLookupKey("svc", "c1"): c},
+		gw:      gw,
+	}
+
+	return b, gw
+}
+
+func TestContextDeadlineSendsTimeoutError(t *testing.T) {
+	var (
+		deadlineSet bool
+		gotErr      error
+	)
+
+	contract := &testContract{
+		id:      "c1",
+		timeout: 10 * time.Millisecond,
+		handlers: []HandlerFunc{
+			func(ctx *Context) {
+				_, deadlineSet = ctx.Context().Deadline()
+				<-ctx.Context().Done()
+			},
+			func(ctx *Context) {
+				gotErr = ctx.err
+			},
+		},
+	}
+
+	b, _ := newDeadlineBridge(contract)
+	conn := newTestRESTConn()
+	b.OnMessage(conn, []byte("in"))
+
+	if !deadlineSet {
+		t.Fatal("expected deadline to be set on the context")
+	}
+	if gotErr != nil {
+		t.Fatalf("unexpected error inside handlers: %v", gotErr)
+	}
+	if conn.statusCode != http.StatusGatewayTimeout {
+		t.Fatalf("unexpected status code: %d", conn.statusCode)
+	}
+	if len(conn.out) != 1 {
+		t.Fatalf("expected one envelope, got: %d", len(conn.out))
+	}
+
+	msg, ok := conn.out[0].GetMsg().(*Error)
+	if !ok || msg.GetCode() != http.StatusGatewayTimeout {
+		t.Fatalf("unexpected timeout message: %#v", conn.out[0].GetMsg())
+	}
+}
+
+func TestContextDeadlineRepliesBeforeHandlersReturn(t *testing.T) {
+	release := make(chan struct{})
+	contract := &testContract{
+		id:      "c1",
+		timeout: 10 * time.Millisecond,
+		handlers: []HandlerFunc{
+			func(ctx *Context) {
+				<-release
+				// the client has already got the timeout error, hence it is dropped.
+				ctx.Out().SetMsg(&bridgeOut{OK: true}).Send()
+			},
+		},
+	}
+
+	b, _ := newDeadlineBridge(contract)
+	conn := newTestRESTConn()
+
+	done := make(chan struct{})
+	go func() {
+		b.OnMessage(conn, []byte("in"))
+		close(done)
+	}()
+
+	var replied bool
+	for range 100 {
+		conn.Lock()
+		replied = len(conn.out) == 1
+		conn.Unlock()
+
+		if replied {
+			break
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if !replied {
+		t.Fatal("expected the timeout error while the handler is blocked")
+	}
+
+	close(release)
+	<-done
+
+	if len(conn.out) != 1 {
+		t.Fatalf("expected one envelope, got: %d", len(conn.out))
+	}
+	if conn.statusCode != http.StatusGatewayTimeout {
+		t.Fatalf("unexpected status code: %d", conn.statusCode)
+	}
+}
+
+func TestContextDeadlineKeepsSentResponse(t *testing.T) {
+	contract := &testContract{
+		id:      "c1",
+		timeout: 10 * time.Millisecond,
+		handlers: []HandlerFunc{
+			func(ctx *Context) {
+				ctx.Out().SetMsg(&bridgeOut{OK: true}).Send()
+				<-ctx.Context().Done()
+			},
+		},
+	}
+
+	b, _ := newDeadlineBridge(contract)
+	conn := newTestRESTConn()
+	b.OnMessage(conn, []byte("in"))
+
+	if len(conn.out) != 1 {
+		t.Fatalf("expected one envelope, got: %d", len(conn.out))
+	}
+	if _, ok := conn.out[0].GetMsg().(*bridgeOut); !ok {
+		t.Fatalf("unexpected message: %#v", conn.out[0].GetMsg())
+	}
+}
+
+func TestContextDeadlineSetsRemoteExecutionTimeout(t *testing.T) {
+	ctx := newContext(&localStore{kv: map[string]any{}})
+	ctx.conn = newTestConn()
+	ctx.in = newEnvelope(ctx, ctx.conn, false)
+
+	var rxt time.Duration
+
+	ctx.execute(
+		ExecuteArg{ServiceName: "svc", ContractID: "c1"},
+		&testContract{
+			id:      "c1",
+			timeout: time.Second,
+			handlers: []HandlerFunc{
+				func(ctx *Context) { rxt = ctx.rxt },
+			},
+		},
+	)
+
+	if rxt != time.Second {
+		t.Fatalf("unexpected remote execution timeout: %v", rxt)
+	}
+}
+
+func TestNorthBridgeOnCloseCancelsStream(t *testing.T) {
+	started := make(chan struct{})
+
+	var canceledErr error
+
+	contract := &testContract{
+		id: "c1",
+		handlers: []HandlerFunc{
+			func(ctx *Context) {
+				close(started)
+				<-ctx.Context().Done()
+				canceledErr = ctx.Context().Err()
+			},
+		},
+	}
+
+	b, _ := newDeadlineBridge(contract)
+	conn := newTestConn()
+	conn.stream = true
+
+	done := make(chan struct{})
+	go func() {
+		b.OnMessage(conn, []byte("in"))
+		close(done)
+	}()
+
+	<-started
+	b.OnClose(conn.ConnID())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handler was not canceled on connection close")
+	}
+
+	if !errors.Is(canceledErr, context.Canceled) {
+		t.Fatalf("unexpected context error: %v", canceledErr)
+	}
+	if len(b.streams) != 0 {
+		t.Fatalf("expected no tracked streams, got: %d", len(b.streams))
+	}
+}
+
+var errTestWrite = errors.New("write failed")
+
+type failingConn struct {
+	*testConn
+}
+
+func (c failingConn) WriteEnvelope(*Envelope) error {
+	return errTestWrite
+}
+
+func TestEnvelopeSendWriteError(t *testing.T) {
+	var gotErr error
+
+	contract := &testContract{
+		id:      "c1",
+		timeout: time.Second,
+		handlers: []HandlerFunc{
+			func(ctx *Context) {
+				// the envelope is released by Send, hence the error must be set on the
+				// Context of the request, not on the one of the released envelope.
+				ctx.Out().SetMsg(&bridgeOut{OK: true}).Send()
+			},
+			func(ctx *Context) {
+				gotErr = ctx.err
+			},
+		},
+	}
+
+	b, _ := newDeadlineBridge(contract)
+	b.OnMessage(failingConn{newTestConn()}, []byte("in"))
+
+	if !errors.Is(gotErr, errTestWrite) {
+		t.Fatalf("expected the write error, got: %v", gotErr)
+	}
+}
+
+func TestWrapWithTimeout(t *testing.T) {
+	s := &EdgeServer{contractTimeout: time.Second}
+
+	c := s.wrapWithTimeout(&testContract{id: "c1"})
+	if ContractTimeout(c) != time.Second {
+		t.Fatalf("expected default timeout, got: %v", ContractTimeout(c))
+	}
+
+	c = s.wrapWithTimeout(&testContract{id: "c2", timeout: time.Millisecond})
+	if ContractTimeout(c) != time.Millisecond {
+		t.Fatalf("expected contract timeout, got: %v", ContractTimeout(c))
+	}
+}
@@ -0,0 +1,142 @@
+package kit
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/clubpay/ronykit/kit/errors"
+)
+
+var ErrDependencyNotFound = errors.New("dependency not found")
+
+// dependency is a value or a lazy factory registered by Provide or ProvideFunc.
+type dependency struct {
+	v        any
+	f        func(ctx *Context) (any, error)
+	resolved bool
+	// owned is true if the value is constructed by the factory, hence it is closed
+	// when the Context is released.
+	owned bool
+}
+
+// Provide stores v in the Context keyed by its type T. The handlers of the same request can
+// get it by Resolve[T]. The values are scoped to the request and are dropped when the
+// Context is released.
+//
+// T could be an interface, e.g., Provide[Repository](ctx, repo), in that case the value is
+// resolved by the interface type, not the concrete type.
+func Provide[T any](ctx *Context, v T) {
+	ctx.setDependency(reflect.TypeFor[T](), &dependency{v: v, resolved: true})
+}
+
+// ProvideFunc registers a factory for T, which is called on the first Resolve[T] of the
+// request, and its value is reused by the next calls. If the value implements io.Closer,
+// it is closed when the Context is released.
+// NOTE: the handlers resolving T concurrently could call f more than once; the first value
+// is kept, and the others are closed if they implement io.Closer.
+func ProvideFunc[T any](ctx *Context, f func(ctx *Context) (T, error)) {
+	ctx.setDependency(
+		reflect.TypeFor[T](),
+		&dependency{
+			f: func(ctx *Context) (any, error) {
+				return f(ctx)
+			},
+		},
+	)
+}
+
+// Factory returns a HandlerFunc which registers f by ProvideFunc. Use it in the global
+// handlers (WithGlobalHandlers) or the services' handlers to make T available to all their
+// contracts, while it is constructed only if a handler resolves it.
+func Factory[T any](f func(ctx *Context) (T, error)) HandlerFunc {
+	return func(ctx *Context) {
+		ProvideFunc(ctx, f)
+	}
+}
+
+// Resolve returns the value of type T, which is provided by Provide or constructed by the
+// factory registered by ProvideFunc. If there is no value or factory for T, it returns
+// ErrDependencyNotFound, and if the factory fails, its error.
+func Resolve[T any](ctx *Context) (T, error) {
+	var zero T
+
+	v, err := ctx.resolveDependency(reflect.TypeFor[T]())
+	if err != nil {
+		return zero, err
+	}
+
+	t, _ := v.(T) //nolint:errcheck
+
+	return t, nil
+}
+
+// MustResolve is like Resolve, but it panics if it fails.
+func MustResolve[T any](ctx *Context) T {
+	t, err := Resolve[T](ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+func (ctx *Context) setDependency(t reflect.Type, dep *dependency) {
+	ctx.Lock()
+	if ctx.deps == nil {
+		ctx.deps = make(map[reflect.Type]*dependency, 4)
+	}
+	ctx.deps[t] = dep
+	ctx.Unlock()
+}
+
+func (ctx *Context) resolveDependency(t reflect.Type) (any, error) {
+	ctx.Lock()
+	dep := ctx.deps[t]
+	if dep != nil && dep.resolved {
+		v := dep.v
+		ctx.Unlock()
+
+		return v, nil
+	}
+	ctx.Unlock()
+
+	if dep == nil {
+		return nil, errors.Wrap(ErrDependencyNotFound, errors.New("type: %s", t))
+	}
+
+	// We call the factory without holding the lock, since it might resolve the
+	// other dependencies. Hence, concurrent resolves could construct more than one value,
+	// the first one is kept and the others are closed.
+	v, err := dep.f(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Lock()
+	lost := dep.resolved
+	if !lost {
+		dep.v = v
+		dep.resolved = true
+		dep.owned = true
+	}
+	kept := dep.v
+	ctx.Unlock()
+
+	if c, ok := v.(io.Closer); ok && lost {
+		_ = c.Close()
+	}
+
+	return kept, nil
+}
+
+// releaseDependencies closes the values constructed by the factories, and drops all
+// the dependencies. The errors of Close are ignored, since the request is already done.
+func (ctx *Context) releaseDependencies() {
+	for t, dep := range ctx.deps {
+		if c, ok := dep.v.(io.Closer); ok && dep.owned {
+			_ = c.Close()
+		}
+
+		delete(ctx.deps, t)
+	}
+}
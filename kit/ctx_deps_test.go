@@ -0,0 +1,176 @@
+package kit
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type testRepo interface {
+	Name() string
+}
+
+type testRepoImpl struct {
+	name   string
+	closed bool
+}
+
+func (r *testRepoImpl) Name() string { return r.name }
+
+func (r *testRepoImpl) Close() error {
+	r.closed = true
+
+	return nil
+}
+
+func newDepsTestContext() *Context {
+	ctx := newContext(nil)
+	ctx.in = newEnvelope(ctx, newTestConn(), false)
+
+	return ctx
+}
+
+func TestProvideResolve(t *testing.T) {
+	ctx := newDepsTestContext()
+
+	if _, err := Resolve[*testRepoImpl](ctx); !errors.Is(err, ErrDependencyNotFound) {
+		t.Fatalf("expected ErrDependencyNotFound, got: %v", err)
+	}
+
+	provided := &testRepoImpl{name: "provided"}
+	Provide[testRepo](ctx, provided)
+	Provide(ctx, 42)
+
+	repo, err := Resolve[testRepo](ctx)
+	if err != nil || repo.Name() != "provided" {
+		t.Fatalf("unexpected dependency: %v, %v", repo, err)
+	}
+	if MustResolve[int](ctx) != 42 {
+		t.Fatal("unexpected int dependency")
+	}
+
+	// the values are keyed by the exact type
+	if _, err = Resolve[*testRepoImpl](ctx); !errors.Is(err, ErrDependencyNotFound) {
+		t.Fatalf("expected ErrDependencyNotFound, got: %v", err)
+	}
+
+	ctx.reset()
+	if _, err = Resolve[testRepo](ctx); !errors.Is(err, ErrDependencyNotFound) {
+		t.Fatalf("expected the dependencies to be released, got: %v", err)
+	}
+	if provided.closed {
+		t.Fatal("provided values must not be closed")
+	}
+}
+
+func TestProvideFunc(t *testing.T) {
+	ctx := newDepsTestContext()
+
+	calls := 0
+	Factory(func(ctx *Context) (*testRepoImpl, error) {
+		calls++
+
+		return &testRepoImpl{name: "lazy-" + MustResolve[string](ctx)}, nil
+	})(ctx)
+	Provide(ctx, "x")
+
+	if calls != 0 {
+		t.Fatal("factory must be called lazily")
+	}
+
+	r1 := MustResolve[*testRepoImpl](ctx)
+	r2 := MustResolve[*testRepoImpl](ctx)
+	if calls != 1 || r1 != r2 || r1.Name() != "lazy-x" {
+		t.Fatalf("unexpected factory result: %d, %v, %v", calls, r1, r2)
+	}
+
+	errFactory := errors.New("factory failed")
+	ProvideFunc(ctx, func(_ *Context) (testRepo, error) {
+		return nil, errFactory
+	})
+	if _, err := Resolve[testRepo](ctx); !errors.Is(err, errFactory) {
+		t.Fatalf("expected factory error, got: %v", err)
+	}
+
+	ctx.reset()
+	if !r1.closed {
+		t.Fatal("expected the constructed value to be closed")
+	}
+}
+
+func TestResolveConcurrently(t *testing.T) {
+	ctx := newDepsTestContext()
+
+	const n = 8
+
+	var (
+		mtx     sync.Mutex
+		created []*testRepoImpl
+		entered sync.WaitGroup
+		start   = make(chan struct{})
+	)
+
+	// every resolve enters the factory before any of them returns.
+	entered.Add(n)
+	ProvideFunc(ctx, func(_ *Context) (*testRepoImpl, error) {
+		entered.Done()
+		<-start
+
+		r := &testRepoImpl{name: "lazy"}
+		mtx.Lock()
+		created = append(created, r)
+		mtx.Unlock()
+
+		return r, nil
+	})
+
+	resolved := make([]*testRepoImpl, n)
+	wg := sync.WaitGroup{}
+	for i := range n {
+		wg.Go(func() {
+			resolved[i] = MustResolve[*testRepoImpl](ctx)
+		})
+	}
+	entered.Wait()
+	close(start)
+	wg.Wait()
+
+	kept := resolved[0]
+	for _, r := range resolved {
+		if r != kept {
+			t.Fatal("expected every resolve to get the same value")
+		}
+	}
+
+	for _, r := range created {
+		if r.closed == (r == kept) {
+			t.Fatalf("expected only the discarded values to be closed: %+v", r)
+		}
+	}
+
+	ctx.reset()
+	if !kept.closed {
+		t.Fatal("expected the kept value to be closed on release")
+	}
+}
+
+func TestResolveInHandlers(t *testing.T) {
+	var name string
+
+	err := NewTestContext().
+		SetHandler(
+			Factory(func(_ *Context) (testRepo, error) {
+				return &testRepoImpl{name: "svc"}, nil
+			}),
+			func(ctx *Context) {
+				name = MustResolve[testRepo](ctx).Name()
+			},
+		).
+		Run(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "svc" {
+		t.Fatalf("unexpected name: %s", name)
+	}
+}
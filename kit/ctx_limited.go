@@ -2,7 +2,6 @@ package kit
 
 import (
 	"context"
-	"maps"
 	"time"
 )
 
@@ -44,13 +43,13 @@ func (ctx *LimitedContext) IsREST() bool {
 // repeatedly set those. If you only want to set the header for an envelope, you can
 // use Envelope.SetHdr method instead.
 func (ctx *LimitedContext) SetHdr(k, v string) {
-	ctx.ctx.hdr[k] = v
+	ctx.ctx.PresetHdr(k, v)
 }
 
 // SetHdrMap sets the common header key-value pairs, so in Out method we do not need to
 // repeatedly set those.
 func (ctx *LimitedContext) SetHdrMap(hdr map[string]string) {
-	maps.Copy(ctx.ctx.hdr, hdr)
+	ctx.ctx.PresetHdrMap(hdr)
 }
 
 func (ctx *LimitedContext) Route() string {
@@ -29,3 +29,27 @@ type ClusterStore interface {
 	Scan(ctx context.Context, prefix string, cb func(string) bool) error
 	ScanWithValue(ctx context.Context, prefix string, cb func(string, string) bool) error
 }
+
+// ClusterStoreCompareAndDeleter is an optional interface which is implemented by the
+// ClusterStores which can delete a key atomically, only if it still has the value.
+// Without it, the value is read and then deleted, hence a concurrent Set could be lost.
+type ClusterStoreCompareAndDeleter interface {
+	CompareAndDelete(ctx context.Context, key, value string) (bool, error)
+}
+
+// compareAndDelete deletes the key from the store, if it still has the value.
+func compareAndDelete(ctx context.Context, store ClusterStore, key, value string) error {
+	if cd, ok := store.(ClusterStoreCompareAndDeleter); ok {
+		_, err := cd.CompareAndDelete(ctx, key, value)
+
+		return err
+	}
+
+	// some stores return an error for the missing keys, hence we don't report it.
+	v, err := store.Get(ctx, key)
+	if err != nil || v != value {
+		return nil
+	}
+
+	return store.Delete(ctx, key)
+}
@@ -0,0 +1,128 @@
+package kit
+
+import (
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/utils"
+
+	"github.com/goccy/go-reflect"
+)
+
+var ErrConnNotSubscribable = errors.New("connection is not subscribable")
+
+type publishConfig struct {
+	hdr     map[string]string
+	cluster bool
+}
+
+type PublishOption func(cfg *publishConfig)
+
+// PublishHdr sets the headers of the envelopes which are sent to the subscribers.
+func PublishHdr(hdr map[string]string) PublishOption {
+	return func(cfg *publishConfig) {
+		cfg.hdr = hdr
+	}
+}
+
+// PublishInCluster publishes the message to the subscribers of all the instances of the
+// cluster, not only to the subscribers of this instance. If the Cluster supports
+// ClusterStore, the message is sent only to the instances which have subscribers for the
+// topic, otherwise it is sent to all the instances.
+func PublishInCluster() PublishOption {
+	return func(cfg *publishConfig) {
+		cfg.cluster = true
+	}
+}
+
+// Subscribe subscribes the connection of this Context to the topic, so the next calls of
+// Publish for the topic push the message to this connection. Only the stream connections
+// (e.g., websocket and SSE) can subscribe, otherwise ErrConnNotSubscribable is returned.
+// The subscriptions are removed when the connection is closed.
+func (ctx *Context) Subscribe(topic string) error {
+	if ctx.tr == nil || ctx.nb == nil || !ctx.conn.Stream() {
+		return ErrConnNotSubscribable
+	}
+
+	ctx.tr.subscribe(ctx.nb, ctx.conn, topic)
+
+	return nil
+}
+
+// Unsubscribe removes the subscription of the connection of this Context to the topic.
+func (ctx *Context) Unsubscribe(topic string) error {
+	if ctx.tr == nil || ctx.nb == nil {
+		return ErrConnNotSubscribable
+	}
+
+	ctx.tr.unsubscribe(ctx.nb, ctx.conn.ConnID(), topic)
+
+	return nil
+}
+
+// Publish sends the message to the connections which are subscribed to the topic. By
+// default, only the subscribers of this instance receive the message; use PublishInCluster
+// option to publish it to the other instances of the cluster too.
+// The message is carried to the other instances in its encoding (e.g., protobuf for the
+// proto messages), and they send it by its type if they know the type, e.g., it is the
+// input or output of one of their contracts; otherwise they send it as it is encoded.
+func (ctx *Context) Publish(topic string, msg Message, opts ...PublishOption) error {
+	cfg := publishConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if ctx.tr != nil {
+		for _, conn := range ctx.tr.subscribers(topic) {
+			ctx.OutTo(conn).
+				SetHdrMap(cfg.hdr).
+				SetMsg(msg).
+				Send()
+		}
+	}
+
+	if !cfg.cluster || ctx.sb == nil {
+		return nil
+	}
+
+	members, err := ctx.publishMembers(topic)
+	if err != nil {
+		return err
+	}
+
+	data, _, err := MarshalMessageAs(carrierEncoding(msg), msg)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if member == ctx.sb.id {
+			continue
+		}
+
+		carrier := newEnvelopeCarrier(topicCarrier, utils.RandomID(32), ctx.sb.id, member)
+		carrier.Data = &carrierData{
+			Hdr:     cfg.hdr,
+			MsgType: reflect.TypeOf(msg).String(),
+			Msg:     data,
+			Topic:   topic,
+		}
+
+		err = ctx.sb.publish(carrier)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publishMembers returns the instances which the message of the topic is sent to.
+func (ctx *Context) publishMembers(topic string) ([]string, error) {
+	if ctx.tr != nil {
+		members, ok, err := ctx.tr.members(ctx.Context(), topic)
+		if ok {
+			return members, err
+		}
+	}
+
+	return ctx.sb.cb.Subscribers()
+}
@@ -0,0 +1,203 @@
+package kit
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func subscribeTestConn(t *testing.T, s *EdgeServer, topics ...string) *testConn {
+	t.Helper()
+
+	conn := newTestConn()
+	conn.stream = true
+
+	ctx := s.nb[0].acquireCtx(conn)
+	ctx.nb = s.nb[0]
+
+	for _, topic := range topics {
+		if err := ctx.Subscribe(topic); err != nil {
+			t.Fatalf("subscribe failed: %v", err)
+		}
+	}
+
+	s.nb[0].releaseCtx(ctx)
+
+	return conn
+}
+
+func TestContextPublish(t *testing.T) {
+	s := newRoutedServer(newMemCluster())
+
+	c1 := subscribeTestConn(t, s, "news", "sport")
+	c2 := subscribeTestConn(t, s, "news")
+
+	ctx := s.nb[0].acquireCtx(newTestConn())
+	ctx.nb = s.nb[0]
+
+	err := ctx.Publish("news", &callOut{N: 1}, PublishHdr(map[string]string{"k": "v"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ctx.Publish("sport", &callOut{N: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c1.out) != 2 || len(c2.out) != 1 {
+		t.Fatalf("unexpected envelopes: %d, %d", len(c1.out), len(c2.out))
+	}
+	if c2.out[0].GetHdr("k") != "v" || c2.out[0].GetMsg().(*callOut).N != 1 { //nolint:forcetypeassert
+		t.Fatalf("unexpected envelope: %v", c2.out[0])
+	}
+
+	// unsubscribe and close remove the subscriptions
+	sub := s.nb[0].acquireCtx(c1)
+	sub.nb = s.nb[0]
+	if err = sub.Unsubscribe("news"); err != nil {
+		t.Fatal(err)
+	}
+	s.nb[0].releaseCtx(sub)
+	s.nb[0].OnClose(c2.ConnID())
+
+	if err = ctx.Publish("news", &callOut{N: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if len(c1.out) != 2 || len(c2.out) != 1 {
+		t.Fatalf("unexpected envelopes after unsubscribe: %d, %d", len(c1.out), len(c2.out))
+	}
+
+	s.nb[0].OnClose(c1.ConnID())
+	if len(s.tr.subs) != 0 || len(s.tr.topics) != 0 {
+		t.Fatalf("expected no subscriptions, got: %v, %v", s.tr.subs, s.tr.topics)
+	}
+}
+
+func TestContextPublishInCluster(t *testing.T) {
+	cluster := newMemCluster()
+	s1 := newRoutedServer(cluster)
+	s2 := newRoutedServer(cluster)
+
+	local := subscribeTestConn(t, s1, "news")
+	remote := subscribeTestConn(t, s2, "news")
+	other := subscribeTestConn(t, s2, "sport")
+
+	ctx := s1.nb[0].acquireCtx(newTestConn())
+	ctx.sb = s1.sb
+
+	err := ctx.Publish(
+		"news", &callOut{N: 7},
+		PublishInCluster(), PublishHdr(map[string]string{"k": "v"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitForOut(t, local, 1)
+	waitForOut(t, remote, 1)
+
+	remote.Lock()
+	e := remote.out[0]
+	remote.Unlock()
+
+	if string(e.GetMsg().(RawMessage)) != `{"n":7}` || e.GetHdr("k") != "v" { //nolint:forcetypeassert
+		t.Fatalf("unexpected remote envelope: %s, %s", e.GetMsg(), e.GetHdr("k"))
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	other.Lock()
+	defer other.Unlock()
+
+	if len(other.out) != 0 {
+		t.Fatal("unexpected envelope for the other topic")
+	}
+}
+
+// topicTestCluster counts the carriers sent to each member.
+type topicTestCluster struct {
+	memClusterWithStore
+
+	mtx  sync.Mutex
+	sent map[string]int
+}
+
+func (c *topicTestCluster) Publish(id string, data []byte) error {
+	c.mtx.Lock()
+	c.sent[id]++
+	c.mtx.Unlock()
+
+	return c.memClusterWithStore.Publish(id, data)
+}
+
+func TestContextPublishToSubscribedMembers(t *testing.T) {
+	store := &memStore{kv: map[string]string{}}
+	cluster := &topicTestCluster{
+		memClusterWithStore: memClusterWithStore{memCluster: newMemCluster(), store: store},
+		sent:                map[string]int{},
+	}
+	s1 := newRoutedServer(cluster)
+	s2 := newRoutedServer(cluster)
+	s3 := newRoutedServer(cluster)
+	s2.sb.registerContract(&wrapperspb.StringValue{}, &wrapperspb.StringValue{})
+
+	remote := subscribeTestConn(t, s2, "news")
+	nested := subscribeTestConn(t, s3, "news/local")
+	s2.tr.q.flush()
+	s3.tr.q.flush()
+
+	members, ok, err := s1.tr.members(t.Context(), "news")
+	if err != nil || !ok || !slices.Equal(members, []string{s2.sb.id}) {
+		t.Fatalf("unexpected members: %v, %t, %v", members, ok, err)
+	}
+
+	ctx := s1.nb[0].acquireCtx(newTestConn())
+	ctx.sb = s1.sb
+
+	if err = ctx.Publish("news", wrapperspb.String("hello"), PublishInCluster()); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForOut(t, remote, 1)
+
+	remote.Lock()
+	msg, ok := remote.out[0].GetMsg().(*wrapperspb.StringValue)
+	remote.Unlock()
+
+	if !ok || msg.GetValue() != "hello" {
+		t.Fatalf("expected the proto message by its type, got: %v", msg)
+	}
+
+	cluster.mtx.Lock()
+	sent := cluster.sent[s3.sb.id]
+	cluster.mtx.Unlock()
+
+	nested.Lock()
+	defer nested.Unlock()
+
+	if sent != 0 || len(nested.out) != 0 {
+		t.Fatalf("unexpected carriers for the member without subscribers: %d, %d", sent, len(nested.out))
+	}
+
+	s2.nb[0].OnClose(remote.ConnID())
+	s2.tr.q.flush()
+
+	if _, ok = store.kv[s2.tr.storeKey("news")]; ok {
+		t.Fatal("expected the topic to be released with its last subscriber")
+	}
+}
+
+func TestContextSubscribeNotStream(t *testing.T) {
+	s := newRoutedServer(newMemCluster())
+
+	ctx := s.nb[0].acquireCtx(newTestConn())
+	ctx.nb = s.nb[0]
+
+	if err := ctx.Subscribe("news"); !errors.Is(err, ErrConnNotSubscribable) {
+		t.Fatalf("expected ErrConnNotSubscribable, got: %v", err)
+	}
+}
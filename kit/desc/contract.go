@@ -1,6 +1,8 @@
 package desc
 
 import (
+	"time"
+
 	"github.com/clubpay/ronykit/kit"
 	"github.com/clubpay/ronykit/kit/utils"
 )
@@ -59,6 +61,11 @@ type Contract struct {
 	OutputMeta     MessageMeta
 	PossibleErrors []Error
 	DefaultError   *Error
+	Timeout        time.Duration
+	Validation     bool
+	Version        string
+	Deprecated     bool
+	Sunset         time.Time
 }
 
 func NewContract() *Contract {
@@ -209,6 +216,64 @@ func (c *Contract) Coordinator(f kit.EdgeSelectorFunc) *Contract {
 	return c.SetCoordinator(f)
 }
 
+// SetTimeout sets the maximum duration that the handlers of this contract are allowed to run.
+// When the deadline is exceeded, the kit.Context's Context() is canceled, and the client
+// receives a timeout error if no response has been sent yet. It overrides the default
+// deadline set by kit.WithContractTimeout.
+func (c *Contract) SetTimeout(d time.Duration) *Contract {
+	c.Timeout = d
+
+	return c
+}
+
+// SetIdempotent makes this contract idempotent by kit.Idempotent wrapper. The clients could
+// retry the requests with the same idempotency key, and they get the stored response without
+// executing the handlers again. The header of the idempotency key, kit.HeaderIdempotencyKey
+// unless it is set by kit.IdempotencyHeader, is also added to the input headers.
+func (c *Contract) SetIdempotent(opts ...kit.IdempotencyOption) *Contract {
+	c.InputHeaders = append(c.InputHeaders, OptionalHeader(kit.IdempotencyKeyHeader(opts...)))
+
+	return c.AddWrapper(kit.Idempotent(opts...))
+}
+
+// SetOverflowPolicy sets the policy of the outbound queues of the stream connections, when
+// the handlers of this contract push to a connection whose queue is full. It overrides the
+// policy of the gateway by kit.Overflow wrapper.
+func (c *Contract) SetOverflowPolicy(p kit.OverflowPolicy) *Contract {
+	return c.AddWrapper(kit.Overflow(p))
+}
+
+// SetVersion sets the version of this contract. The different versions of the same contract
+// could be registered side by side with the same name and routes, and the clients select
+// one by the kit.HeaderAcceptVersion header, or the path prefix if the service is versioned
+// by path (Service.SetVersionInPath). The requests without the header are served by the
+// latest version.
+func (c *Contract) SetVersion(version string) *Contract {
+	c.Version = version
+
+	return c
+}
+
+// Deprecate marks all the routes of this contract as deprecated. The responses carry the
+// kit.HeaderDeprecation header, and kit.HeaderSunset if sunset is not zero.
+func (c *Contract) Deprecate(sunset time.Time) *Contract {
+	c.Deprecated = true
+	c.Sunset = sunset
+
+	return c
+}
+
+// SetValidation enables the validation of the input message. The message is checked against
+// the rules which are declared by the `swag` struct tags of its fields or FieldMeta, after
+// the service's handlers and before the contract's handlers. If any rule is violated,
+// the client receives a ValidationError with 400 status code. Check FieldRules for
+// the supported rules. Service.Build panics if a pattern rule is not a valid regular expression.
+func (c *Contract) SetValidation(on bool) *Contract {
+	c.Validation = on
+
+	return c
+}
+
 // AddModifier adds a kit.ModifierFunc for this contract. Modifiers are used to modify
 // the outgoing kit.Envelope just before sending to the client.
 func (c *Contract) AddModifier(m kit.ModifierFunc) *Contract {
@@ -248,6 +313,7 @@ type contractImpl struct {
 	input          kit.Message
 	output         kit.Message
 	enc            kit.Encoding
+	timeout        time.Duration
 }
 
 var _ kit.Contract = (*contractImpl)(nil)
@@ -270,6 +336,12 @@ func (r *contractImpl) setEncoding(enc kit.Encoding) *contractImpl {
 	return r
 }
 
+func (r *contractImpl) setTimeout(timeout time.Duration) *contractImpl {
+	r.timeout = timeout
+
+	return r
+}
+
 func (r *contractImpl) setRouteSelector(selector kit.RouteSelector) *contractImpl {
 	r.routeSelector = selector
 
@@ -325,3 +397,7 @@ func (r *contractImpl) Output() kit.Message {
 func (r *contractImpl) Encoding() kit.Encoding {
 	return r.enc
 }
+
+func (r *contractImpl) Timeout() time.Duration {
+	return r.timeout
+}
@@ -2,6 +2,7 @@ package desc_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/clubpay/ronykit/kit"
 	"github.com/clubpay/ronykit/kit/desc"
@@ -120,3 +121,107 @@ func TestServiceBuildDuplicateContractName(t *testing.T) {
 	}()
 	_ = svc.Build()
 }
+
+func TestContractTimeout(t *testing.T) {
+	svc := desc.NewService("svc").
+		AddContract(
+			desc.NewContract().
+				SetTimeout(time.Second).
+				AddRoute(desc.Route("r1", newREST(kit.JSON, "/path", "GET"))).
+				SetHandler(func(*kit.Context) {}),
+		).
+		Build()
+
+	if got := kit.ContractTimeout(svc.Contracts()[0]); got != time.Second {
+		t.Fatalf("unexpected contract timeout: %v", got)
+	}
+}
+
+func TestContractSetIdempotent(t *testing.T) {
+	c := desc.NewContract().
+		SetInputHeader(desc.RequiredHeader("x-req")).
+		SetIdempotent(kit.IdempotencyTTL(time.Hour))
+
+	if len(c.Wrappers) != 1 {
+		t.Fatalf("expected idempotency wrapper, got: %d", len(c.Wrappers))
+	}
+	if len(c.InputHeaders) != 2 || c.InputHeaders[1] != desc.OptionalHeader(kit.HeaderIdempotencyKey) {
+		t.Fatalf("unexpected input headers: %+v", c.InputHeaders)
+	}
+
+	c = desc.NewContract().SetIdempotent(kit.IdempotencyHeader("X-Request-Id"))
+	if len(c.InputHeaders) != 1 || c.InputHeaders[0] != desc.OptionalHeader("X-Request-Id") {
+		t.Fatalf("unexpected input headers: %+v", c.InputHeaders)
+	}
+}
+
+func TestContractVersions(t *testing.T) {
+	sunset := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	newService := func() *desc.Service {
+		return desc.NewService("svc").
+			AddContract(
+				desc.NewContract().
+					SetName("getUser").
+					SetVersion("v1").
+					Deprecate(sunset).
+					AddRoute(desc.Route("r1", newREST(kit.JSON, "/users", "GET"))).
+					In(&FlatMessage{}).
+					SetHandler(func(*kit.Context) {}),
+				desc.NewContract().
+					SetName("getUser").
+					SetVersion("v2").
+					AddRoute(desc.Route("r1", newREST(kit.JSON, "/users", "GET"))).
+					In(&FlatMessage{}).
+					SetHandler(func(*kit.Context) {}),
+			)
+	}
+
+	svc := newService().Build()
+	v1, v2 := svc.Contracts()[0], svc.Contracts()[1]
+	if v1.ID() != "svc.getUser@v1" || v2.ID() != "svc.getUser@v2" {
+		t.Fatalf("unexpected contract ids: %s, %s", v1.ID(), v2.ID())
+	}
+	if kit.NegotiatedVersion(v1.RouteSelector(), true) != "v1" {
+		t.Fatalf("unexpected version: %s", kit.RouteVersion(v1.RouteSelector()))
+	}
+	if len(v1.Handlers()) != 2 || len(v2.Handlers()) != 1 {
+		t.Fatalf("expected the deprecation handler only for v1")
+	}
+
+	var hdr map[string]string
+
+	err := kit.NewTestContext().
+		SetHandler(append(v1.Handlers(), func(ctx *kit.Context) {
+			ctx.Out().SetMsg(kit.RawMessage("ok")).Send()
+		})...).
+		Expect(func(e *kit.Envelope) error {
+			hdr = map[string]string{
+				kit.HeaderDeprecation: e.GetHdr(kit.HeaderDeprecation),
+				kit.HeaderSunset:      e.GetHdr(kit.HeaderSunset),
+			}
+
+			return nil
+		}).
+		RunREST()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr[kit.HeaderDeprecation] != "true" || hdr[kit.HeaderSunset] != "Wed, 02 Jan 2030 03:04:05 GMT" {
+		t.Fatalf("unexpected deprecation headers: %v", hdr)
+	}
+
+	ps := desc.ParseService(newService().SetVersionInPath(true))
+	if ps.Contracts[0].Path != "/v1/users" || ps.Contracts[1].Path != "/v2/users" {
+		t.Fatalf("unexpected paths: %s, %s", ps.Contracts[0].Path, ps.Contracts[1].Path)
+	}
+	if !ps.Contracts[0].Deprecated || ps.Contracts[1].Deprecated || ps.Contracts[1].Version != "v2" ||
+		!ps.Contracts[1].VersionInPath {
+		t.Fatalf("unexpected parsed contracts: %+v", ps.Contracts)
+	}
+
+	svc = newService().SetVersionInPath(true).Build()
+	rest, _ := svc.Contracts()[1].RouteSelector().(kit.RESTRouteSelector)
+	if rest.GetPath() != "/v2/users" || kit.NegotiatedVersion(rest, true) != "" {
+		t.Fatalf("unexpected versioned path: %s", rest.GetPath())
+	}
+}
@@ -1,6 +1,9 @@
 package desc
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+)
 
 type MessageMeta struct {
 	Fields map[string]FieldMeta
@@ -23,55 +26,103 @@ type FormDataValue struct {
 	Type string
 }
 
+// FieldMeta is the metadata of a message's field. It overrides the values which are
+// declared by the `swag` struct tag of the field.
+// Required, Enum, Min, Max, MinLength, MaxLength and Pattern are the validation rules of
+// the field. They are reflected in the generated API documents, and they are checked
+// before the handlers if the validation is enabled for the contract.
 type FieldMeta struct {
 	Optional   bool
 	Deprecated bool
 	OmitEmpty  bool
 	Enum       []string
 	FormData   *FormDataValue
+	Required   bool
+	Min        *float64
+	Max        *float64
+	MinLength  *int
+	MaxLength  *int
+	Pattern    string
 }
 
 func (fm FieldMeta) SwagTag() string {
-	hasItem := false
-	sb := strings.Builder{}
-	sb.WriteString(`swag:"`)
+	items := make([]string, 0, 10)
 
 	if fm.Optional {
-		sb.WriteString("optional")
-
-		hasItem = true
+		items = append(items, "optional")
 	}
 
 	if fm.Deprecated {
-		if hasItem {
-			sb.WriteRune(',')
-		}
+		items = append(items, "deprecated")
+	}
 
-		sb.WriteString("deprecated")
+	if fm.OmitEmpty {
+		items = append(items, "omitempty")
+	}
 
-		hasItem = true
+	if fm.Required {
+		items = append(items, "required")
 	}
 
-	if fm.OmitEmpty {
-		if hasItem {
-			sb.WriteRune(',')
-		}
+	if fm.Min != nil {
+		items = append(items, "min:"+strconv.FormatFloat(*fm.Min, 'f', -1, 64))
+	}
+
+	if fm.Max != nil {
+		items = append(items, "max:"+strconv.FormatFloat(*fm.Max, 'f', -1, 64))
+	}
 
-		sb.WriteString("omitempty")
+	if fm.MinLength != nil {
+		items = append(items, "minLen:"+strconv.Itoa(*fm.MinLength))
+	}
+
+	if fm.MaxLength != nil {
+		items = append(items, "maxLen:"+strconv.Itoa(*fm.MaxLength))
+	}
 
-		hasItem = true
+	if fm.Pattern != "" {
+		// struct tag values are unquoted by reflect.StructTag, hence we escape the pattern.
+		q := strconv.Quote(escapeSwagSep(fm.Pattern))
+		items = append(items, "pattern:"+q[1:len(q)-1])
 	}
 
 	if len(fm.Enum) > 0 {
-		if hasItem {
-			sb.WriteRune(',')
-		}
+		items = append(items, "enum:"+strings.Join(fm.Enum, swagValueSep))
+	}
 
-		sb.WriteString("enum:")
-		sb.WriteString(strings.Join(fm.Enum, ","))
+	return `swag:"` + strings.Join(items, swagSep) + `"`
+}
+
+// escapeSwagSep replaces the swagSep characters of the regular expression with their
+// hex escape, so the pattern is not split by the parser but still matches the same input.
+// An escaped separator (i.e., `\;`) is replaced as a whole.
+func escapeSwagSep(pattern string) string {
+	if !strings.Contains(pattern, swagSep) {
+		return pattern
 	}
 
-	sb.WriteString(`"`)
+	sb := strings.Builder{}
+	escaped := false
+
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+			if string(r) == swagSep {
+				sb.WriteString(`x3B`)
+
+				continue
+			}
+		case r == '\\':
+			escaped = true
+		case string(r) == swagSep:
+			sb.WriteString(`\x3B`)
+
+			continue
+		}
+
+		sb.WriteRune(r)
+	}
 
 	return sb.String()
 }
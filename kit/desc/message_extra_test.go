@@ -1,6 +1,10 @@
 package desc
 
-import "testing"
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
 
 func TestFieldMetaSwagTag(t *testing.T) {
 	if (FieldMeta{}).SwagTag() != `swag:""` {
@@ -13,11 +17,50 @@ func TestFieldMetaSwagTag(t *testing.T) {
 		OmitEmpty:  true,
 		Enum:       []string{"a", "b"},
 	}
-	if fm.SwagTag() != `swag:"optional,deprecated,omitempty,enum:a,b"` {
+	if fm.SwagTag() != `swag:"optional;deprecated;omitempty;enum:a,b"` {
 		t.Fatalf("unexpected swag tag: %s", fm.SwagTag())
 	}
 }
 
+func TestFieldMetaSwagTagRoundTrip(t *testing.T) {
+	minV, maxV := 1.5, 10.0
+	minLen, maxLen := 2, 8
+	fm := FieldMeta{
+		Optional:   true,
+		Deprecated: true,
+		Required:   true,
+		Min:        &minV,
+		Max:        &maxV,
+		MinLength:  &minLen,
+		MaxLength:  &maxLen,
+		Pattern:    `^[a-z;]+\;"\d:$`,
+		Enum:       []string{"a", "b", "c"},
+	}
+
+	tag := fm.SwagTag()
+	pst := getParsedStructTag(reflect.StructTag(`json:"name" `+tag), "json")
+	if !pst.Optional || !pst.Deprecated || !pst.Required ||
+		pst.Min == nil || *pst.Min != minV || pst.Max == nil || *pst.Max != maxV ||
+		pst.MinLength == nil || *pst.MinLength != minLen ||
+		pst.MaxLength == nil || *pst.MaxLength != maxLen {
+		t.Fatalf("unexpected parsed rules from %s: %+v", tag, pst)
+	}
+	if !reflect.DeepEqual(pst.PossibleValues, fm.Enum) {
+		t.Fatalf("unexpected enum values from %s: %v", tag, pst.PossibleValues)
+	}
+
+	re := regexp.MustCompile(pst.Pattern)
+	for in, match := range map[string]bool{
+		`ab;c;"1:`: true,
+		`ab;"1:`:   true,
+		`ab"1:`:    false,
+	} {
+		if re.MatchString(in) != match {
+			t.Fatalf("pattern %q parsed from %s: match(%q) != %t", pst.Pattern, tag, in, match)
+		}
+	}
+}
+
 func TestMessageMetaWithField(t *testing.T) {
 	meta := MessageMeta{}
 	WithField("name", FieldMeta{Optional: true})(&meta)
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/clubpay/ronykit/kit"
@@ -52,16 +53,23 @@ func (ps *ParsedService) parseContract(c Contract) []ParsedContract {
 	var pcs []ParsedContract //nolint:prealloc
 
 	for idx, s := range c.RouteSelectors {
+		enc := selectorEncoding(s.Selector, c.Encoding)
 		pc := ParsedContract{
 			Index:        idx,
 			GroupName:    c.Name,
 			Name:         utils.Coalesce(s.Name, c.Name),
 			SelectorName: utils.Coalesce(s.Name, s.Selector.String()),
-			Deprecated:   s.Deprecated,
-			Encoding:     s.Selector.GetEncoding().Tag(),
+			Deprecated:   s.Deprecated || c.Deprecated,
+			Encoding:     enc.Tag(),
+			Version:      c.Version,
 		}
 
-		switch r := s.Selector.(type) {
+		versionInPath := ps.Origin != nil && ps.Origin.VersionInPath
+		if c.Version != "" {
+			pc.VersionInPath = versionInPath
+		}
+
+		switch r := versionedSelector(s.Selector, c.Version, versionInPath).(type) {
 		case kit.RESTRouteSelector:
 			pc.Type = REST
 			pc.Path = r.GetPath()
@@ -83,14 +91,14 @@ func (ps *ParsedService) parseContract(c Contract) []ParsedContract {
 
 		pc.Request = ParsedRequest{
 			Headers: c.InputHeaders,
-			Message: ps.parseMessage(c.Input, c.InputMeta, s.Selector.GetEncoding()),
+			Message: ps.parseMessage(c.Input, c.InputMeta, enc),
 		}
 
 		if c.Output != nil {
 			pc.Responses = append(
 				pc.Responses,
 				ParsedResponse{
-					Message: ps.parseMessage(c.Output, c.OutputMeta, s.Selector.GetEncoding()),
+					Message: ps.parseMessage(c.Output, c.OutputMeta, enc),
 				},
 			)
 		}
@@ -99,7 +107,7 @@ func (ps *ParsedService) parseContract(c Contract) []ParsedContract {
 			pc.Responses = append(
 				pc.Responses,
 				ParsedResponse{
-					Message: ps.parseMessage(e.Message, e.Meta, s.Selector.GetEncoding()),
+					Message: ps.parseMessage(e.Message, e.Meta, enc),
 					ErrCode: e.Code,
 					ErrItem: e.Item,
 				},
@@ -111,7 +119,7 @@ func (ps *ParsedService) parseContract(c Contract) []ParsedContract {
 				Message: ps.parseMessage(
 					c.DefaultError.Message,
 					c.DefaultError.Meta,
-					s.Selector.GetEncoding(),
+					enc,
 				),
 				ErrCode: c.DefaultError.Code,
 				ErrItem: c.DefaultError.Item,
@@ -124,6 +132,16 @@ func (ps *ParsedService) parseContract(c Contract) []ParsedContract {
 	return pcs
 }
 
+// selectorEncoding returns the encoding of the route selector, or enc, the encoding of the
+// contract, if the selector does not set it.
+func selectorEncoding(sel kit.RouteSelector, enc kit.Encoding) kit.Encoding {
+	if e := sel.GetEncoding(); e != kit.Undefined {
+		return e
+	}
+
+	return enc
+}
+
 func (ps *ParsedService) parseMessage(m kit.Message, meta MessageMeta, enc kit.Encoding) ParsedMessage {
 	mt := reflect.TypeOf(m)
 	if mt.Kind() == reflect.Pointer {
@@ -154,10 +172,7 @@ func (ps *ParsedService) parseMessage(m kit.Message, meta MessageMeta, enc kit.E
 
 	ps.setVisited(mt)
 
-	tagName := enc.Tag()
-	if tagName == "" {
-		tagName = kit.JSON.Tag()
-	}
+	tagName := enc.FieldTag()
 
 	// if we are here, it means that mt is a struct
 	fields := make([]ParsedField, 0, mt.NumField())
@@ -261,6 +276,10 @@ type ParsedContract struct {
 	SelectorName string
 	Encoding     string
 	Deprecated   bool
+	// Version is the version of the contract. If VersionInPath is false, the clients select
+	// the version by kit.HeaderAcceptVersion header, otherwise the Path is prefixed by it.
+	Version       string
+	VersionInPath bool
 
 	Type       ContractType
 	Path       string
@@ -620,6 +639,12 @@ type ParsedStructTag struct {
 	Deprecated     bool
 	OmitEmpty      bool
 	OmitZero       bool
+	Required       bool
+	Min            *float64
+	Max            *float64
+	MinLength      *int
+	MaxLength      *int
+	Pattern        string
 }
 
 func (pst ParsedStructTag) Tags(keys ...string) map[string]string {
@@ -676,6 +701,19 @@ func getParsedStructTag(tag reflect.StructTag, name string) ParsedStructTag {
 			pst.Optional = true
 		case x == "deprecated":
 			pst.Deprecated = true
+		case x == "required":
+			pst.Required = true
+		case strings.HasPrefix(x, "min:"):
+			pst.Min = parseTagFloat(p)
+		case strings.HasPrefix(x, "max:"):
+			pst.Max = parseTagFloat(p)
+		case strings.HasPrefix(x, "minlen:"):
+			pst.MinLength = parseTagInt(p)
+		case strings.HasPrefix(x, "maxlen:"):
+			pst.MaxLength = parseTagInt(p)
+		case strings.HasPrefix(x, "pattern:"):
+			xx := strings.SplitN(strings.TrimSpace(p), swagIdentSep, 2)
+			pst.Pattern = xx[1]
 		case strings.HasPrefix(x, "enum:"):
 			xx := strings.SplitN(p, swagIdentSep, 2)
 			if len(xx) == 2 {
@@ -689,3 +727,25 @@ func getParsedStructTag(tag reflect.StructTag, name string) ParsedStructTag {
 
 	return pst
 }
+
+func parseTagFloat(p string) *float64 {
+	xx := strings.SplitN(p, swagIdentSep, 2)
+
+	v, err := strconv.ParseFloat(strings.TrimSpace(xx[1]), 64)
+	if err != nil {
+		return nil
+	}
+
+	return &v
+}
+
+func parseTagInt(p string) *int {
+	xx := strings.SplitN(p, swagIdentSep, 2)
+
+	v, err := strconv.Atoi(strings.TrimSpace(xx[1]))
+	if err != nil {
+		return nil
+	}
+
+	return &v
+}
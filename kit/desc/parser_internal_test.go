@@ -55,6 +55,14 @@ func TestGetParsedStructTag(t *testing.T) {
 		t.Fatalf("unexpected enum values: %v", pst.PossibleValues)
 	}
 
+	tag = reflect.StructTag(`json:"age" swag:"required;min:1.5;max:10;minLen:2;maxLen:4;pattern:^[a-z:]+$"`)
+	pst = getParsedStructTag(tag, "json")
+	if !pst.Required || pst.Min == nil || *pst.Min != 1.5 || pst.Max == nil || *pst.Max != 10 ||
+		pst.MinLength == nil || *pst.MinLength != 2 || pst.MaxLength == nil || *pst.MaxLength != 4 ||
+		pst.Pattern != "^[a-z:]+$" {
+		t.Fatalf("unexpected validation rules: %+v", pst)
+	}
+
 	tag = reflect.StructTag(`json:"other,omitzero"`)
 	pst = getParsedStructTag(tag, "json")
 	if !pst.OmitZero || pst.Value != "other" {
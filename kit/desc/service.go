@@ -2,6 +2,7 @@ package desc
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/clubpay/ronykit/kit"
 	"github.com/clubpay/ronykit/kit/utils/reflector"
@@ -19,6 +20,8 @@ type Service struct {
 	Wrappers       []kit.ServiceWrapper
 	Contracts      []Contract
 	Handlers       []kit.HandlerFunc
+	Validation     bool
+	VersionInPath  bool
 
 	contractNames map[string]struct{}
 }
@@ -58,6 +61,22 @@ func (s *Service) AddHandler(h ...kit.HandlerFunc) *Service {
 	return s
 }
 
+// SetValidation enables the validation of the input messages of all the contracts.
+// Check Contract.SetValidation for more details.
+func (s *Service) SetValidation(on bool) *Service {
+	s.Validation = on
+
+	return s
+}
+
+// SetVersionInPath prefixes the REST paths of the versioned contracts with their version,
+// e.g., "/v2/users", instead of negotiating the version by the kit.HeaderAcceptVersion header.
+func (s *Service) SetVersionInPath(on bool) *Service {
+	s.VersionInPath = on
+
+	return s
+}
+
 // AddWrapper adds service wrappers to the Service description.
 func (s *Service) AddWrapper(wrappers ...kit.ServiceWrapper) *Service {
 	s.Wrappers = append(s.Wrappers, wrappers...)
@@ -132,27 +151,60 @@ func (s *Service) Build() kit.Service {
 		contractID := fmt.Sprintf("%s.%d", svc.name, index)
 
 		if c.Name != "" {
-			if _, ok := s.contractNames[c.Name]; ok {
-				panic(fmt.Sprintf("contract name %s already defined in service %s", c.Name, svc.name))
+			// the versions of the same contract share the name.
+			nameKey := c.Name
+			if c.Version != "" {
+				nameKey = fmt.Sprintf("%s@%s", c.Name, c.Version)
+			}
+
+			if _, ok := s.contractNames[nameKey]; ok {
+				panic(fmt.Sprintf("contract name %s already defined in service %s", nameKey, svc.name))
 			}
 
 			contractID = fmt.Sprintf("%s.%s", svc.name, c.Name)
-			s.contractNames[c.Name] = struct{}{}
+			s.contractNames[nameKey] = struct{}{}
+		}
+
+		if c.Version != "" {
+			contractID = fmt.Sprintf("%s@%s", contractID, c.Version)
 		}
 
 		contracts := make([]kit.Contract, len(c.RouteSelectors))
+		validate := s.Validation || c.Validation
+		versionInPath := s.VersionInPath
 		for idx, s := range c.RouteSelectors {
 			ci := (&contractImpl{id: contractID}).
-				addHandler(svc.h...).
-				addHandler(c.Handlers...).
+				addHandler(svc.h...)
+
+			if validate {
+				// the fields are named by the encoding which the input is decoded by.
+				h, err := validationHandler(c.Input, selectorEncoding(s.Selector, c.Encoding), c.InputMeta)
+				if err != nil {
+					panic(fmt.Sprintf("contract %s: %v", contractID, err))
+				}
+
+				if h != nil {
+					ci.addHandler(h)
+				}
+			}
+
+			ci.addHandler(c.Handlers...).
 				setModifier(c.Modifiers...).
 				setInput(c.Input).
 				setOutput(c.Output).
-				setRouteSelector(s.Selector).
+				setRouteSelector(versionedSelector(s.Selector, c.Version, versionInPath)).
 				setMemberSelector(c.EdgeSelector).
-				setEncoding(c.Encoding)
+				setEncoding(c.Encoding).
+				setTimeout(c.Timeout)
+
+			wrappers := c.Wrappers
+			if c.Deprecated || s.Deprecated {
+				// it must be the outermost wrapper, so the headers are set before any
+				// other wrapper sends a response.
+				wrappers = append(slices.Clip(wrappers), kit.Deprecated(c.Sunset))
+			}
 
-			contracts[idx] = kit.WrapContract(ci, c.Wrappers...)
+			contracts[idx] = kit.WrapContract(ci, wrappers...)
 		}
 
 		svc.contracts = append(svc.contracts, contracts...)
@@ -161,6 +213,17 @@ func (s *Service) Build() kit.Service {
 	return kit.WrapService(svc, s.Wrappers...)
 }
 
+func versionedSelector(sel kit.RouteSelector, version string, inPath bool) kit.RouteSelector {
+	switch {
+	case version == "":
+		return sel
+	case inPath:
+		return kit.VersionedPath(sel, version)
+	default:
+		return kit.Versioned(sel, version)
+	}
+}
+
 // serviceImpl is a simple implementation of kit.Service interface.
 type serviceImpl struct {
 	name      string
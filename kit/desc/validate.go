@@ -0,0 +1,511 @@
+package desc
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/clubpay/ronykit/kit"
+)
+
+// FieldRules is the set of validation rules of a field. The rules are declared by the
+// `swag` struct tag of the field, e.g.
+//
+//	Name string `json:"name" swag:"required;minLen:3;maxLen:32;pattern:^[a-z]+$"`
+//	Age  int    `json:"age" swag:"min:18;max:120"`
+//	Kind string `json:"kind" swag:"enum:a,b,c"`
+//
+// or by FieldMeta, which overrides the struct tag.
+//
+// The rules, except Required, are skipped if the field is nil or has the zero value. MinLength
+// and MaxLength are applied to the length of strings, slices and maps, and the other rules to
+// the field's value, or to each element if the field is a slice or map.
+type FieldRules struct {
+	Required  bool
+	Enum      []string
+	Min       *float64
+	Max       *float64
+	MinLength *int
+	MaxLength *int
+	Pattern   string
+}
+
+func (r FieldRules) IsEmpty() bool {
+	return !r.Required && len(r.Enum) == 0 && r.Min == nil && r.Max == nil &&
+		r.MinLength == nil && r.MaxLength == nil && r.Pattern == ""
+}
+
+func getFieldRules(tag ParsedStructTag, meta FieldMeta) FieldRules {
+	r := FieldRules{
+		Required:  tag.Required || meta.Required,
+		Enum:      tag.PossibleValues,
+		Min:       tag.Min,
+		Max:       tag.Max,
+		MinLength: tag.MinLength,
+		MaxLength: tag.MaxLength,
+		Pattern:   tag.Pattern,
+	}
+
+	if meta.Enum != nil {
+		r.Enum = meta.Enum
+	}
+
+	if meta.Min != nil {
+		r.Min = meta.Min
+	}
+
+	if meta.Max != nil {
+		r.Max = meta.Max
+	}
+
+	if meta.MinLength != nil {
+		r.MinLength = meta.MinLength
+	}
+
+	if meta.MaxLength != nil {
+		r.MaxLength = meta.MaxLength
+	}
+
+	if meta.Pattern != "" {
+		r.Pattern = meta.Pattern
+	}
+
+	return r
+}
+
+// Rules returns the validation rules of the field.
+func (pf ParsedField) Rules() FieldRules {
+	return getFieldRules(pf.Tag, pf.Meta)
+}
+
+const (
+	RuleRequired  = "required"
+	RuleEnum      = "enum"
+	RuleMin       = "min"
+	RuleMax       = "max"
+	RuleMinLength = "minLen"
+	RuleMaxLength = "maxLen"
+	RulePattern   = "pattern"
+)
+
+// FieldError is the violation of a single rule by a field. Field is the path of the field
+// by its encoded name, e.g., "items[2].name".
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError is the message which is sent to the client, with 400 status code, when
+// the input message violates the validation rules. It contains all the violations.
+type ValidationError struct {
+	Code   int          `json:"code"`
+	Item   string       `json:"item"`
+	Fields []FieldError `json:"fields"`
+}
+
+var _ kit.ErrorMessage = (*ValidationError)(nil)
+
+func (e ValidationError) GetCode() int {
+	return e.Code
+}
+
+func (e ValidationError) GetItem() string {
+	return e.Item
+}
+
+func (e ValidationError) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString(strconv.Itoa(e.Code))
+	sb.WriteString(": ")
+	sb.WriteString(e.Item)
+
+	for idx, fe := range e.Fields {
+		if idx == 0 {
+			sb.WriteString(": ")
+		} else {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(fe.Field)
+		sb.WriteRune(' ')
+		sb.WriteString(fe.Message)
+	}
+
+	return sb.String()
+}
+
+// ValidateMessage checks m against the validation rules of its fields, and returns
+// a *ValidationError if any rule is violated. meta overrides the rules of the top-level
+// fields, and tagName is the struct tag which the field names are read from, e.g., "json".
+// The rules which cannot be compiled, e.g., invalid patterns, are returned as errors.
+func ValidateMessage(m kit.Message, tagName string, meta MessageMeta) error {
+	mv, err := newValidator(reflect.TypeOf(m), tagName, meta)
+	if err != nil || mv == nil {
+		return err
+	}
+
+	return mv.validate(m)
+}
+
+type validator struct {
+	types map[reflect.Type]*messageValidator
+}
+
+type messageValidator struct {
+	fields []fieldValidator
+	// compiling is true while the fields are being compiled. It lets the recursive
+	// types refer to themselves.
+	compiling bool
+}
+
+type fieldValidator struct {
+	index    int
+	name     string
+	embedded bool
+	rules    FieldRules
+	re       *regexp.Regexp
+	nested   *messageValidator
+}
+
+// newValidator compiles the validation rules of the message type t. It returns nil if
+// the message has no rule.
+func newValidator(t reflect.Type, tagName string, meta MessageMeta) (*messageValidator, error) {
+	if t == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, nil //nolint:nilnil
+	}
+
+	v := &validator{types: map[reflect.Type]*messageValidator{}}
+
+	mv, err := v.compile(t, tagName, meta)
+	if err != nil || mv.isEmpty() {
+		return nil, err
+	}
+
+	return mv, nil
+}
+
+func (v *validator) compile(t reflect.Type, tagName string, meta MessageMeta) (*messageValidator, error) {
+	if mv, ok := v.types[t]; ok {
+		return mv, nil
+	}
+
+	mv := &messageValidator{compiling: true}
+	v.types[t] = mv
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		tag := getParsedStructTag(f.Tag, tagName)
+		if tag.Value == "-" {
+			continue
+		}
+
+		fv := fieldValidator{
+			index:    i,
+			name:     tag.Value,
+			embedded: f.Anonymous && tag.Value == "",
+			rules:    getFieldRules(tag, meta.Fields[f.Name]),
+		}
+		if fv.name == "" {
+			fv.name = f.Name
+		}
+
+		if fv.rules.Pattern != "" {
+			re, err := regexp.Compile(fv.rules.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern of field %s.%s: %w", t.Name(), f.Name, err)
+			}
+
+			fv.re = re
+		}
+
+		if st := structOf(f.Type); st != nil {
+			nested, err := v.compile(st, tagName, MessageMeta{})
+			if err != nil {
+				return nil, err
+			}
+
+			if !nested.isEmpty() {
+				fv.nested = nested
+			}
+		}
+
+		if fv.rules.IsEmpty() && fv.nested == nil {
+			continue
+		}
+
+		mv.fields = append(mv.fields, fv)
+	}
+
+	mv.compiling = false
+
+	return mv, nil
+}
+
+// structOf returns the struct type which t holds, directly or as the element of
+// pointers, slices, arrays or maps.
+func structOf(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			return t
+		default:
+			return nil
+		}
+	}
+}
+
+func (mv *messageValidator) isEmpty() bool {
+	return !mv.compiling && len(mv.fields) == 0
+}
+
+func (mv *messageValidator) validate(m kit.Message) error {
+	v := reflect.ValueOf(m)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []FieldError
+
+	mv.check(v, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &ValidationError{
+		Code:   http.StatusBadRequest,
+		Item:   "VALIDATION_FAILED",
+		Fields: errs,
+	}
+}
+
+func (mv *messageValidator) check(v reflect.Value, path string, errs *[]FieldError) {
+	for idx := range mv.fields {
+		fv := &mv.fields[idx]
+
+		name := path
+		if !fv.embedded {
+			name = joinPath(path, fv.name)
+		}
+
+		fv.check(v.Field(fv.index), name, errs)
+	}
+}
+
+func (fv *fieldValidator) check(v reflect.Value, path string, errs *[]FieldError) {
+	addErr := func(rule, format string, args ...any) {
+		*errs = append(*errs, FieldError{Field: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if fv.rules.Required {
+				addErr(RuleRequired, "is required")
+			}
+
+			return
+		}
+
+		v = v.Elem()
+	}
+
+	// The rules are only applied to the present values, and the missing ones are
+	// rejected only if the field is required.
+	if isEmptyValue(v) {
+		if fv.rules.Required {
+			addErr(RuleRequired, "is required")
+		}
+
+		return
+	}
+
+	switch v.Kind() {
+	default:
+		fv.checkScalar(v, path, errs)
+	case reflect.String:
+		fv.checkLength(utf8.RuneCountInString(v.String()), path, errs)
+		fv.checkScalar(v, path, errs)
+	case reflect.Slice, reflect.Array:
+		fv.checkLength(v.Len(), path, errs)
+
+		for i := range v.Len() {
+			fv.checkElem(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Map:
+		fv.checkLength(v.Len(), path, errs)
+
+		iter := v.MapRange()
+		for iter.Next() {
+			fv.checkElem(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), errs)
+		}
+	case reflect.Struct:
+		if fv.nested != nil {
+			fv.nested.check(v, path, errs)
+		}
+	}
+}
+
+// checkElem checks an element of a slice, array or map field. The length rules are
+// applied to the field itself, and the other rules to each of its elements.
+func (fv *fieldValidator) checkElem(v reflect.Value, path string, errs *[]FieldError) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	default:
+		fv.checkScalar(v, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			fv.checkElem(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Struct:
+		if fv.nested != nil {
+			fv.nested.check(v, path, errs)
+		}
+	}
+}
+
+func (fv *fieldValidator) checkLength(n int, path string, errs *[]FieldError) {
+	if fv.rules.MinLength != nil && n < *fv.rules.MinLength {
+		*errs = append(*errs, FieldError{
+			Field:   path,
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("length must be at least %d", *fv.rules.MinLength),
+		})
+	}
+
+	if fv.rules.MaxLength != nil && n > *fv.rules.MaxLength {
+		*errs = append(*errs, FieldError{
+			Field:   path,
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("length must be at most %d", *fv.rules.MaxLength),
+		})
+	}
+}
+
+func (fv *fieldValidator) checkScalar(v reflect.Value, path string, errs *[]FieldError) {
+	addErr := func(rule, format string, args ...any) {
+		*errs = append(*errs, FieldError{Field: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n, ok := numberOf(v); ok {
+		if fv.rules.Min != nil && n < *fv.rules.Min {
+			addErr(RuleMin, "must be greater than or equal to %v", *fv.rules.Min)
+		}
+
+		if fv.rules.Max != nil && n > *fv.rules.Max {
+			addErr(RuleMax, "must be less than or equal to %v", *fv.rules.Max)
+		}
+	}
+
+	if fv.re != nil && v.Kind() == reflect.String && !fv.re.MatchString(v.String()) {
+		addErr(RulePattern, "must match the pattern %s", fv.rules.Pattern)
+	}
+
+	if len(fv.rules.Enum) > 0 {
+		s, ok := stringOf(v)
+		if ok && !slices.Contains(fv.rules.Enum, s) {
+			addErr(RuleEnum, "must be one of [%s]", strings.Join(fv.rules.Enum, ", "))
+		}
+	}
+}
+
+func numberOf(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	default:
+		return 0, false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+}
+
+func stringOf(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	default:
+		if n, ok := numberOf(v); ok {
+			return strconv.FormatFloat(n, 'f', -1, 64), true
+		}
+
+		return "", false
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	}
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// validationHandler returns a kit.HandlerFunc which validates the input message of the
+// contract, and rejects it by ValidationError. It returns nil if the input has no rule.
+func validationHandler(input kit.Message, enc kit.Encoding, meta MessageMeta) (kit.HandlerFunc, error) {
+	tagName := enc.FieldTag()
+
+	mv, err := newValidator(reflect.TypeOf(input), tagName, meta)
+	if err != nil || mv == nil {
+		return nil, err
+	}
+
+	return func(ctx *kit.Context) {
+		err := mv.validate(ctx.In().GetMsg())
+		if err == nil {
+			return
+		}
+
+		ctx.Error(err)
+		ctx.SetStatusCode(http.StatusBadRequest)
+		ctx.Out().SetMsg(err).Send()
+		ctx.StopExecution()
+	}, nil
+}
@@ -0,0 +1,187 @@
+package desc_test
+
+import (
+	"testing"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/desc"
+	"github.com/clubpay/ronykit/kit/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type validatedItem struct {
+	SKU string `json:"sku" swag:"required;pattern:^[A-Z]{3}-[0-9]+$"`
+	Qty int    `json:"qty" swag:"min:1;max:10"`
+}
+
+type validatedMessage struct {
+	Name  string          `json:"name" swag:"required;minLen:3;maxLen:8"`
+	Kind  string          `json:"kind" swag:"enum:a,b"`
+	Tags  []string        `json:"tags" swag:"maxLen:2;enum:x,y"`
+	Items []validatedItem `json:"items"`
+	Note  *string         `json:"note" swag:"minLen:2"`
+	Age   int             `json:"age"`
+}
+
+func fieldRules(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	verr, ok := err.(*desc.ValidationError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rules := map[string]string{}
+	for _, fe := range verr.Fields {
+		rules[fe.Field] = fe.Rule
+	}
+
+	return rules
+}
+
+func TestValidateMessage(t *testing.T) {
+	valid := &validatedMessage{
+		Name:  "alice",
+		Kind:  "a",
+		Tags:  []string{"x"},
+		Items: []validatedItem{{SKU: "ABC-1", Qty: 2}},
+	}
+	assert.NoError(t, desc.ValidateMessage(valid, "json", desc.MessageMeta{}))
+
+	invalid := &validatedMessage{
+		Kind:  "c",
+		Tags:  []string{"x", "z", "y"},
+		Items: []validatedItem{{SKU: "ABC-1", Qty: 2}, {SKU: "abc", Qty: 11}},
+		Note:  utils.ValPtr("n"),
+	}
+	err := desc.ValidateMessage(invalid, "json", desc.MessageMeta{})
+	assert.Equal(t,
+		map[string]string{
+			"name":         desc.RuleRequired,
+			"kind":         desc.RuleEnum,
+			"tags":         desc.RuleMaxLength,
+			"tags[1]":      desc.RuleEnum,
+			"items[1].sku": desc.RulePattern,
+			"items[1].qty": desc.RuleMax,
+			"note":         desc.RuleMinLength,
+		},
+		fieldRules(t, err),
+	)
+
+	// FieldMeta overrides the struct tags
+	err = desc.ValidateMessage(
+		valid, "json",
+		desc.MessageMeta{
+			Fields: map[string]desc.FieldMeta{
+				"Name": {MaxLength: utils.ValPtr(3)},
+				"Age":  {Required: true},
+			},
+		},
+	)
+	assert.Equal(t,
+		map[string]string{
+			"name": desc.RuleMaxLength,
+			"age":  desc.RuleRequired,
+		},
+		fieldRules(t, err),
+	)
+
+	assert.NoError(t, desc.ValidateMessage(kit.RawMessage{}, "json", desc.MessageMeta{}))
+}
+
+func TestServiceValidation(t *testing.T) {
+	called := false
+	svc := desc.NewService("sample").
+		SetValidation(true).
+		AddContract(
+			desc.NewContract().
+				AddRoute(desc.Route("s1", newREST(kit.JSON, "/path1", "POST"))).
+				In(&validatedMessage{}).
+				SetHandler(func(ctx *kit.Context) { called = true }),
+		).
+		Build()
+
+	var verr *desc.ValidationError
+
+	err := kit.NewTestContext().
+		SetHandler(svc.Contracts()[0].Handlers()...).
+		Input(&validatedMessage{Name: "al"}, kit.EnvelopeHdr{}).
+		Expect(func(e *kit.Envelope) error {
+			verr, _ = e.GetMsg().(*desc.ValidationError)
+
+			return nil
+		}).
+		RunREST()
+	assert.NoError(t, err)
+	assert.False(t, called)
+	assert.NotNil(t, verr)
+	assert.Equal(t, 400, verr.GetCode())
+	assert.Equal(t, "VALIDATION_FAILED", verr.GetItem())
+	assert.Len(t, verr.Fields, 1)
+
+	err = kit.NewTestContext().
+		SetHandler(svc.Contracts()[0].Handlers()...).
+		Input(&validatedMessage{Name: "alice"}, kit.EnvelopeHdr{}).
+		RunREST()
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+type taggedMessage struct {
+	Name string `json:"name" yaml:"title" swag:"required"`
+}
+
+func TestServiceValidationContractEncoding(t *testing.T) {
+	// the selector has no encoding, hence the fields are named by the contract's.
+	svc := desc.NewService("sample").
+		SetValidation(true).
+		AddContract(
+			desc.NewContract().
+				SetEncoding(kit.CustomEncoding("yaml")).
+				AddRoute(desc.Route("s1", newREST(kit.Undefined, "/path1", "POST"))).
+				In(&taggedMessage{}).
+				SetHandler(func(_ *kit.Context) {}),
+		).
+		Build()
+
+	var verr *desc.ValidationError
+
+	err := kit.NewTestContext().
+		SetHandler(svc.Contracts()[0].Handlers()...).
+		Input(&taggedMessage{}, kit.EnvelopeHdr{}).
+		Expect(func(e *kit.Envelope) error {
+			verr, _ = e.GetMsg().(*desc.ValidationError)
+
+			return nil
+		}).
+		RunREST()
+	assert.NoError(t, err)
+	assert.NotNil(t, verr)
+	assert.Equal(t, map[string]string{"title": desc.RuleRequired}, fieldRules(t, verr))
+}
+
+type badPatternMessage struct {
+	Code string `json:"code" swag:"pattern:[a-"`
+}
+
+func TestValidationInvalidPattern(t *testing.T) {
+	err := desc.ValidateMessage(&badPatternMessage{}, "json", desc.MessageMeta{})
+	assert.ErrorContains(t, err, "badPatternMessage.Code")
+
+	assert.PanicsWithValue(t,
+		"contract sample.bad: invalid pattern of field badPatternMessage.Code: "+
+			"error parsing regexp: missing closing ]: `[a-`",
+		func() {
+			desc.NewService("sample").
+				SetValidation(true).
+				AddContract(
+					desc.NewContract().
+						SetName("bad").
+						AddRoute(desc.Route("s1", newREST(kit.JSON, "/path1", "POST"))).
+						In(&badPatternMessage{}).
+						SetHandler(func(_ *kit.Context) {}),
+				).
+				Build()
+		},
+	)
+}
@@ -37,6 +37,14 @@ type EdgeServer struct {
 	cd        ConnDelegate
 	contracts map[string]Contract
 	eh        ErrHandlerFunc
+	pm        ErrorMessage
+	cc        CarrierCodec
+	cr        *connRouter
+	tr        *topicRouter
+	st        serverState
+	hs        *healthService
+	reg       *connRegistry
+	rec       *recorder
 	l         Logger
 	wg        sync.WaitGroup
 
@@ -47,6 +55,7 @@ type EdgeServer struct {
 	prefork         bool
 	reusePort       bool
 	shutdownTimeout time.Duration
+	contractTimeout time.Duration
 
 	// local store
 	ls localStore
@@ -58,14 +67,18 @@ type EdgeServer struct {
 func NewServer(opts ...Option) *EdgeServer {
 	s := &EdgeServer{
 		contracts: map[string]Contract{},
+		cr:        newConnRouter(),
+		tr:        newTopicRouter(),
 		ls: localStore{
 			kv: map[string]any{},
 		},
 	}
 
 	cfg := &edgeConfig{
-		logger:     NOPLogger{},
-		errHandler: func(ctx *Context, err error) {},
+		logger:       NOPLogger{},
+		errHandler:   func(ctx *Context, err error) {},
+		panicMsg:     defaultPanicMessage,
+		carrierCodec: JSONCarrierCodec,
 	}
 	for _, opt := range opts {
 		opt(cfg)
@@ -74,14 +87,25 @@ func NewServer(opts ...Option) *EdgeServer {
 	s.l = cfg.logger
 	s.prefork = cfg.prefork
 	s.reusePort = cfg.reusePort
+	s.shutdownTimeout = cfg.shutdownTimeout
+	s.contractTimeout = cfg.contractTimeout
 	s.eh = cfg.errHandler
+	s.cr.q.eh = s.eh
+	s.tr.q.eh = s.eh
+	s.pm = cfg.panicMsg
+	s.cc = cfg.carrierCodec
 	s.gh = cfg.globalHandlers
+	s.rec = cfg.recorder
 
 	s.cd = cfg.connDelegate
 	if cfg.tracer != nil {
 		s.t = cfg.tracer
 	}
 
+	if cfg.connRegistry != nil {
+		s.reg = newConnRegistry(cfg.connRegistry, s.eh)
+	}
+
 	if cfg.cluster != nil {
 		s.registerCluster(utils.RandomID(32), cfg.cluster)
 	}
@@ -94,6 +118,19 @@ func NewServer(opts ...Option) *EdgeServer {
 		s.registerService(svc)
 	}
 
+	if cfg.health != nil {
+		s.hs = &healthService{s: s, cfg: cfg.health}
+		s.registerService(s.hs)
+	}
+
+	if cfg.connRegistry != nil && cfg.connRegistry.admin != nil {
+		if cfg.connRegistry.admin.auth == nil {
+			panic(ErrConnAdminAuthRequired)
+		}
+
+		s.registerService(&connAdminService{s: s, cfg: cfg.connRegistry.admin})
+	}
+
 	return s
 }
 
@@ -109,15 +146,22 @@ func (s *EdgeServer) registerGateway(gw Gateway) *EdgeServer {
 	nb := &northBridge{
 		ctxPool: ctxPool{
 			ls: &s.ls,
+			cr: s.cr,
+			tr: s.tr,
+			st: &s.st,
 			th: th,
 		},
 		cd:   s.cd,
 		wg:   &s.wg,
 		eh:   s.eh,
+		pm:   s.pm,
 		c:    s.contracts,
 		gw:   gw,
 		sb:   s.sb,
 		elog: &s.elog,
+		name: fmt.Sprintf("gateway.%d", len(s.nb)),
+		reg:  s.reg,
+		rec:  s.rec,
 	}
 	s.nb = append(s.nb, nb)
 
@@ -134,21 +178,40 @@ func (s *EdgeServer) registerCluster(id string, cb Cluster) *EdgeServer {
 		th = s.t.Handler()
 	}
 
+	s.cr.id = id
+	if cs, ok := cb.(ClusterWithStore); ok {
+		s.cr.store = cs.Store()
+	}
+
+	s.tr.id = id
+	s.tr.store = s.cr.store
+
+	if s.reg != nil {
+		s.reg.id = id
+		s.reg.store = s.cr.store
+	}
+
 	s.sb = &southBridge{
 		ctxPool: ctxPool{
 			ls: &s.ls,
+			cr: s.cr,
+			tr: s.tr,
+			st: &s.st,
 			th: th,
 		},
 		id:            id,
 		wg:            &s.wg,
 		eh:            s.eh,
+		pm:            s.pm,
 		c:             s.contracts,
 		cb:            cb,
 		tp:            s.t,
+		cc:            s.cc,
 		inProgressMtx: utils.SpinLock{},
 		inProgress:    map[string]*clusterConn{},
 		msgFactories:  map[string]MessageFactoryFunc{},
 		l:             s.l,
+		reg:           s.reg,
 	}
 
 	// Subscribe the southBridge, which is a ClusterDelegate, to connect southBridge with the Cluster
@@ -170,6 +233,7 @@ func (s *EdgeServer) registerService(svc Service) *EdgeServer {
 	for _, c := range svc.Contracts() {
 		s.contracts[contractLookupKey(svc.Name(), c.ID())] = WrapContract(
 			c,
+			ContractWrapperFunc(s.wrapWithTimeout),
 			ContractWrapperFunc(s.wrapWithGlobalHandlers),
 			ContractWrapperFunc(s.sb.wrapWithCoordinator),
 		)
@@ -191,6 +255,19 @@ func (s *EdgeServer) wrapWithGlobalHandlers(c Contract) Contract {
 	return cw
 }
 
+func (s *EdgeServer) wrapWithTimeout(c Contract) Contract {
+	if s.contractTimeout <= 0 || ContractTimeout(c) > 0 {
+		return c
+	}
+
+	cw := &contractWrap{
+		Contract: c,
+		timeout:  s.contractTimeout,
+	}
+
+	return cw
+}
+
 // Start registers services in the registered bundles and start the bundles.
 func (s *EdgeServer) Start(ctx context.Context) *EdgeServer {
 	s.l.Debugf("server started.")
@@ -334,14 +411,28 @@ func (s *EdgeServer) startup(ctx context.Context) {
 			panic(err)
 		}
 	}
+
+	if s.reg != nil {
+		s.reg.start()
+	}
+
+	s.tr.start()
+	s.st.set(ServerStateReady)
+}
+
+// State returns the lifecycle state of the EdgeServer.
+func (s *EdgeServer) State() ServerState {
+	return s.st.get()
 }
 
 // Shutdown stops the server. If there is no signal input, then it shut down the server immediately.
 // However, if there is one or more signals added in the input argument, then it waits for any of them to
 // trigger the shutdown process.
-// Since this is a graceful shutdown, it waits for all flying requests to complete. However, you can set
-// the maximum time that it waits before forcefully shutting down the server, by WithShutdownTimeout
-// option. The Default value is 1 minute.
+// Since this is a graceful shutdown, it first drains the server: the state changes to
+// ServerStateDraining, the instance is deregistered from the Cluster, the gateways stop accepting
+// new work and close their open streams, and then it waits for all flying requests to complete.
+// However, you can set the maximum time that it waits before forcefully shutting down the server,
+// by WithShutdownTimeout option. The Default value is 1 minute.
 func (s *EdgeServer) Shutdown(ctx context.Context, signals ...os.Signal) {
 	if len(signals) > 0 {
 		// Create a signal channel and bind it to all the os signals in the arg
@@ -360,14 +451,35 @@ func (s *EdgeServer) Shutdown(ctx context.Context, signals ...os.Signal) {
 }
 
 func (s *EdgeServer) shutdown(ctx context.Context) {
-	// Shutdown all the registered gateways
+	s.st.set(ServerStateDraining)
+
+	if s.shutdownTimeout == 0 {
+		s.shutdownTimeout = time.Minute
+	}
+
+	drainCtx, cf := context.WithTimeout(ctx, s.shutdownTimeout)
+	s.drain(drainCtx)
+	cf()
+
+	// Shutdown the drained gateways, the rest are already shut down.
 	for idx := range s.nb {
+		if _, ok := s.nb[idx].gw.(GatewayDrainer); !ok {
+			continue
+		}
+
 		err := s.nb[idx].gw.Shutdown(ctx)
 		if err != nil {
 			s.l.Errorf("[EdgeServer] got error on shutdown gateway: %v", err)
 		}
 	}
 
+	if s.reg != nil {
+		s.reg.stop()
+	}
+
+	s.tr.stop()
+	s.cr.q.flush()
+
 	if s.sb != nil {
 		err := s.sb.Shutdown(ctx)
 		if err != nil {
@@ -375,8 +487,36 @@ func (s *EdgeServer) shutdown(ctx context.Context) {
 		}
 	}
 
-	if s.shutdownTimeout == 0 {
-		s.shutdownTimeout = time.Minute
+	s.st.set(ServerStateStopped)
+}
+
+// drain stops accepting new work, and waits for the in-flight requests to finish or ctx
+// to be done.
+// The gateways which do not support draining are shut down immediately.
+func (s *EdgeServer) drain(ctx context.Context) {
+	if s.sb != nil {
+		if d, ok := s.sb.cb.(ClusterDeregisterer); ok {
+			err := d.Deregister(ctx)
+			if err != nil {
+				s.l.Errorf("[EdgeServer] got error on deregister from cluster: %v", err)
+			}
+		}
+	}
+
+	for idx := range s.nb {
+		var err error
+		if d, ok := s.nb[idx].gw.(GatewayDrainer); ok {
+			err = d.Drain(ctx)
+		} else {
+			err = s.nb[idx].gw.Shutdown(ctx)
+		}
+
+		if err != nil {
+			s.l.Errorf("[EdgeServer] got error on shutdown gateway: %v", err)
+		}
+
+		// The streams are long-lived, and their clients have been told to go away.
+		s.nb[idx].cancelStreams()
 	}
 
 	waitCh := make(chan struct{}, 1)
@@ -389,7 +529,7 @@ func (s *EdgeServer) shutdown(ctx context.Context) {
 
 	select {
 	case <-waitCh:
-	case <-time.After(s.shutdownTimeout):
+	case <-ctx.Done():
 	}
 }
 
@@ -644,7 +784,7 @@ func rpcRoute(rs RouteSelector) string {
 		return ""
 	}
 
-	return text.Colors{text.Bold, text.FgHiCyan}.Sprint(rpc.GetPredicate())
+	return text.Colors{text.Bold, text.FgHiCyan}.Sprint(rpc.GetPredicate()) + versionTag(rs)
 }
 
 func restRoute(rs RouteSelector) string {
@@ -656,7 +796,16 @@ func restRoute(rs RouteSelector) string {
 	return fmt.Sprintf("%s %s",
 		httpMethodColor(rest.GetMethod()).Sprint(rest.GetMethod()),
 		text.Colors{text.FgHiWhite}.Sprint(rest.GetPath()),
-	)
+	) + versionTag(rs)
+}
+
+func versionTag(rs RouteSelector) string {
+	v := RouteVersion(rs)
+	if v == "" {
+		return ""
+	}
+
+	return " " + text.Colors{text.FgHiBlack}.Sprintf("[%s]", v)
 }
 
 func writeEndpointLog(elog *endpointLog, ctx *Context, dur time.Duration) {
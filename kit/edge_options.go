@@ -7,13 +7,19 @@ type edgeConfig struct {
 	prefork         bool
 	reusePort       bool
 	shutdownTimeout time.Duration
+	contractTimeout time.Duration
 	gateways        []Gateway
 	cluster         Cluster
+	carrierCodec    CarrierCodec
 	services        []Service
 	errHandler      ErrHandlerFunc
+	panicMsg        ErrorMessage
 	globalHandlers  []HandlerFunc
 	tracer          Tracer
 	connDelegate    ConnDelegate
+	health          *healthConfig
+	connRegistry    *connRegistryConfig
+	recorder        *recorder
 }
 
 type Option func(s *edgeConfig)
@@ -44,6 +50,17 @@ func WithCluster(cb Cluster) Option {
 	}
 }
 
+// WithCarrierCodec sets the wire format of the messages that this instance sends to the
+// other instances through the Cluster. Incoming messages are decoded by their own format,
+// hence instances with different codecs could work together, e.g., during a rolling
+// deploy which switches the codec.
+// Default is JSONCarrierCodec.
+func WithCarrierCodec(cc CarrierCodec) Option {
+	return func(s *edgeConfig) {
+		s.carrierCodec = cc
+	}
+}
+
 // WithService lets you register a service in constructor of the EdgeServer.
 func WithService(service ...Service) Option {
 	return func(s *edgeConfig) {
@@ -60,19 +77,30 @@ func WithServiceBuilder(builder ...ServiceBuilder) Option {
 }
 
 // WithShutdownTimeout sets the maximum time to wait until all running requests to finish.
-// Default is to wait forever.
+// Default is 1 minute.
 func WithShutdownTimeout(d time.Duration) Option {
 	return func(s *edgeConfig) {
 		s.shutdownTimeout = d
 	}
 }
 
+// WithContractTimeout sets the default deadline for the handlers of the contracts that
+// do not declare their own timeout (i.e., desc.Contract.SetTimeout).
+// When the deadline is exceeded, the Context.Context() is canceled, and if the handlers
+// have not sent any envelope yet, a timeout error is sent to the client.
+// This deadline is also the default remote execution timeout for the forwarded requests.
+func WithContractTimeout(d time.Duration) Option {
+	return func(s *edgeConfig) {
+		s.contractTimeout = d
+	}
+}
+
 // WithErrorHandler registers a global error handler to catch any error that
 // happens before EdgeServer can deliver the incoming message to the handler, or delivering
 // the outgoing message to the client.
 // Internal errors are usually wrapped and could be checked for better error handling.
 // You can check with errors.Is function to see if the error is one of the following:
-// ErrDispatchFailed, ErrWriteToClosedConn, ErrNoHandler
+// ErrDispatchFailed, ErrWriteToClosedConn, ErrNoHandler, ErrHandlerPanic
 // ErrDecodeIncomingMessageFailed, ErrEncodeOutgoingMessageFailed
 func WithErrorHandler(h ErrHandlerFunc) Option {
 	return func(s *edgeConfig) {
@@ -80,6 +108,16 @@ func WithErrorHandler(h ErrHandlerFunc) Option {
 	}
 }
 
+// WithPanicMessage sets the message which is sent to the client, when a handler or a
+// modifier panics before any envelope is sent. The panic is recovered, and an error
+// which wraps ErrHandlerPanic is passed to the ErrHandlerFunc.
+// Default is a kit.Error with 500 code.
+func WithPanicMessage(msg ErrorMessage) Option {
+	return func(s *edgeConfig) {
+		s.panicMsg = msg
+	}
+}
+
 // WithGlobalHandlers sets the handlers that will be executed before any service's contract.
 func WithGlobalHandlers(handlers ...HandlerFunc) Option {
 	return func(s *edgeConfig) {
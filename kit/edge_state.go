@@ -0,0 +1,75 @@
+package kit
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// ServerState is the lifecycle state of the EdgeServer. Handlers can read it by
+// Context.ServerState, for example, to report readiness in health check endpoints.
+type ServerState int32
+
+const (
+	// ServerStateStarting means the EdgeServer has not started its gateways yet.
+	ServerStateStarting ServerState = iota
+	// ServerStateReady means the EdgeServer is accepting new connections and requests.
+	ServerStateReady
+	// ServerStateDraining means the EdgeServer is shutting down. It does not accept new
+	// work, and it waits for the in-flight requests to finish.
+	ServerStateDraining
+	// ServerStateStopped means the EdgeServer is shut down.
+	ServerStateStopped
+)
+
+func (s ServerState) String() string {
+	switch s {
+	case ServerStateStarting:
+		return "starting"
+	case ServerStateReady:
+		return "ready"
+	case ServerStateDraining:
+		return "draining"
+	case ServerStateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+type serverState struct {
+	v atomic.Int32
+}
+
+func (s *serverState) get() ServerState {
+	if s == nil {
+		return ServerStateReady
+	}
+
+	return ServerState(s.v.Load())
+}
+
+func (s *serverState) set(state ServerState) {
+	s.v.Store(int32(state))
+}
+
+// GatewayDrainer is an optional interface which is implemented by the Gateways which
+// support graceful draining. When the EdgeServer is shutting down, it calls Drain before
+// waiting for the in-flight requests. The Gateway must stop accepting new connections and
+// requests, and tell the clients of the open streams (e.g., websocket close frames or a
+// final SSE event) that the server is going away. Drain must not block until the
+// connections are closed; Shutdown is called afterward.
+type GatewayDrainer interface {
+	Drain(ctx context.Context) error
+}
+
+// ClusterDeregisterer is an optional interface which is implemented by the Clusters which
+// can remove this instance from the Subscribers of the cluster, before Shutdown is called.
+// This instance must still be able to publish and receive the in-flight messages.
+type ClusterDeregisterer interface {
+	Deregister(ctx context.Context) error
+}
+
+// ServerState returns the lifecycle state of the EdgeServer.
+func (ctx *Context) ServerState() ServerState {
+	return ctx.st.get()
+}
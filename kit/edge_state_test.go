@@ -0,0 +1,193 @@
+package kit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type drainGateway struct {
+	testGateway
+
+	calls *[]string
+}
+
+func (g *drainGateway) Drain(_ context.Context) error {
+	*g.calls = append(*g.calls, "drain")
+
+	return nil
+}
+
+func (g *drainGateway) Shutdown(ctx context.Context) error {
+	*g.calls = append(*g.calls, "shutdown")
+
+	return g.testGateway.Shutdown(ctx)
+}
+
+type deregisterCluster struct {
+	testCluster
+
+	calls *[]string
+}
+
+func (c *deregisterCluster) Deregister(_ context.Context) error {
+	*c.calls = append(*c.calls, "deregister")
+
+	return nil
+}
+
+func (c *deregisterCluster) Shutdown(ctx context.Context) error {
+	*c.calls = append(*c.calls, "cluster-shutdown")
+
+	return c.testCluster.Shutdown(ctx)
+}
+
+func TestEdgeServerDrain(t *testing.T) {
+	var calls []string
+
+	gw := &drainGateway{calls: &calls}
+	cluster := &deregisterCluster{calls: &calls}
+
+	started := make(chan struct{})
+
+	var (
+		stateInHandler ServerState
+		canceled       bool
+	)
+
+	gw.dispatchFn = func(_ *Context, _ []byte) (ExecuteArg, error) {
+		return ExecuteArg{ServiceName: "svc", ContractID: "stream"}, nil
+	}
+
+	s := NewServer(
+		WithGateway(gw),
+		WithCluster(cluster),
+		WithService(testService{
+			name: "svc",
+			contracts: []Contract{
+				&testContract{
+					id:     "stream",
+					input:  RawMessage{},
+					output: RawMessage{},
+					handlers: []HandlerFunc{
+						func(ctx *Context) {
+							close(started)
+							<-ctx.Context().Done()
+							canceled = true
+							stateInHandler = ctx.ServerState()
+						},
+					},
+				},
+			},
+		}),
+	)
+
+	if s.State() != ServerStateStarting {
+		t.Fatalf("unexpected state: %s", s.State())
+	}
+
+	s.Start(t.Context())
+
+	if s.State() != ServerStateReady {
+		t.Fatalf("unexpected state: %s", s.State())
+	}
+
+	conn := newTestConn()
+	conn.stream = true
+
+	done := make(chan struct{})
+	go func() {
+		gw.delegate.OnMessage(conn, []byte("in"))
+		close(done)
+	}()
+
+	<-started
+	s.Shutdown(t.Context())
+	<-done
+
+	if !canceled {
+		t.Fatal("expected the stream to be canceled")
+	}
+	if stateInHandler != ServerStateDraining {
+		t.Fatalf("unexpected state in handler: %s", stateInHandler)
+	}
+	if s.State() != ServerStateStopped {
+		t.Fatalf("unexpected state: %s", s.State())
+	}
+
+	expected := []string{"deregister", "drain", "shutdown", "cluster-shutdown"}
+	if len(calls) != len(expected) {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Fatalf("unexpected calls: %v", calls)
+		}
+	}
+}
+
+func TestEdgeServerShutdownTimeout(t *testing.T) {
+	gw := &testGateway{
+		dispatchFn: func(_ *Context, _ []byte) (ExecuteArg, error) {
+			return ExecuteArg{ServiceName: "svc", ContractID: "slow"}, nil
+		},
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+
+	s := NewServer(
+		WithGateway(gw),
+		WithShutdownTimeout(50*time.Millisecond),
+		WithService(testService{
+			name: "svc",
+			contracts: []Contract{
+				&testContract{
+					id:     "slow",
+					input:  RawMessage{},
+					output: RawMessage{},
+					handlers: []HandlerFunc{
+						func(_ *Context) {
+							close(started)
+							<-release
+						},
+					},
+				},
+			},
+		}),
+	).Start(t.Context())
+
+	go gw.delegate.OnMessage(newTestConn(), []byte("in"))
+	<-started
+
+	start := time.Now()
+	s.Shutdown(t.Context())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown did not respect the timeout: %v", elapsed)
+	}
+	if gw.shutdownCalls != 1 {
+		t.Fatalf("expected gateway shutdown, got: %d", gw.shutdownCalls)
+	}
+}
+
+func TestServerStateString(t *testing.T) {
+	for state, expected := range map[ServerState]string{
+		ServerStateStarting: "starting",
+		ServerStateReady:    "ready",
+		ServerStateDraining: "draining",
+		ServerStateStopped:  "stopped",
+		ServerState(100):    "unknown",
+	} {
+		if state.String() != expected {
+			t.Fatalf("unexpected string: %s", state.String())
+		}
+	}
+
+	if NewContext(nil).ServerState() != ServerStateReady {
+		t.Fatal("expected detached contexts to be ready")
+	}
+}
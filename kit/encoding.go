@@ -0,0 +1,217 @@
+package kit
+
+import (
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/clubpay/ronykit/kit/errors"
+)
+
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeProto   = "application/x-protobuf"
+	ContentTypeMsgpack = "application/msgpack"
+)
+
+// ErrUnsupportedMessage is returned by the codecs of the encodings which cannot encode
+// or decode the message, e.g., the Proto codec for a message which is not a proto.Message.
+var ErrUnsupportedMessage = errors.New("message is not supported by the codec")
+
+type encodingCodec struct {
+	codec        MessageCodec
+	contentTypes []string
+}
+
+var (
+	encodingsMtx   sync.RWMutex
+	encodings      = map[string]*encodingCodec{}
+	encodingsByCT  = map[string]Encoding{}
+	jsonEncodingMC = jsonCodec{}
+)
+
+func init() {
+	RegisterEncoding(JSON, jsonEncodingMC, ContentTypeJSON)
+	RegisterEncoding(Proto, protoCodec{}, ContentTypeProto, "application/protobuf")
+	RegisterEncoding(MSG, msgpackCodec{}, ContentTypeMsgpack, "application/x-msgpack", "application/vnd.msgpack")
+}
+
+// RegisterEncoding registers the codec of the encoding, and the content types which
+// identify it in the Content-Type and Accept headers. The first content type is used in
+// the responses. Registering an encoding again replaces its codec.
+func RegisterEncoding(enc Encoding, codec MessageCodec, contentTypes ...string) {
+	encodingsMtx.Lock()
+	defer encodingsMtx.Unlock()
+
+	encodings[enc.tag] = &encodingCodec{
+		codec:        codec,
+		contentTypes: contentTypes,
+	}
+
+	for _, ct := range contentTypes {
+		encodingsByCT[strings.ToLower(ct)] = enc
+	}
+}
+
+// ContentType returns the content type of the encoding, or an empty string if the encoding
+// is not registered.
+func (enc Encoding) ContentType() string {
+	encodingsMtx.RLock()
+	defer encodingsMtx.RUnlock()
+
+	ec := encodings[enc.tag]
+	if ec == nil || len(ec.contentTypes) == 0 {
+		return ""
+	}
+
+	return ec.contentTypes[0]
+}
+
+// FieldTag returns the struct tag which names the fields of the messages in the query and
+// path parameters and the API documents. The codecs of Proto and MSG use the json tags, hence
+// JSON's tag is returned for them, and for Undefined.
+func (enc Encoding) FieldTag() string {
+	switch enc {
+	case Undefined, Proto, MSG:
+		return JSON.tag
+	default:
+		return enc.tag
+	}
+}
+
+// GetEncodingCodec returns the codec of the encoding. For the encodings which are not
+// registered, the default codec (check SetCustomCodec) is returned.
+func GetEncodingCodec(enc Encoding) MessageCodec {
+	encodingsMtx.RLock()
+	ec := encodings[enc.tag]
+	encodingsMtx.RUnlock()
+
+	if ec == nil {
+		return jsonEncodingMC
+	}
+
+	return ec.codec
+}
+
+// EncodingByContentType returns the encoding registered for the content type. The
+// parameters of the content type, e.g., charset, are ignored.
+func EncodingByContentType(contentType string) (Encoding, bool) {
+	if contentType == "" {
+		return Undefined, false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Undefined, false
+	}
+
+	encodingsMtx.RLock()
+	enc, ok := encodingsByCT[mediaType]
+	encodingsMtx.RUnlock()
+
+	return enc, ok
+}
+
+// NegotiateEncoding selects the encoding of the response. The registered encodings in the
+// accept header (the Accept header of the request) are preferred by their quality. If the
+// accept header is empty or accepts any type, the encoding of contentType (the Content-Type
+// header of the request) is selected, otherwise def.
+func NegotiateEncoding(accept, contentType string, def Encoding) Encoding {
+	type candidate struct {
+		enc Encoding
+		q   float64
+	}
+
+	var (
+		candidates []candidate
+		anyType    = accept == ""
+	)
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		if mediaType == "*/*" || mediaType == "application/*" {
+			anyType = true
+
+			continue
+		}
+
+		encodingsMtx.RLock()
+		enc, ok := encodingsByCT[mediaType]
+		encodingsMtx.RUnlock()
+
+		if ok {
+			candidates = append(candidates, candidate{enc: enc, q: q})
+		}
+	}
+
+	if len(candidates) > 0 {
+		// SliceStable keeps the order of the header for the same quality.
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+		return candidates[0].enc
+	}
+
+	if enc, ok := EncodingByContentType(contentType); ok && anyType {
+		return enc
+	}
+
+	return def
+}
+
+// MarshalMessageAs marshals m by the codec of enc. If the codec does not support m, e.g.,
+// an ErrorMessage for the Proto encoding, it is marshaled by JSON. The returned Encoding
+// is the one actually used.
+func MarshalMessageAs(enc Encoding, m Message) ([]byte, Encoding, error) {
+	if v, ok := m.(RawMessage); ok {
+		return v, enc, nil
+	}
+
+	if enc == JSON || enc == Undefined {
+		data, err := MarshalMessage(m)
+
+		return data, JSON, err
+	}
+
+	data, err := GetEncodingCodec(enc).Marshal(m)
+	if errors.Is(err, ErrUnsupportedMessage) {
+		data, err = MarshalMessage(m)
+
+		return data, JSON, err
+	}
+
+	return data, enc, err
+}
+
+// UnmarshalMessageAs unmarshals data into m by the codec of enc.
+func UnmarshalMessageAs(enc Encoding, data []byte, m Message) error {
+	if enc == JSON || enc == Undefined {
+		return UnmarshalMessage(data, m)
+	}
+
+	return GetEncodingCodec(enc).Unmarshal(data, m)
+}
+
+// jsonCodec delegates to the default codec, so SetCustomCodec also applies to the
+// JSON encoding.
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(m Message, w io.Writer) error { return defaultMessageCodec.Encode(m, w) }
+func (jsonCodec) Marshal(m any) ([]byte, error)       { return defaultMessageCodec.Marshal(m) }
+func (jsonCodec) Decode(m Message, r io.Reader) error { return defaultMessageCodec.Decode(m, r) }
+func (jsonCodec) Unmarshal(data []byte, m any) error  { return defaultMessageCodec.Unmarshal(data, m) }
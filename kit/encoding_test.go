@@ -0,0 +1,93 @@
+package kit
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		accept, contentType string
+		def, expected       Encoding
+	}{
+		{"", "", JSON, JSON},
+		{"", "", Proto, Proto},
+		{"", "application/x-protobuf", JSON, Proto},
+		{"*/*", "application/protobuf", JSON, Proto},
+		{"application/json", "application/x-protobuf", Proto, JSON},
+		{"application/json;q=0.5, application/x-protobuf", "", JSON, Proto},
+		{"application/x-protobuf;q=0, application/json", "", Proto, JSON},
+		{"text/html", "application/x-protobuf", JSON, JSON},
+		{"application/x-msgpack", "", JSON, MSG},
+		{"", "application/msgpack", JSON, MSG},
+	} {
+		got := NegotiateEncoding(tc.accept, tc.contentType, tc.def)
+		if got != tc.expected {
+			t.Fatalf("NegotiateEncoding(%q, %q, %s): expected %s, got %s",
+				tc.accept, tc.contentType, tc.def.Tag(), tc.expected.Tag(), got.Tag())
+		}
+	}
+}
+
+func TestMarshalMessageAs(t *testing.T) {
+	data, enc, err := MarshalMessageAs(Proto, wrapperspb.String("hello"))
+	if err != nil || enc != Proto {
+		t.Fatalf("unexpected result: %s, %v", enc.Tag(), err)
+	}
+
+	out := &wrapperspb.StringValue{}
+	if err = UnmarshalMessageAs(Proto, data, out); err != nil || out.GetValue() != "hello" {
+		t.Fatalf("unexpected message: %v, %v", out, err)
+	}
+
+	// the messages which are not proto messages fall back to JSON
+	data, enc, err = MarshalMessageAs(Proto, &callOut{N: 1})
+	if err != nil || enc != JSON || string(data) != `{"n":1}` {
+		t.Fatalf("unexpected result: %s, %s, %v", data, enc.Tag(), err)
+	}
+
+	err = UnmarshalMessageAs(Proto, data, &callOut{})
+	if !errors.Is(err, ErrUnsupportedMessage) {
+		t.Fatalf("expected ErrUnsupportedMessage, got: %v", err)
+	}
+}
+
+func TestEnvelopeCarrierProto(t *testing.T) {
+	data := marshalEnvelopeCarrier(wrapperspb.String("hello"))
+
+	out := &wrapperspb.StringValue{}
+	unmarshalEnvelopeCarrier(data, out)
+
+	if out.GetValue() != "hello" {
+		t.Fatalf("unexpected message: %v", out)
+	}
+}
+
+func TestMsgpackCodec(t *testing.T) {
+	data, enc, err := MarshalMessageAs(MSG, &callOut{N: 7})
+	if err != nil || enc != MSG {
+		t.Fatalf("unexpected result: %s, %v", enc.Tag(), err)
+	}
+
+	out := &callOut{}
+	if err = UnmarshalMessageAs(MSG, data, out); err != nil || out.N != 7 {
+		t.Fatalf("unexpected message: %+v, %v", out, err)
+	}
+
+	// the fields are named by their json tags
+	m := map[string]int{}
+	if err = UnmarshalMessageAs(MSG, data, &m); err != nil || m["n"] != 7 {
+		t.Fatalf("unexpected map: %v, %v", m, err)
+	}
+
+	var raw RawMessage
+	if err = UnmarshalMessageAs(MSG, data, &raw); err != nil || string(raw) != string(data) {
+		t.Fatalf("unexpected raw message: %v, %v", raw, err)
+	}
+
+	if MSG.FieldTag() != "json" || Proto.FieldTag() != "json" || MultipartForm.FieldTag() != MultipartForm.Tag() {
+		t.Fatal("unexpected field tags")
+	}
+}
@@ -162,6 +162,14 @@ func (e *Envelope) GetMsg() Message {
 	return e.m
 }
 
+// GetContext returns the Context of the Envelope, e.g., for the modifiers which keep the
+// state of the request in the Context. The modifiers run while the envelope is sent,
+// hence they MUST NOT call the methods of the Context which change the response, e.g.,
+// SetStatusCode, PresetHdr or AddModifier.
+func (e *Envelope) GetContext() *Context {
+	return e.ctx
+}
+
 // Send writes the envelope to the connection based on the Gateway specification.
 // You **MUST NOT** use the Envelope after calling this method.
 // You **MUST NOT** call this function more than once.
@@ -176,17 +184,44 @@ func (e *Envelope) Send() {
 		panic("BUG!! do not call Send on incoming envelope")
 	}
 
+	// send releases the envelope, which may then be reused by another Context, hence we
+	// keep the Context of this one.
+	ctx := e.ctx
+
+	ctx.sendMtx.Lock()
+	defer ctx.sendMtx.Unlock()
+
+	// the client has already got the timeout error.
+	if ctx.timedOut.Load() {
+		e.release()
+
+		return
+	}
+
+	ctx.Error(e.send())
+}
+
+// send runs the modifiers and writes the envelope to the connection. The caller MUST hold
+// the sendMtx of the Context.
+func (e *Envelope) send() error {
 	// run the modifiers in LIFO order
 	modifiersCount := len(e.ctx.modifiers) - 1
 	for idx := range e.ctx.modifiers {
 		e.ctx.modifiers[modifiersCount-idx](e)
 	}
 
+	if e.ctx.rec != nil {
+		e.ctx.rec.addOut(e)
+	}
+
 	// Use WriteFunc to write the Envelope into the connection
-	e.ctx.Error(e.conn.WriteEnvelope(e))
+	err := e.conn.WriteEnvelope(e)
+	e.ctx.responded.Store(true)
 
 	// Release the envelope
 	e.release()
+
+	return err
 }
 
 // Reply creates a new envelope which it's id is
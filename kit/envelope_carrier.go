@@ -14,6 +14,10 @@ const (
 	incomingCarrier carrierKind = iota + 1
 	outgoingCarrier
 	eofCarrier
+	pushCarrier
+	topicCarrier
+	kickCarrier
+	connPushCarrier
 )
 
 // envelopeCarrier is a serializable message which is used by the Cluster component of the
@@ -116,6 +120,8 @@ type carrierData struct {
 	ContractID  string            `json:"cid,omitempty"`
 	ServiceName string            `json:"svc,omitempty"`
 	Route       string            `json:"route,omitempty"`
+	ConnKeys    []string          `json:"connKeys,omitempty"`
+	Topic       string            `json:"topic,omitempty"`
 }
 
 func (c carrierData) Get(key string) string {
@@ -152,8 +158,19 @@ func newEnvelopeCarrier(kind carrierKind, sessionID, originID, targetID string)
 	}
 }
 
+// carrierEncoding returns the encoding of m in the carriers. The proto messages are
+// encoded by Proto, and the others by JSON. Since both sides know the type of the message,
+// the encoding is not carried.
+func carrierEncoding(m Message) Encoding {
+	if IsProtoMessage(m) {
+		return Proto
+	}
+
+	return JSON
+}
+
 func unmarshalEnvelopeCarrier(data []byte, m Message) {
-	err := UnmarshalMessage(data, m)
+	err := UnmarshalMessageAs(carrierEncoding(m), data, m)
 	if err != nil {
 		panic(err)
 	}
@@ -165,7 +182,7 @@ func marshalEnvelopeCarrier(m Message) []byte {
 		return v
 	}
 
-	data, err := MarshalMessage(m)
+	data, _, err := MarshalMessageAs(carrierEncoding(m), m)
 	if err != nil {
 		panic(err)
 	}
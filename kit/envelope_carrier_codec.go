@@ -0,0 +1,327 @@
+package kit
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/utils/buf"
+)
+
+// CarrierCodec defines the wire format of the messages that EdgeServer instances
+// exchange through the Cluster. Regardless of the selected CarrierCodec, every instance
+// can decode the messages encoded by the other built-in codecs, so instances running
+// different codecs can co-exist during a rolling deploy.
+//
+// Use JSONCarrierCodec or BinaryCarrierCodec with WithCarrierCodec option, or wrap
+// another JSON MessageCodec by NewJSONCarrierCodec.
+type CarrierCodec interface {
+	// EncodeCarrier writes the carrier into w. The carrier is a struct with json tags.
+	// The receiving instances detect the format by the first byte, hence the output
+	// MUST be either a JSON object or the format of BinaryCarrierCodec.
+	EncodeCarrier(carrier Message, w *buf.Bytes) error
+}
+
+// NewJSONCarrierCodec returns a CarrierCodec which encodes the carriers by mc, e.g., to
+// use a faster JSON library than the default one. The output of mc MUST be JSON.
+func NewJSONCarrierCodec(mc MessageCodec) CarrierCodec {
+	return jsonCarrierCodec{mc: mc}
+}
+
+var (
+	// JSONCarrierCodec encodes the carriers using the default MessageCodec. This is the
+	// default CarrierCodec.
+	JSONCarrierCodec CarrierCodec = jsonCarrierCodec{}
+	// BinaryCarrierCodec encodes the carriers using a compact length-prefixed binary
+	// format. The first byte of each message is the version of the format.
+	BinaryCarrierCodec CarrierCodec = binaryCarrierCodec{}
+)
+
+const (
+	binaryCarrierV1 byte = 0x01
+	// binaryCarrierV2 appends the topic of the published messages to the data.
+	binaryCarrierV2 byte = 0x02
+)
+
+const (
+	carrierFlagData byte = 1 << iota
+	carrierFlagREST
+)
+
+// decodeEnvelopeCarrier detects the codec by the first byte of the data. JSON encoded
+// carriers always start with '{', and binary encoded carriers start with their version.
+func decodeEnvelopeCarrier(data []byte) (*envelopeCarrier, error) {
+	ec := &envelopeCarrier{}
+
+	if len(data) == 0 {
+		return nil, ErrInvalidCarrier
+	}
+
+	var err error
+
+	switch data[0] {
+	case '{', ' ', '\t', '\r', '\n':
+		err = ec.FromJSON(data)
+	case binaryCarrierV1, binaryCarrierV2:
+		err = ec.fromBinary(data[0], data[1:])
+	default:
+		err = errors.Wrap(ErrUnsupportedCarrierVersion, errors.New("version=%d", data[0]))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ec, nil
+}
+
+type jsonCarrierCodec struct {
+	mc MessageCodec
+}
+
+func (cc jsonCarrierCodec) EncodeCarrier(carrier Message, w *buf.Bytes) error {
+	if cc.mc == nil {
+		return defaultMessageCodec.Encode(carrier, w)
+	}
+
+	return cc.mc.Encode(carrier, w)
+}
+
+type binaryCarrierCodec struct{}
+
+func (binaryCarrierCodec) EncodeCarrier(carrier Message, w *buf.Bytes) error {
+	ec, ok := carrier.(*envelopeCarrier)
+	if !ok {
+		return ErrInvalidCarrier
+	}
+
+	b := *w.Bytes()
+	b = append(b, binaryCarrierV2)
+	b = binary.AppendUvarint(b, uint64(ec.Kind))
+	b = appendCarrierString(b, ec.SessionID)
+	b = appendCarrierString(b, ec.OriginID)
+	b = appendCarrierString(b, ec.TargetID)
+
+	d := ec.Data
+	if d == nil {
+		b = append(b, 0)
+		w.SetBytes(&b)
+
+		return nil
+	}
+
+	flags := carrierFlagData
+	if d.IsREST {
+		flags |= carrierFlagREST
+	}
+
+	b = append(b, flags)
+	b = binary.AppendVarint(b, int64(d.StatusCode))
+	b = appendCarrierString(b, d.EnvelopeID)
+	b = appendCarrierMap(b, d.ConnHdr)
+	b = appendCarrierMap(b, d.Hdr)
+	b = appendCarrierString(b, d.MsgType)
+	b = appendCarrierBytes(b, d.Msg)
+	b = appendCarrierString(b, d.ContractID)
+	b = appendCarrierString(b, d.ServiceName)
+	b = appendCarrierString(b, d.Route)
+	b = appendCarrierList(b, d.ConnKeys)
+	b = appendCarrierString(b, d.Topic)
+	w.SetBytes(&b)
+
+	return nil
+}
+
+func appendCarrierBytes(b, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(v)))
+
+	return append(b, v...)
+}
+
+func appendCarrierString(b []byte, v string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(v)))
+
+	return append(b, v...)
+}
+
+func appendCarrierList(b []byte, l []string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(l)))
+	for _, v := range l {
+		b = appendCarrierString(b, v)
+	}
+
+	return b
+}
+
+func appendCarrierMap(b []byte, m map[string]string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(m)))
+	for k, v := range m {
+		b = appendCarrierString(b, k)
+		b = appendCarrierString(b, v)
+	}
+
+	return b
+}
+
+func (ec *envelopeCarrier) fromBinary(version byte, data []byte) error {
+	r := carrierReader{b: data}
+
+	ec.Kind = carrierKind(r.uvarint())
+	ec.SessionID = r.string()
+	ec.OriginID = r.string()
+	ec.TargetID = r.string()
+
+	flags := r.byte()
+	if flags&carrierFlagData != 0 {
+		ec.Data = &carrierData{
+			IsREST:      flags&carrierFlagREST != 0,
+			StatusCode:  r.int(),
+			EnvelopeID:  r.string(),
+			ConnHdr:     r.stringMap(),
+			Hdr:         r.stringMap(),
+			MsgType:     r.string(),
+			Msg:         r.bytes(),
+			ContractID:  r.string(),
+			ServiceName: r.string(),
+			Route:       r.string(),
+			ConnKeys:    r.stringList(),
+		}
+
+		if version >= binaryCarrierV2 {
+			ec.Data.Topic = r.string()
+		}
+	}
+
+	if r.err || len(r.b) > 0 {
+		return ErrInvalidCarrier
+	}
+
+	return nil
+}
+
+// carrierReader reads the binary encoded carrier. After the first failure, all the
+// subsequent reads return zero values and err is set.
+type carrierReader struct {
+	b   []byte
+	err bool
+}
+
+func (r *carrierReader) uvarint() uint64 {
+	if r.err {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.err = true
+
+		return 0
+	}
+
+	r.b = r.b[n:]
+
+	return v
+}
+
+func (r *carrierReader) int() int {
+	if r.err {
+		return 0
+	}
+
+	v, n := binary.Varint(r.b)
+	if n <= 0 || v > math.MaxInt32 || v < math.MinInt32 {
+		r.err = true
+
+		return 0
+	}
+
+	r.b = r.b[n:]
+
+	return int(v)
+}
+
+func (r *carrierReader) byte() byte {
+	if r.err || len(r.b) == 0 {
+		r.err = true
+
+		return 0
+	}
+
+	v := r.b[0]
+	r.b = r.b[1:]
+
+	return v
+}
+
+func (r *carrierReader) next() []byte {
+	l := r.uvarint()
+	if r.err || l > uint64(len(r.b)) {
+		r.err = true
+
+		return nil
+	}
+
+	v := r.b[:l]
+	r.b = r.b[l:]
+
+	return v
+}
+
+func (r *carrierReader) bytes() []byte {
+	v := r.next()
+	if len(v) == 0 {
+		return nil
+	}
+
+	// we copy the data, since Cluster implementations could reuse their buffers.
+	return append([]byte(nil), v...)
+}
+
+func (r *carrierReader) string() string {
+	return string(r.next())
+}
+
+func (r *carrierReader) stringList() []string {
+	l := r.uvarint()
+	if r.err || l == 0 {
+		return nil
+	}
+
+	// each item needs at least one byte, we check it to avoid huge allocations
+	// for corrupted data.
+	if l > uint64(len(r.b)) {
+		r.err = true
+
+		return nil
+	}
+
+	list := make([]string, 0, l)
+	for range l {
+		list = append(list, r.string())
+	}
+
+	return list
+}
+
+func (r *carrierReader) stringMap() map[string]string {
+	l := r.uvarint()
+	if r.err || l == 0 {
+		return nil
+	}
+
+	// each entry needs at least two bytes, we check it to avoid huge allocations
+	// for corrupted data.
+	if l > uint64(len(r.b)/2) {
+		r.err = true
+
+		return nil
+	}
+
+	m := make(map[string]string, l)
+	for range l {
+		k := r.string()
+		m[k] = r.string()
+	}
+
+	return m
+}
@@ -0,0 +1,171 @@
+package kit
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/clubpay/ronykit/kit/utils/buf"
+)
+
+func testFullCarrier() *envelopeCarrier {
+	ec := newEnvelopeCarrier(outgoingCarrier, "sid", "origin", "target")
+	ec.Data = &carrierData{
+		EnvelopeID:  "env",
+		ConnHdr:     map[string]string{"k1": "v1", "k2": ""},
+		IsREST:      true,
+		StatusCode:  201,
+		Hdr:         map[string]string{"h": "v"},
+		MsgType:     "kit.RawMessage",
+		Msg:         []byte(`{"value":"ok"}`),
+		ContractID:  "c1",
+		ServiceName: "svc",
+		Route:       "/route",
+		ConnKeys:    []string{"user:1", "user:2"},
+		Topic:       "news",
+	}
+
+	return ec
+}
+
+func encodeTestCarrier(t *testing.T, cc CarrierCodec, ec *envelopeCarrier) []byte {
+	t.Helper()
+
+	b := buf.GetCap(CodecDefaultBufferSize)
+	defer b.Release()
+
+	if err := cc.EncodeCarrier(ec, b); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	return append([]byte(nil), *b.Bytes()...)
+}
+
+func TestCarrierCodecRoundTrip(t *testing.T) {
+	for name, cc := range map[string]CarrierCodec{
+		"json":    JSONCarrierCodec,
+		"binary":  BinaryCarrierCodec,
+		"adapter": NewJSONCarrierCodec(stdCodec{}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, ec := range []*envelopeCarrier{
+				testFullCarrier(),
+				newEnvelopeCarrier(eofCarrier, "sid", "origin", "target"),
+			} {
+				decoded, err := decodeEnvelopeCarrier(encodeTestCarrier(t, cc, ec))
+				if err != nil {
+					t.Fatalf("decode failed: %v", err)
+				}
+				if !reflect.DeepEqual(decoded, ec) {
+					t.Fatalf("unexpected carrier: %#v, expected: %#v", decoded, ec)
+				}
+			}
+		})
+	}
+}
+
+func TestBinaryCarrierCodecIsCompact(t *testing.T) {
+	ec := testFullCarrier()
+
+	jsonSize := len(encodeTestCarrier(t, JSONCarrierCodec, ec))
+	binarySize := len(encodeTestCarrier(t, BinaryCarrierCodec, ec))
+	if binarySize >= jsonSize {
+		t.Fatalf("expected binary (%d) to be smaller than json (%d)", binarySize, jsonSize)
+	}
+}
+
+func TestDecodeEnvelopeCarrierErrors(t *testing.T) {
+	data := encodeTestCarrier(t, BinaryCarrierCodec, testFullCarrier())
+
+	if _, err := decodeEnvelopeCarrier(nil); !errors.Is(err, ErrInvalidCarrier) {
+		t.Fatalf("expected ErrInvalidCarrier, got: %v", err)
+	}
+	if _, err := decodeEnvelopeCarrier([]byte{0x7f, 1}); !errors.Is(err, ErrUnsupportedCarrierVersion) {
+		t.Fatalf("expected ErrUnsupportedCarrierVersion, got: %v", err)
+	}
+
+	for _, corrupted := range [][]byte{
+		data[:len(data)-1],
+		append(append([]byte(nil), data...), 0),
+		{binaryCarrierV1, 1, 0xff},
+	} {
+		if _, err := decodeEnvelopeCarrier(corrupted); !errors.Is(err, ErrInvalidCarrier) {
+			t.Fatalf("expected ErrInvalidCarrier, got: %v", err)
+		}
+	}
+}
+
+func TestDecodeBinaryCarrierV1(t *testing.T) {
+	ec := testFullCarrier()
+	ec.Data.Topic = ""
+
+	// the v1 format is the v2 format without the trailing topic.
+	data := encodeTestCarrier(t, BinaryCarrierCodec, ec)
+	data[0] = binaryCarrierV1
+	data = data[:len(data)-1]
+
+	decoded, err := decodeEnvelopeCarrier(data)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, ec) {
+		t.Fatalf("unexpected carrier: %#v, expected: %#v", decoded, ec)
+	}
+}
+
+func TestSouthBridgeCarrierCodec(t *testing.T) {
+	for name, cc := range map[string]CarrierCodec{
+		"default": nil,
+		"json":    JSONCarrierCodec,
+		"binary":  BinaryCarrierCodec,
+	} {
+		t.Run(name, func(t *testing.T) {
+			cluster := &testCluster{}
+			sb := &southBridge{
+				ctxPool:    ctxPool{ls: &localStore{kv: map[string]any{}}},
+				wg:         &sync.WaitGroup{},
+				eh:         func(_ *Context, _ error) {},
+				cb:         cluster,
+				cc:         cc,
+				inProgress: map[string]*clusterConn{},
+			}
+
+			ec := testFullCarrier()
+			if err := sb.sendMessage(ec); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(cluster.published) != 1 {
+				t.Fatalf("expected one published carrier, got: %d", len(cluster.published))
+			}
+
+			data := cluster.published[0].data
+			if cc == BinaryCarrierCodec && data[0] != binaryCarrierV2 {
+				t.Fatalf("expected binary carrier, got: %q", data)
+			}
+			if cc != BinaryCarrierCodec && data[0] != '{' {
+				t.Fatalf("expected json carrier, got: %q", data)
+			}
+
+			decoded, err := decodeEnvelopeCarrier(data)
+			if err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, ec) {
+				t.Fatalf("unexpected carrier: %#v", decoded)
+			}
+		})
+	}
+}
+
+func TestNewServerCarrierCodec(t *testing.T) {
+	s := NewServer(WithCluster(&testCluster{}))
+	if s.sb.cc != JSONCarrierCodec {
+		t.Fatalf("expected json carrier codec by default")
+	}
+
+	s = NewServer(WithCluster(&testCluster{}), WithCarrierCodec(BinaryCarrierCodec))
+	if s.sb.cc != BinaryCarrierCodec {
+		t.Fatalf("expected binary carrier codec")
+	}
+}
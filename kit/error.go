@@ -0,0 +1,32 @@
+package kit
+
+import "fmt"
+
+// Error is a simple implementation of ErrorMessage. EdgeServer uses it whenever it needs
+// to reply to the client on behalf of the contract's handlers, for example, when the
+// request deadline is exceeded.
+type Error struct {
+	Code int    `json:"code"`
+	Item string `json:"item"`
+}
+
+var _ ErrorMessage = (*Error)(nil)
+
+func NewError(code int, item string) *Error {
+	return &Error{
+		Code: code,
+		Item: item,
+	}
+}
+
+func (e Error) GetCode() int {
+	return e.Code
+}
+
+func (e Error) GetItem() string {
+	return e.Item
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Item)
+}
@@ -0,0 +1,381 @@
+package kit
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/utils"
+)
+
+// HealthServiceName is the name of the Service which is registered by WithHealth.
+const HealthServiceName = "health"
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+
+	defaultHealthCheckTimeout = 3 * time.Second
+	healthStoreKeyPrefix      = "kit:health:"
+)
+
+var (
+	ErrHealthCheckTimeout  = errors.New("health check timeout")
+	ErrHealthStoreMismatch = errors.New("health check value mismatch in cluster store")
+	ErrHealthNoSubscribers = errors.New("no subscriber in the cluster")
+)
+
+// HealthChecker reports the health of a dependency. The EdgeServer calls CheckHealth
+// for every health or readiness request, hence it must be cheap. A nil error means
+// the dependency is healthy.
+// Gateways and Clusters could also implement this interface to be included in the checks.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// HealthCheckerFunc implements HealthChecker interface.
+type HealthCheckerFunc func(ctx context.Context) error
+
+func (f HealthCheckerFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+// HealthCheckResult is the result of a single HealthChecker.
+type HealthCheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthReport is the output message of the health contracts.
+type HealthReport struct {
+	Status string              `json:"status"`
+	State  string              `json:"state"`
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+type namedHealthChecker struct {
+	name string
+	hc   HealthChecker
+}
+
+type healthConfig struct {
+	timeout    time.Duration
+	checks     []namedHealthChecker
+	livePath   string
+	readyPath  string
+	healthPath string
+	livePred   string
+	readyPred  string
+	healthPred string
+}
+
+type HealthOption func(cfg *healthConfig)
+
+// HealthCheck registers a HealthChecker identified by name in the reports.
+func HealthCheck(name string, hc HealthChecker) HealthOption {
+	return func(cfg *healthConfig) {
+		cfg.checks = append(cfg.checks, namedHealthChecker{name: name, hc: hc})
+	}
+}
+
+// HealthCheckTimeout sets the maximum time that each HealthChecker is allowed to run.
+// Default is 3 seconds.
+func HealthCheckTimeout(d time.Duration) HealthOption {
+	return func(cfg *healthConfig) {
+		cfg.timeout = d
+	}
+}
+
+// HealthPaths sets the REST paths of the liveness, readiness and health contracts.
+// Default is "/livez", "/readyz" and "/healthz". An empty path disables the REST route.
+func HealthPaths(live, ready, health string) HealthOption {
+	return func(cfg *healthConfig) {
+		cfg.livePath = live
+		cfg.readyPath = ready
+		cfg.healthPath = health
+	}
+}
+
+// HealthPredicates sets the RPC predicates of the liveness, readiness and health contracts.
+// Default is "health.live", "health.ready" and "health.check". An empty predicate disables
+// the RPC route.
+func HealthPredicates(live, ready, health string) HealthOption {
+	return func(cfg *healthConfig) {
+		cfg.livePred = live
+		cfg.readyPred = ready
+		cfg.healthPred = health
+	}
+}
+
+// WithHealth registers a Service, named HealthServiceName, with three contracts:
+//
+//   - live: reports 'up' until the EdgeServer is stopped. It does not run any check.
+//   - ready: reports 'up' if the EdgeServer is in ServerStateReady and all the checks pass.
+//   - check: runs all the checks and reports the result of each one.
+//
+// The checks are the HealthCheckers registered by HealthCheck, the gateways and the cluster
+// which implement HealthChecker, the reachability of the cluster (Cluster.Subscribers)
+// and a round trip to the ClusterStore if the cluster has one.
+// REST clients get 503 status code if the report is 'down'.
+func WithHealth(opts ...HealthOption) Option {
+	return func(s *edgeConfig) {
+		cfg := &healthConfig{
+			timeout:    defaultHealthCheckTimeout,
+			livePath:   "/livez",
+			readyPath:  "/readyz",
+			healthPath: "/healthz",
+			livePred:   "health.live",
+			readyPred:  "health.ready",
+			healthPred: "health.check",
+		}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+
+		s.health = cfg
+	}
+}
+
+type healthService struct {
+	s   *EdgeServer
+	cfg *healthConfig
+}
+
+var _ Service = (*healthService)(nil)
+
+func (hs *healthService) Name() string {
+	return HealthServiceName
+}
+
+func (hs *healthService) Contracts() []Contract {
+	return []Contract{
+		&healthContract{
+			id:  "live",
+			sel: healthSelector{path: hs.cfg.livePath, predicate: hs.cfg.livePred},
+			h:   hs.live,
+		},
+		&healthContract{
+			id:  "ready",
+			sel: healthSelector{path: hs.cfg.readyPath, predicate: hs.cfg.readyPred},
+			h:   hs.ready,
+		},
+		&healthContract{
+			id:  "check",
+			sel: healthSelector{path: hs.cfg.healthPath, predicate: hs.cfg.healthPred},
+			h:   hs.check,
+		},
+	}
+}
+
+func (hs *healthService) live(ctx *Context) {
+	report := &HealthReport{
+		Status: HealthStatusUp,
+		State:  hs.s.State().String(),
+	}
+	if hs.s.State() == ServerStateStopped {
+		report.Status = HealthStatusDown
+	}
+
+	hs.send(ctx, report)
+}
+
+func (hs *healthService) ready(ctx *Context) {
+	report := hs.s.CheckHealth(ctx.Context())
+	if hs.s.State() != ServerStateReady {
+		report.Status = HealthStatusDown
+	}
+
+	hs.send(ctx, report)
+}
+
+func (hs *healthService) check(ctx *Context) {
+	hs.send(ctx, hs.s.CheckHealth(ctx.Context()))
+}
+
+func (hs *healthService) send(ctx *Context, report *HealthReport) {
+	if report.Status != HealthStatusUp {
+		ctx.SetStatusCode(http.StatusServiceUnavailable)
+	}
+
+	ctx.Out().SetMsg(report).Send()
+}
+
+// checkers returns all the HealthCheckers of the EdgeServer, including the user-registered ones.
+func (hs *healthService) checkers() []namedHealthChecker {
+	checks := make([]namedHealthChecker, 0, len(hs.cfg.checks)+len(hs.s.nb)+3)
+
+	for _, nb := range hs.s.nb {
+		if hc, ok := nb.gw.(HealthChecker); ok {
+			checks = append(checks, namedHealthChecker{name: nb.name, hc: hc})
+		}
+	}
+
+	if hs.s.sb != nil {
+		checks = append(checks, namedHealthChecker{name: "cluster", hc: HealthCheckerFunc(hs.checkCluster)})
+		if hc, ok := hs.s.sb.cb.(HealthChecker); ok {
+			checks = append(checks, namedHealthChecker{name: "cluster.backend", hc: hc})
+		}
+		if hs.s.cr.store != nil {
+			checks = append(checks, namedHealthChecker{name: "cluster.store", hc: HealthCheckerFunc(hs.checkStore)})
+		}
+	}
+
+	return append(checks, hs.cfg.checks...)
+}
+
+func (hs *healthService) checkCluster(_ context.Context) error {
+	members, err := hs.s.sb.cb.Subscribers()
+	if err != nil {
+		return err
+	}
+
+	if len(members) == 0 {
+		return ErrHealthNoSubscribers
+	}
+
+	return nil
+}
+
+func (hs *healthService) checkStore(ctx context.Context) error {
+	key := healthStoreKeyPrefix + hs.s.sb.id
+	val := utils.RandomID(8)
+
+	err := hs.s.cr.store.Set(ctx, key, val, hs.cfg.timeout)
+	if err != nil {
+		return err
+	}
+
+	got, err := hs.s.cr.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if got != val {
+		return ErrHealthStoreMismatch
+	}
+
+	return hs.s.cr.store.Delete(ctx, key)
+}
+
+func (hs *healthService) run(ctx context.Context, nhc namedHealthChecker) HealthCheckResult {
+	ctx, cf := context.WithTimeout(ctx, hs.cfg.timeout)
+	defer cf()
+
+	startTime := utils.NanoTime()
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- newPanicError(r)
+			}
+		}()
+
+		errCh <- nhc.hc.CheckHealth(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ErrHealthCheckTimeout
+	}
+
+	res := HealthCheckResult{
+		Name:     nhc.name,
+		Status:   HealthStatusUp,
+		Duration: time.Duration(utils.NanoTime() - startTime).String(),
+	}
+	if err != nil {
+		res.Status = HealthStatusDown
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// CheckHealth runs all the health checks concurrently, and returns the report. The report's
+// status is 'up' if all the checks pass.
+// If WithHealth option is not set, the report only contains the state of the EdgeServer.
+func (s *EdgeServer) CheckHealth(ctx context.Context) *HealthReport {
+	report := &HealthReport{
+		Status: HealthStatusUp,
+		State:  s.State().String(),
+	}
+
+	if s.hs == nil {
+		return report
+	}
+
+	checks := s.hs.checkers()
+	report.Checks = make([]HealthCheckResult, len(checks))
+
+	wg := sync.WaitGroup{}
+	for idx := range checks {
+		wg.Add(1)
+
+		go func(idx int) {
+			defer wg.Done()
+
+			report.Checks[idx] = s.hs.run(ctx, checks[idx])
+		}(idx)
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Status != HealthStatusUp {
+			report.Status = HealthStatusDown
+
+			break
+		}
+	}
+
+	return report
+}
+
+type healthContract struct {
+	id  string
+	sel RouteSelector
+	h   HandlerFunc
+}
+
+var _ Contract = (*healthContract)(nil)
+
+func (c *healthContract) ID() string                     { return c.id }
+func (c *healthContract) RouteSelector() RouteSelector   { return c.sel }
+func (c *healthContract) EdgeSelector() EdgeSelectorFunc { return nil }
+func (c *healthContract) Encoding() Encoding             { return JSON }
+func (c *healthContract) Input() Message                 { return RawMessage{} }
+func (c *healthContract) Output() Message                { return &HealthReport{} }
+func (c *healthContract) Handlers() []HandlerFunc        { return []HandlerFunc{c.h} }
+func (c *healthContract) Modifiers() []ModifierFunc      { return nil }
+
+// healthSelector is a gateway-agnostic selector. REST gateways register it by its
+// method and path, and RPC gateways by its predicate.
+type healthSelector struct {
+	path      string
+	predicate string
+}
+
+var (
+	_ RESTRouteSelector = healthSelector{}
+	_ RPCRouteSelector  = healthSelector{}
+)
+
+func (r healthSelector) Query(string) any      { return nil }
+func (r healthSelector) GetEncoding() Encoding { return JSON }
+func (r healthSelector) GetPredicate() string  { return r.predicate }
+func (r healthSelector) GetPath() string       { return r.path }
+func (r healthSelector) String() string        { return r.path }
+
+func (r healthSelector) GetMethod() string {
+	if r.path == "" {
+		return ""
+	}
+
+	return http.MethodGet
+}
@@ -0,0 +1,159 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type healthGateway struct {
+	testGateway
+
+	err error
+}
+
+func (g *healthGateway) CheckHealth(_ context.Context) error {
+	return g.err
+}
+
+func execHealth(t *testing.T, gw *healthGateway, contractID string) (*HealthReport, int) {
+	t.Helper()
+
+	gw.dispatchFn = func(_ *Context, _ []byte) (ExecuteArg, error) {
+		return ExecuteArg{ServiceName: HealthServiceName, ContractID: contractID}, nil
+	}
+
+	conn := newTestRESTConn()
+	gw.delegate.OnMessage(conn, []byte("{}"))
+
+	if len(conn.out) != 1 {
+		t.Fatalf("expected one envelope, got: %d", len(conn.out))
+	}
+
+	report, ok := conn.out[0].GetMsg().(*HealthReport)
+	if !ok {
+		t.Fatalf("unexpected message: %#v", conn.out[0].GetMsg())
+	}
+
+	return report, conn.statusCode
+}
+
+func TestHealthService(t *testing.T) {
+	gw := &healthGateway{}
+	userErr := errors.New("db is down")
+	dbDown := false
+
+	store := &memStore{kv: map[string]string{}}
+	s := NewServer(
+		WithGateway(gw),
+		WithCluster(memClusterWithStore{memCluster: newMemCluster(), store: store}),
+		WithHealth(
+			HealthCheck("db", HealthCheckerFunc(func(_ context.Context) error {
+				if dbDown {
+					return userErr
+				}
+
+				return nil
+			})),
+			HealthCheck("slow", HealthCheckerFunc(func(ctx context.Context) error {
+				<-ctx.Done()
+
+				return nil
+			})),
+			HealthCheckTimeout(20*time.Millisecond),
+		),
+	)
+
+	report := s.CheckHealth(t.Context())
+	if report.Status != HealthStatusDown || report.State != ServerStateStarting.String() {
+		t.Fatalf("unexpected report: %#v", report)
+	}
+
+	names := map[string]HealthCheckResult{}
+	for _, res := range report.Checks {
+		names[res.Name] = res
+	}
+	for _, name := range []string{"gateway.0", "cluster", "cluster.store", "db", "slow"} {
+		if _, ok := names[name]; !ok {
+			t.Fatalf("expected %s check, got: %#v", name, report.Checks)
+		}
+	}
+	if names["slow"].Error != ErrHealthCheckTimeout.Error() {
+		t.Fatalf("expected timeout, got: %#v", names["slow"])
+	}
+	if names["db"].Status != HealthStatusUp || names["cluster.store"].Status != HealthStatusUp {
+		t.Fatalf("unexpected report: %#v", report.Checks)
+	}
+	if len(store.kv) != 0 {
+		t.Fatalf("expected the store check to clean up, got: %v", store.kv)
+	}
+
+	dbDown = true
+	gw.err = errors.New("not listening")
+
+	for _, res := range s.CheckHealth(t.Context()).Checks {
+		if (res.Name == "db" || res.Name == "gateway.0") && res.Status != HealthStatusDown {
+			t.Fatalf("expected %s to be down: %#v", res.Name, res)
+		}
+	}
+}
+
+func TestHealthContracts(t *testing.T) {
+	gw := &healthGateway{}
+	s := NewServer(WithGateway(gw), WithHealth(HealthPredicates("", "ready", "")))
+
+	s.Start(t.Context())
+
+	for _, reg := range gw.regs {
+		if reg.svc != HealthServiceName {
+			t.Fatalf("unexpected service: %s", reg.svc)
+		}
+	}
+	if len(gw.regs) != 3 {
+		t.Fatalf("expected 3 contracts, got: %d", len(gw.regs))
+	}
+	if sel := gw.regs[1].sel.(healthSelector); sel.GetMethod() != http.MethodGet || //nolint:forcetypeassert
+		sel.GetPath() != "/readyz" || sel.GetPredicate() != "ready" {
+		t.Fatalf("unexpected selector: %#v", sel)
+	}
+	if sel := gw.regs[0].sel.(healthSelector); sel.GetPredicate() != "" { //nolint:forcetypeassert
+		t.Fatalf("expected no predicate: %#v", sel)
+	}
+
+	for _, contractID := range []string{"live", "ready", "check"} {
+		report, code := execHealth(t, gw, contractID)
+		if report.Status != HealthStatusUp || report.State != ServerStateReady.String() || code != 0 {
+			t.Fatalf("unexpected %s report: %#v", contractID, report)
+		}
+	}
+
+	gw.err = errors.New("not listening")
+
+	if report, _ := execHealth(t, gw, "live"); report.Status != HealthStatusUp {
+		t.Fatalf("liveness must not run the checks: %#v", report)
+	}
+	if report, code := execHealth(t, gw, "ready"); report.Status != HealthStatusDown ||
+		code != http.StatusServiceUnavailable {
+		t.Fatalf("expected not ready: %#v, %d", report, code)
+	}
+
+	gw.err = nil
+	s.st.set(ServerStateDraining)
+
+	report, _ := execHealth(t, gw, "ready")
+	if report.Status != HealthStatusDown || report.State != ServerStateDraining.String() {
+		t.Fatalf("expected not ready while draining: %#v", report)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if !strings.HasPrefix(string(data), `{"status":"down","state":"draining","checks":[{"name":"gateway.0","status":"up",`) {
+		t.Fatalf("unexpected json: %s", data)
+	}
+}
@@ -0,0 +1,431 @@
+package kit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/utils"
+
+	"github.com/goccy/go-reflect"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	defaultIdempotencyTTL     = 24 * time.Hour
+	idempotencyStoreKeyPrefix = "kit:idem:"
+)
+
+var (
+	ErrIdempotencyKeyMissing = errors.New("idempotency key is missing")
+	ErrIdempotencyKeyReused  = errors.New("idempotency key is reused with a different request")
+)
+
+type idempotencyConfig struct {
+	header   string
+	ttl      time.Duration
+	required bool
+	local    bool
+}
+
+type IdempotencyOption func(cfg *idempotencyConfig)
+
+// IdempotencyTTL sets how long the response of a request is kept for replay.
+// Default is 24 hours.
+func IdempotencyTTL(d time.Duration) IdempotencyOption {
+	return func(cfg *idempotencyConfig) {
+		cfg.ttl = d
+	}
+}
+
+// IdempotencyHeader sets the name of the header which carries the idempotency key.
+// Default is HeaderIdempotencyKey.
+func IdempotencyHeader(name string) IdempotencyOption {
+	return func(cfg *idempotencyConfig) {
+		cfg.header = name
+	}
+}
+
+// IdempotencyRequired rejects the requests without the idempotency key with 400 status code.
+// By default, these requests are executed normally.
+func IdempotencyRequired() IdempotencyOption {
+	return func(cfg *idempotencyConfig) {
+		cfg.required = true
+	}
+}
+
+// IdempotencyLocal keeps the responses in the LocalStore, even if the Cluster has a
+// ClusterStore.
+func IdempotencyLocal() IdempotencyOption {
+	return func(cfg *idempotencyConfig) {
+		cfg.local = true
+	}
+}
+
+func newIdempotencyConfig(opts ...IdempotencyOption) idempotencyConfig {
+	cfg := idempotencyConfig{
+		header: HeaderIdempotencyKey,
+		ttl:    defaultIdempotencyTTL,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
+
+// IdempotencyKeyHeader returns the name of the header which carries the idempotency key,
+// when Idempotent is called with the same options.
+func IdempotencyKeyHeader(opts ...IdempotencyOption) string {
+	return newIdempotencyConfig(opts...).header
+}
+
+// Idempotent returns a ContractWrapper which makes the unary contracts idempotent. It reads
+// the idempotency key from the incoming envelope's header (or the connection's, e.g., HTTP headers),
+// and stores the response (status code, headers, and the encoded message with its encoding) in
+// the ClusterStore, or the LocalStore if the Cluster has no store. The requests with the same key
+// get the stored response, with the HeaderIdempotentReplayed header set, without executing the
+// main handler of the contract. The other handlers, e.g., authentication, still run for them;
+// check InnerHandlers.
+//
+// The concurrent requests with the same key are serialized in each instance: only one of them
+// executes the handlers, and the others get its response. The responses with 5xx status codes
+// are not stored, so the clients could retry them. If the key is reused with a different request,
+// i.e., a different body or path, the client gets 422 status code.
+func Idempotent(opts ...IdempotencyOption) ContractWrapper {
+	ic := &idempotency{
+		cfg:      newIdempotencyConfig(opts...),
+		inFlight: map[string]utils.SingleFlightCall[*idempotencyRecord]{},
+	}
+
+	return ContractWrapperFunc(func(c Contract) Contract {
+		ctr := &idempotentContract{
+			Contract: c,
+			ic:       ic,
+			msgFactories: map[string]MessageFactoryFunc{
+				reflect.TypeOf(&Error{}).String(): CreateMessageFactory(&Error{}),
+			},
+		}
+
+		if out := c.Output(); out != nil {
+			ctr.msgFactories[reflect.TypeOf(out).String()] = CreateMessageFactory(out)
+		}
+
+		return ctr
+	})
+}
+
+type idempotentContract struct {
+	Contract
+
+	ic *idempotency
+	// msgFactories creates the messages of the replayed envelopes by their types.
+	msgFactories map[string]MessageFactoryFunc
+}
+
+func (c *idempotentContract) Handlers() []HandlerFunc {
+	return InnerHandlers(c.Contract, c.handler)
+}
+
+func (c *idempotentContract) handler(ctx *Context) {
+	c.ic.handler(ctx, c)
+}
+
+type idempotency struct {
+	cfg idempotencyConfig
+
+	mtx       sync.Mutex
+	inFlight  map[string]utils.SingleFlightCall[*idempotencyRecord]
+	lastSweep time.Time
+}
+
+type idempotencyRecord struct {
+	Fingerprint string                `json:"fp"`
+	StatusCode  int                   `json:"status"`
+	Envelopes   []idempotencyEnvelope `json:"envelopes"`
+	ExpiresAt   int64                 `json:"-"`
+}
+
+type idempotencyEnvelope struct {
+	Hdr     map[string]string `json:"hdr,omitempty"`
+	MsgType string            `json:"type,omitempty"`
+	Enc     string            `json:"enc,omitempty"`
+	Msg     []byte            `json:"msg,omitempty"`
+}
+
+func (ic *idempotency) handler(ctx *Context, c *idempotentContract) {
+	key := ctx.In().GetHdr(ic.cfg.header)
+	if key == "" {
+		key = ctx.Conn().Get(ic.cfg.header)
+	}
+
+	if key == "" {
+		if ic.cfg.required {
+			ic.reject(ctx, http.StatusBadRequest, "IDEMPOTENCY_KEY_MISSING", ErrIdempotencyKeyMissing)
+		}
+
+		return
+	}
+
+	key = idempotencyStoreKeyPrefix + ctx.ServiceName() + "." + ctx.ContractID() + ":" + key
+	fp := ic.fingerprint(ctx)
+
+	if rec := ic.load(ctx, key); rec != nil {
+		ic.replay(ctx, c, fp, rec)
+
+		return
+	}
+
+	leader := false
+
+	rec, err := ic.singleFlight(key)(
+		func() (*idempotencyRecord, error) {
+			leader = true
+			defer ic.done(key)
+
+			// the other instance of the same request might have finished meanwhile
+			if rec := ic.load(ctx, key); rec != nil {
+				leader = false
+
+				return rec, nil
+			}
+
+			rec := ic.execute(ctx, c, fp)
+			if rec != nil {
+				ic.save(ctx, key, rec)
+			}
+
+			return rec, nil
+		},
+	)
+	if leader {
+		return
+	}
+
+	if err != nil || rec == nil {
+		// the leader has not stored a response, hence we execute this one normally.
+		return
+	}
+
+	ic.replay(ctx, c, fp, rec)
+}
+
+func (ic *idempotency) singleFlight(key string) utils.SingleFlightCall[*idempotencyRecord] {
+	ic.mtx.Lock()
+	defer ic.mtx.Unlock()
+
+	sf, ok := ic.inFlight[key]
+	if !ok {
+		sf = utils.SingleFlight[*idempotencyRecord]()
+		ic.inFlight[key] = sf
+	}
+
+	return sf
+}
+
+func (ic *idempotency) done(key string) {
+	ic.mtx.Lock()
+	delete(ic.inFlight, key)
+	ic.mtx.Unlock()
+}
+
+// execute runs the rest of the handlers, and captures the envelopes which are sent to the
+// connection. It returns nil if the response must not be stored.
+func (ic *idempotency) execute(ctx *Context, c *idempotentContract, fp string) *idempotencyRecord {
+	rec := &idempotencyRecord{
+		Fingerprint: fp,
+	}
+
+	var captureErr error
+
+	// The capture modifier must run after all the other modifiers, hence we put it
+	// first, since modifiers run in LIFO order.
+	ctx.sendMtx.Lock()
+	ctx.modifiers = append(
+		[]ModifierFunc{
+			func(e *Envelope) {
+				if e.conn != ctx.conn {
+					return
+				}
+
+				msg, enc, err := MarshalMessageAs(c.Encoding(), e.GetMsg())
+				if err != nil {
+					captureErr = err
+
+					return
+				}
+
+				ie := idempotencyEnvelope{
+					Hdr:     make(map[string]string, len(e.kv)),
+					MsgType: reflect.TypeOf(e.GetMsg()).String(),
+					Enc:     enc.Tag(),
+					Msg:     append([]byte(nil), msg...),
+				}
+				for k, v := range e.kv {
+					ie.Hdr[k] = v
+				}
+
+				rec.Envelopes = append(rec.Envelopes, ie)
+			},
+		},
+		ctx.modifiers...,
+	)
+	ctx.sendMtx.Unlock()
+
+	ctx.Next()
+
+	rec.StatusCode = ctx.GetStatusCode()
+	if captureErr != nil || len(rec.Envelopes) == 0 || rec.StatusCode >= http.StatusInternalServerError {
+		return nil
+	}
+
+	return rec
+}
+
+func (ic *idempotency) replay(ctx *Context, c *idempotentContract, fp string, rec *idempotencyRecord) {
+	if rec.Fingerprint != fp {
+		ic.reject(ctx, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", ErrIdempotencyKeyReused)
+
+		return
+	}
+
+	if rec.StatusCode != 0 {
+		ctx.SetStatusCode(rec.StatusCode)
+	}
+
+	for _, ie := range rec.Envelopes {
+		ctx.Out().
+			SetHdrMap(ie.Hdr).
+			SetHdr(HeaderIdempotentReplayed, "true").
+			SetMsg(c.message(ie)).
+			Send()
+	}
+
+	ctx.StopExecution()
+}
+
+// message decodes the stored message into its type, so the gateway encodes it as it does for
+// the original response. The messages of the unknown types are sent as they are stored.
+func (c *idempotentContract) message(ie idempotencyEnvelope) Message {
+	f, ok := c.msgFactories[ie.MsgType]
+	if !ok {
+		return RawMessage(ie.Msg)
+	}
+
+	msg := f()
+	if _, ok = msg.(RawMessage); ok {
+		return RawMessage(ie.Msg)
+	}
+
+	if err := UnmarshalMessageAs(CustomEncoding(ie.Enc), ie.Msg, msg); err != nil {
+		return RawMessage(ie.Msg)
+	}
+
+	return msg
+}
+
+func (ic *idempotency) reject(ctx *Context, code int, item string, err error) {
+	ctx.Error(err)
+	ctx.SetStatusCode(code)
+	ctx.Out().SetMsg(NewError(code, item)).Send()
+	ctx.StopExecution()
+}
+
+// fingerprint identifies the request by its route, body, and for REST, its path and query,
+// which carry the path parameters.
+func (ic *idempotency) fingerprint(ctx *Context) string {
+	h := sha256.New()
+	_, _ = h.Write(utils.S2B(ctx.Route()))
+	_, _ = h.Write([]byte{0})
+
+	if rc, ok := ctx.Conn().(RESTConn); ok {
+		_, _ = h.Write(utils.S2B(rc.GetRequestURI()))
+		_, _ = h.Write([]byte{0})
+	}
+
+	_, _ = h.Write(ctx.InputRawData())
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (ic *idempotency) clusterStore(ctx *Context) ClusterStore {
+	if ic.cfg.local || !ctx.HasCluster() {
+		return nil
+	}
+
+	return ctx.ClusterStore()
+}
+
+func (ic *idempotency) load(ctx *Context, key string) *idempotencyRecord {
+	if cs := ic.clusterStore(ctx); cs != nil {
+		v, err := cs.Get(ctx.Context(), key)
+		if err != nil || v == "" {
+			return nil
+		}
+
+		rec := &idempotencyRecord{}
+		if err = UnmarshalMessage(utils.S2B(v), rec); err != nil {
+			return nil
+		}
+
+		return rec
+	}
+
+	rec, ok := ctx.LocalStore().Get(key).(*idempotencyRecord)
+	if !ok || rec.ExpiresAt < utils.TimeUnix() {
+		return nil
+	}
+
+	return rec
+}
+
+func (ic *idempotency) save(ctx *Context, key string, rec *idempotencyRecord) {
+	if cs := ic.clusterStore(ctx); cs != nil {
+		data, err := MarshalMessage(rec)
+		if err != nil {
+			ctx.Error(err)
+
+			return
+		}
+
+		ctx.Error(cs.Set(ctx.Context(), key, string(data), ic.cfg.ttl))
+
+		return
+	}
+
+	rec.ExpiresAt = utils.TimeUnix() + int64(ic.cfg.ttl/time.Second)
+	ctx.LocalStore().Set(key, rec)
+	ic.sweep(ctx.LocalStore())
+}
+
+// sweep removes the expired records from the LocalStore, at most once per TTL.
+func (ic *idempotency) sweep(s Store) {
+	ic.mtx.Lock()
+	if time.Since(ic.lastSweep) < ic.cfg.ttl {
+		ic.mtx.Unlock()
+
+		return
+	}
+	ic.lastSweep = time.Now()
+	ic.mtx.Unlock()
+
+	now := utils.TimeUnix()
+
+	var keys []string
+
+	s.Scan(idempotencyStoreKeyPrefix, func(key string) bool {
+		keys = append(keys, key)
+
+		return false
+	})
+
+	for _, key := range keys {
+		if rec, ok := s.Get(key).(*idempotencyRecord); ok && rec.ExpiresAt < now {
+			s.Delete(key)
+		}
+	}
+}
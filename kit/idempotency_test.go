@@ -0,0 +1,255 @@
+package kit
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type idemIn struct {
+	N int `json:"n"`
+}
+
+func newIdempotentServer(
+	t *testing.T, cluster Cluster, h HandlerFunc, opts ...IdempotencyOption,
+) *testGateway {
+	t.Helper()
+
+	return startIdempotentServer(
+		t, cluster,
+		&testContract{
+			id:       "pay",
+			input:    RawMessage{},
+			output:   RawMessage{},
+			handlers: []HandlerFunc{h},
+		},
+		opts...,
+	)
+}
+
+func startIdempotentServer(t *testing.T, cluster Cluster, c *testContract, opts ...IdempotencyOption) *testGateway {
+	t.Helper()
+
+	gw := &testGateway{
+		dispatchFn: func(ctx *Context, in []byte) (ExecuteArg, error) {
+			ctx.In().SetMsg(RawMessage(in))
+
+			return ExecuteArg{ServiceName: "svc", ContractID: "pay", Route: "POST /pay"}, nil
+		},
+	}
+
+	serverOpts := []Option{
+		WithGateway(gw),
+		WithService(testService{
+			name: "svc",
+			contracts: []Contract{
+				WrapContract(c, Idempotent(opts...)),
+			},
+		}),
+	}
+	if cluster != nil {
+		serverOpts = append(serverOpts, WithCluster(cluster))
+	}
+
+	NewServer(serverOpts...).Start(t.Context())
+
+	return gw
+}
+
+func sendIdempotent(gw *testGateway, key string, body string) *testRESTConn {
+	conn := newTestRESTConn()
+	conn.kv = map[string]string{}
+	if key != "" {
+		conn.kv[HeaderIdempotencyKey] = key
+	}
+
+	gw.delegate.OnMessage(conn, []byte(body))
+
+	return conn
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	for name, cluster := range map[string]Cluster{
+		"local": nil,
+		"cluster": memClusterWithStore{
+			memCluster: newMemCluster(),
+			store:      &memStore{kv: map[string]string{}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+
+			gw := newIdempotentServer(t, cluster, func(ctx *Context) {
+				calls.Add(1)
+				ctx.SetStatusCode(http.StatusCreated)
+				ctx.Out().SetHdr("X-Payment", "p1").SetMsg(&idemIn{N: int(calls.Load())}).Send()
+			})
+
+			first := sendIdempotent(gw, "k1", `{"amount":1}`)
+			second := sendIdempotent(gw, "k1", `{"amount":1}`)
+
+			if calls.Load() != 1 {
+				t.Fatalf("expected one execution, got: %d", calls.Load())
+			}
+			if len(first.out) != 1 || len(second.out) != 1 {
+				t.Fatalf("unexpected envelopes: %d, %d", len(first.out), len(second.out))
+			}
+			if second.statusCode != http.StatusCreated {
+				t.Fatalf("unexpected status code: %d", second.statusCode)
+			}
+			if second.out[0].GetHdr(HeaderIdempotentReplayed) != "true" || second.out[0].GetHdr("X-Payment") != "p1" {
+				t.Fatalf("unexpected headers: %v", second.out[0].kv)
+			}
+			if string(second.out[0].GetMsg().(RawMessage)) != `{"n":1}` { //nolint:forcetypeassert
+				t.Fatalf("unexpected message: %s", second.out[0].GetMsg())
+			}
+
+			// the same key with a different body is rejected
+			reused := sendIdempotent(gw, "k1", `{"amount":2}`)
+			if reused.statusCode != http.StatusUnprocessableEntity {
+				t.Fatalf("unexpected status code: %d", reused.statusCode)
+			}
+
+			// the requests without key and with other keys are executed
+			sendIdempotent(gw, "", `{"amount":1}`)
+			sendIdempotent(gw, "k2", `{"amount":1}`)
+
+			if calls.Load() != 3 {
+				t.Fatalf("expected three executions, got: %d", calls.Load())
+			}
+		})
+	}
+}
+
+func TestIdempotentReplayAfterAuth(t *testing.T) {
+	var auths, calls atomic.Int32
+
+	gw := startIdempotentServer(
+		t, nil,
+		&testContract{
+			id:     "pay",
+			input:  RawMessage{},
+			output: &idemIn{},
+			handlers: []HandlerFunc{
+				func(ctx *Context) {
+					auths.Add(1)
+
+					if ctx.Conn().Get("Authorization") != "ok" {
+						ctx.SetStatusCode(http.StatusUnauthorized)
+						ctx.Out().SetMsg(NewError(http.StatusUnauthorized, "UNAUTHORIZED")).Send()
+						ctx.StopExecution()
+					}
+				},
+				func(ctx *Context) {
+					calls.Add(1)
+					ctx.Out().SetMsg(&idemIn{N: int(calls.Load())}).Send()
+				},
+			},
+		},
+	)
+
+	send := func(uri, auth string) *testRESTConn {
+		conn := newTestRESTConn()
+		conn.requestURI = uri
+		conn.kv = map[string]string{HeaderIdempotencyKey: "k1", "Authorization": auth}
+		gw.delegate.OnMessage(conn, []byte(`{"amount":1}`))
+
+		return conn
+	}
+
+	send("/pay/1", "ok")
+
+	// the replay is typed, so the gateway encodes it as the original response
+	replayed := send("/pay/1", "ok")
+	if msg, ok := replayed.out[0].GetMsg().(*idemIn); !ok || msg.N != 1 {
+		t.Fatalf("unexpected message: %#v", replayed.out[0].GetMsg())
+	}
+
+	// the replay is not sent to the unauthorized clients
+	if conn := send("/pay/1", ""); conn.statusCode != http.StatusUnauthorized || len(conn.out) != 1 {
+		t.Fatalf("unexpected response: %d, %d", conn.statusCode, len(conn.out))
+	}
+
+	// the same key with a different path parameter is rejected
+	if conn := send("/pay/2", "ok"); conn.statusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("unexpected status code: %d", conn.statusCode)
+	}
+
+	if auths.Load() != 4 || calls.Load() != 1 {
+		t.Fatalf("unexpected executions: %d auths, %d calls", auths.Load(), calls.Load())
+	}
+}
+
+func TestIdempotentConcurrentDuplicates(t *testing.T) {
+	var calls atomic.Int32
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	gw := newIdempotentServer(t, nil, func(ctx *Context) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+
+		<-release
+		ctx.Out().SetMsg(&idemIn{N: 1}).Send()
+	})
+
+	conns := make([]*testRESTConn, 5)
+	wg := sync.WaitGroup{}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		conns[0] = sendIdempotent(gw, "k", "{}")
+	}()
+
+	<-started
+
+	for i := 1; i < len(conns); i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			conns[i] = sendIdempotent(gw, "k", "{}")
+		}(i)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected one execution, got: %d", calls.Load())
+	}
+
+	for _, conn := range conns {
+		if len(conn.out) != 1 {
+			t.Fatalf("expected one envelope, got: %d", len(conn.out))
+		}
+	}
+}
+
+func TestIdempotentSkipsServerErrors(t *testing.T) {
+	var calls atomic.Int32
+
+	gw := newIdempotentServer(t, nil, func(ctx *Context) {
+		calls.Add(1)
+		ctx.SetStatusCode(http.StatusServiceUnavailable)
+		ctx.Out().SetMsg(NewError(http.StatusServiceUnavailable, "UNAVAILABLE")).Send()
+	}, IdempotencyRequired())
+
+	sendIdempotent(gw, "k", "{}")
+	sendIdempotent(gw, "k", "{}")
+
+	if calls.Load() != 2 {
+		t.Fatalf("expected the failed requests to be executed again, got: %d", calls.Load())
+	}
+
+	missing := sendIdempotent(gw, "", "{}")
+	if missing.statusCode != http.StatusBadRequest || calls.Load() != 2 {
+		t.Fatalf("expected the request without key to be rejected, got: %d", missing.statusCode)
+	}
+}
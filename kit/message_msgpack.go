@@ -0,0 +1,76 @@
+package kit
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// msgpackCodec is the codec of the MSG encoding. The fields of the structs are named by
+// their msgpack tags, or their json tags if they have no msgpack tag, so the messages have
+// the same shape in JSON and MessagePack.
+type msgpackCodec struct{}
+
+func (c msgpackCodec) Marshal(m any) ([]byte, error) {
+	var b bytes.Buffer
+
+	err := c.encode(m, &b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func (c msgpackCodec) Unmarshal(data []byte, m any) error {
+	if v, ok := m.(*RawMessage); ok {
+		return v.Unmarshal(data)
+	}
+
+	return c.decode(m, bytes.NewReader(data))
+}
+
+func (c msgpackCodec) Encode(m Message, w io.Writer) error {
+	return c.encode(m, w)
+}
+
+func (c msgpackCodec) Decode(m Message, r io.Reader) error {
+	if v, ok := m.(*RawMessage); ok {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		return v.Unmarshal(data)
+	}
+
+	return c.decode(m, r)
+}
+
+func (msgpackCodec) encode(m any, w io.Writer) error {
+	// the raw messages are already encoded
+	if v, ok := m.(RawMessage); ok {
+		_, err := w.Write(v)
+
+		return err
+	}
+
+	enc := msgpack.GetEncoder()
+	enc.Reset(w)
+	enc.SetCustomStructTag(JSON.tag)
+	err := enc.Encode(m)
+	msgpack.PutEncoder(enc)
+
+	return err
+}
+
+func (msgpackCodec) decode(m any, r io.Reader) error {
+	dec := msgpack.GetDecoder()
+	dec.Reset(r)
+	dec.SetCustomStructTag(JSON.tag)
+	err := dec.Decode(m)
+	msgpack.PutDecoder(dec)
+
+	return err
+}
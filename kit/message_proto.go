@@ -0,0 +1,67 @@
+package kit
+
+import (
+	"io"
+
+	"github.com/clubpay/ronykit/kit/errors"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// protoCodec is the codec of the Proto encoding. It supports proto.Message, and the
+// messages which implement ProtoMarshaler and ProtoUnmarshaler.
+type protoCodec struct{}
+
+// IsProtoMessage reports whether m could be encoded by the Proto encoding.
+func IsProtoMessage(m Message) bool {
+	switch m.(type) {
+	case proto.Message, ProtoMarshaler:
+		return true
+	default:
+		return false
+	}
+}
+
+func (protoCodec) Marshal(m any) ([]byte, error) {
+	switch v := m.(type) {
+	case proto.Message:
+		return proto.Marshal(v)
+	case ProtoMarshaler:
+		return v.MarshalProto()
+	default:
+		return nil, errors.Wrap(ErrUnsupportedMessage, errors.New("type: %T", m))
+	}
+}
+
+func (protoCodec) Unmarshal(data []byte, m any) error {
+	switch v := m.(type) {
+	case proto.Message:
+		return proto.Unmarshal(data, v)
+	case ProtoUnmarshaler:
+		return v.UnmarshalProto(data)
+	case *RawMessage:
+		return v.Unmarshal(data)
+	default:
+		return errors.Wrap(ErrUnsupportedMessage, errors.New("type: %T", m))
+	}
+}
+
+func (c protoCodec) Encode(m Message, w io.Writer) error {
+	data, err := c.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+
+	return err
+}
+
+func (c protoCodec) Decode(m Message, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return c.Unmarshal(data, m)
+}
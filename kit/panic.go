@@ -0,0 +1,63 @@
+package kit
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/internal/stacktrace"
+)
+
+// PanicError holds the value recovered from a panic in the handlers or modifiers, and
+// the stack trace of the goroutine at the time of the panic. The error passed to the
+// ErrHandlerFunc wraps ErrHandlerPanic, and you can use errors.As to access PanicError.
+type PanicError struct {
+	Value any
+	Stack string
+}
+
+func newPanicError(v any) error {
+	return errors.Wrap(
+		ErrHandlerPanic,
+		&PanicError{
+			Value: v,
+			// skip newPanicError and the deferred function which called recover.
+			Stack: stacktrace.TakeStacktrace(2),
+		},
+	)
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v\n\n%s", e.Value, e.Stack)
+}
+
+func (e *PanicError) Unwrap() error {
+	err, ok := e.Value.(error)
+	if !ok {
+		return nil
+	}
+
+	return err
+}
+
+var defaultPanicMessage = NewError(http.StatusInternalServerError, "INTERNAL_SERVER_ERROR")
+
+// replyPanic sends msg to the client if the handlers have not sent any envelope yet.
+// Modifiers are skipped, since they could be the source of the panic.
+func (ctx *Context) replyPanic(msg ErrorMessage) {
+	if msg == nil || ctx.responded.Load() {
+		return
+	}
+
+	// the connection could be in a broken state, hence we don't let it panic again.
+	defer func() {
+		_ = recover()
+	}()
+
+	ctx.SetStatusCode(msg.GetCode())
+
+	e := ctx.In().Reply().SetMsg(msg)
+	ctx.Error(ctx.conn.WriteEnvelope(e))
+	ctx.responded.Store(true)
+	e.release()
+}
@@ -0,0 +1,184 @@
+package kit
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newPanicBridge(c Contract, eh ErrHandlerFunc) *northBridge {
+	return &northBridge{
+		ctxPool: ctxPool{ls: &localStore{kv: map[string]any{}}},
+		wg:      &sync.WaitGroup{},
+		eh:      eh,
+		pm:      defaultPanicMessage,
+		c:       map[string]Contract{contractLookupKey("svc", "c1"): c},
+		gw: &testGateway{
+			dispatchFn: func(_ *Context, _ []byte) (ExecuteArg, error) {
+				return ExecuteArg{ServiceName: "svc", ContractID: "c1"}, nil
+			},
+		},
+	}
+}
+
+func panickingHandler(_ *Context) {
+	panic("boom")
+}
+
+func TestNorthBridgeRecoversHandlerPanic(t *testing.T) {
+	var gotErr error
+
+	b := newPanicBridge(
+		&testContract{id: "c1", handlers: []HandlerFunc{panickingHandler}},
+		func(_ *Context, err error) { gotErr = err },
+	)
+
+	conn := newTestRESTConn()
+	b.OnMessage(conn, []byte("in"))
+
+	if !errors.Is(gotErr, ErrHandlerPanic) {
+		t.Fatalf("expected ErrHandlerPanic, got: %v", gotErr)
+	}
+
+	var pErr *PanicError
+	if !errors.As(gotErr, &pErr) {
+		t.Fatalf("expected PanicError, got: %T", gotErr)
+	}
+	if pErr.Value != "boom" {
+		t.Fatalf("unexpected panic value: %v", pErr.Value)
+	}
+	if !strings.Contains(pErr.Stack, "panickingHandler") {
+		t.Fatalf("expected stack trace to include the handler, got: %s", pErr.Stack)
+	}
+
+	if conn.statusCode != http.StatusInternalServerError {
+		t.Fatalf("unexpected status code: %d", conn.statusCode)
+	}
+	if len(conn.out) != 1 {
+		t.Fatalf("expected one envelope, got: %d", len(conn.out))
+	}
+	if conn.out[0].GetMsg() != defaultPanicMessage {
+		t.Fatalf("unexpected message: %#v", conn.out[0].GetMsg())
+	}
+}
+
+func TestNorthBridgeRecoversModifierPanic(t *testing.T) {
+	var gotErr error
+
+	b := newPanicBridge(
+		&testContract{
+			id: "c1",
+			handlers: []HandlerFunc{
+				func(ctx *Context) {
+					ctx.Out().SetMsg(&bridgeOut{OK: true}).Send()
+				},
+			},
+			modifiers: []ModifierFunc{
+				func(_ *Envelope) { panic("modifier") },
+			},
+		},
+		func(_ *Context, err error) { gotErr = err },
+	)
+
+	conn := newTestRESTConn()
+	b.OnMessage(conn, []byte("in"))
+
+	if !errors.Is(gotErr, ErrHandlerPanic) {
+		t.Fatalf("expected ErrHandlerPanic, got: %v", gotErr)
+	}
+	if len(conn.out) != 1 || conn.out[0].GetMsg() != defaultPanicMessage {
+		t.Fatalf("expected the panic message to be sent, got: %d envelope(s)", len(conn.out))
+	}
+}
+
+func TestNorthBridgePanicAfterResponse(t *testing.T) {
+	b := newPanicBridge(
+		&testContract{
+			id: "c1",
+			handlers: []HandlerFunc{
+				func(ctx *Context) {
+					ctx.Out().SetMsg(&bridgeOut{OK: true}).Send()
+					panic("late")
+				},
+			},
+		},
+		func(_ *Context, _ error) {},
+	)
+
+	conn := newTestRESTConn()
+	b.OnMessage(conn, []byte("in"))
+
+	if len(conn.out) != 1 {
+		t.Fatalf("expected one envelope, got: %d", len(conn.out))
+	}
+	if _, ok := conn.out[0].GetMsg().(*bridgeOut); !ok {
+		t.Fatalf("unexpected message: %#v", conn.out[0].GetMsg())
+	}
+}
+
+func TestSouthBridgeRecoversPanic(t *testing.T) {
+	var gotErr error
+
+	sb := &southBridge{
+		ctxPool:      ctxPool{ls: &localStore{kv: map[string]any{}}},
+		wg:           &sync.WaitGroup{},
+		eh:           func(_ *Context, err error) { gotErr = err },
+		cb:           &testCluster{},
+		inProgress:   map[string]*clusterConn{},
+		msgFactories: map[string]MessageFactoryFunc{},
+	}
+
+	carrier := newEnvelopeCarrier(incomingCarrier, "sid", "origin", "target")
+	carrier.Data = &carrierData{MsgType: "unknown"}
+
+	sb.wg.Add(1)
+	sb.handleCarrierMessage(carrier)
+	sb.wg.Wait()
+
+	if !errors.Is(gotErr, ErrHandlerPanic) {
+		t.Fatalf("expected ErrHandlerPanic, got: %v", gotErr)
+	}
+}
+
+func TestSouthBridgeExecuteRecoversPanic(t *testing.T) {
+	cluster := &testCluster{}
+
+	var gotErr error
+
+	sb := &southBridge{
+		ctxPool: ctxPool{ls: &localStore{kv: map[string]any{}}},
+		wg:      &sync.WaitGroup{},
+		eh:      func(_ *Context, err error) { gotErr = err },
+		pm:      defaultPanicMessage,
+		c: map[string]Contract{
+			contractLookupKey("svc", "c1"): &testContract{
+				id:       "c1",
+				handlers: []HandlerFunc{panickingHandler},
+			},
+		},
+		cb:           cluster,
+		inProgress:   map[string]*clusterConn{},
+		msgFactories: map[string]MessageFactoryFunc{"kit.RawMessage": CreateMessageFactory(RawMessage{})},
+	}
+
+	carrier := newEnvelopeCarrier(incomingCarrier, "sid", "origin", "target")
+	carrier.Data = &carrierData{
+		MsgType:     "kit.RawMessage",
+		ServiceName: "svc",
+		ContractID:  "c1",
+	}
+
+	sb.wg.Add(1)
+	sb.handleCarrierMessage(carrier)
+
+	if !errors.Is(gotErr, ErrHandlerPanic) {
+		t.Fatalf("expected ErrHandlerPanic, got: %v", gotErr)
+	}
+
+	// the panic message and the EOF carrier must be published to the origin
+	if len(cluster.published) != 2 {
+		t.Fatalf("expected two published carriers, got: %d", len(cluster.published))
+	}
+}
@@ -0,0 +1,504 @@
+package kit
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/utils"
+
+	"github.com/goccy/go-reflect"
+)
+
+// RedactedValue replaces the values which are removed by RedactHdr and RedactFields.
+const RedactedValue = "[REDACTED]"
+
+var ErrRecordingDropped = errors.New("recording is dropped")
+
+// Recording is a request which is recorded by the recorder of the EdgeServer (check
+// WithRecorder), and could be replayed by EdgeServer.Replay or TestContext.Replay.
+type Recording struct {
+	ID          string `json:"id"`
+	Gateway     string `json:"gateway"`
+	ServiceName string `json:"service"`
+	ContractID  string `json:"contract"`
+	Route       string `json:"route"`
+	ConnID      uint64 `json:"connID"`
+	ClientIP    string `json:"clientIP,omitempty"`
+	Stream      bool   `json:"stream,omitempty"`
+	// REST is set for the requests of the REST connections.
+	REST *RecordedREST `json:"rest,omitempty"`
+	// ConnHdr is the key-values of the connection (Conn.Walk) when the request is received.
+	ConnHdr map[string]string `json:"connHdr,omitempty"`
+	// RawData is the raw data of the request, which is received by the gateway.
+	RawData []byte `json:"rawData,omitempty"`
+	// In is the incoming envelope, which its message is decoded by the gateway.
+	In RecordedEnvelope `json:"in"`
+	// Out is the envelopes which are sent to the connection until the handlers return.
+	Out        []RecordedEnvelope `json:"out,omitempty"`
+	StatusCode int                `json:"statusCode"`
+	Error      string             `json:"error,omitempty"`
+	StartedAt  time.Time          `json:"startedAt"`
+	Duration   time.Duration      `json:"duration"`
+}
+
+// RecordedREST is the information of the REST requests in the Recording.
+type RecordedREST struct {
+	Method     string `json:"method"`
+	Host       string `json:"host,omitempty"`
+	Path       string `json:"path"`
+	RequestURI string `json:"requestURI"`
+}
+
+// RecordedEnvelope is an Envelope in the Recording. The message is kept in its JSON form,
+// and the messages which are not JSON, e.g., proto messages or binary RawMessage, are kept
+// in Bin. Enc is the tag of the Encoding of Bin, and it is empty for the raw bytes.
+type RecordedEnvelope struct {
+	ID      string            `json:"id,omitempty"`
+	Hdr     map[string]string `json:"hdr,omitempty"`
+	MsgType string            `json:"msgType,omitempty"`
+	Msg     json.RawMessage   `json:"msg,omitempty"`
+	Bin     []byte            `json:"bin,omitempty"`
+	Enc     string            `json:"enc,omitempty"`
+	// Elapsed is the time from the start of the request until the envelope is sent.
+	Elapsed time.Duration `json:"elapsed,omitempty"`
+}
+
+func recordEnvelope(e *Envelope) RecordedEnvelope {
+	re := RecordedEnvelope{
+		ID: e.GetID(),
+	}
+
+	e.WalkHdr(func(key, val string) bool {
+		if re.Hdr == nil {
+			re.Hdr = make(map[string]string, 4)
+		}
+
+		re.Hdr[key] = val
+
+		return true
+	})
+
+	msg := e.GetMsg()
+	if msg == nil {
+		return re
+	}
+
+	re.MsgType = reflect.TypeOf(msg).String()
+
+	data, enc, err := MarshalMessageAs(carrierEncoding(msg), msg)
+	switch {
+	case err != nil:
+	case enc == JSON && json.Valid(data):
+		re.Msg = append(json.RawMessage(nil), data...)
+	default:
+		re.Bin = append([]byte(nil), data...)
+		if enc != JSON {
+			re.Enc = enc.Tag()
+		}
+	}
+
+	return re
+}
+
+// RecordSink stores the recordings. WriteRecording is called after the handlers of the
+// request return, hence it must be fast, or it must store the recordings in the background.
+type RecordSink interface {
+	WriteRecording(r *Recording) error
+}
+
+// RecordSinkFunc implements RecordSink interface.
+type RecordSinkFunc func(r *Recording) error
+
+func (f RecordSinkFunc) WriteRecording(r *Recording) error {
+	return f(r)
+}
+
+// ChannelSink returns a RecordSink which sends the recordings to ch. It never blocks, and
+// the recordings are dropped with ErrRecordingDropped if ch is full.
+func ChannelSink(ch chan<- *Recording) RecordSink {
+	return RecordSinkFunc(
+		func(r *Recording) error {
+			select {
+			case ch <- r:
+				return nil
+			default:
+				return ErrRecordingDropped
+			}
+		},
+	)
+}
+
+// FileSink is a RecordSink which writes the recordings to a file as JSON lines, which could
+// be read by ReadRecordings. When the file reaches its max size, it is rotated: the file is
+// renamed to 'path.1', and the older backups are shifted to 'path.2', 'path.3', etc.
+type FileSink struct {
+	mtx        sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+var _ RecordSink = (*FileSink)(nil)
+
+// NewFileSink opens the file in path to append the recordings. If maxSize is zero, the file
+// is never rotated, and if maxBackups is zero, the rotated files are removed.
+func NewFileSink(path string, maxSize int64, maxBackups int) (*FileSink, error) {
+	fs := &FileSink{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	err := fs.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+func (fs *FileSink) open() error {
+	f, err := os.OpenFile(fs.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	fs.f = f
+	fs.size = st.Size()
+
+	return nil
+}
+
+func (fs *FileSink) WriteRecording(r *Recording) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	data = append(data, '\n')
+
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	if fs.f == nil {
+		return os.ErrClosed
+	}
+
+	if fs.maxSize > 0 && fs.size > 0 && fs.size+int64(len(data)) > fs.maxSize {
+		err = fs.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := fs.f.Write(data)
+	fs.size += int64(n)
+
+	return err
+}
+
+func (fs *FileSink) rotate() error {
+	err := fs.f.Close()
+	if err != nil {
+		return err
+	}
+
+	fs.f = nil
+
+	if fs.maxBackups <= 0 {
+		err = os.Remove(fs.path)
+	} else {
+		for i := fs.maxBackups - 1; i > 0; i-- {
+			_ = os.Rename(fs.backupPath(i), fs.backupPath(i+1))
+		}
+
+		err = os.Rename(fs.path, fs.backupPath(1))
+	}
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return fs.open()
+}
+
+func (fs *FileSink) backupPath(idx int) string {
+	return fs.path + "." + strconv.Itoa(idx)
+}
+
+// Close closes the file. The recordings which are written after Close get os.ErrClosed.
+func (fs *FileSink) Close() error {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	if fs.f == nil {
+		return nil
+	}
+
+	err := fs.f.Close()
+	fs.f = nil
+
+	return err
+}
+
+// ReadRecordings reads the recordings which are written by FileSink.
+func ReadRecordings(r io.Reader) ([]*Recording, error) {
+	var recs []*Recording
+
+	dec := json.NewDecoder(r)
+	for {
+		rec := &Recording{}
+
+		err := dec.Decode(rec)
+		if errors.Is(err, io.EOF) {
+			return recs, nil
+		}
+
+		if err != nil {
+			return recs, err
+		}
+
+		recs = append(recs, rec)
+	}
+}
+
+type recorderConfig struct {
+	rate      float64
+	filter    func(ctx *Context) bool
+	redactors []func(r *Recording)
+}
+
+type RecorderOption func(cfg *recorderConfig)
+
+// RecordSampleRate sets the fraction of the requests, between 0 and 1, which are recorded.
+// Default is 1, i.e., all the requests.
+func RecordSampleRate(rate float64) RecorderOption {
+	return func(cfg *recorderConfig) {
+		cfg.rate = rate
+	}
+}
+
+// RecordFilter sets the function which selects the requests to be recorded, e.g., by
+// Context.ServiceName or Context.ContractID. The selected requests are also sampled.
+func RecordFilter(f func(ctx *Context) bool) RecorderOption {
+	return func(cfg *recorderConfig) {
+		cfg.filter = f
+	}
+}
+
+// RecordRedactor adds a function which removes the sensitive data, e.g., PII, from the
+// recordings before they are written to the sink. The redactors run in the order they
+// are added. Check RedactHdr and RedactFields.
+func RecordRedactor(f func(r *Recording)) RecorderOption {
+	return func(cfg *recorderConfig) {
+		cfg.redactors = append(cfg.redactors, f)
+	}
+}
+
+// RedactHdr returns a redactor which replaces the values of the headers (case-insensitive)
+// with RedactedValue in the connection, incoming and outgoing headers.
+func RedactHdr(keys ...string) func(r *Recording) {
+	redact := func(hdr map[string]string) {
+		for k := range hdr {
+			for _, key := range keys {
+				if strings.EqualFold(k, key) {
+					hdr[k] = RedactedValue
+				}
+			}
+		}
+	}
+
+	return func(r *Recording) {
+		redact(r.ConnHdr)
+		redact(r.In.Hdr)
+
+		for idx := range r.Out {
+			redact(r.Out[idx].Hdr)
+		}
+	}
+}
+
+// RedactFields returns a redactor which replaces the values of the fields, at any depth,
+// with RedactedValue in the JSON messages and the raw data. The raw data which is not
+// JSON is removed, since it could not be redacted.
+func RedactFields(names ...string) func(r *Recording) {
+	fields := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		fields[name] = struct{}{}
+	}
+
+	redact := func(data []byte) []byte {
+		var v any
+		if json.Unmarshal(data, &v) != nil {
+			return nil
+		}
+
+		out, err := json.Marshal(redactJSON(v, fields))
+		if err != nil {
+			return nil
+		}
+
+		return out
+	}
+
+	return func(r *Recording) {
+		if len(r.RawData) > 0 {
+			r.RawData = redact(r.RawData)
+		}
+
+		if len(r.In.Msg) > 0 {
+			r.In.Msg = redact(r.In.Msg)
+		}
+
+		for idx := range r.Out {
+			if len(r.Out[idx].Msg) > 0 {
+				r.Out[idx].Msg = redact(r.Out[idx].Msg)
+			}
+		}
+	}
+}
+
+func redactJSON(v any, fields map[string]struct{}) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, fv := range v {
+			if _, ok := fields[k]; ok {
+				v[k] = RedactedValue
+			} else {
+				v[k] = redactJSON(fv, fields)
+			}
+		}
+	case []any:
+		for idx := range v {
+			v[idx] = redactJSON(v[idx], fields)
+		}
+	}
+
+	return v
+}
+
+// WithRecorder records the requests which are received by the gateways, with their incoming
+// envelope, raw data, route, outgoing envelopes and timing, and writes them to the sink.
+// The recordings could be replayed by EdgeServer.Replay or TestContext.Replay to reproduce
+// a problem, and to compare the responses.
+//
+// NOTE: the recordings could contain sensitive data, check RecordRedactor.
+func WithRecorder(sink RecordSink, opts ...RecorderOption) Option {
+	return func(s *edgeConfig) {
+		cfg := &recorderConfig{
+			rate: 1,
+		}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+
+		s.recorder = &recorder{sink: sink, cfg: cfg}
+	}
+}
+
+type recorder struct {
+	sink RecordSink
+	cfg  *recorderConfig
+}
+
+// recording is the Recording of a request in progress.
+type recording struct {
+	mtx   sync.Mutex
+	start time.Time
+	r     *Recording
+}
+
+func (rec *recorder) sampled(ctx *Context) bool {
+	if rec.cfg.filter != nil && !rec.cfg.filter(ctx) {
+		return false
+	}
+
+	if rec.cfg.rate >= 1 {
+		return true
+	}
+
+	return float64(utils.FastRand()) < rec.cfg.rate*math.MaxUint32
+}
+
+// start starts the recording of the request. It must be called after the request is
+// dispatched, so the incoming envelope and the route are available.
+func (rec *recorder) start(ctx *Context, gateway string) *recording {
+	if !rec.sampled(ctx) {
+		return nil
+	}
+
+	conn := ctx.Conn()
+	r := &Recording{
+		ID:          utils.RandomID(24),
+		Gateway:     gateway,
+		ServiceName: ctx.ServiceName(),
+		ContractID:  ctx.ContractID(),
+		Route:       ctx.Route(),
+		ConnID:      conn.ConnID(),
+		ClientIP:    conn.ClientIP(),
+		Stream:      conn.Stream(),
+		ConnHdr:     connKV(conn),
+		RawData:     append([]byte(nil), ctx.rawData...),
+		In:          recordEnvelope(ctx.In()),
+		StartedAt:   time.Now().UTC(),
+	}
+
+	if rc, ok := conn.(RESTConn); ok {
+		r.REST = &RecordedREST{
+			Method:     rc.GetMethod(),
+			Host:       rc.GetHost(),
+			Path:       rc.GetPath(),
+			RequestURI: rc.GetRequestURI(),
+		}
+	}
+
+	return &recording{start: time.Now(), r: r}
+}
+
+func (rec *recorder) finish(ctx *Context, rr *recording) error {
+	rr.mtx.Lock()
+	r := rr.r
+	rr.r = nil
+	rr.mtx.Unlock()
+
+	r.Duration = time.Since(rr.start)
+	r.StatusCode = ctx.GetStatusCode()
+	if ctx.err != nil {
+		r.Error = ctx.err.Error()
+	}
+
+	for _, redact := range rec.cfg.redactors {
+		redact(r)
+	}
+
+	return rec.sink.WriteRecording(r)
+}
+
+// addOut records the outgoing envelope. The envelopes which are sent after the recording
+// is finished, e.g., by a goroutine of the handler, are not recorded.
+func (rr *recording) addOut(e *Envelope) {
+	re := recordEnvelope(e)
+
+	rr.mtx.Lock()
+	if rr.r != nil {
+		re.Elapsed = time.Since(rr.start)
+		rr.r.Out = append(rr.r.Out, re)
+	}
+	rr.mtx.Unlock()
+}
@@ -0,0 +1,421 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/utils"
+
+	"github.com/goccy/go-reflect"
+)
+
+var ErrReplayNoGateway = errors.New("no gateway to replay the recording")
+
+// ReplayDiff is a difference between the recorded and the replayed responses. Index is the
+// index of the outgoing envelope, or -1 for the differences of the whole response.
+type ReplayDiff struct {
+	Index    int    `json:"index"`
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func (d ReplayDiff) String() string {
+	if d.Index < 0 {
+		return fmt.Sprintf("%s: expected %s, got %s", d.Field, d.Expected, d.Actual)
+	}
+
+	return fmt.Sprintf("out[%d].%s: expected %s, got %s", d.Index, d.Field, d.Expected, d.Actual)
+}
+
+// ReplayResult is the result of replaying a Recording.
+type ReplayResult struct {
+	Recording  *Recording
+	Out        []RecordedEnvelope
+	StatusCode int
+	Diffs      []ReplayDiff
+}
+
+// Equal reports if the replayed responses are the same as the recorded ones.
+func (r *ReplayResult) Equal() bool {
+	return len(r.Diffs) == 0
+}
+
+type replayConfig struct {
+	ignoreHdr    map[string]struct{}
+	ignoreFields map[string]struct{}
+}
+
+type ReplayOption func(cfg *replayConfig)
+
+// ReplayIgnoreHdr ignores the headers in the diff, e.g., the headers which carry a timestamp.
+func ReplayIgnoreHdr(keys ...string) ReplayOption {
+	return func(cfg *replayConfig) {
+		for _, key := range keys {
+			cfg.ignoreHdr[key] = struct{}{}
+		}
+	}
+}
+
+// ReplayIgnoreFields ignores the fields of the messages, at any depth, in the diff, e.g.,
+// the generated ids and timestamps.
+func ReplayIgnoreFields(names ...string) ReplayOption {
+	return func(cfg *replayConfig) {
+		for _, name := range names {
+			cfg.ignoreFields[name] = struct{}{}
+		}
+	}
+}
+
+func newReplayConfig(opts []ReplayOption) *replayConfig {
+	cfg := &replayConfig{
+		ignoreHdr:    map[string]struct{}{},
+		ignoreFields: map[string]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// Replay runs the handlers of the TestContext by the incoming envelope of the recording, and
+// compares their responses with the recorded ones. The recorded message is decoded into in,
+// which must be a pointer to the input message of the contract.
+func (testCtx *TestContext) Replay(rec *Recording, in Message, opts ...ReplayOption) (*ReplayResult, error) {
+	err := decodeRecordedMsg(rec.In, in)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		statusCode int
+		out        []*Envelope
+	)
+
+	handlers := testCtx.handlers
+	testCtx.handlers = append(
+		HandlerFuncChain{
+			func(ctx *Context) {
+				ctx.Next()
+				statusCode = ctx.GetStatusCode()
+			},
+		},
+		handlers...,
+	)
+	receiverFunc := testCtx.receiverFunc
+	testCtx.receiverFunc = func(e ...*Envelope) error {
+		out = e
+
+		return nil
+	}
+
+	defer func() {
+		testCtx.handlers = handlers
+		testCtx.receiverFunc = receiverFunc
+	}()
+
+	testCtx.Input(in, rec.In.Hdr)
+
+	err = testCtx.RunWithConn(newReplayConn(rec))
+	if err != nil {
+		return nil, err
+	}
+
+	return newReplayResult(rec, out, statusCode, newReplayConfig(opts)), nil
+}
+
+// Replay executes the contract of the recording, which must be registered in this EdgeServer,
+// by the incoming envelope of the recording, and compares the responses with the recorded
+// ones. The gateway is not involved, i.e., the contract's handlers run with the decoded
+// message of the recording on an in-memory connection, and the envelopes which are sent
+// after the handlers return are not compared. The replays are not recorded.
+func (s *EdgeServer) Replay(ctx context.Context, rec *Recording, opts ...ReplayOption) (*ReplayResult, error) {
+	c, err := resolveContract(s.contracts, rec.ServiceName, rec.ContractID)
+	if err != nil {
+		return nil, err
+	}
+
+	var nb *northBridge
+	for _, b := range s.nb {
+		if b.name == rec.Gateway || nb == nil {
+			nb = b
+		}
+	}
+
+	if nb == nil {
+		return nil, ErrReplayNoGateway
+	}
+
+	in := CreateMessageFactory(c.Input())()
+	if _, ok := in.(RawMessage); ok {
+		in = &RawMessage{}
+	}
+
+	err = decodeRecordedMsg(rec.In, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, ok := in.(*RawMessage); ok {
+		in = *v
+	}
+
+	conn := newReplayConn(rec)
+
+	kitCtx := nb.acquireCtx(conn)
+	kitCtx.nb = nb
+	kitCtx.sb = s.sb
+	kitCtx.rawData = rec.RawData
+	kitCtx.In().
+		SetID(rec.In.ID).
+		SetHdrMap(rec.In.Hdr).
+		SetMsg(in)
+
+	stop := context.AfterFunc(ctx, kitCtx.cancel)
+	nb.replay(kitCtx, ExecuteArg{ServiceName: rec.ServiceName, ContractID: rec.ContractID, Route: rec.Route}, c)
+	stop()
+
+	var out []*Envelope
+	switch conn := conn.(type) {
+	case *testRESTConn:
+		out = conn.out
+	case *testConn:
+		out = conn.out
+	}
+
+	res := newReplayResult(rec, out, kitCtx.GetStatusCode(), newReplayConfig(opts))
+	nb.releaseCtx(kitCtx)
+
+	return res, nil
+}
+
+func (n *northBridge) replay(ctx *Context, arg ExecuteArg, c Contract) {
+	defer n.recoverPanic(ctx)
+
+	ctx.execute(arg, c)
+}
+
+// newReplayConn returns an in-memory connection which looks like the recorded one.
+func newReplayConn(rec *Recording) Conn {
+	kv := make(map[string]string, len(rec.ConnHdr))
+	for k, v := range rec.ConnHdr {
+		kv[k] = v
+	}
+
+	if rec.REST == nil {
+		return &testConn{
+			id:       utils.RandomUint64(0),
+			clientIP: rec.ClientIP,
+			stream:   rec.Stream,
+			kv:       kv,
+		}
+	}
+
+	return &testRESTConn{
+		testConn: testConn{
+			id:       utils.RandomUint64(0),
+			clientIP: rec.ClientIP,
+			kv:       kv,
+		},
+		method:     rec.REST.Method,
+		path:       rec.REST.Path,
+		host:       rec.REST.Host,
+		requestURI: rec.REST.RequestURI,
+	}
+}
+
+func decodeRecordedMsg(re RecordedEnvelope, m Message) error {
+	data := []byte(re.Msg)
+	if len(data) == 0 {
+		data = re.Bin
+	}
+
+	if v, ok := m.(*RawMessage); ok {
+		*v = append((*v)[:0], data...)
+
+		return nil
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	if len(re.Msg) > 0 {
+		return UnmarshalMessage(data, m)
+	}
+
+	if re.Enc == "" {
+		return ErrUnsupportedMessage
+	}
+
+	return UnmarshalMessageAs(CustomEncoding(re.Enc), data, m)
+}
+
+func newReplayResult(rec *Recording, out []*Envelope, statusCode int, cfg *replayConfig) *ReplayResult {
+	res := &ReplayResult{
+		Recording:  rec,
+		StatusCode: statusCode,
+	}
+
+	for _, e := range out {
+		res.Out = append(res.Out, recordEnvelope(e))
+	}
+
+	// the recorded REST requests always have a status code, 200 by default.
+	if rec.REST != nil && rec.StatusCode != 0 && rec.StatusCode != statusCode {
+		res.Diffs = append(res.Diffs, ReplayDiff{
+			Index:    -1,
+			Field:    "statusCode",
+			Expected: strconv.Itoa(rec.StatusCode),
+			Actual:   strconv.Itoa(statusCode),
+		})
+	}
+
+	if len(rec.Out) != len(res.Out) {
+		res.Diffs = append(res.Diffs, ReplayDiff{
+			Index:    -1,
+			Field:    "out",
+			Expected: strconv.Itoa(len(rec.Out)) + " envelope(s)",
+			Actual:   strconv.Itoa(len(res.Out)) + " envelope(s)",
+		})
+	}
+
+	for idx := 0; idx < len(rec.Out) && idx < len(res.Out); idx++ {
+		res.Diffs = append(res.Diffs, diffEnvelope(idx, rec.Out[idx], res.Out[idx], cfg)...)
+	}
+
+	return res
+}
+
+func diffEnvelope(idx int, expected, actual RecordedEnvelope, cfg *replayConfig) []ReplayDiff {
+	var diffs []ReplayDiff
+
+	keys := make([]string, 0, len(expected.Hdr)+len(actual.Hdr))
+	for k := range expected.Hdr {
+		keys = append(keys, k)
+	}
+
+	for k := range actual.Hdr {
+		if _, ok := expected.Hdr[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if _, ok := cfg.ignoreHdr[k]; ok || expected.Hdr[k] == RedactedValue {
+			continue
+		}
+
+		if expected.Hdr[k] != actual.Hdr[k] {
+			diffs = append(diffs, ReplayDiff{
+				Index:    idx,
+				Field:    "hdr." + k,
+				Expected: strconv.Quote(expected.Hdr[k]),
+				Actual:   strconv.Quote(actual.Hdr[k]),
+			})
+		}
+	}
+
+	if len(expected.Msg) == 0 || len(actual.Msg) == 0 {
+		if string(expected.Msg) != string(actual.Msg) || string(expected.Bin) != string(actual.Bin) {
+			diffs = append(diffs, ReplayDiff{
+				Index:    idx,
+				Field:    "msg",
+				Expected: recordedMsgString(expected),
+				Actual:   recordedMsgString(actual),
+			})
+		}
+
+		return diffs
+	}
+
+	var ev, av any
+
+	_ = json.Unmarshal(expected.Msg, &ev)
+	_ = json.Unmarshal(actual.Msg, &av)
+
+	if path, ok := diffJSON("msg", ev, av, cfg.ignoreFields); !ok {
+		diffs = append(diffs, ReplayDiff{
+			Index:    idx,
+			Field:    path,
+			Expected: string(expected.Msg),
+			Actual:   string(actual.Msg),
+		})
+	}
+
+	return diffs
+}
+
+// diffJSON compares the decoded JSON values, and returns the path of the first difference.
+// The recorded values which are redacted match any value.
+func diffJSON(path string, expected, actual any, ignore map[string]struct{}) (string, bool) {
+	if expected == RedactedValue {
+		return "", true
+	}
+
+	switch ev := expected.(type) {
+	case map[string]any:
+		av, ok := actual.(map[string]any)
+		if !ok {
+			return path, false
+		}
+
+		keys := make([]string, 0, len(ev)+len(av))
+		for k := range ev {
+			keys = append(keys, k)
+		}
+
+		for k := range av {
+			if _, ok := ev[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if _, ok := ignore[k]; ok {
+				continue
+			}
+
+			if p, ok := diffJSON(path+"."+k, ev[k], av[k], ignore); !ok {
+				return p, false
+			}
+		}
+
+		return "", true
+	case []any:
+		av, ok := actual.([]any)
+		if !ok || len(av) != len(ev) {
+			return path, false
+		}
+
+		for idx := range ev {
+			if p, ok := diffJSON(path+"["+strconv.Itoa(idx)+"]", ev[idx], av[idx], ignore); !ok {
+				return p, false
+			}
+		}
+
+		return "", true
+	default:
+		return path, reflect.DeepEqual(expected, actual)
+	}
+}
+
+func recordedMsgString(re RecordedEnvelope) string {
+	switch {
+	case len(re.Msg) > 0:
+		return string(re.Msg)
+	case len(re.Bin) > 0:
+		return strconv.Itoa(len(re.Bin)) + " byte(s)"
+	default:
+		return "nothing"
+	}
+}
@@ -0,0 +1,241 @@
+package kit
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type recordIn struct {
+	N        int    `json:"n"`
+	Password string `json:"password"`
+}
+
+func newRecordedServer(t *testing.T, h HandlerFunc, opts ...Option) (*EdgeServer, *testGateway) {
+	t.Helper()
+
+	gw := &testGateway{}
+	gw.dispatchFn = func(ctx *Context, in []byte) (ExecuteArg, error) {
+		msg := &recordIn{}
+		if err := UnmarshalMessage(in, msg); err != nil {
+			return ExecuteArg{}, err
+		}
+
+		ctx.In().SetHdr("Authorization", "secret").SetMsg(msg)
+
+		return ExecuteArg{ServiceName: "svc", ContractID: "echo", Route: "POST /echo"}, nil
+	}
+
+	s := NewServer(
+		append(
+			opts,
+			WithGateway(gw),
+			WithService(testService{
+				name: "svc",
+				contracts: []Contract{
+					&testContract{id: "echo", input: &recordIn{}, output: &callOut{}, handlers: []HandlerFunc{h}},
+				},
+			}),
+		)...,
+	)
+
+	return s, gw
+}
+
+func echoHandler(ctx *Context) {
+	in := ctx.In().GetMsg().(*recordIn) //nolint:forcetypeassert
+
+	ctx.SetStatusCode(http.StatusCreated)
+	ctx.Out().SetHdr("Authorization", "secret").SetMsg(&callOut{N: in.N * 2}).Send()
+}
+
+func TestRecorder(t *testing.T) {
+	ch := make(chan *Recording, 1)
+	s, gw := newRecordedServer(
+		t, echoHandler,
+		WithRecorder(
+			ChannelSink(ch),
+			RecordRedactor(RedactHdr("authorization")),
+			RecordRedactor(RedactFields("password")),
+		),
+	)
+
+	conn := newTestRESTConn()
+	conn.method = http.MethodPost
+	conn.path = "/echo"
+	conn.kv = map[string]string{"userID": "1"}
+	gw.delegate.OnMessage(conn, []byte(`{"n":21,"password":"pass"}`))
+
+	var rec *Recording
+	select {
+	case rec = <-ch:
+	default:
+		t.Fatal("expected a recording")
+	}
+
+	if rec.ServiceName != "svc" || rec.ContractID != "echo" || rec.Route != "POST /echo" ||
+		rec.Gateway != "gateway.0" || rec.REST == nil || rec.REST.Path != "/echo" ||
+		rec.ConnHdr["userID"] != "1" || rec.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected recording: %#v", rec)
+	}
+	if string(rec.RawData) != `{"n":21,"password":"[REDACTED]"}` ||
+		string(rec.In.Msg) != `{"n":21,"password":"[REDACTED]"}` {
+		t.Fatalf("expected the password to be redacted: %s, %s", rec.RawData, rec.In.Msg)
+	}
+	if rec.In.Hdr["Authorization"] != RedactedValue || len(rec.Out) != 1 ||
+		rec.Out[0].Hdr["Authorization"] != RedactedValue || string(rec.Out[0].Msg) != `{"n":42}` {
+		t.Fatalf("unexpected envelopes: %#v, %#v", rec.In, rec.Out)
+	}
+
+	// the channel is full, hence the next recording is dropped.
+	ch <- rec
+
+	var dropped error
+	s.eh = func(_ *Context, err error) { dropped = err }
+	s.nb[0].eh = s.eh
+	gw.delegate.OnMessage(newTestRESTConn(), []byte(`{"n":1}`))
+
+	if !errors.Is(dropped, ErrRecordingDropped) {
+		t.Fatalf("expected ErrRecordingDropped, got: %v", dropped)
+	}
+}
+
+func TestRecorderSampling(t *testing.T) {
+	var n int
+
+	sink := RecordSinkFunc(func(*Recording) error { n++; return nil })
+	_, gw := newRecordedServer(
+		t, echoHandler,
+		WithRecorder(sink, RecordFilter(func(ctx *Context) bool { return ctx.ContractID() == "echo" })),
+	)
+	_, gw2 := newRecordedServer(t, echoHandler, WithRecorder(sink, RecordSampleRate(0)))
+
+	for range 3 {
+		gw.delegate.OnMessage(newTestConn(), []byte(`{"n":1}`))
+		gw2.delegate.OnMessage(newTestConn(), []byte(`{"n":1}`))
+	}
+
+	if n != 3 {
+		t.Fatalf("expected 3 recordings, got: %d", n)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.jsonl")
+
+	fs, err := NewFileSink(path, 200, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for idx := range 4 {
+		err = fs.WriteRecording(&Recording{ID: string(rune('a' + idx)), ServiceName: "svc", ContractID: "echo"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = fs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !errors.Is(fs.WriteRecording(&Recording{}), os.ErrClosed) {
+		t.Fatal("expected os.ErrClosed")
+	}
+
+	var all []*Recording
+	for _, p := range []string{path + ".2", path + ".1", path} {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recs, err := ReadRecordings(bytes.NewReader(data))
+		if err != nil || len(recs) == 0 {
+			t.Fatalf("unexpected recordings in %s: %v, %v", p, recs, err)
+		}
+
+		all = append(all, recs...)
+	}
+
+	// each file keeps one recording, and the first one is in the removed backup.
+	if len(all) != 3 || all[0].ID != "b" || all[2].ID != "d" {
+		t.Fatalf("unexpected recordings: %d", len(all))
+	}
+}
+
+func TestReplay(t *testing.T) {
+	ch := make(chan *Recording, 1)
+	s, gw := newRecordedServer(t, echoHandler, WithRecorder(ChannelSink(ch), RecordRedactor(RedactHdr("Authorization"))))
+
+	gw.delegate.OnMessage(newTestRESTConn(), []byte(`{"n":21}`))
+	rec := <-ch
+
+	res, err := s.Replay(t.Context(), rec)
+	if err != nil || !res.Equal() || res.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected replay: %#v, %v", res, err)
+	}
+
+	if len(ch) != 0 {
+		t.Fatal("the replays must not be recorded")
+	}
+
+	// a changed handler is caught by the diff
+	res, err = NewTestContext().
+		SetHandler(func(ctx *Context) {
+			ctx.SetStatusCode(http.StatusCreated)
+			ctx.Out().SetHdr("Authorization", "other").SetMsg(&callOut{N: 43}).Send()
+		}).
+		Replay(rec, &recordIn{})
+	if err != nil || res.Equal() {
+		t.Fatalf("expected diffs: %#v, %v", res, err)
+	}
+	if len(res.Diffs) != 1 || res.Diffs[0].Field != "msg.n" ||
+		res.Diffs[0].String() != `out[0].msg.n: expected {"n":42}, got {"n":43}` {
+		t.Fatalf("unexpected diffs: %v", res.Diffs)
+	}
+
+	res, _ = NewTestContext().SetHandler(echoHandler).Replay(rec, &recordIn{}, ReplayIgnoreFields("n"))
+	if !res.Equal() {
+		t.Fatalf("unexpected diffs: %v", res.Diffs)
+	}
+
+	rec.ContractID = "unknown"
+	if _, err = s.Replay(t.Context(), rec); !errors.Is(err, ErrContractNotFound) {
+		t.Fatalf("expected ErrContractNotFound, got: %v", err)
+	}
+}
+
+func TestReplayProto(t *testing.T) {
+	ctx := newContext(nil)
+	in := recordEnvelope(newEnvelope(ctx, newTestConn(), false).SetMsg(wrapperspb.String("hello")))
+	if in.Enc != Proto.Tag() || len(in.Msg) != 0 || len(in.Bin) == 0 {
+		t.Fatalf("expected the proto message in its binary form: %+v", in)
+	}
+
+	out := newEnvelope(ctx, newTestConn(), true).SetMsg(wrapperspb.String("HELLO"))
+	rec := &Recording{In: in, Out: []RecordedEnvelope{recordEnvelope(out)}}
+
+	upper := func(ctx *Context) {
+		msg := ctx.In().GetMsg().(*wrapperspb.StringValue) //nolint:forcetypeassert
+		ctx.Out().SetMsg(wrapperspb.String(strings.ToUpper(msg.GetValue()))).Send()
+	}
+
+	res, err := NewTestContext().SetHandler(upper).Replay(rec, &wrapperspb.StringValue{})
+	if err != nil || !res.Equal() {
+		t.Fatalf("unexpected replay: %v, %v", res.Diffs, err)
+	}
+
+	res, err = NewTestContext().
+		SetHandler(func(ctx *Context) { ctx.Out().SetMsg(wrapperspb.String("other")).Send() }).
+		Replay(rec, &wrapperspb.StringValue{})
+	if err != nil || res.Equal() {
+		t.Fatalf("expected diffs: %v, %v", res, err)
+	}
+}
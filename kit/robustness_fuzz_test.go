@@ -22,6 +22,23 @@ func FuzzEnvelopeCarrierFromJSONNoPanic(f *testing.F) {
 	})
 }
 
+func FuzzDecodeEnvelopeCarrierNoPanic(f *testing.F) {
+	f.Add([]byte(`{"id":"s","kind":1,"originID":"o","targetID":"t"}`))
+	f.Add([]byte{binaryCarrierV1, 1, 1, 's', 1, 'o', 1, 't', 0})
+	f.Add([]byte{binaryCarrierV1, 1, 0, 0, 0, 1, 0, 0, 0xff, 0xff})
+	f.Add([]byte(""))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("decodeEnvelopeCarrier panicked: %v", r)
+			}
+		}()
+
+		_, _ = decodeEnvelopeCarrier(data)
+	})
+}
+
 func FuzzCastRawMessageNoPanic(f *testing.F) {
 	f.Add([]byte(`{"a":1}`))
 	f.Add([]byte(`null`))
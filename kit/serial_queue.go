@@ -0,0 +1,48 @@
+package kit
+
+import (
+	"sync"
+)
+
+// serialQueues runs the functions of each key one at a time, in the order they are queued,
+// on a goroutine per key, which exits when the queue of the key is empty. Hence, the work of
+// one key never waits for the work of the other keys. The zero value is ready to use.
+type serialQueues[K comparable] struct {
+	mtx sync.Mutex
+	fns map[K][]func()
+}
+
+func (q *serialQueues[K]) push(key K, fn func()) {
+	q.mtx.Lock()
+	if q.fns == nil {
+		q.fns = map[K][]func(){}
+	}
+
+	fns, running := q.fns[key]
+	q.fns[key] = append(fns, fn)
+	q.mtx.Unlock()
+
+	if !running {
+		go q.run(key)
+	}
+}
+
+func (q *serialQueues[K]) run(key K) {
+	for {
+		q.mtx.Lock()
+		fns := q.fns[key]
+		if len(fns) == 0 {
+			delete(q.fns, key)
+			q.mtx.Unlock()
+
+			return
+		}
+
+		fn := fns[0]
+		fns[0] = nil
+		q.fns[key] = fns[1:]
+		q.mtx.Unlock()
+
+		fn()
+	}
+}
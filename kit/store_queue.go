@@ -0,0 +1,85 @@
+package kit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultStoreOpTimeout = 5 * time.Second
+
+type storeOp func(ctx context.Context) error
+
+// storeQueue runs the ClusterStore operations of the gateway callbacks (e.g., OnOpen and
+// OnClose) in the background, so the event loops of the gateways never wait for the
+// store. The operations run one at a time in the order they are queued, hence an entry
+// which is set on open is never deleted before it is set. Each operation is bounded by
+// the timeout.
+type storeQueue struct {
+	timeout time.Duration
+	eh      ErrHandlerFunc
+
+	mtx     sync.Mutex
+	ops     []storeOp
+	running bool
+	pending sync.WaitGroup
+}
+
+func newStoreQueue(eh ErrHandlerFunc) *storeQueue {
+	return &storeQueue{
+		timeout: defaultStoreOpTimeout,
+		eh:      eh,
+	}
+}
+
+func (q *storeQueue) push(op storeOp) {
+	q.pending.Add(1)
+
+	q.mtx.Lock()
+	q.ops = append(q.ops, op)
+	if q.running {
+		q.mtx.Unlock()
+
+		return
+	}
+
+	q.running = true
+	q.mtx.Unlock()
+
+	go q.run()
+}
+
+func (q *storeQueue) run() {
+	for {
+		q.mtx.Lock()
+		if len(q.ops) == 0 {
+			q.running = false
+			q.mtx.Unlock()
+
+			return
+		}
+
+		op := q.ops[0]
+		q.ops[0] = nil
+		q.ops = q.ops[1:]
+		q.mtx.Unlock()
+
+		q.exec(op)
+	}
+}
+
+func (q *storeQueue) exec(op storeOp) {
+	defer q.pending.Done()
+
+	ctx, cf := context.WithTimeout(context.Background(), q.timeout)
+	defer cf()
+
+	if err := op(ctx); err != nil && q.eh != nil {
+		q.eh(nil, err)
+	}
+}
+
+// flush waits for the queued operations to finish.
+func (q *storeQueue) flush() {
+	q.pending.Wait()
+}
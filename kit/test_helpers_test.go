@@ -2,6 +2,7 @@ package kit
 
 import (
 	"context"
+	"time"
 )
 
 type testGateway struct {
@@ -139,6 +140,7 @@ type testContract struct {
 	handlers  []HandlerFunc
 	modifiers []ModifierFunc
 	edgeSel   EdgeSelectorFunc
+	timeout   time.Duration
 }
 
 func (c *testContract) ID() string                     { return c.id }
@@ -149,6 +151,7 @@ func (c *testContract) Input() Message                 { return c.input }
 func (c *testContract) Output() Message                { return c.output }
 func (c *testContract) Handlers() []HandlerFunc        { return c.handlers }
 func (c *testContract) Modifiers() []ModifierFunc      { return c.modifiers }
+func (c *testContract) Timeout() time.Duration         { return c.timeout }
 
 type testService struct {
 	name      string
@@ -0,0 +1,248 @@
+package kit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	topicKeyPrefix  = "kit:topic:"
+	defaultTopicTTL = time.Minute
+)
+
+// topicRouter keeps the stream connections which are subscribed to the topics. Each
+// instance delivers the messages of a topic to its own subscribers. If the Cluster supports
+// ClusterStore, the topics which have local subscribers are published there, so the other
+// instances send the messages of a topic only to the instances which have subscribers.
+// The published topics are refreshed periodically and expire if the instance crashes.
+type topicRouter struct {
+	id    string
+	store ClusterStore
+	ttl   time.Duration
+	q     *storeQueue
+
+	mtx    sync.RWMutex
+	subs   map[string]map[connOwner]Conn
+	topics map[connOwner]map[string]struct{}
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newTopicRouter() *topicRouter {
+	return &topicRouter{
+		ttl:    defaultTopicTTL,
+		q:      newStoreQueue(nil),
+		subs:   map[string]map[connOwner]Conn{},
+		topics: map[connOwner]map[string]struct{}{},
+		done:   make(chan struct{}),
+	}
+}
+
+// start refreshes the topics of this instance in the ClusterStore, until stop is called.
+func (r *topicRouter) start() {
+	if r.store == nil {
+		return
+	}
+
+	go r.heartbeat()
+}
+
+func (r *topicRouter) stop() {
+	r.stopOnce.Do(func() { close(r.done) })
+	r.q.flush()
+}
+
+func (r *topicRouter) heartbeat() {
+	t := time.NewTicker(r.ttl / 3)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-t.C:
+			r.refresh()
+		}
+	}
+}
+
+func (r *topicRouter) subscribe(nb *northBridge, conn Conn, topic string) {
+	owner := connOwner{nb: nb, connID: conn.ConnID()}
+
+	r.mtx.Lock()
+	subs, ok := r.subs[topic]
+	first := !ok
+	if first {
+		subs = map[connOwner]Conn{}
+		r.subs[topic] = subs
+	}
+
+	subs[owner] = conn
+
+	topics, ok := r.topics[owner]
+	if !ok {
+		topics = map[string]struct{}{}
+		r.topics[owner] = topics
+	}
+
+	topics[topic] = struct{}{}
+
+	// the store operations are queued under the lock, so they keep the order of the changes.
+	if first {
+		r.publishTopic(topic)
+	}
+	r.mtx.Unlock()
+}
+
+func (r *topicRouter) unsubscribe(nb *northBridge, connID uint64, topic string) {
+	owner := connOwner{nb: nb, connID: connID}
+
+	r.mtx.Lock()
+	removed := r.deleteSub(owner, topic)
+
+	delete(r.topics[owner], topic)
+	if len(r.topics[owner]) == 0 {
+		delete(r.topics, owner)
+	}
+
+	if removed {
+		r.releaseTopics(topic)
+	}
+	r.mtx.Unlock()
+}
+
+// unsubscribeConn removes all the subscriptions of the connection.
+func (r *topicRouter) unsubscribeConn(nb *northBridge, connID uint64) {
+	owner := connOwner{nb: nb, connID: connID}
+
+	var removed []string
+
+	r.mtx.Lock()
+	for topic := range r.topics[owner] {
+		if r.deleteSub(owner, topic) {
+			removed = append(removed, topic)
+		}
+	}
+
+	delete(r.topics, owner)
+	r.releaseTopics(removed...)
+	r.mtx.Unlock()
+}
+
+// deleteSub removes the subscription, and reports if the topic has no local subscriber
+// anymore.
+func (r *topicRouter) deleteSub(owner connOwner, topic string) bool {
+	subs, ok := r.subs[topic]
+	if !ok {
+		return false
+	}
+
+	delete(subs, owner)
+
+	if len(subs) == 0 {
+		delete(r.subs, topic)
+
+		return true
+	}
+
+	return false
+}
+
+// subscribers returns the local connections which are subscribed to the topic.
+func (r *topicRouter) subscribers(topic string) []Conn {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	subs := r.subs[topic]
+	if len(subs) == 0 {
+		return nil
+	}
+
+	conns := make([]Conn, 0, len(subs))
+	for _, conn := range subs {
+		conns = append(conns, conn)
+	}
+
+	return conns
+}
+
+// members returns the ids of the other instances which have subscribers for the topic.
+// It reports false if there is no ClusterStore, hence the members are not known.
+func (r *topicRouter) members(ctx context.Context, topic string) ([]string, bool, error) {
+	if r.store == nil {
+		return nil, false, nil
+	}
+
+	var members []string
+
+	prefix := topicKeyPrefix + topic + "/"
+	err := r.store.ScanWithValue(
+		ctx, prefix,
+		func(key, val string) bool {
+			// the prefix also matches the nested topics, e.g., "a/b/<id>" for "a", hence
+			// we check the whole key.
+			if val != r.id && key == prefix+val {
+				members = append(members, val)
+			}
+
+			return true
+		},
+	)
+	if err != nil {
+		return nil, true, err
+	}
+
+	return members, true, nil
+}
+
+func (r *topicRouter) storeKey(topic string) string {
+	return topicKeyPrefix + topic + "/" + r.id
+}
+
+func (r *topicRouter) publishTopic(topic string) {
+	if r.store == nil {
+		return
+	}
+
+	key := r.storeKey(topic)
+	r.q.push(func(ctx context.Context) error {
+		return r.store.Set(ctx, key, r.id, r.ttl)
+	})
+}
+
+func (r *topicRouter) releaseTopics(topics ...string) {
+	if r.store == nil || len(topics) == 0 {
+		return
+	}
+
+	r.q.push(func(ctx context.Context) error {
+		var err error
+		for _, topic := range topics {
+			if dErr := r.store.Delete(ctx, r.storeKey(topic)); dErr != nil && err == nil {
+				err = dErr
+			}
+		}
+
+		return err
+	})
+}
+
+// refresh publishes the topics of this instance again, which extends their time-to-live.
+func (r *topicRouter) refresh() {
+	r.mtx.RLock()
+	kv := make(map[string]string, len(r.subs))
+	for topic := range r.subs {
+		kv[r.storeKey(topic)] = r.id
+	}
+	r.mtx.RUnlock()
+
+	if len(kv) == 0 {
+		return
+	}
+
+	r.q.push(func(ctx context.Context) error {
+		return r.store.SetMulti(ctx, kv, r.ttl)
+	})
+}
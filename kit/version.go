@@ -0,0 +1,279 @@
+package kit
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderAcceptVersion is the header (or the envelope's header for RPC requests) which the
+	// clients set to select the version of a contract.
+	HeaderAcceptVersion = "Accept-Version"
+	HeaderDeprecation   = "Deprecation"
+	HeaderSunset        = "Sunset"
+)
+
+// VersionedRouteSelector is implemented by the RouteSelectors which belong to a specific
+// version of a contract. Use Versioned or VersionedPath to create one from any RouteSelector.
+type VersionedRouteSelector interface {
+	RouteSelector
+	GetVersion() string
+	// VersionInPath reports whether the version is the prefix of the REST path. If it is
+	// false, the version is negotiated by HeaderAcceptVersion.
+	VersionInPath() bool
+}
+
+// Versioned returns a RouteSelector which registers sel as the given version of the contract.
+// Gateways register the different versions of the same route side by side, and select one
+// by HeaderAcceptVersion. Requests without the header get the latest version, or the
+// unversioned route if there is one.
+func Versioned(sel RouteSelector, version string) RouteSelector {
+	return newVersionedSelector(versionedSelector{sel: sel, version: version})
+}
+
+// VersionedPath is like Versioned, but it prefixes the REST path with the version,
+// e.g., "/v2/users". The RPC routes are still negotiated by HeaderAcceptVersion.
+func VersionedPath(sel RouteSelector, version string) RouteSelector {
+	return newVersionedSelector(versionedSelector{sel: sel, version: version, inPath: true})
+}
+
+// RouteVersion returns the version of the RouteSelector, or an empty string if it is
+// not versioned.
+func RouteVersion(sel RouteSelector) string {
+	vs, ok := sel.(VersionedRouteSelector)
+	if !ok {
+		return ""
+	}
+
+	return vs.GetVersion()
+}
+
+// NegotiatedVersion returns the version of the RouteSelector if it is negotiated by
+// HeaderAcceptVersion. Gateways use it to group the routes which share the same
+// REST path or RPC predicate. It returns an empty string for the unversioned selectors.
+func NegotiatedVersion(sel RouteSelector, rest bool) string {
+	vs, ok := sel.(VersionedRouteSelector)
+	if !ok || (rest && vs.VersionInPath()) {
+		return ""
+	}
+
+	return vs.GetVersion()
+}
+
+func newVersionedSelector(vs versionedSelector) RouteSelector {
+	_, isREST := vs.sel.(RESTRouteSelector)
+	_, isRPC := vs.sel.(RPCRouteSelector)
+
+	switch {
+	case isREST && isRPC:
+		return versionedRESTRPCSelector{vs}
+	case isREST:
+		return versionedRESTSelector{vs}
+	case isRPC:
+		return versionedRPCSelector{vs}
+	default:
+		return vs
+	}
+}
+
+type versionedSelector struct {
+	sel     RouteSelector
+	version string
+	inPath  bool
+}
+
+var _ VersionedRouteSelector = versionedSelector{}
+
+func (s versionedSelector) Query(q string) any    { return s.sel.Query(q) }
+func (s versionedSelector) GetEncoding() Encoding { return s.sel.GetEncoding() }
+func (s versionedSelector) GetVersion() string    { return s.version }
+func (s versionedSelector) VersionInPath() bool   { return s.inPath }
+func (s versionedSelector) Unwrap() RouteSelector { return s.sel }
+func (s versionedSelector) String() string        { return s.sel.String() + "@" + s.version }
+
+func (s versionedSelector) getPredicate() string {
+	rpc, _ := s.sel.(RPCRouteSelector) //nolint:errcheck
+
+	return rpc.GetPredicate()
+}
+
+func (s versionedSelector) getMethod() string {
+	rest, _ := s.sel.(RESTRouteSelector) //nolint:errcheck
+
+	return rest.GetMethod()
+}
+
+func (s versionedSelector) getPath() string {
+	rest, _ := s.sel.(RESTRouteSelector) //nolint:errcheck
+
+	path := rest.GetPath()
+	if !s.inPath || path == "" {
+		return path
+	}
+
+	return "/" + strings.Trim(s.version, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+func (s versionedSelector) isStream() bool {
+	ss, ok := s.sel.(StreamRouteSelector)
+
+	return ok && ss.IsStream()
+}
+
+type versionedRESTSelector struct{ versionedSelector }
+
+var _ StreamRouteSelector = versionedRESTSelector{}
+
+func (s versionedRESTSelector) GetMethod() string { return s.getMethod() }
+func (s versionedRESTSelector) GetPath() string   { return s.getPath() }
+func (s versionedRESTSelector) IsStream() bool    { return s.isStream() }
+
+type versionedRPCSelector struct{ versionedSelector }
+
+var _ RPCRouteSelector = versionedRPCSelector{}
+
+func (s versionedRPCSelector) GetPredicate() string { return s.getPredicate() }
+
+type versionedRESTRPCSelector struct{ versionedSelector }
+
+var (
+	_ StreamRouteSelector = versionedRESTRPCSelector{}
+	_ RPCRouteSelector    = versionedRESTRPCSelector{}
+)
+
+func (s versionedRESTRPCSelector) GetMethod() string    { return s.getMethod() }
+func (s versionedRESTRPCSelector) GetPath() string      { return s.getPath() }
+func (s versionedRESTRPCSelector) IsStream() bool       { return s.isStream() }
+func (s versionedRESTRPCSelector) GetPredicate() string { return s.getPredicate() }
+
+// VersionedRoutes keeps the routes of the different versions of the same REST path or
+// RPC predicate. Gateways use it to register the versions side by side, and to select
+// one by the requested version. The zero value is ready to use, but it is not safe for
+// concurrent writes; the routes are expected to be added before the gateway starts.
+type VersionedRoutes[T any] struct {
+	unversioned    T
+	hasUnversioned bool
+	routes         map[string]T
+	versions       []string
+	latest         string
+}
+
+// Add registers the route for the version. An empty version registers the unversioned route.
+func (vr *VersionedRoutes[T]) Add(version string, route T) {
+	if version == "" {
+		vr.unversioned = route
+		vr.hasUnversioned = true
+
+		return
+	}
+
+	if vr.routes == nil {
+		vr.routes = map[string]T{}
+	}
+
+	v := normalizeVersion(version)
+	if _, ok := vr.routes[v]; !ok {
+		vr.versions = append(vr.versions, version)
+		slices.SortFunc(vr.versions, CompareVersions)
+	}
+
+	vr.routes[v] = route
+
+	if vr.latest == "" || CompareVersions(v, vr.latest) > 0 {
+		vr.latest = v
+	}
+}
+
+// Has reports whether a route is registered for the version.
+func (vr *VersionedRoutes[T]) Has(version string) bool {
+	if version == "" {
+		return vr.hasUnversioned
+	}
+
+	_, ok := vr.routes[normalizeVersion(version)]
+
+	return ok
+}
+
+// Get returns the route of the requested version. If version is empty, it returns the
+// unversioned route if there is one, otherwise the latest version. If there is no versioned
+// route, the requested version is ignored.
+func (vr *VersionedRoutes[T]) Get(version string) (T, bool) {
+	if version != "" && len(vr.routes) > 0 {
+		route, ok := vr.routes[normalizeVersion(version)]
+
+		return route, ok
+	}
+
+	if vr.hasUnversioned {
+		return vr.unversioned, true
+	}
+
+	route, ok := vr.routes[vr.latest]
+
+	return route, ok
+}
+
+// Versions returns the registered versions in ascending order.
+func (vr *VersionedRoutes[T]) Versions() []string {
+	return vr.versions
+}
+
+// CompareVersions compares two versions, e.g., "v1.2" and "v1.10", part by part. The numeric
+// parts are compared by their values, and the others lexically. The leading 'v' is ignored.
+// It returns -1, 0 or +1.
+func CompareVersions(a, b string) int {
+	pa := strings.Split(normalizeVersion(a), ".")
+	pb := strings.Split(normalizeVersion(b), ".")
+
+	for i := range min(len(pa), len(pb)) {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+
+		var c int
+		if errA == nil && errB == nil {
+			c = cmp.Compare(na, nb)
+		} else {
+			c = strings.Compare(pa[i], pb[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(pa), len(pb))
+}
+
+func normalizeVersion(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+
+	return strings.TrimPrefix(v, "v")
+}
+
+// Deprecated returns a ContractWrapper which marks the responses of the contract by
+// HeaderDeprecation, and HeaderSunset if sunset is not zero. The headers are set on
+// every envelope of the response, hence the RPC clients also receive them.
+func Deprecated(sunset time.Time) ContractWrapper {
+	hdr := map[string]string{
+		HeaderDeprecation: "true",
+	}
+	if !sunset.IsZero() {
+		hdr[HeaderSunset] = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return ContractWrapperFunc(func(c Contract) Contract {
+		return &contractWrap{
+			Contract: c,
+			h: []HandlerFunc{
+				func(ctx *Context) {
+					ctx.PresetHdrMap(hdr)
+				},
+			},
+		}
+	})
+}
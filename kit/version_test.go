@@ -0,0 +1,145 @@
+package kit
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVersionedSelector(t *testing.T) {
+	rest := testRESTSelector{method: http.MethodGet, path: "/users/{id}", encoding: JSON}
+
+	sel := Versioned(rest, "v2")
+	rs, ok := sel.(RESTRouteSelector)
+	if !ok {
+		t.Fatalf("expected REST selector, got: %T", sel)
+	}
+	if _, ok = sel.(RPCRouteSelector); ok {
+		t.Fatalf("unexpected RPC selector: %T", sel)
+	}
+	if rs.GetPath() != "/users/{id}" || rs.GetMethod() != http.MethodGet {
+		t.Fatalf("unexpected route: %s %s", rs.GetMethod(), rs.GetPath())
+	}
+	if RouteVersion(sel) != "v2" || NegotiatedVersion(sel, true) != "v2" {
+		t.Fatalf("unexpected version: %s", RouteVersion(sel))
+	}
+
+	sel = VersionedPath(rest, "v2")
+	rs, _ = sel.(RESTRouteSelector)
+	if rs.GetPath() != "/v2/users/{id}" {
+		t.Fatalf("unexpected path: %s", rs.GetPath())
+	}
+	if NegotiatedVersion(sel, true) != "" || NegotiatedVersion(sel, false) != "v2" {
+		t.Fatalf("unexpected negotiated version")
+	}
+
+	sel = Versioned(testRPCSelector{predicate: "getUser", encoding: JSON}, "v3")
+	rpc, ok := sel.(RPCRouteSelector)
+	if !ok || rpc.GetPredicate() != "getUser" {
+		t.Fatalf("unexpected RPC selector: %T", sel)
+	}
+	if _, ok = sel.(RESTRouteSelector); ok {
+		t.Fatalf("unexpected REST selector: %T", sel)
+	}
+
+	if RouteVersion(rest) != "" {
+		t.Fatalf("unexpected version for unversioned selector")
+	}
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	vr := VersionedRoutes[string]{}
+	if _, ok := vr.Get(""); ok {
+		t.Fatal("expected no route")
+	}
+
+	vr.Add("", "default")
+	if r, ok := vr.Get("v9"); !ok || r != "default" {
+		t.Fatalf("expected unversioned route to ignore the version, got: %s", r)
+	}
+
+	vr.Add("v1", "one")
+	vr.Add("v10", "ten")
+	vr.Add("v2", "two")
+
+	for _, tc := range []struct {
+		version string
+		route   string
+		ok      bool
+	}{
+		{"", "default", true},
+		{"v1", "one", true},
+		{"2", "two", true},
+		{"V10", "ten", true},
+		{"v3", "", false},
+	} {
+		r, ok := vr.Get(tc.version)
+		if ok != tc.ok || r != tc.route {
+			t.Fatalf("version %q: unexpected route: %q, %v", tc.version, r, ok)
+		}
+	}
+
+	if strings.Join(vr.Versions(), ",") != "v1,v2,v10" {
+		t.Fatalf("unexpected versions: %v", vr.Versions())
+	}
+	if !vr.Has("v1") || !vr.Has("") || vr.Has("v3") {
+		t.Fatal("unexpected Has result")
+	}
+
+	latest := VersionedRoutes[string]{}
+	latest.Add("v1", "one")
+	latest.Add("v1.10", "one-ten")
+	latest.Add("v1.9", "one-nine")
+	if r, _ := latest.Get(""); r != "one-ten" {
+		t.Fatalf("expected the latest version, got: %s", r)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		res  int
+	}{
+		{"v1", "v2", -1},
+		{"v10", "v9", 1},
+		{"1.2", "v1.2", 0},
+		{"v1", "v1.1", -1},
+		{"v2-beta", "v2-alpha", 1},
+	} {
+		if res := CompareVersions(tc.a, tc.b); res != tc.res {
+			t.Fatalf("CompareVersions(%q, %q) = %d, expected %d", tc.a, tc.b, res, tc.res)
+		}
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	sunset := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := WrapContract(
+		&testContract{
+			handlers: []HandlerFunc{
+				func(ctx *Context) {
+					ctx.Out().SetMsg(RawMessage("ok")).Send()
+				},
+			},
+		},
+		Deprecated(sunset),
+	)
+
+	err := NewTestContext().
+		SetHandler(c.Handlers()...).
+		Expect(func(e *Envelope) error {
+			if e.GetHdr(HeaderDeprecation) != "true" {
+				t.Fatalf("unexpected deprecation header: %q", e.GetHdr(HeaderDeprecation))
+			}
+			if e.GetHdr(HeaderSunset) != "Wed, 02 Jan 2030 03:04:05 GMT" {
+				t.Fatalf("unexpected sunset header: %q", e.GetHdr(HeaderSunset))
+			}
+
+			return nil
+		}).
+		Run(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
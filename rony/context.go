@@ -220,6 +220,23 @@ func (c *StreamCtx[S, A, M]) PushTo(conn kit.Conn, m M, opt ...PushOpt) {
 	e.Send()
 }
 
+// Subscribe subscribes the connection to the topic. The subscription is removed when the
+// connection is closed.
+func (c *StreamCtx[S, A, M]) Subscribe(topic string) error {
+	return c.ctx.Subscribe(topic)
+}
+
+// Unsubscribe removes the subscription of the connection to the topic.
+func (c *StreamCtx[S, A, M]) Unsubscribe(topic string) error {
+	return c.ctx.Unsubscribe(topic)
+}
+
+// Publish pushes m to the connections which are subscribed to the topic.
+// Use kit.PublishInCluster to push it to the subscribers of the other instances too.
+func (c *StreamCtx[S, A, M]) Publish(topic string, m M, opt ...kit.PublishOption) error {
+	return c.ctx.Publish(topic, m, opt...)
+}
+
 /*
 	RelayCtx
 */
@@ -100,6 +100,29 @@ func (s *Server) LogEndpoints(w io.Writer) {
 	s.edge.LogEndpoints(w)
 }
 
+// Replay executes the contract of the recording, and compares the responses with the recorded
+// ones. The server must be started.
+func (s *Server) Replay(ctx context.Context, rec *kit.Recording, opts ...kit.ReplayOption) (*kit.ReplayResult, error) {
+	return s.edge.Replay(ctx, rec, opts...)
+}
+
+// ListConns returns the live connections which match the filter. The server must be started
+// with the WithConnRegistry option.
+func (s *Server) ListConns(ctx context.Context, filter kit.ConnFilter) ([]kit.ConnInfo, error) {
+	return s.edge.ListConns(ctx, filter)
+}
+
+// KickConn closes the connection. The server must be started with the WithConnRegistry option.
+func (s *Server) KickConn(ctx context.Context, ref kit.ConnRef) error {
+	return s.edge.KickConn(ctx, ref)
+}
+
+// PushToConn sends the message to the connection. The server must be started with the
+// WithConnRegistry option.
+func (s *Server) PushToConn(ctx context.Context, ref kit.ConnRef, msg kit.Message, hdr map[string]string) error {
+	return s.edge.PushToConn(ctx, ref, msg, hdr)
+}
+
 // Run the service in blocking mode. If you need more control over the
 // lifecycle of the service, you can use the Start and Stop methods.
 func (s *Server) Run(ctx context.Context, signals ...os.Signal) error {
@@ -1,6 +1,7 @@
 package rony
 
 import (
+	"crypto/tls"
 	"io/fs"
 	"time"
 
@@ -147,6 +148,23 @@ func WithCustomRPC(in kit.IncomingRPCFactory, out kit.OutgoingRPCFactory) Server
 	}
 }
 
+// WithWebsocketBinaryMode writes the websocket messages as binary frames. Use it with
+// binary RPC containers, e.g., common.SimpleIncomingProtoRPC and common.SimpleOutgoingProtoRPC.
+func WithWebsocketBinaryMode() ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.gatewayOpts = append(cfg.gatewayOpts, fasthttp.WithWebsocketBinaryMode())
+	}
+}
+
+// WithJSONRPC2 switches the websocket RPC protocol to JSON-RPC 2.0, so the JSON-RPC clients
+// can call the stream handlers by their predicate as the method. If path is not empty, the
+// requests are also accepted by HTTP POST on path.
+func WithJSONRPC2(path string) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.gatewayOpts = append(cfg.gatewayOpts, fasthttp.WithJSONRPC2(path))
+	}
+}
+
 func WithTracer(tracer kit.Tracer) ServerOption {
 	return func(cfg *serverConfig) {
 		cfg.edgeOpts = append(cfg.edgeOpts, kit.WithTrace(tracer))
@@ -171,6 +189,14 @@ func WithShutdownTimeout(timeout time.Duration) ServerOption {
 	}
 }
 
+// WithContractTimeout sets the default deadline for the contracts which do not set
+// their own timeout using UnaryTimeout.
+func WithContractTimeout(timeout time.Duration) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.edgeOpts = append(cfg.edgeOpts, kit.WithContractTimeout(timeout))
+	}
+}
+
 func WithGlobalHandlers(handlers ...kit.HandlerFunc) ServerOption {
 	return func(cfg *serverConfig) {
 		cfg.edgeOpts = append(cfg.edgeOpts, kit.WithGlobalHandlers(handlers...))
@@ -218,3 +244,77 @@ func WithErrorHandler(handler kit.ErrHandlerFunc) ServerOption {
 		cfg.edgeOpts = append(cfg.edgeOpts, kit.WithErrorHandler(handler))
 	}
 }
+
+// WithPanicMessage sets the error message which is sent to the client when a handler panics.
+func WithPanicMessage(msg kit.ErrorMessage) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.edgeOpts = append(cfg.edgeOpts, kit.WithPanicMessage(msg))
+	}
+}
+
+// WithConnRegistry records the live websocket and SSE connections, so they could be listed,
+// kicked and receive messages by Server.ListConns, Server.KickConn and Server.PushToConn, or
+// by the admin service which is enabled by kit.ConnAdmin. Check kit.WithConnRegistry for
+// more details.
+func WithConnRegistry(opts ...kit.ConnRegistryOption) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.edgeOpts = append(cfg.edgeOpts, kit.WithConnRegistry(opts...))
+	}
+}
+
+// WithRecorder records the requests and their responses to the sink, so they could be
+// replayed by Server.Replay or kit.TestContext.Replay. Check kit.WithRecorder for more details.
+func WithRecorder(sink kit.RecordSink, opts ...kit.RecorderOption) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.edgeOpts = append(cfg.edgeOpts, kit.WithRecorder(sink, opts...))
+	}
+}
+
+// WithOutboundQueue bounds the outbound queue of each websocket and SSE connection. Check
+// fasthttp.WithOutboundQueue for more details.
+func WithOutboundQueue(size int, policy kit.OverflowPolicy) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.gatewayOpts = append(cfg.gatewayOpts, fasthttp.WithOutboundQueue(size, policy))
+	}
+}
+
+// WithListenNetwork sets the network of the listener, e.g., "tcp" for dual-stack IPv4 and
+// IPv6. Check fasthttp.WithListenNetwork for more details.
+func WithListenNetwork(network string) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.gatewayOpts = append(cfg.gatewayOpts, fasthttp.WithListenNetwork(network))
+	}
+}
+
+// WithTLS serves the requests over TLS by the certificate and the key files, which are
+// reloaded when they are renewed. Check fasthttp.WithTLS for more details.
+func WithTLS(certFile, keyFile string) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.gatewayOpts = append(cfg.gatewayOpts, fasthttp.WithTLS(certFile, keyFile))
+	}
+}
+
+// WithTLSConfig serves the requests over TLS by the config. Check fasthttp.WithTLSConfig
+// for more details.
+func WithTLSConfig(tlsCfg *tls.Config) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.gatewayOpts = append(cfg.gatewayOpts, fasthttp.WithTLSConfig(tlsCfg))
+	}
+}
+
+// WithClientCA verifies the client certificates (mTLS) by the CA file, and exposes the
+// peer identity by the fasthttp.ClientCert keys of the connection. Check
+// fasthttp.WithClientCA for more details.
+func WithClientCA(caFile string, auth tls.ClientAuthType) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.gatewayOpts = append(cfg.gatewayOpts, fasthttp.WithClientCA(caFile, auth))
+	}
+}
+
+// WithTLSReloadInterval sets how often the files of WithTLS and WithClientCA are checked
+// for modifications. Check fasthttp.WithTLSReloadInterval for more details.
+func WithTLSReloadInterval(d time.Duration) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.gatewayOpts = append(cfg.gatewayOpts, fasthttp.WithTLSReloadInterval(d))
+	}
+}
@@ -2,6 +2,7 @@ package rony
 
 import (
 	"context"
+	"crypto/tls"
 	"testing"
 	"testing/fstest"
 	"time"
@@ -30,15 +31,25 @@ func TestServerOptionsApply(t *testing.T) {
 	WithPredicateKey("cmd")(&cfg)
 	WithWebsocketEndpoint("/ws")(&cfg)
 	WithCustomRPC(nil, nil)(&cfg)
+	WithJSONRPC2("/rpc")(&cfg)
 	WithTracer(dummyTracer{})(&cfg)
 	WithLogger(kit.NOPLogger{})(&cfg)
 	WithPrefork()(&cfg)
 	WithShutdownTimeout(time.Second)(&cfg)
+	WithContractTimeout(time.Second)(&cfg)
 	WithGlobalHandlers(func(*kit.Context) {})(&cfg)
 	WithDisableHeaderNamesNormalizing()(&cfg)
 	WithAPIDocs("/docs")(&cfg)
 	WithServerFS("/static", ".", fstest.MapFS{"index.html": {Data: []byte("ok")}})(&cfg)
 	WithErrorHandler(func(*kit.Context, error) {})(&cfg)
+	WithPanicMessage(kit.NewError(500, "INTERNAL"))(&cfg)
+	WithConnRegistry(kit.ConnAdmin("/admin/conns", "", func(*kit.Context) {}))(&cfg)
+	WithRecorder(kit.ChannelSink(make(chan *kit.Recording)), kit.RecordSampleRate(0.1))(&cfg)
+	WithListenNetwork("tcp")(&cfg)
+	WithTLS("cert.pem", "key.pem")(&cfg)
+	WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12})(&cfg)
+	WithClientCA("ca.pem", tls.RequireAndVerifyClientCert)(&cfg)
+	WithTLSReloadInterval(time.Minute)(&cfg)
 	UseSwaggerUI()(&cfg)
 	UseRedocUI()(&cfg)
 	UseScalarUI()(&cfg)
@@ -59,6 +59,10 @@ func registerStream[IN, OUT Message, S State[A], A Action](
 	c.In(&in, cfg.InputMetaOptions...).
 		Out(&out, cfg.OutputMetaOptions...)
 
+	if cfg.Overflow != kit.OverflowDefault {
+		c.SetOverflowPolicy(cfg.Overflow)
+	}
+
 	setupCtx.cfg.getService(setupCtx.name).AddContract(c)
 }
 
@@ -78,6 +82,15 @@ func StreamOutputMeta(opt ...desc.MessageMetaOption) StreamOption {
 	}
 }
 
+// StreamOverflow sets the policy of the outbound queues when the handler pushes to a
+// connection whose queue is full. It requires the server to be configured by
+// WithOutboundQueue.
+func StreamOverflow(p kit.OverflowPolicy) StreamOption {
+	return func(cfg *streamConfig) {
+		cfg.Overflow = p
+	}
+}
+
 // RPC is a StreamOption to set up a websocket RPC handler.
 func RPC(predicate string, opt ...StreamSelectorOption) StreamOption {
 	return func(cfg *streamConfig) {
@@ -112,6 +125,7 @@ type streamConfig struct {
 	Selectors         []streamSelectorConfig
 	InputMetaOptions  []desc.MessageMetaOption
 	OutputMetaOptions []desc.MessageMetaOption
+	Overflow          kit.OverflowPolicy
 }
 
 func genStreamConfig(opt ...StreamOption) streamConfig {
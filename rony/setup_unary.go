@@ -5,6 +5,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/clubpay/ronykit/kit"
 	"github.com/clubpay/ronykit/kit/desc"
@@ -62,6 +63,9 @@ func registerUnary[IN, OUT Message, S State[A], A Action](
 	c.
 		Out(&out, cfg.OutputMetaOptions...).
 		SetDefaultError(&errs.Error{}).
+		SetTimeout(cfg.Timeout).
+		SetValidation(cfg.Validation).
+		SetVersion(cfg.Version).
 		SetHandler(handlers...)
 
 	if setupCtx.nodeSel != nil {
@@ -131,6 +135,9 @@ func registerRawUnary[IN Message, S State[A], A Action](
 
 	c.
 		Out(out, cfg.OutputMetaOptions...).
+		SetTimeout(cfg.Timeout).
+		SetValidation(cfg.Validation).
+		SetVersion(cfg.Version).
 		SetHandler(handlers...)
 
 	if setupCtx.nodeSel != nil {
@@ -311,6 +318,32 @@ func OPTIONS(path string, opt ...UnarySelectorOption) UnaryOption {
 	return REST("OPTIONS", path, opt...)
 }
 
+// UnaryTimeout sets the maximum duration that the handler is allowed to run. When the
+// deadline is exceeded, the ctx.Context() is canceled, and the client receives a timeout
+// error if no response has been sent yet.
+func UnaryTimeout(timeout time.Duration) UnaryOption {
+	return func(cfg *unaryConfig) {
+		cfg.Timeout = timeout
+	}
+}
+
+// UnaryValidation validates the input message by the rules of its `swag` struct tags and
+// UnaryInputMeta before the handler runs. Check desc.FieldRules for the supported rules.
+func UnaryValidation() UnaryOption {
+	return func(cfg *unaryConfig) {
+		cfg.Validation = true
+	}
+}
+
+// UnaryVersion registers the contract as the given version. The different versions of
+// the same route are registered side by side, and the clients select one by the
+// kit.HeaderAcceptVersion header. Requests without the header get the latest version.
+func UnaryVersion(version string) UnaryOption {
+	return func(cfg *unaryConfig) {
+		cfg.Version = version
+	}
+}
+
 func UnaryMiddleware(
 	mw ...StatelessMiddleware,
 ) UnaryOption {
@@ -333,6 +366,9 @@ type unaryConfig struct {
 	Headers           []desc.Header
 	InputMetaOptions  []desc.MessageMetaOption
 	OutputMetaOptions []desc.MessageMetaOption
+	Timeout           time.Duration
+	Validation        bool
+	Version           string
 }
 
 func genUnaryConfig(opt ...UnaryOption) unaryConfig {
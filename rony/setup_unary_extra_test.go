@@ -3,6 +3,7 @@ package rony
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/clubpay/ronykit/kit"
 	"github.com/clubpay/ronykit/kit/desc"
@@ -117,6 +118,9 @@ func TestUnaryOptionHelpers(t *testing.T) {
 	UnaryHeader(RequiredHeader("x"), OptionalHeader("y"))(&cfg)
 	UnaryMiddleware(func(*kit.Context) {})(&cfg)
 	UnaryMiddlewareFn(func() StatelessMiddleware { return func(*kit.Context) {} })(&cfg)
+	UnaryTimeout(time.Second)(&cfg)
+	UnaryValidation()(&cfg)
+	UnaryVersion("v2")(&cfg)
 
 	if len(cfg.Selectors) == 0 {
 		t.Fatal("expected selectors to be set")
@@ -130,6 +134,15 @@ func TestUnaryOptionHelpers(t *testing.T) {
 	if cfg.InputMetaOptions == nil || cfg.OutputMetaOptions == nil {
 		t.Fatal("expected meta options to be set")
 	}
+	if cfg.Timeout != time.Second {
+		t.Fatalf("unexpected timeout: %v", cfg.Timeout)
+	}
+	if !cfg.Validation {
+		t.Fatal("expected validation to be enabled")
+	}
+	if cfg.Version != "v2" {
+		t.Fatalf("unexpected version: %s", cfg.Version)
+	}
 }
 
 func TestSetupRawUnary(t *testing.T) {
@@ -27,8 +27,11 @@ type cluster struct {
 }
 
 var (
-	_ kit.Cluster      = (*cluster)(nil)
-	_ kit.ClusterStore = (*cluster)(nil)
+	_ kit.Cluster             = (*cluster)(nil)
+	_ kit.ClusterStore        = (*cluster)(nil)
+	_ kit.ClusterDeregisterer = (*cluster)(nil)
+
+	_ kit.ClusterStoreCompareAndDeleter = (*cluster)(nil)
 )
 
 func New(name string, opts ...Option) (kit.Cluster, error) {
@@ -106,6 +109,12 @@ func (c *cluster) Start(ctx context.Context) error { //nolint:contextcheck
 	return nil
 }
 
+// Deregister implements kit.ClusterDeregisterer. It removes this instance from the
+// Subscribers, but keeps listening for the in-flight messages until Shutdown.
+func (c *cluster) Deregister(ctx context.Context) error {
+	return c.rc.HDel(ctx, fmt.Sprintf("%s:instances", c.prefix), c.id).Err()
+}
+
 func (c *cluster) Shutdown(ctx context.Context) error {
 	c.shutdownFn()
 
@@ -155,6 +164,16 @@ func (c *cluster) Delete(ctx context.Context, key string) error {
 	).Err()
 }
 
+func (c *cluster) CompareAndDelete(ctx context.Context, key, value string) (bool, error) {
+	n, err := luaCAD.Run(
+		ctx, c.rc,
+		[]string{fmt.Sprintf("%s:kv:%s", c.prefix, key)},
+		value,
+	).Int()
+
+	return n > 0, err
+}
+
 func (c *cluster) Get(ctx context.Context, key string) (string, error) {
 	return c.rc.Get(
 		ctx,
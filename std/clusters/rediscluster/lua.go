@@ -10,4 +10,8 @@ var (
 	//go:embed lua/gc.lua
 	luaScript string
 	luaGC     = redis.NewScript(luaScript)
+
+	//go:embed lua/cad.lua
+	luaCADScript string
+	luaCAD       = redis.NewScript(luaCADScript)
 )
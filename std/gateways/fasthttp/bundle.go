@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"mime/multipart"
 	"net"
@@ -39,6 +40,7 @@ type bundle struct {
 	d        kit.GatewayDelegate
 	srv      *fasthttp.Server
 	listen   string
+	network  string
 	connPool sync.Pool
 	cors     *cors
 	utils    util
@@ -46,25 +48,46 @@ type bundle struct {
 	reverseProxyPath string
 	reverseProxy     *proxy.ReverseProxy
 	httpRouter       *router.Router
+	restRoutes       map[string]*kit.VersionedRoutes[fasthttp.RequestHandler]
 	compress         CompressionLevel
 	autoDecompress   bool
+	tlsOpts          tlsOptions
+	tlsConfig        *tls.Config
 
 	wsUpgrade     websocket.FastHTTPUpgrader
-	rpcRoutes     map[string]*routeData
+	rpcRoutes     map[string]*kit.VersionedRoutes[*routeData]
 	wsEndpoint    string
 	wsNextID      atomic.Uint64
 	predicateKey  string
 	rpcInFactory  kit.IncomingRPCFactory
 	rpcOutFactory kit.OutgoingRPCFactory
+	wsBinary      bool
+	jsonrpc2      bool
+	jsonrpc2Path  string
+	queueSize     int
+	queuePolicy   kit.OverflowPolicy
+
+	// streamsMtx protects wsConns and sseConns, which are the open streams that we need
+	// to close gracefully when draining.
+	draining   atomic.Bool
+	streamsMtx sync.Mutex
+	wsConns    map[uint64]*wsConn
+	sseConns   map[*sseHTTPConn]struct{}
 }
 
-var _ kit.Gateway = (*bundle)(nil)
+var (
+	_ kit.Gateway        = (*bundle)(nil)
+	_ kit.GatewayDrainer = (*bundle)(nil)
+)
 
 func New(opts ...Option) (kit.Gateway, error) {
 	r := &bundle{
 		httpRouter: router.New(),
 		compress:   CompressionLevelDefault,
-		rpcRoutes:  map[string]*routeData{},
+		restRoutes: map[string]*kit.VersionedRoutes[fasthttp.RequestHandler]{},
+		rpcRoutes:  map[string]*kit.VersionedRoutes[*routeData]{},
+		wsConns:    map[uint64]*wsConn{},
+		sseConns:   map[*sseHTTPConn]struct{}{},
 		srv: &fasthttp.Server{
 			MaxRequestBodySize: fasthttp.DefaultMaxRequestBodySize,
 		},
@@ -72,6 +95,9 @@ func New(opts ...Option) (kit.Gateway, error) {
 		rpcOutFactory: common.SimpleOutgoingJSONRPC,
 		l:             common.NewNopLogger(),
 		utils:         defaultUtil(),
+		tlsOpts: tlsOptions{
+			reload: defaultTLSReloadInterval,
+		},
 	}
 
 	r.wsUpgrade.CheckOrigin = func(ctx *fasthttp.RequestCtx) bool {
@@ -81,6 +107,15 @@ func New(opts ...Option) (kit.Gateway, error) {
 		opt(r)
 	}
 
+	if r.tlsOpts.enabled() {
+		var err error
+
+		r.tlsConfig, err = r.tlsOpts.listenerConfig(r.l)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	r.httpRouter.HandleOPTIONS = true
 	r.httpRouter.GlobalOPTIONS = r.cors.handle
 
@@ -114,7 +149,11 @@ func New(opts ...Option) (kit.Gateway, error) {
 		r.httpRouter.GET(r.wsEndpoint, r.wsHandler)
 	}
 
-	r.srv.Handler = httpHandler
+	if r.jsonrpc2 && r.jsonrpc2Path != "" {
+		r.httpRouter.POST(r.jsonrpc2Path, r.jsonrpc2Handler)
+	}
+
+	r.srv.Handler = r.drainHandler(httpHandler)
 
 	return r, nil
 }
@@ -136,6 +175,7 @@ type routeData struct {
 	ContractID  string
 	Decoder     DecoderFunc
 	Factory     kit.MessageFactoryFunc
+	Encoding    kit.Encoding
 	Stream      bool
 }
 
@@ -168,7 +208,17 @@ func (b *bundle) registerRPC(
 		Factory:     kit.CreateMessageFactory(input),
 	}
 
-	b.rpcRoutes[rd.Predicate] = rd
+	b.addRPCRoute(kit.NegotiatedVersion(sel, false), rd)
+}
+
+func (b *bundle) addRPCRoute(version string, rd *routeData) {
+	vr := b.rpcRoutes[rd.Predicate]
+	if vr == nil {
+		vr = &kit.VersionedRoutes[*routeData]{}
+		b.rpcRoutes[rd.Predicate] = vr
+	}
+
+	vr.Add(version, rd)
 }
 
 func (b *bundle) registerREST(
@@ -193,19 +243,47 @@ func (b *bundle) registerREST(
 		stream = ss.IsStream()
 	}
 
-	b.httpRouter.Handle(
-		restSelector.GetMethod(), restSelector.GetPath(),
-		b.genHTTPHandler(
-			routeData{
-				ServiceName: svcName,
-				ContractID:  contractID,
-				Method:      restSelector.GetMethod(),
-				Path:        restSelector.GetPath(),
-				Decoder:     decoder,
-				Stream:      stream,
-			},
-		),
+	h := b.genHTTPHandler(
+		routeData{
+			ServiceName: svcName,
+			ContractID:  contractID,
+			Method:      restSelector.GetMethod(),
+			Path:        restSelector.GetPath(),
+			Decoder:     decoder,
+			Encoding:    enc,
+			Stream:      stream,
+		},
 	)
+
+	// The different versions of the same route share one handler in the router,
+	// which selects the version by the Accept-Version header.
+	version := kit.NegotiatedVersion(sel, true)
+	key := restSelector.GetMethod() + " " + restSelector.GetPath()
+	vr := b.restRoutes[key]
+	switch {
+	case vr == nil:
+		vr = &kit.VersionedRoutes[fasthttp.RequestHandler]{}
+		b.restRoutes[key] = vr
+		b.httpRouter.Handle(restSelector.GetMethod(), restSelector.GetPath(), genVersionedHandler(vr))
+	case vr.Has(version):
+		// let the router complain about the duplicate route
+		b.httpRouter.Handle(restSelector.GetMethod(), restSelector.GetPath(), h)
+	}
+
+	vr.Add(version, h)
+}
+
+func genVersionedHandler(vr *kit.VersionedRoutes[fasthttp.RequestHandler]) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		h, ok := vr.Get(utils.B2S(ctx.Request.Header.Peek(kit.HeaderAcceptVersion)))
+		if !ok {
+			ctx.SetStatusCode(fasthttp.StatusNotFound)
+
+			return
+		}
+
+		h(ctx)
+	}
 }
 
 func (b *bundle) genHTTPHandler(rd routeData) fasthttp.RequestHandler {
@@ -262,6 +340,22 @@ func (b *bundle) genSSEHTTPHandler(rd routeData) fasthttp.RequestHandler {
 			}()
 
 			c.attachWriter(w)
+			if b.queueSize > 0 {
+				c.q = kit.NewOutboundQueue(
+					b.queueSize, b.queuePolicy, c.writeNow,
+					func() {
+						// closing the connection unblocks the writer, if the client does not read.
+						_ = ctx.Conn().Close()
+						c.attachWriter(nil)
+					},
+				)
+				// the stream is closed when the handler returns, so we write the queued
+				// events before that.
+				defer c.drainQueue()
+			}
+			b.trackSSE(c)
+			defer b.untrackSSE(c)
+
 			b.d.OnOpen(c)
 
 			var body []byte
@@ -288,7 +382,7 @@ func (b *bundle) Dispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
 	switch ctx.Conn().(type) {
 	case *httpConn, *sseHTTPConn:
 		return b.httpDispatch(ctx, in)
-	case *wsConn:
+	case *wsConn, *common.JSONRPC2Conn:
 		return b.rpcDispatch(ctx, in)
 	default:
 		panic("BUG!! incorrect connection")
@@ -296,16 +390,34 @@ func (b *bundle) Dispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
 }
 
 func (b *bundle) wsHandler(ctx *fasthttp.RequestCtx) {
+	// the peer identity of the TLS connections is kept by the key-values of the connection.
+	kv := map[string]string{}
+	if ctx.IsTLS() {
+		walkClientCert(
+			ctx.TLSConnectionState(),
+			func(key, val string) bool {
+				kv[key] = val
+
+				return true
+			},
+		)
+	}
+
 	_ = b.wsUpgrade.Upgrade(
 		ctx,
 		func(conn *websocket.Conn) {
 			wsc := &wsConn{
-				kv:            map[string]string{},
+				kv:            kv,
 				id:            b.wsNextID.Add(1),
 				clientIP:      realip.FromRequest(ctx),
 				c:             conn,
 				rpcOutFactory: b.rpcOutFactory,
+				binary:        b.wsBinary,
+			}
+			if b.queueSize > 0 {
+				wsc.q = kit.NewOutboundQueue(b.queueSize, b.queuePolicy, wsc.writeNow, wsc.Close)
 			}
+			b.trackWS(wsc)
 			b.d.OnOpen(wsc)
 
 			for {
@@ -318,6 +430,7 @@ func (b *bundle) wsHandler(ctx *fasthttp.RequestCtx) {
 				go b.wsHandlerExec(inBuf, wsc)
 			}
 
+			b.untrackWS(wsc)
 			wsc.Close()
 			b.d.OnClose(wsc.id)
 		},
@@ -325,11 +438,75 @@ func (b *bundle) wsHandler(ctx *fasthttp.RequestCtx) {
 }
 
 func (b *bundle) wsHandlerExec(buf *buf.Bytes, wsc *wsConn) {
-	b.d.OnMessage(wsc, *buf.Bytes())
+	if b.jsonrpc2 {
+		res := common.ServeJSONRPC2(b.d, wsc, *buf.Bytes(), b.hasRPCRoute)
+		if res != nil {
+			_, err := wsc.Write(res)
+			if err != nil {
+				b.l.Errorf("[Gateway][fasthttp] could not write the json-rpc response: %v", err)
+			}
+		}
+	} else {
+		b.d.OnMessage(wsc, *buf.Bytes())
+	}
+
 	buf.Release()
 }
 
+// jsonrpc2Handler serves the JSON-RPC 2.0 requests over HTTP POST. The request, or the
+// batch of requests, is answered in the body of the response, and if there is nothing to
+// reply, e.g., the request is a notification, the status is 204.
+func (b *bundle) jsonrpc2Handler(ctx *fasthttp.RequestCtx) {
+	b.cors.handle(ctx)
+
+	c, ok := b.connPool.Get().(*httpConn)
+	if !ok {
+		c = &httpConn{}
+	}
+
+	c.ctx = ctx
+	c.rd = nil
+	b.d.OnOpen(c)
+
+	var (
+		body []byte
+		err  error
+	)
+	if b.autoDecompress {
+		body, err = c.getBodyUncompressed()
+	} else {
+		body = ctx.PostBody()
+	}
+
+	if err != nil {
+		b.l.Errorf("[Gateway][fasthttp] could not uncompress the body: %v", err)
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+	} else if res := common.ServeJSONRPC2(b.d, c, body, b.hasRPCRoute); res != nil {
+		ctx.SetContentType(kit.ContentTypeJSON)
+		ctx.SetBody(res)
+	} else {
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	}
+
+	b.d.OnClose(c.ConnID())
+
+	c.bb.Reset()
+	b.connPool.Put(c)
+}
+
+func (b *bundle) hasRPCRoute(predicate string) bool {
+	return b.rpcRoutes[predicate] != nil
+}
+
 func (b *bundle) rpcDispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
+	if jc, ok := ctx.Conn().(*common.JSONRPC2Conn); ok {
+		// The container is parsed, and released, by common.ServeJSONRPC2.
+		arg, err := b.dispatchRPCContainer(ctx, jc.Container())
+		jc.SetDispatchError(err)
+
+		return arg, err
+	}
+
 	if len(in) == 0 {
 		return noExecuteArg, kit.ErrDecodeIncomingContainerFailed
 	}
@@ -341,8 +518,29 @@ func (b *bundle) rpcDispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error
 		return noExecuteArg, err
 	}
 
-	routeData := b.rpcRoutes[inputMsgContainer.GetHdr(b.predicateKey)]
-	if routeData == nil {
+	arg, err := b.dispatchRPCContainer(ctx, inputMsgContainer)
+	if err != nil {
+		return noExecuteArg, err
+	}
+
+	// release the container
+	inputMsgContainer.Release()
+
+	return arg, nil
+}
+
+func (b *bundle) dispatchRPCContainer(
+	ctx *kit.Context, inputMsgContainer kit.IncomingRPCContainer,
+) (kit.ExecuteArg, error) {
+	var err error
+
+	vr := b.rpcRoutes[inputMsgContainer.GetHdr(b.predicateKey)]
+	if vr == nil {
+		return noExecuteArg, kit.ErrNoHandler
+	}
+
+	routeData, ok := vr.Get(inputMsgContainer.GetHdr(kit.HeaderAcceptVersion))
+	if !ok {
 		return noExecuteArg, kit.ErrNoHandler
 	}
 
@@ -381,9 +579,6 @@ func (b *bundle) rpcDispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error
 		SetHdrMap(inputMsgContainer.GetHdrMap()).
 		SetMsg(msg)
 
-	// release the container
-	inputMsgContainer.Release()
-
 	return kit.ExecuteArg{
 		ServiceName: routeData.ServiceName,
 		ContractID:  routeData.ContractID,
@@ -432,16 +627,7 @@ func (b *bundle) httpDispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, erro
 }
 
 func (b *bundle) Start(ctx context.Context, cfg kit.GatewayStartConfig) error {
-	var (
-		ln  net.Listener
-		err error
-	)
-	if cfg.ReusePort {
-		ln, err = reuseport.Listen("tcp4", b.listen)
-	} else {
-		ln, err = (&net.ListenConfig{}).Listen(ctx, "tcp4", b.listen)
-	}
-
+	ln, err := b.newListener(ctx, cfg.ReusePort)
 	if err != nil {
 		return err
 	}
@@ -457,6 +643,36 @@ func (b *bundle) Start(ctx context.Context, cfg kit.GatewayStartConfig) error {
 	return nil
 }
 
+func (b *bundle) newListener(ctx context.Context, reusePort bool) (net.Listener, error) {
+	var (
+		ln      net.Listener
+		err     error
+		network = listenNetwork(b.network, b.listen)
+	)
+	if reusePort {
+		addr := b.listen
+		// reuseport binds the dual-stack listeners to the IPv4 wildcard address, unless
+		// the IPv6 one is given.
+		if host, port, splitErr := net.SplitHostPort(addr); splitErr == nil && host == "" && network == "tcp" {
+			addr = net.JoinHostPort("::", port)
+		}
+
+		ln, err = reuseport.Listen(network, addr)
+	} else {
+		ln, err = (&net.ListenConfig{}).Listen(ctx, network, b.listen)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if b.tlsConfig != nil {
+		ln = tls.NewListener(ln, b.tlsConfig)
+	}
+
+	return ln, nil
+}
+
 func (b *bundle) Shutdown(_ context.Context) error {
 	return b.srv.Shutdown()
 }
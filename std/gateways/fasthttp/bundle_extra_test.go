@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"mime/multipart"
 	"net"
 	"testing"
@@ -281,24 +282,24 @@ func TestWSDispatchPaths(t *testing.T) {
 	gw, _ := New(WithPredicateKey("pred"))
 	b := gw.(*bundle) //nolint:forcetypeassert
 
-	b.rpcRoutes["route"] = &routeData{
+	b.addRPCRoute("", &routeData{
 		Predicate:   "route",
 		ServiceName: "svc",
 		ContractID:  "c1",
 		Factory:     kit.CreateMessageFactory(&wsPayload{}),
-	}
-	b.rpcRoutes["raw"] = &routeData{
+	})
+	b.addRPCRoute("", &routeData{
 		Predicate:   "raw",
 		ServiceName: "svc",
 		ContractID:  "c2",
 		Factory:     kit.CreateMessageFactory(kit.RawMessage{}),
-	}
-	b.rpcRoutes["form"] = &routeData{
+	})
+	b.addRPCRoute("", &routeData{
 		Predicate:   "form",
 		ServiceName: "svc",
 		ContractID:  "c3",
 		Factory:     kit.CreateMessageFactory(&kit.MultipartFormMessage{}),
-	}
+	})
 
 	ctx := newTestContext(&wsConn{kv: map[string]string{}})
 	_, err := b.rpcDispatch(ctx, nil)
@@ -439,3 +440,216 @@ func TestWSHandler(t *testing.T) {
 		t.Fatalf("did not receive close")
 	}
 }
+
+// jsonrpc2Delegate dispatches the message by the bundle, and greets the name of the input.
+type jsonrpc2Delegate struct {
+	b *bundle
+}
+
+func (d *jsonrpc2Delegate) OnOpen(_ kit.Conn) {}
+
+func (d *jsonrpc2Delegate) OnClose(_ uint64) {}
+
+func (d *jsonrpc2Delegate) OnMessage(c kit.Conn, msg []byte) {
+	ctx := newTestContext(c)
+	if _, err := d.b.Dispatch(ctx, msg); err != nil {
+		return
+	}
+
+	in := ctx.In().GetMsg().(*wsPayload) //nolint:forcetypeassert
+	_ = c.WriteEnvelope(newTestEnvelope(ctx, c).SetMsg(&wsPayload{Name: "hello " + in.Name}))
+}
+
+func newJSONRPC2Bundle(t *testing.T, opts ...Option) *bundle {
+	t.Helper()
+
+	gw, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := gw.(*bundle) //nolint:forcetypeassert
+	b.Subscribe(&jsonrpc2Delegate{b: b})
+	b.addRPCRoute("", &routeData{
+		Predicate:   "greet",
+		ServiceName: "svc",
+		ContractID:  "c1",
+		Factory:     kit.CreateMessageFactory(&wsPayload{}),
+	})
+
+	return b
+}
+
+func TestJSONRPC2HTTP(t *testing.T) {
+	b := newJSONRPC2Bundle(t, WithJSONRPC2("/rpc"))
+
+	for _, tc := range []struct {
+		in, expected string
+		status       int
+	}{
+		{
+			`{"jsonrpc":"2.0","method":"greet","params":{"name":"alice"},"id":7}`,
+			`{"jsonrpc":"2.0","id":7,"result":{"name":"hello alice"}}`,
+			fasthttp.StatusOK,
+		},
+		{
+			`[{"jsonrpc":"2.0","method":"greet","params":{"name":"bob"},"id":"a"},` +
+				`{"jsonrpc":"2.0","method":"greet","params":{"name":"carol"}},` +
+				`{"jsonrpc":"2.0","method":"greet","params":{"name":1},"id":"b"},` +
+				`{"jsonrpc":"2.0","method":"missing","id":"c"}]`,
+			`[{"jsonrpc":"2.0","id":"a","result":{"name":"hello bob"}},` +
+				`{"jsonrpc":"2.0","id":"b","error":{"code":-32602,"message":"Invalid params"}},` +
+				`{"jsonrpc":"2.0","id":"c","error":{"code":-32601,"message":"Method not found"}}]`,
+			fasthttp.StatusOK,
+		},
+		{`{"jsonrpc":"2.0","method":"greet","params":{"name":"dave"}}`, ``, fasthttp.StatusNoContent},
+	} {
+		ctx := newRequestCtx(MethodPost, "/rpc")
+		ctx.Request.SetBodyString(tc.in)
+		b.srv.Handler(ctx)
+
+		if ctx.Response.StatusCode() != tc.status || string(ctx.Response.Body()) != tc.expected {
+			t.Fatalf("unexpected response: %d, %s", ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+	}
+}
+
+func TestJSONRPC2Websocket(t *testing.T) {
+	b := newJSONRPC2Bundle(t, WithWebsocketEndpoint("/ws"), WithJSONRPC2(""))
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		_ = b.srv.Serve(ln)
+	}()
+	defer b.srv.Shutdown()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+ln.Addr().String()+"/ws", nil)
+	if err != nil {
+		t.Fatalf("ws dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// the notification is not answered, hence the next message is the response of the request.
+	for _, in := range []string{
+		`{"jsonrpc":"2.0","method":"greet","params":{"name":"alice"}}`,
+		`{"jsonrpc":"2.0","method":"greet","params":{"name":"bob"},"id":1}`,
+	} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(in)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, res, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(res) != `{"jsonrpc":"2.0","id":1,"result":{"name":"hello bob"}}` {
+		t.Fatalf("unexpected response: %s", res)
+	}
+
+	// the server pushes are sent as notifications.
+	b.streamsMtx.Lock()
+	wsc := b.wsConns[1]
+	b.streamsMtx.Unlock()
+
+	ctx := newTestContext(wsc)
+	err = wsc.WriteEnvelope(
+		newTestEnvelope(ctx, wsc).
+			SetHdr(common.JSONRPC2MethodKey, "news").
+			SetMsg(&wsPayload{Name: "update"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, res, err = conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(res) != `{"jsonrpc":"2.0","method":"news","params":{"name":"update"}}` {
+		t.Fatalf("unexpected notification: %s", res)
+	}
+}
+
+type routeCaptureDelegate struct {
+	captureDelegate
+	contracts []string
+}
+
+func (d *routeCaptureDelegate) OnMessage(c kit.Conn, msg []byte) {
+	if hc, ok := c.(*httpConn); ok {
+		d.contracts = append(d.contracts, hc.rd.ContractID)
+	}
+	d.captureDelegate.OnMessage(c, msg)
+}
+
+func TestRegisterVersionedRoutes(t *testing.T) {
+	gw, _ := New(WithPredicateKey("pred"))
+	b := gw.(*bundle) //nolint:forcetypeassert
+	delegate := &routeCaptureDelegate{}
+	b.Subscribe(delegate)
+
+	b.Register("svc", "c1", kit.JSON, kit.Versioned(GET("/users"), "v1"), kit.RawMessage{}, kit.RawMessage{})
+	b.Register("svc", "c2", kit.JSON, kit.Versioned(GET("/users"), "v2"), kit.RawMessage{}, kit.RawMessage{})
+	b.Register("svc", "c3", kit.JSON, kit.VersionedPath(GET("/users"), "v3"), kit.RawMessage{}, kit.RawMessage{})
+
+	for _, tc := range []struct {
+		path, version string
+		status        int
+	}{
+		{"/users", "", fasthttp.StatusOK},
+		{"/users", "v1", fasthttp.StatusOK},
+		{"/users", "v9", fasthttp.StatusNotFound},
+		{"/v3/users", "", fasthttp.StatusOK},
+	} {
+		ctx := newRequestCtx(MethodGet, tc.path)
+		if tc.version != "" {
+			ctx.Request.Header.Set(kit.HeaderAcceptVersion, tc.version)
+		}
+		b.httpRouter.Handler(ctx)
+		if ctx.Response.StatusCode() != tc.status {
+			t.Fatalf("%s@%s: unexpected status: %d", tc.path, tc.version, ctx.Response.StatusCode())
+		}
+	}
+	if fmt.Sprint(delegate.contracts) != "[c2 c1 c3]" {
+		t.Fatalf("unexpected contracts: %v", delegate.contracts)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expected duplicate route to panic")
+			}
+		}()
+		b.Register("svc", "c4", kit.JSON, kit.Versioned(GET("/users"), "v2"), kit.RawMessage{}, kit.RawMessage{})
+	}()
+
+	b.Register("svc", "r1", kit.JSON, kit.Versioned(RPC("getUser"), "v1"), kit.RawMessage{}, kit.RawMessage{})
+	b.Register("svc", "r2", kit.JSON, kit.Versioned(RPC("getUser"), "v2"), kit.RawMessage{}, kit.RawMessage{})
+
+	ctx := newTestContext(&wsConn{kv: map[string]string{}})
+	for version, contractID := range map[string]string{"": "r2", "v1": "r1", "v2": "r2"} {
+		in, _ := json.Marshal(incomingEnvelope{
+			ID:      "1",
+			Header:  map[string]string{"pred": "getUser", kit.HeaderAcceptVersion: version},
+			Payload: []byte(`{}`),
+		})
+		arg, err := b.rpcDispatch(ctx, in)
+		if err != nil || arg.ContractID != contractID {
+			t.Fatalf("version %q: unexpected route: %s, %v", version, arg.ContractID, err)
+		}
+	}
+
+	in, _ := json.Marshal(incomingEnvelope{
+		ID:     "1",
+		Header: map[string]string{"pred": "getUser", kit.HeaderAcceptVersion: "v3"},
+	})
+	if _, err := b.rpcDispatch(ctx, in); !errors.Is(err, kit.ErrNoHandler) {
+		t.Fatalf("expected no handler error, got %v", err)
+	}
+}
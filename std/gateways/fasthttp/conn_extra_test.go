@@ -7,6 +7,8 @@ import (
 
 	"github.com/clubpay/ronykit/kit"
 	"github.com/valyala/fasthttp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestHTTPConnMethods(t *testing.T) {
@@ -95,6 +97,120 @@ func TestHTTPConnMethods(t *testing.T) {
 	}
 }
 
+func TestHTTPConnProtoEncoding(t *testing.T) {
+	body, err := proto.Marshal(wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := newRequestCtx(MethodPost, "/proto")
+	ctx.Request.Header.SetContentType(kit.ContentTypeProto)
+	ctx.Request.SetBodyRaw(body)
+
+	dec := reflectDecoder(kit.Proto, kit.CreateMessageFactory(&wrapperspb.StringValue{}))
+	msg, err := dec(ctx, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := msg.(*wrapperspb.StringValue); !ok || v.GetValue() != "hello" {
+		t.Fatalf("unexpected message: %v", msg)
+	}
+
+	for _, tc := range []struct {
+		accept      string
+		msg         kit.Message
+		contentType string
+	}{
+		{"", wrapperspb.String("world"), kit.ContentTypeProto},
+		{"application/json;q=0.5, application/x-protobuf", wrapperspb.String("world"), kit.ContentTypeProto},
+		// the messages which are not proto messages, e.g., errors, fall back to JSON
+		{"", &struct {
+			Code int `json:"code"`
+		}{Code: 400}, kit.ContentTypeJSON},
+	} {
+		ctx.Response.Reset()
+		ctx.Request.Header.Set(fasthttp.HeaderAccept, tc.accept)
+		conn := &httpConn{ctx: ctx, rd: &routeData{Encoding: kit.Proto}}
+		env := newTestEnvelope(newTestContext(conn), conn)
+		env.SetMsg(tc.msg)
+		if err = conn.WriteEnvelope(env); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(ctx.Response.Header.ContentType()); got != tc.contentType {
+			t.Fatalf("unexpected content type: %s", got)
+		}
+	}
+
+	out := &wrapperspb.StringValue{}
+	ctx.Response.Reset()
+	ctx.Request.Header.Set(fasthttp.HeaderAccept, "")
+	conn := &httpConn{ctx: ctx, rd: &routeData{Encoding: kit.Proto}}
+	env := newTestEnvelope(newTestContext(conn), conn)
+	env.SetMsg(wrapperspb.String("world"))
+	if err = conn.WriteEnvelope(env); err != nil {
+		t.Fatal(err)
+	}
+	if err = proto.Unmarshal(ctx.Response.Body(), out); err != nil || out.GetValue() != "world" {
+		t.Fatalf("unexpected body: %v, %v", out, err)
+	}
+
+	// JSON clients of the proto contracts get JSON
+	ctx.Response.Reset()
+	ctx.Request.Header.Set(fasthttp.HeaderAccept, kit.ContentTypeJSON)
+	env.SetMsg(&struct {
+		N int `json:"n"`
+	}{N: 1})
+	if err = conn.WriteEnvelope(env); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(bytes.TrimSpace(ctx.Response.Body())); got != `{"n":1}` {
+		t.Fatalf("unexpected body: %s", got)
+	}
+}
+
+func TestHTTPConnMsgpackEncoding(t *testing.T) {
+	type msg struct {
+		Name string `json:"name"`
+		N    int    `json:"n"`
+	}
+
+	body, _, err := kit.MarshalMessageAs(kit.MSG, &msg{Name: "body", N: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := newRequestCtx(MethodPost, "/msgpack")
+	ctx.Request.Header.SetContentType("application/x-msgpack")
+	ctx.Request.SetBodyRaw(body)
+	ctx.SetUserValue("name", "param")
+
+	// the JSON contracts accept MessagePack bodies too
+	dec := reflectDecoder(kit.JSON, kit.CreateMessageFactory(&msg{}))
+	in, err := dec(ctx, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := in.(*msg); !ok || v.Name != "param" || v.N != 1 {
+		t.Fatalf("unexpected message: %v", in)
+	}
+
+	ctx.Request.Header.Set(fasthttp.HeaderAccept, "application/json;q=0.9, application/msgpack")
+	conn := &httpConn{ctx: ctx, rd: &routeData{Encoding: kit.JSON}}
+	env := newTestEnvelope(newTestContext(conn), conn)
+	env.SetMsg(&msg{Name: "out", N: 2})
+	if err = conn.WriteEnvelope(env); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(ctx.Response.Header.ContentType()); got != kit.ContentTypeMsgpack {
+		t.Fatalf("unexpected content type: %s", got)
+	}
+
+	out := &msg{}
+	if err = kit.UnmarshalMessageAs(kit.MSG, ctx.Response.Body(), out); err != nil || out.N != 2 {
+		t.Fatalf("unexpected body: %+v, %v", out, err)
+	}
+}
+
 func TestHTTPConnGetBodyUncompressed(t *testing.T) {
 	body := []byte("hello")
 
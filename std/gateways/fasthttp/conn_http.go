@@ -24,16 +24,21 @@ var _ kit.RESTConn = (*httpConn)(nil)
 
 func (c *httpConn) Walk(f func(key string, val string) bool) {
 	stopCall := false
+	isTLS := c.ctx.IsTLS()
 
 	c.ctx.Request.Header.VisitAll(
 		func(key, value []byte) {
-			if stopCall {
+			if stopCall || (isTLS && isClientCertKey(utils.B2S(key))) {
 				return
 			}
 
 			stopCall = !f(utils.B2S(key), utils.B2S(value))
 		},
 	)
+
+	if isTLS && !stopCall {
+		walkClientCert(c.ctx.TLSConnectionState(), f)
+	}
 }
 
 func (c *httpConn) WalkQueryParams(f func(key string, val string) bool) {
@@ -50,7 +55,13 @@ func (c *httpConn) WalkQueryParams(f func(key string, val string) bool) {
 	)
 }
 
+// Get returns the request header. On TLS connections, the ClientCert keys return the
+// values of the verified client certificate instead.
 func (c *httpConn) Get(key string) string {
+	if isClientCertKey(key) && c.ctx.IsTLS() {
+		return clientCertValue(c.ctx.TLSConnectionState(), key)
+	}
+
 	return utils.B2S(c.ctx.Request.Header.Peek(key))
 }
 
@@ -77,6 +88,10 @@ func (c *httpConn) Write(data []byte) (int, error) {
 }
 
 func (c *httpConn) WriteEnvelope(e *kit.Envelope) error {
+	if enc := c.responseEncoding(); enc != kit.JSON && enc != kit.Undefined {
+		return c.writeEnvelopeAs(enc, e)
+	}
+
 	dataBuf := buf.GetCap(e.SizeHint())
 
 	err := kit.EncodeMessage(e.GetMsg(), dataBuf)
@@ -98,6 +113,45 @@ func (c *httpConn) WriteEnvelope(e *kit.Envelope) error {
 	return nil
 }
 
+// responseEncoding negotiates the encoding of the response by the Accept and Content-Type
+// headers of the request, and the encoding of the contract.
+func (c *httpConn) responseEncoding() kit.Encoding {
+	def := kit.JSON
+	if c.rd != nil && c.rd.Encoding != kit.Undefined {
+		def = c.rd.Encoding
+	}
+
+	return kit.NegotiateEncoding(
+		utils.B2S(c.ctx.Request.Header.Peek(fasthttp.HeaderAccept)),
+		utils.B2S(c.ctx.Request.Header.ContentType()),
+		def,
+	)
+}
+
+func (c *httpConn) writeEnvelopeAs(enc kit.Encoding, e *kit.Envelope) error {
+	data, used, err := kit.MarshalMessageAs(enc, e.GetMsg())
+	if err != nil {
+		return err
+	}
+
+	// the raw messages are already encoded, so the handler sets their content type.
+	if _, ok := e.GetMsg().(kit.RawMessage); !ok {
+		c.ctx.Response.Header.SetContentType(used.ContentType())
+	}
+
+	e.WalkHdr(
+		func(key string, val string) bool {
+			c.ctx.Response.Header.Set(key, val)
+
+			return true
+		},
+	)
+
+	c.ctx.Response.SetBody(data)
+
+	return nil
+}
+
 func (c *httpConn) Stream() bool {
 	return false
 }
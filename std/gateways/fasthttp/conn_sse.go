@@ -2,6 +2,7 @@ package fasthttp
 
 import (
 	"bufio"
+	"context"
 	"sync"
 
 	"github.com/clubpay/ronykit/kit"
@@ -11,18 +12,24 @@ import (
 type sseHTTPConn struct {
 	httpConn
 
+	wMtx  sync.Mutex // protects w
 	w     *bufio.Writer
 	done  chan struct{}
 	close sync.Once
+	// q is nil unless the gateway is configured by WithOutboundQueue.
+	q *kit.OutboundQueue
 }
 
 var (
-	_ kit.RESTConn = (*sseHTTPConn)(nil)
-	_ kit.Conn     = (*sseHTTPConn)(nil)
+	_ kit.RESTConn   = (*sseHTTPConn)(nil)
+	_ kit.Conn       = (*sseHTTPConn)(nil)
+	_ kit.QueuedConn = (*sseHTTPConn)(nil)
 )
 
 func (c *sseHTTPConn) attachWriter(w *bufio.Writer) {
+	c.wMtx.Lock()
 	c.w = w
+	c.wMtx.Unlock()
 }
 
 func (c *sseHTTPConn) Stream() bool {
@@ -30,6 +37,18 @@ func (c *sseHTTPConn) Stream() bool {
 }
 
 func (c *sseHTTPConn) Write(data []byte) (int, error) {
+	if c.q != nil {
+		err := c.q.Push(appendSSEEvent(nil, "", data), kit.OverflowDefault)
+		if err != nil {
+			return 0, err
+		}
+
+		return len(data), nil
+	}
+
+	c.wMtx.Lock()
+	defer c.wMtx.Unlock()
+
 	if c.w == nil {
 		return 0, kit.ErrWriteToClosedConn
 	}
@@ -52,13 +71,23 @@ func (c *sseHTTPConn) WriteEnvelope(e *kit.Envelope) error {
 		return err
 	}
 
+	if c.q != nil {
+		err = c.q.Push(appendSSEEvent(nil, sseEventMessage, *dataBuf.Bytes()), e.OverflowPolicy())
+		dataBuf.Release()
+
+		return err
+	}
+
+	c.wMtx.Lock()
 	if c.w == nil {
+		c.wMtx.Unlock()
 		dataBuf.Release()
 
 		return kit.ErrWriteToClosedConn
 	}
 
 	err = writeSSEEvent(c.w, sseEventMessage, *dataBuf.Bytes())
+	c.wMtx.Unlock()
 	dataBuf.Release()
 
 	if err != nil {
@@ -76,6 +105,51 @@ func (c *sseHTTPConn) WriteEnvelope(e *kit.Envelope) error {
 	return nil
 }
 
+// writeNow writes the encoded event, which is queued by the OutboundQueue.
+func (c *sseHTTPConn) writeNow(event []byte) error {
+	c.wMtx.Lock()
+	defer c.wMtx.Unlock()
+
+	if c.w == nil {
+		return kit.ErrWriteToClosedConn
+	}
+
+	if _, err := c.w.Write(event); err != nil {
+		return err
+	}
+
+	return c.w.Flush()
+}
+
+// drainQueue writes the queued events, or gives up after drainGracePeriod and closes
+// the connection.
+func (c *sseHTTPConn) drainQueue() {
+	ctx, cancel := context.WithTimeout(context.Background(), drainGracePeriod)
+	defer cancel()
+
+	_ = c.q.Drain(ctx)
+}
+
+// OutboundQueueStats implements kit.QueuedConn. It returns zero stats if the gateway
+// is not configured by WithOutboundQueue.
+func (c *sseHTTPConn) OutboundQueueStats() kit.OutboundQueueStats {
+	if c.q == nil {
+		return kit.OutboundQueueStats{}
+	}
+
+	return c.q.Stats()
+}
+
+// closeGracefully sends the final event to the client, and any further write will fail.
+func (c *sseHTTPConn) closeGracefully(event, data string) {
+	c.wMtx.Lock()
+	if c.w != nil {
+		_ = writeSSEEvent(c.w, event, []byte(data))
+		c.w = nil
+	}
+	c.wMtx.Unlock()
+}
+
 func (c *sseHTTPConn) signalDone() {
 	c.close.Do(func() {
 		close(c.done)
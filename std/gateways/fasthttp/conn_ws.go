@@ -17,17 +17,38 @@ type wsConn struct {
 	clientIP      string
 	c             *websocket.Conn
 	rpcOutFactory kit.OutgoingRPCFactory
+	binary        bool
+	// q is nil unless the gateway is configured by WithOutboundQueue.
+	q *kit.OutboundQueue
 }
 
 var (
-	_ kit.Conn    = (*wsConn)(nil)
-	_ kit.RPCConn = (*wsConn)(nil)
+	_ kit.Conn       = (*wsConn)(nil)
+	_ kit.RPCConn    = (*wsConn)(nil)
+	_ kit.QueuedConn = (*wsConn)(nil)
 )
 
 func (w *wsConn) Close() {
+	if w.q != nil {
+		w.q.Close()
+	}
+
 	w.Lock()
-	_ = w.c.SetReadDeadline(time.Now())
-	w.c = nil
+	if w.c != nil {
+		_ = w.c.SetReadDeadline(time.Now())
+		w.c = nil
+	}
+	w.Unlock()
+}
+
+// closeGracefully sends a close frame to the client, and waits until the deadline for
+// the client to close the connection.
+func (w *wsConn) closeGracefully(code int, reason string, deadline time.Time) {
+	w.Lock()
+	if w.c != nil {
+		_ = w.c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+		_ = w.c.SetReadDeadline(deadline)
+	}
 	w.Unlock()
 }
 
@@ -40,23 +61,44 @@ func (w *wsConn) ClientIP() string {
 }
 
 func (w *wsConn) Write(data []byte) (int, error) {
+	return w.write(data, kit.OverflowDefault)
+}
+
+func (w *wsConn) write(data []byte, policy kit.OverflowPolicy) (int, error) {
+	if w.q != nil {
+		if err := w.q.Push(data, policy); err != nil {
+			return 0, err
+		}
+
+		return len(data), nil
+	}
+
+	if err := w.writeNow(data); err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+func (w *wsConn) writeNow(data []byte) error {
 	var err error
 
+	msgType := websocket.TextMessage
+	if w.binary {
+		msgType = websocket.BinaryMessage
+	}
+
 	w.Lock()
 
 	if w.c != nil {
-		err = w.c.WriteMessage(websocket.TextMessage, data)
+		err = w.c.WriteMessage(msgType, data)
 	} else {
 		err = kit.ErrWriteToClosedConn
 	}
 
 	w.Unlock()
 
-	if err != nil {
-		return 0, err
-	}
-
-	return len(data), nil
+	return err
 }
 
 func (w *wsConn) WriteEnvelope(e *kit.Envelope) error {
@@ -76,13 +118,23 @@ func (w *wsConn) WriteEnvelope(e *kit.Envelope) error {
 		return err
 	}
 
-	_, err = w.Write(data)
+	_, err = w.write(data, e.OverflowPolicy())
 
 	outC.Release()
 
 	return err
 }
 
+// OutboundQueueStats implements kit.QueuedConn. It returns zero stats if the gateway
+// is not configured by WithOutboundQueue.
+func (w *wsConn) OutboundQueueStats() kit.OutboundQueueStats {
+	if w.q == nil {
+		return kit.OutboundQueueStats{}
+	}
+
+	return w.q.Stats()
+}
+
 func (w *wsConn) Stream() bool {
 	return true
 }
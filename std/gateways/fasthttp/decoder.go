@@ -128,10 +128,7 @@ func reflectDecoder(enc kit.Encoding, factory kit.MessageFactoryFunc) DecoderFun
 	default:
 	}
 
-	tagKey := enc.Tag()
-	if tagKey == "" {
-		tagKey = kit.JSON.Tag()
-	}
+	tagKey := enc.FieldTag()
 
 	rVal := reflect.ValueOf(factory())
 	if rVal.Kind() != reflect.Ptr {
@@ -145,11 +142,21 @@ func reflectDecoder(enc kit.Encoding, factory kit.MessageFactoryFunc) DecoderFun
 
 	pcs := extractFields(rVal, tagKey)
 
-	return genDecoderFunc(factory, pcs...)
+	return genDecoderFunc(enc, factory, pcs...)
+}
+
+// bodyEncoding returns the encoding of the request body by its Content-Type header. If
+// the content type is not registered in kit, the encoding of the contract is used.
+func bodyEncoding(reqCtx *RequestCtx, enc kit.Encoding) kit.Encoding {
+	if ct, ok := kit.EncodingByContentType(utils.B2S(reqCtx.Request.Header.ContentType())); ok {
+		return ct
+	}
+
+	return enc
 }
 
 //nolint:cyclop,gocognit,gocyclo
-func genDecoderFunc(factory kit.MessageFactoryFunc, pcs ...paramCaster) DecoderFunc {
+func genDecoderFunc(enc kit.Encoding, factory kit.MessageFactoryFunc, pcs ...paramCaster) DecoderFunc {
 	pcsMap := make(map[string]paramCaster, len(pcs))
 	for _, pc := range pcs {
 		pcsMap[pc.name] = pc
@@ -162,7 +169,7 @@ func genDecoderFunc(factory kit.MessageFactoryFunc, pcs ...paramCaster) DecoderF
 		)
 
 		if len(data) > 0 {
-			err = kit.UnmarshalMessage(data, v)
+			err = kit.UnmarshalMessageAs(bodyEncoding(reqCtx, enc), data, v)
 			if err != nil {
 				return nil, err
 			}
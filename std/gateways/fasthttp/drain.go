@@ -0,0 +1,80 @@
+package fasthttp
+
+import (
+	"context"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// drainGracePeriod is the time we wait for the websocket clients to reply to
+	// the close frame, if the drain context has no deadline. It also bounds the time
+	// a closing SSE stream waits for its queued events to be written.
+	drainGracePeriod = 5 * time.Second
+	drainReason      = "server is shutting down"
+)
+
+// drainHandler rejects the new requests when the gateway is draining.
+func (b *bundle) drainHandler(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if b.draining.Load() {
+			ctx.SetConnectionClose()
+			ctx.Error(drainReason, fasthttp.StatusServiceUnavailable)
+
+			return
+		}
+
+		h(ctx)
+	}
+}
+
+func (b *bundle) trackWS(c *wsConn) {
+	b.streamsMtx.Lock()
+	b.wsConns[c.id] = c
+	b.streamsMtx.Unlock()
+}
+
+func (b *bundle) untrackWS(c *wsConn) {
+	b.streamsMtx.Lock()
+	delete(b.wsConns, c.id)
+	b.streamsMtx.Unlock()
+}
+
+func (b *bundle) trackSSE(c *sseHTTPConn) {
+	b.streamsMtx.Lock()
+	b.sseConns[c] = struct{}{}
+	b.streamsMtx.Unlock()
+}
+
+func (b *bundle) untrackSSE(c *sseHTTPConn) {
+	b.streamsMtx.Lock()
+	delete(b.sseConns, c)
+	b.streamsMtx.Unlock()
+}
+
+// Drain implements kit.GatewayDrainer. It rejects the new requests with 503 status
+// code, sends a close frame to the websocket connections, and sends a final
+// 'shutdown' event to the SSE streams.
+func (b *bundle) Drain(ctx context.Context) error {
+	b.draining.Store(true)
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(drainGracePeriod)
+	}
+
+	b.streamsMtx.Lock()
+	defer b.streamsMtx.Unlock()
+
+	for _, c := range b.wsConns {
+		c.closeGracefully(websocket.CloseGoingAway, drainReason, deadline)
+	}
+
+	for c := range b.sseConns {
+		c.closeGracefully(sseEventShutdown, drainReason)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package fasthttp
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/fasthttp/websocket"
+)
+
+type blockingDelegate struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (d *blockingDelegate) OnOpen(_ kit.Conn) {}
+func (d *blockingDelegate) OnClose(_ uint64)  {}
+func (d *blockingDelegate) OnMessage(c kit.Conn, _ []byte) {
+	if _, ok := c.(*sseHTTPConn); !ok {
+		return
+	}
+
+	d.started <- struct{}{}
+	<-d.release
+}
+
+func TestBundleDrain(t *testing.T) {
+	gw, _ := New(WithWebsocketEndpoint("/ws"))
+	b := gw.(*bundle) //nolint:forcetypeassert
+
+	delegate := &blockingDelegate{
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+	b.Subscribe(delegate)
+	b.Register("svc", "c1", kit.JSON, SSE("/stream"), kit.RawMessage{}, kit.RawMessage{})
+	b.Register("svc", "c2", kit.JSON, GET("/ping"), kit.RawMessage{}, kit.RawMessage{})
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		_ = b.srv.Serve(ln)
+	}()
+	defer b.srv.Shutdown() //nolint:errcheck
+
+	addr := ln.Addr().String()
+
+	wsc, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	if err != nil {
+		t.Fatalf("ws dial failed: %v", err)
+	}
+	defer wsc.Close()
+
+	// the response headers are not flushed until the first event, hence we need to
+	// wait for the response in another goroutine.
+	respCh := make(chan *http.Response, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+addr+"/stream", nil) //nolint:noctx
+		// compressed streams are not flushed per event
+		req.Header.Set("Accept-Encoding", "identity")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("request failed: %v", err)
+		}
+
+		respCh <- resp
+	}()
+
+	select {
+	case <-delegate.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("sse stream did not start")
+	}
+
+	if err = b.Drain(t.Context()); err != nil {
+		t.Fatalf("drain failed: %v", err)
+	}
+
+	resp := <-respCh
+	if resp == nil {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	// the websocket client must receive a close frame
+	_ = wsc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = wsc.ReadMessage()
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Fatalf("expected going away close frame, got: %v", err)
+	}
+
+	// the sse client must receive the shutdown event
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if strings.TrimSpace(line) != "event: "+sseEventShutdown {
+		t.Fatalf("unexpected sse line: %q", line)
+	}
+
+	close(delegate.release)
+
+	// new requests are rejected
+	resp2, err := http.Get("http://" + addr + "/ping") //nolint:noctx
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp2.Body.Close()
+
+	if resp2.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status: %d", resp2.StatusCode)
+	}
+}
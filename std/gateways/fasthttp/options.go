@@ -1,10 +1,13 @@
 package fasthttp
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/fs"
+	"time"
 
 	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/common"
 	"github.com/clubpay/ronykit/std/gateways/fasthttp/proxy"
 
 	"github.com/valyala/fasthttp"
@@ -43,6 +46,59 @@ func Listen(addr string) Option {
 	}
 }
 
+// WithListenNetwork sets the network of the listener: "tcp4", "tcp6", or "tcp" for the
+// dual-stack listener which accepts both IPv4 and IPv6 connections. By default, the IPv6
+// addresses, e.g., "[::1]:80", are listened on "tcp6", and the others on "tcp4".
+func WithListenNetwork(network string) Option {
+	return func(b *bundle) {
+		b.network = network
+	}
+}
+
+// WithTLS serves the connections over TLS by the certificate and the key files, which are
+// PEM encoded. The files are checked for modifications on the handshakes, and the renewed
+// certificate is served without a restart. Check WithTLSReloadInterval for more details.
+func WithTLS(certFile, keyFile string) Option {
+	return func(b *bundle) {
+		b.tlsOpts.certFile = certFile
+		b.tlsOpts.keyFile = keyFile
+	}
+}
+
+// WithTLSConfig serves the connections over TLS by the config. If it is used with WithTLS
+// or WithClientCA, the config is the base of the loaded files, i.e., the certificates
+// and the client CAs of the files override the ones of the config.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(b *bundle) {
+		b.tlsOpts.base = cfg
+	}
+}
+
+// WithClientCA verifies the client certificates (mTLS) by the CA certificates of the file,
+// which is PEM encoded and reloaded like the files of WithTLS. If auth is
+// tls.NoClientCert, it defaults to tls.RequireAndVerifyClientCert. The peer identity of
+// the verified certificates is exposed by Conn.Get and Conn.Walk, with the ClientCert keys,
+// e.g., ClientCertCommonName.
+func WithClientCA(caFile string, auth tls.ClientAuthType) Option {
+	return func(b *bundle) {
+		if auth == tls.NoClientCert {
+			auth = tls.RequireAndVerifyClientCert
+		}
+
+		b.tlsOpts.caFile = caFile
+		b.tlsOpts.clientAuth = auth
+	}
+}
+
+// WithTLSReloadInterval sets how often the files of WithTLS and WithClientCA are checked
+// for modifications. The files are checked on the handshakes, hence an idle server does not
+// touch them. Zero disables the reload. The default is 30 seconds.
+func WithTLSReloadInterval(d time.Duration) Option {
+	return func(b *bundle) {
+		b.tlsOpts.reload = d
+	}
+}
+
 func WithCORS(cfg CORSConfig) Option {
 	return func(b *bundle) {
 		b.cors = newCORS(cfg)
@@ -86,6 +142,30 @@ func WithCustomRPC(in kit.IncomingRPCFactory, out kit.OutgoingRPCFactory) Option
 	}
 }
 
+// WithWebsocketBinaryMode writes the websocket messages as binary frames, which is
+// required by the binary RPC containers, e.g., common.SimpleOutgoingProtoRPC.
+// By default, the messages are written as text frames.
+func WithWebsocketBinaryMode() Option {
+	return func(b *bundle) {
+		b.wsBinary = true
+	}
+}
+
+// WithJSONRPC2 switches the websocket RPC protocol to JSON-RPC 2.0: the "method" of the
+// requests is the predicate, and the "params" is the input message. The batches and the
+// notifications are supported, and the dispatch failures are answered by the error objects
+// of the specification. If path is not empty, the JSON-RPC 2.0 requests are also accepted
+// by HTTP POST on path.
+func WithJSONRPC2(path string) Option {
+	return func(b *bundle) {
+		b.jsonrpc2 = true
+		b.jsonrpc2Path = path
+		b.rpcInFactory = common.IncomingJSONRPC2
+		b.rpcOutFactory = common.OutgoingJSONRPC2
+		b.predicateKey = common.JSONRPC2MethodKey
+	}
+}
+
 func WithDisableHeaderNamesNormalizing() Option {
 	return func(b *bundle) {
 		b.srv.DisableHeaderNamesNormalizing = true
@@ -151,3 +231,14 @@ func WithServerConfigOverride(fn func(srv *fasthttp.Server)) Option {
 		fn(b.srv)
 	}
 }
+
+// WithOutboundQueue writes the envelopes of the websocket and SSE connections through a
+// kit.OutboundQueue of the given size per connection, hence a slow client does not block
+// the handlers. policy defines what happens when the queue is full; the contracts can
+// override it by kit.Overflow. By default, the envelopes are written synchronously.
+func WithOutboundQueue(size int, policy kit.OverflowPolicy) Option {
+	return func(b *bundle) {
+		b.queueSize = size
+		b.queuePolicy = policy
+	}
+}
@@ -8,8 +8,9 @@ import (
 )
 
 const (
-	sseContentType  = "text/event-stream"
-	sseEventMessage = "message"
+	sseContentType   = "text/event-stream"
+	sseEventMessage  = "message"
+	sseEventShutdown = "shutdown"
 )
 
 func setSSEHeaders(ctx *fasthttp.RequestCtx) {
@@ -18,6 +19,20 @@ func setSSEHeaders(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Connection", "keep-alive")
 }
 
+// appendSSEEvent appends the event in the same format as writeSSEEvent.
+func appendSSEEvent(b []byte, event string, data []byte) []byte {
+	if event != "" {
+		b = append(b, "event: "...)
+		b = append(b, event...)
+		b = append(b, '\n')
+	}
+
+	b = append(b, "data: "...)
+	b = append(b, data...)
+
+	return append(b, '\n', '\n')
+}
+
 func writeSSEEvent(w *bufio.Writer, event string, data []byte) error {
 	if event != "" {
 		if _, err := fmt.Fprintf(w, "event: %s\n", event); err != nil {
@@ -3,6 +3,7 @@ package fasthttp
 import (
 	"bufio"
 	"bytes"
+	"io"
 	"net"
 	"net/http"
 	"strings"
@@ -118,3 +119,68 @@ func TestGenHTTPHandlerUsesSSEForStreamRoutes(t *testing.T) {
 		t.Fatal("expected plain handler")
 	}
 }
+
+func TestAppendSSEEvent(t *testing.T) {
+	for _, event := range []string{"", sseEventMessage} {
+		var buf bytes.Buffer
+		w := bufio.NewWriter(&buf)
+
+		if err := writeSSEEvent(w, event, []byte(`{"ok":true}`)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := string(appendSSEEvent(nil, event, []byte(`{"ok":true}`))); got != buf.String() {
+			t.Fatalf("unexpected event: %q, expected: %q", got, buf.String())
+		}
+	}
+}
+
+type writerDelegate struct {
+	msgs []string
+	conn chan kit.Conn
+}
+
+func (d *writerDelegate) OnOpen(c kit.Conn) { d.conn <- c }
+func (d *writerDelegate) OnClose(uint64)    {}
+func (d *writerDelegate) OnMessage(c kit.Conn, _ []byte) {
+	for _, m := range d.msgs {
+		_, _ = c.(io.Writer).Write([]byte(m)) //nolint:forcetypeassert
+	}
+}
+
+func TestSSEOutboundQueue(t *testing.T) {
+	gw, _ := New(WithOutboundQueue(8, kit.OverflowBlock))
+	b := gw.(*bundle) //nolint:forcetypeassert
+
+	delegate := &writerDelegate{msgs: []string{"a", "b", "c"}, conn: make(chan kit.Conn, 1)}
+	b.Subscribe(delegate)
+	b.Register("svc", "c1", kit.JSON, SSE("/stream"), kit.RawMessage{}, kit.RawMessage{})
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		_ = b.srv.Serve(ln)
+	}()
+	defer b.srv.Shutdown()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/stream") //nolint:noctx
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if _, ok := (<-delegate.conn).(kit.QueuedConn); !ok {
+		t.Fatal("expected a queued connection")
+	}
+
+	// the queued events must be written before the stream is closed
+	var body bytes.Buffer
+	_, _ = body.ReadFrom(resp.Body)
+	if got := body.String(); got != "data: a\n\ndata: b\n\ndata: c\n\n" {
+		t.Fatalf("unexpected body: %q", got)
+	}
+}
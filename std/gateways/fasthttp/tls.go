@@ -0,0 +1,289 @@
+package fasthttp
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/errors"
+)
+
+// The keys of the verified client certificate, which are exposed by Conn.Get and Conn.Walk
+// of the connections accepted over TLS. On those connections, the request headers of the
+// same names are ignored, hence the clients cannot forge them.
+const (
+	ClientCertSubject     = "X-Client-Cert-Subject"
+	ClientCertCommonName  = "X-Client-Cert-Cn"
+	ClientCertSerial      = "X-Client-Cert-Serial"
+	ClientCertFingerprint = "X-Client-Cert-Fingerprint"
+	ClientCertSAN         = "X-Client-Cert-San"
+
+	clientCertPrefix = "X-Client-Cert-"
+)
+
+var ErrNoClientCA = errors.New("no certificate found in the client CA file")
+
+const defaultTLSReloadInterval = 30 * time.Second
+
+type tlsOptions struct {
+	base       *tls.Config
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+	reload     time.Duration
+}
+
+func (o tlsOptions) enabled() bool {
+	return o.base != nil || o.certFile != "" || o.caFile != ""
+}
+
+// listenerConfig returns the config of the TLS listener. If the certificates or the client
+// CAs are loaded from the files, they are served by a certReloader.
+func (o tlsOptions) listenerConfig(l kit.Logger) (*tls.Config, error) {
+	if o.certFile == "" && o.caFile == "" {
+		return o.base.Clone(), nil
+	}
+
+	r, err := newCertReloader(o, l)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: r.getConfigForClient,
+	}, nil
+}
+
+// certReloader serves the TLS config which is built by the certificate and the client CA
+// files, and rebuilds it when the files are modified. The files are checked on the
+// handshakes, at most once per reload interval, hence the renewed certificates are served
+// without a restart. If the new files cannot be loaded, e.g., the certificate is written
+// but the key is not yet, the current config is kept and the files are checked again
+// after the interval.
+type certReloader struct {
+	opts tlsOptions
+	l    kit.Logger
+	tc   atomic.Pointer[tls.Config]
+	next atomic.Int64
+
+	mtx   sync.Mutex // protects stamp
+	stamp string
+}
+
+func newCertReloader(opts tlsOptions, l kit.Logger) (*certReloader, error) {
+	r := &certReloader{
+		opts: opts,
+		l:    l,
+	}
+
+	stamp, err := r.filesStamp()
+	if err != nil {
+		return nil, err
+	}
+
+	tc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	r.stamp = stamp
+	r.tc.Store(tc)
+	r.next.Store(time.Now().Add(opts.reload).UnixNano())
+
+	return r, nil
+}
+
+func (r *certReloader) getConfigForClient(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+	if r.opts.reload > 0 && time.Now().UnixNano() >= r.next.Load() {
+		r.reload()
+	}
+
+	return r.tc.Load(), nil
+}
+
+func (r *certReloader) reload() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// another handshake has checked the files meanwhile.
+	if time.Now().UnixNano() < r.next.Load() {
+		return
+	}
+
+	r.next.Store(time.Now().Add(r.opts.reload).UnixNano())
+
+	stamp, err := r.filesStamp()
+	if err != nil {
+		r.l.Errorf("[Gateway][fasthttp] could not check the certificate files: %v", err)
+
+		return
+	}
+
+	if stamp == r.stamp {
+		return
+	}
+
+	tc, err := r.load()
+	if err != nil {
+		r.l.Errorf("[Gateway][fasthttp] could not reload the certificates: %v", err)
+
+		return
+	}
+
+	r.stamp = stamp
+	r.tc.Store(tc)
+}
+
+// filesStamp returns the modification time and the size of the files, which changes when
+// any of them is modified or replaced.
+func (r *certReloader) filesStamp() (string, error) {
+	sb := strings.Builder{}
+	for _, f := range []string{r.opts.certFile, r.opts.keyFile, r.opts.caFile} {
+		if f == "" {
+			continue
+		}
+
+		fi, err := os.Stat(f)
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(strconv.FormatInt(fi.ModTime().UnixNano(), 10))
+		sb.WriteByte('/')
+		sb.WriteString(strconv.FormatInt(fi.Size(), 10))
+		sb.WriteByte(';')
+	}
+
+	return sb.String(), nil
+}
+
+func (r *certReloader) load() (*tls.Config, error) {
+	tc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if r.opts.base != nil {
+		tc = r.opts.base.Clone()
+		tc.GetConfigForClient = nil
+	}
+
+	if r.opts.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(r.opts.certFile, r.opts.keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tc.Certificates = []tls.Certificate{cert}
+		tc.GetCertificate = nil
+	}
+
+	if r.opts.caFile != "" {
+		data, err := os.ReadFile(r.opts.caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, ErrNoClientCA
+		}
+
+		tc.ClientCAs = pool
+		tc.ClientAuth = r.opts.clientAuth
+	}
+
+	return tc, nil
+}
+
+func isClientCertKey(key string) bool {
+	return len(key) > len(clientCertPrefix) && strings.EqualFold(key[:len(clientCertPrefix)], clientCertPrefix)
+}
+
+// walkClientCert calls f for the key-values of the verified client certificate of the
+// TLS connection. It returns false if f stops the walk.
+func walkClientCert(state *tls.ConnectionState, f func(key, val string) bool) bool {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return true
+	}
+
+	cert := state.PeerCertificates[0]
+	sum := sha256.Sum256(cert.Raw)
+
+	if !f(ClientCertSubject, cert.Subject.String()) ||
+		!f(ClientCertCommonName, cert.Subject.CommonName) ||
+		!f(ClientCertSerial, cert.SerialNumber.Text(16)) ||
+		!f(ClientCertFingerprint, hex.EncodeToString(sum[:])) {
+		return false
+	}
+
+	if san := clientCertSAN(cert); san != "" {
+		return f(ClientCertSAN, san)
+	}
+
+	return true
+}
+
+func clientCertValue(state *tls.ConnectionState, key string) string {
+	var v string
+
+	walkClientCert(
+		state,
+		func(k, val string) bool {
+			if strings.EqualFold(k, key) {
+				v = val
+
+				return false
+			}
+
+			return true
+		},
+	)
+
+	return v
+}
+
+// clientCertSAN returns the subject alternative names of the certificate in the format
+// of openssl, e.g., "DNS:example.com,URI:spiffe://example.com/service".
+func clientCertSAN(cert *x509.Certificate) string {
+	var names []string
+	for _, n := range cert.DNSNames {
+		names = append(names, "DNS:"+n)
+	}
+
+	for _, n := range cert.EmailAddresses {
+		names = append(names, "email:"+n)
+	}
+
+	for _, ip := range cert.IPAddresses {
+		names = append(names, "IP:"+ip.String())
+	}
+
+	for _, u := range cert.URIs {
+		names = append(names, "URI:"+u.String())
+	}
+
+	return strings.Join(names, ",")
+}
+
+// listenNetwork returns the network of the listener. If it is not set by WithListenNetwork,
+// the IPv6 addresses are listened on "tcp6", and the others on "tcp4".
+func listenNetwork(network, addr string) string {
+	if network != "" {
+		return network
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err == nil && strings.Contains(host, ":") {
+		return "tcp6"
+	}
+
+	return "tcp4"
+}
@@ -0,0 +1,341 @@
+package fasthttp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/fasthttp/websocket"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// issue returns the PEM encoded certificate and key, which are signed by the CA.
+func (ca *testCA) issue(t *testing.T, serial int64, cn string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"ronykit"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		URIs:         []*url.URL{{Scheme: "spiffe", Host: "ronykit", Path: "/" + cn}},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeTestFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type peerDelegate struct {
+	peers chan map[string]string
+}
+
+func (d *peerDelegate) OnOpen(_ kit.Conn) {}
+func (d *peerDelegate) OnClose(_ uint64)  {}
+func (d *peerDelegate) OnMessage(c kit.Conn, _ []byte) {
+	kv := map[string]string{}
+	c.Walk(
+		func(key, val string) bool {
+			kv[key] = val
+
+			return true
+		},
+	)
+	kv["get"] = c.Get(ClientCertCommonName)
+	d.peers <- kv
+
+	if w, ok := c.(kit.RPCConn); ok {
+		_, _ = w.Write([]byte("ok"))
+	}
+}
+
+func startTLSBundle(t *testing.T, opts ...Option) (*bundle, *peerDelegate, string) {
+	t.Helper()
+
+	gw, err := New(append(opts, Listen("127.0.0.1:0"), WithWebsocketEndpoint("/ws"))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := gw.(*bundle) //nolint:forcetypeassert
+	d := &peerDelegate{peers: make(chan map[string]string, 1)}
+	b.Subscribe(d)
+	b.Register("svc", "c1", kit.JSON, GET("/peer"), kit.RawMessage{}, kit.RawMessage{})
+
+	ln, err := b.newListener(t.Context(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		_ = b.srv.Serve(ln)
+	}()
+
+	t.Cleanup(func() { _ = b.srv.Shutdown() })
+
+	return b, d, ln.Addr().String()
+}
+
+func newTLSClient(ca *testCA, certs ...tls.Certificate) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig: &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				RootCAs:      ca.pool,
+				Certificates: certs,
+			},
+		},
+	}
+}
+
+func TestTLSClientCert(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.issue(t, 2, "server", x509.ExtKeyUsageServerAuth)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+	writeTestFile(t, filepath.Join(dir, "cert.pem"), certPEM, time.Now())
+	writeTestFile(t, filepath.Join(dir, "key.pem"), keyPEM, time.Now())
+	writeTestFile(t, filepath.Join(dir, "ca.pem"), caPEM, time.Now())
+
+	_, d, addr := startTLSBundle(
+		t,
+		WithTLS(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")),
+		WithClientCA(filepath.Join(dir, "ca.pem"), tls.NoClientCert),
+	)
+
+	clientCertPEM, clientKeyPEM := ca.issue(t, 0xabc, "client-1", x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://"+addr+"/peer", nil) //nolint:noctx
+	req.Header.Set(ClientCertCommonName, "forged")
+	req.Header.Set("X-Custom", "1")
+
+	resp, err := newTLSClient(ca, clientCert).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	peer := <-d.peers
+	if peer["get"] != "client-1" || peer[ClientCertCommonName] != "client-1" || peer["X-Custom"] != "1" ||
+		peer[ClientCertSerial] != "abc" || peer[ClientCertSubject] != "CN=client-1,O=ronykit" ||
+		peer[ClientCertSAN] != "IP:127.0.0.1,URI:spiffe://ronykit/client-1" || len(peer[ClientCertFingerprint]) != 64 {
+		t.Fatalf("unexpected peer identity: %v", peer)
+	}
+
+	// the clients without a certificate are rejected
+	if _, err = newTLSClient(ca).Get("https://" + addr + "/peer"); err == nil { //nolint:noctx
+		t.Fatal("expected the request without a client certificate to fail")
+	}
+
+	// the websocket connections keep the peer identity
+	dialer := websocket.Dialer{
+		TLSClientConfig: &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			RootCAs:      ca.pool,
+			Certificates: []tls.Certificate{clientCert},
+		},
+	}
+
+	wsc, _, err := dialer.Dial("wss://"+addr+"/ws", http.Header{ClientCertCommonName: []string{"forged"}})
+	if err != nil {
+		t.Fatalf("ws dial failed: %v", err)
+	}
+	defer wsc.Close()
+
+	if err = wsc.WriteMessage(websocket.TextMessage, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+
+	if peer = <-d.peers; peer["get"] != "client-1" || peer[ClientCertSAN] == "" {
+		t.Fatalf("unexpected websocket peer identity: %v", peer)
+	}
+}
+
+func TestTLSCertReload(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	modTime := time.Now().Add(-time.Minute)
+
+	certPEM, keyPEM := ca.issue(t, 2, "server-1", x509.ExtKeyUsageServerAuth)
+	writeTestFile(t, certFile, certPEM, modTime)
+	writeTestFile(t, keyFile, keyPEM, modTime)
+
+	_, d, addr := startTLSBundle(t, WithTLS(certFile, keyFile), WithTLSReloadInterval(time.Millisecond))
+
+	serverCN := func() string {
+		t.Helper()
+
+		resp, err := newTLSClient(ca).Get("https://" + addr + "/peer") //nolint:noctx
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		<-d.peers
+
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	if cn := serverCN(); cn != "server-1" {
+		t.Fatalf("unexpected server certificate: %s", cn)
+	}
+
+	// a certificate without its key is not loaded, and the current one is kept.
+	certPEM, keyPEM = ca.issue(t, 3, "server-2", x509.ExtKeyUsageServerAuth)
+	writeTestFile(t, certFile, certPEM, modTime.Add(time.Second))
+	time.Sleep(5 * time.Millisecond)
+
+	if cn := serverCN(); cn != "server-1" {
+		t.Fatalf("unexpected server certificate: %s", cn)
+	}
+
+	writeTestFile(t, keyFile, keyPEM, modTime.Add(time.Second))
+	time.Sleep(5 * time.Millisecond)
+
+	if cn := serverCN(); cn != "server-2" {
+		t.Fatalf("expected the renewed certificate, got: %s", cn)
+	}
+}
+
+func TestTLSInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeTestFile(t, caFile, []byte("not a certificate"), time.Now())
+
+	if _, err := New(WithTLS(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))); err == nil {
+		t.Fatal("expected an error for the missing files")
+	}
+
+	if _, err := New(WithClientCA(caFile, tls.RequireAndVerifyClientCert)); err != ErrNoClientCA { //nolint:errorlint
+		t.Fatalf("expected ErrNoClientCA, got: %v", err)
+	}
+}
+
+func TestListenNetwork(t *testing.T) {
+	for addr, network := range map[string]string{
+		"127.0.0.1:80": "tcp4",
+		":80":          "tcp4",
+		"[::1]:80":     "tcp6",
+		"[::]:80":      "tcp6",
+		"localhost:80": "tcp4",
+	} {
+		if n := listenNetwork("", addr); n != network {
+			t.Fatalf("unexpected network of %s: %s", addr, n)
+		}
+	}
+
+	if n := listenNetwork("tcp", "127.0.0.1:80"); n != "tcp" {
+		t.Fatalf("unexpected network: %s", n)
+	}
+
+	for _, reusePort := range []bool{false, true} {
+		gw, _ := New(Listen(":0"), WithListenNetwork("tcp"))
+		b := gw.(*bundle) //nolint:forcetypeassert
+
+		ln, err := b.newListener(t.Context(), reusePort)
+		if err != nil {
+			t.Skipf("dual-stack listener is not supported: %v", err)
+		}
+
+		_, port, _ := net.SplitHostPort(ln.Addr().String())
+		for _, host := range []string{"127.0.0.1", "::1"} {
+			c, err := net.Dial("tcp", net.JoinHostPort(host, port))
+			if err != nil {
+				if host == "::1" {
+					continue // no IPv6 loopback in this environment
+				}
+
+				t.Fatalf("dial %s failed (reusePort: %t): %v", host, reusePort, err)
+			}
+
+			_ = c.Close()
+		}
+
+		_ = ln.Close()
+	}
+}
@@ -2,6 +2,7 @@ package fastws
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/clubpay/ronykit/kit"
@@ -14,6 +15,7 @@ import (
 
 const (
 	queryPredicate = "fastws.predicate"
+	drainReason    = "server is shutting down"
 )
 
 var noExecuteArg = kit.ExecuteArg{}
@@ -25,17 +27,24 @@ type bundle struct {
 	d      kit.GatewayDelegate
 
 	predicateKey  string
-	routes        map[string]*routeData
+	routes        map[string]*kit.VersionedRoutes[*routeData]
 	rpcInFactory  kit.IncomingRPCFactory
 	rpcOutFactory kit.OutgoingRPCFactory
 	writeMode     ws.OpCode
+	jsonrpc2      bool
+	queueSize     int
+	queuePolicy   kit.OverflowPolicy
+	draining      atomic.Bool
 }
 
-var _ kit.Gateway = (*bundle)(nil)
+var (
+	_ kit.Gateway        = (*bundle)(nil)
+	_ kit.GatewayDrainer = (*bundle)(nil)
+)
 
 func New(opts ...Option) (kit.Gateway, error) {
 	b := &bundle{
-		routes:        map[string]*routeData{},
+		routes:        map[string]*kit.VersionedRoutes[*routeData]{},
 		predicateKey:  "predicate",
 		rpcInFactory:  common.SimpleIncomingJSONRPC,
 		rpcOutFactory: common.SimpleOutgoingJSONRPC,
@@ -68,15 +77,32 @@ func (b *bundle) Register(
 		return
 	}
 
-	b.routes[rpcSelector.GetPredicate()] = &routeData{
-		ServiceName: svcName,
-		ContractID:  contractID,
-		Predicate:   rpcSelector.GetPredicate(),
-		Factory:     kit.CreateMessageFactory(input),
+	vr := b.routes[rpcSelector.GetPredicate()]
+	if vr == nil {
+		vr = &kit.VersionedRoutes[*routeData]{}
+		b.routes[rpcSelector.GetPredicate()] = vr
 	}
+
+	vr.Add(
+		kit.NegotiatedVersion(sel, false),
+		&routeData{
+			ServiceName: svcName,
+			ContractID:  contractID,
+			Predicate:   rpcSelector.GetPredicate(),
+			Factory:     kit.CreateMessageFactory(input),
+		},
+	)
 }
 
 func (b *bundle) Dispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
+	if jc, ok := ctx.Conn().(*common.JSONRPC2Conn); ok {
+		// The container is parsed, and released, by common.ServeJSONRPC2.
+		arg, err := b.dispatchContainer(ctx, jc.Container())
+		jc.SetDispatchError(err)
+
+		return arg, err
+	}
+
 	if len(in) == 0 {
 		return noExecuteArg, kit.ErrDecodeIncomingContainerFailed
 	}
@@ -88,8 +114,29 @@ func (b *bundle) Dispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
 		return noExecuteArg, errors.Wrap(kit.ErrDecodeIncomingMessageFailed, err)
 	}
 
-	routeData := b.routes[inputMsgContainer.GetHdr(b.predicateKey)]
-	if routeData == nil {
+	arg, err := b.dispatchContainer(ctx, inputMsgContainer)
+	if err != nil {
+		return noExecuteArg, err
+	}
+
+	// release the container
+	inputMsgContainer.Release()
+
+	return arg, nil
+}
+
+func (b *bundle) dispatchContainer(
+	ctx *kit.Context, inputMsgContainer kit.IncomingRPCContainer,
+) (kit.ExecuteArg, error) {
+	var err error
+
+	vr := b.routes[inputMsgContainer.GetHdr(b.predicateKey)]
+	if vr == nil {
+		return noExecuteArg, kit.ErrNoHandler
+	}
+
+	routeData, ok := vr.Get(inputMsgContainer.GetHdr(kit.HeaderAcceptVersion))
+	if !ok {
 		return noExecuteArg, kit.ErrNoHandler
 	}
 
@@ -111,9 +158,6 @@ func (b *bundle) Dispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
 		SetHdrMap(inputMsgContainer.GetHdrMap()).
 		SetMsg(msg)
 
-	// release the container
-	inputMsgContainer.Release()
-
 	return kit.ExecuteArg{
 		ServiceName: routeData.ServiceName,
 		ContractID:  routeData.ContractID,
@@ -150,6 +194,74 @@ func (b *bundle) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Drain implements kit.GatewayDrainer. It rejects the new connections and sends a
+// close frame to the open websocket connections.
+func (b *bundle) Drain(_ context.Context) error {
+	b.draining.Store(true)
+
+	gw, ok := b.eh.(*gateway)
+	if !ok {
+		return nil
+	}
+
+	gw.Lock()
+	conns := make([]*wsConn, 0, len(gw.conns))
+	for _, c := range gw.conns {
+		conns = append(conns, c)
+	}
+	gw.Unlock()
+
+	for _, c := range conns {
+		c.closeGracefully(ws.StatusGoingAway, drainReason)
+	}
+
+	return nil
+}
+
+// delegate returns the delegate which the messages of the connections are passed to.
+func (b *bundle) delegate() kit.GatewayDelegate {
+	if b.jsonrpc2 {
+		return jsonrpc2Delegate{b: b}
+	}
+
+	return b.d
+}
+
+// jsonrpc2Delegate serves the messages of the connections by common.ServeJSONRPC2 in the
+// JSON-RPC 2.0 mode.
+type jsonrpc2Delegate struct {
+	b *bundle
+}
+
+func (d jsonrpc2Delegate) OnOpen(c kit.Conn) {
+	d.b.d.OnOpen(c)
+}
+
+func (d jsonrpc2Delegate) OnClose(connID uint64) {
+	d.b.d.OnClose(connID)
+}
+
+func (d jsonrpc2Delegate) OnMessage(c kit.Conn, msg []byte) {
+	res := common.ServeJSONRPC2(d.b.d, c, msg, d.b.hasRoute)
+	if res == nil {
+		return
+	}
+
+	wsc, ok := c.(kit.RPCConn)
+	if !ok {
+		return
+	}
+
+	_, err := wsc.Write(res)
+	if err != nil {
+		d.b.l.Errorf("[Gateway][fastws] could not write the json-rpc response: %v", err)
+	}
+}
+
+func (b *bundle) hasRoute(predicate string) bool {
+	return b.routes[predicate] != nil
+}
+
 func (b *bundle) Subscribe(d kit.GatewayDelegate) {
 	b.d = d
 }
@@ -32,11 +32,14 @@ type wsConn struct {
 	currHead      *ws.Header
 	w             *wsutil.Writer
 	c             gnet.Conn
+	// q is nil unless the gateway is configured by WithOutboundQueue.
+	q *kit.OutboundQueue
 }
 
 var (
-	_ kit.Conn    = (*wsConn)(nil)
-	_ kit.RPCConn = (*wsConn)(nil)
+	_ kit.Conn       = (*wsConn)(nil)
+	_ kit.RPCConn    = (*wsConn)(nil)
+	_ kit.QueuedConn = (*wsConn)(nil)
 )
 
 func newWebsocketConn(
@@ -239,6 +242,29 @@ func (wsc *wsConn) ClientIP() string {
 }
 
 func (wsc *wsConn) Write(data []byte) (int, error) {
+	return wsc.write(data, kit.OverflowDefault)
+}
+
+func (wsc *wsConn) write(data []byte, policy kit.OverflowPolicy) (int, error) {
+	if wsc.q != nil {
+		if err := wsc.q.Push(data, policy); err != nil {
+			return 0, err
+		}
+
+		return len(data), nil
+	}
+
+	return wsc.writeSync(data)
+}
+
+// writeNow is the write function of the OutboundQueue.
+func (wsc *wsConn) writeNow(data []byte) error {
+	_, err := wsc.writeSync(data)
+
+	return err
+}
+
+func (wsc *wsConn) writeSync(data []byte) (int, error) {
 	wsc.Lock()
 	defer wsc.Unlock()
 
@@ -267,13 +293,23 @@ func (wsc *wsConn) WriteEnvelope(e *kit.Envelope) error {
 		return errors.Wrap(kit.ErrEncodeOutgoingMessageFailed, err)
 	}
 
-	_, err = wsc.Write(data)
+	_, err = wsc.write(data, e.OverflowPolicy())
 
 	outC.Release()
 
 	return err
 }
 
+// OutboundQueueStats implements kit.QueuedConn. It returns zero stats if the gateway
+// is not configured by WithOutboundQueue.
+func (wsc *wsConn) OutboundQueueStats() kit.OutboundQueueStats {
+	if wsc.q == nil {
+		return kit.OutboundQueueStats{}
+	}
+
+	return wsc.q.Stats()
+}
+
 func (wsc *wsConn) Stream() bool {
 	return true
 }
@@ -303,6 +339,19 @@ func (wsc *wsConn) Set(key string, val string) {
 	wsc.Unlock()
 }
 
+// closeGracefully sends a close frame to the client, so it can reconnect to
+// another instance. The connection is closed when the client replies.
+func (wsc *wsConn) closeGracefully(code ws.StatusCode, reason string) {
+	wsc.Lock()
+	defer wsc.Unlock()
+
+	if !wsc.handshakeDone || wsc.c == nil {
+		return
+	}
+
+	_ = ws.WriteFrame(wsc.c, ws.NewCloseFrame(ws.NewCloseFrameBody(code, reason)))
+}
+
 func (wsc *wsConn) Close() {
 	if wsc.c != nil {
 		_ = wsc.c.Close()
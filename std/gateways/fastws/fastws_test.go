@@ -1,11 +1,14 @@
 package fastws
 
 import (
+	"context"
 	"testing"
 
 	"github.com/clubpay/ronykit/kit"
 	"github.com/clubpay/ronykit/kit/common"
 	kiterrors "github.com/clubpay/ronykit/kit/errors"
+
+	"github.com/panjf2000/gnet/v2"
 )
 
 type simpleMsg struct {
@@ -78,3 +81,122 @@ func TestBundleRegisterStoresRoute(t *testing.T) {
 		t.Fatal("expected route to be registered")
 	}
 }
+
+func TestBundleVersionedRoutes(t *testing.T) {
+	gw, err := New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := gw.(*bundle)
+
+	b.Register("svc", "c1", kit.JSON, kit.Versioned(RPC("evt"), "v1"), simpleMsg{}, simpleMsg{})
+	b.Register("svc", "c2", kit.JSON, kit.Versioned(RPC("evt"), "v2"), simpleMsg{}, simpleMsg{})
+
+	for version, contractID := range map[string]string{"": "c2", "v1": "c1", "v2": "c2"} {
+		rd, ok := b.routes["evt"].Get(version)
+		if !ok || rd.ContractID != contractID {
+			t.Fatalf("version %q: unexpected route: %v", version, rd)
+		}
+	}
+
+	out := common.SimpleOutgoingJSONRPC()
+	out.SetID("1")
+	out.SetHdr(b.predicateKey, "evt")
+	out.SetHdr(kit.HeaderAcceptVersion, "v3")
+	out.InjectMessage(simpleMsg{Value: "v"})
+	data, marshalErr := out.Marshal()
+	if marshalErr != nil {
+		t.Fatalf("marshal failed: %v", marshalErr)
+	}
+	out.Release()
+
+	_, err = b.Dispatch(&kit.Context{}, data)
+	if err != kit.ErrNoHandler {
+		t.Fatalf("expected ErrNoHandler, got: %v", err)
+	}
+}
+
+func TestBundleDrain(t *testing.T) {
+	gw, err := New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := gw.(*bundle)
+
+	// not upgraded connections are skipped
+	b.eh.(*gateway).conns[1] = &wsConn{id: 1}
+
+	if err = b.Drain(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !b.draining.Load() {
+		t.Fatal("expected the bundle to be draining")
+	}
+
+	_, action := b.eh.OnOpen(nil)
+	if action != gnet.Close {
+		t.Fatalf("expected new connections to be rejected, got: %v", action)
+	}
+}
+
+// rpcTestConn records the data written to it.
+type rpcTestConn struct {
+	kit.Conn
+	written [][]byte
+}
+
+func (c *rpcTestConn) Stream() bool { return true }
+
+func (c *rpcTestConn) Write(data []byte) (int, error) {
+	c.written = append(c.written, append([]byte(nil), data...))
+
+	return len(data), nil
+}
+
+func (c *rpcTestConn) Close() {}
+
+// echoDelegate dispatches the message by the bundle, and echoes the input.
+type echoDelegate struct {
+	b *bundle
+}
+
+func (d echoDelegate) OnOpen(kit.Conn) {}
+
+func (d echoDelegate) OnClose(uint64) {}
+
+func (d echoDelegate) OnMessage(c kit.Conn, msg []byte) {
+	_ = kit.NewTestContext().
+		SetHandler(func(ctx *kit.Context) {
+			if _, err := d.b.Dispatch(ctx, msg); err != nil {
+				return
+			}
+
+			in := ctx.In().GetMsg().(*simpleMsg) //nolint:forcetypeassert
+			ctx.Out().SetMsg(&simpleMsg{Value: "echo " + in.Value}).Send()
+		}).
+		RunWithConn(c)
+}
+
+func TestBundleJSONRPC2(t *testing.T) {
+	gw, err := New(WithJSONRPC2())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := gw.(*bundle)
+	b.Subscribe(echoDelegate{b: b})
+	b.Register("svc", "c1", kit.JSON, RPC("echo"), &simpleMsg{}, &simpleMsg{})
+
+	conn := &rpcTestConn{}
+	b.delegate().OnMessage(conn, []byte(`[
+		{"jsonrpc":"2.0","method":"echo","params":{"value":"a"},"id":1},
+		{"jsonrpc":"2.0","method":"echo","params":{"value":"b"}},
+		{"jsonrpc":"2.0","method":"echo","params":{"value":2},"id":2}
+	]`))
+	b.delegate().OnMessage(conn, []byte(`{"jsonrpc":"2.0","method":"unknown"}`))
+
+	expected := `[{"jsonrpc":"2.0","id":1,"result":{"value":"echo a"}},` +
+		`{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"Invalid params"}}]`
+	if len(conn.written) != 1 || string(conn.written[0]) != expected {
+		t.Fatalf("unexpected responses: %q", conn.written)
+	}
+}
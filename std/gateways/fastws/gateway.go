@@ -8,6 +8,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/clubpay/ronykit/kit"
 	"github.com/clubpay/ronykit/kit/utils"
 
 	"github.com/gobwas/ws"
@@ -51,12 +52,19 @@ func (gw *gateway) OnBoot(_ gnet.Engine) (action gnet.Action) {
 func (gw *gateway) OnShutdown(_ gnet.Engine) {}
 
 func (gw *gateway) OnOpen(c gnet.Conn) (out []byte, action gnet.Action) {
+	if gw.b.draining.Load() {
+		return nil, gnet.Close
+	}
+
 	wsc := newWebsocketConn(
 		gw.nextID.Add(1),
 		c,
 		gw.b.rpcOutFactory,
 		gw.b.writeMode,
 	)
+	if gw.b.queueSize > 0 {
+		wsc.q = kit.NewOutboundQueue(gw.b.queueSize, gw.b.queuePolicy, wsc.writeNow, wsc.Close)
+	}
 	c.SetContext(wsc.id)
 
 	gw.Lock()
@@ -74,6 +82,9 @@ func (gw *gateway) OnClose(c gnet.Conn, _ error) (action gnet.Action) {
 		gw.b.d.OnClose(connID)
 
 		gw.Lock()
+		if wsc := gw.conns[connID]; wsc != nil && wsc.q != nil {
+			wsc.q.Close()
+		}
 		delete(gw.conns, connID)
 		gw.Unlock()
 	}
@@ -117,7 +128,7 @@ func (gw *gateway) OnTraffic(c gnet.Conn) gnet.Action {
 		return gnet.Close
 	}
 
-	err = wsc.executeMessages(c, gw.b.d)
+	err = wsc.executeMessages(c, gw.b.delegate())
 	if err != nil {
 		gw.b.l.Debugf("failed to execute message connID(%d): %v", utils.TryCast[uint64](c.Context()), err)
 
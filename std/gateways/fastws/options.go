@@ -2,6 +2,7 @@ package fastws
 
 import (
 	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/common"
 
 	"github.com/gobwas/ws"
 )
@@ -50,3 +51,27 @@ func WithWebsocketBinaryMode() Option {
 		b.writeMode = ws.OpBinary
 	}
 }
+
+// WithOutboundQueue writes the envelopes of each connection through a kit.OutboundQueue
+// of the given size, hence a slow client does not block the handlers. policy defines what
+// happens when the queue is full; the contracts can override it by kit.Overflow.
+// By default, the envelopes are written synchronously.
+func WithOutboundQueue(size int, policy kit.OverflowPolicy) Option {
+	return func(b *bundle) {
+		b.queueSize = size
+		b.queuePolicy = policy
+	}
+}
+
+// WithJSONRPC2 switches the RPC protocol to JSON-RPC 2.0: the "method" of the requests is
+// the predicate, and the "params" is the input message. The batches and the notifications
+// are supported, and the dispatch failures are answered by the error objects of the
+// specification.
+func WithJSONRPC2() Option {
+	return func(b *bundle) {
+		b.jsonrpc2 = true
+		b.rpcInFactory = common.IncomingJSONRPC2
+		b.rpcOutFactory = common.OutgoingJSONRPC2
+		b.predicateKey = common.JSONRPC2MethodKey
+	}
+}
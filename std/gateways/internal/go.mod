@@ -0,0 +1,26 @@
+module github.com/clubpay/ronykit/std/gateways/internal
+
+go 1.25.0
+
+require (
+	github.com/andybalholm/brotli v1.2.2
+	github.com/clubpay/ronykit/kit v0.26.11
+	github.com/gobwas/ws v1.4.0
+	github.com/klauspost/compress v1.19.1
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/clipperhouse/uax29/v2 v2.7.0 // indirect
+	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
+	github.com/gobwas/httphead v0.1.0 // indirect
+	github.com/gobwas/pool v0.2.1 // indirect
+	github.com/goccy/go-json v0.10.6 // indirect
+	github.com/goccy/go-reflect v1.2.0 // indirect
+	github.com/jedib0t/go-pretty/v6 v6.8.1 // indirect
+	github.com/mattn/go-runewidth v0.0.23 // indirect
+	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
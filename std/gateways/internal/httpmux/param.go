@@ -0,0 +1,30 @@
+package httpmux
+
+import (
+	"strings"
+)
+
+// Param is a single URL parameter, consisting of a key and a value.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params is a Param-slice, as returned by the httpMux.
+// The slice is ordered, the first URL parameter is also the first slice value.
+// It is therefore safe to read values by the index.
+type Params []Param
+
+// ByName returns the value of the first Param which key matches the given name.
+// If no matching Param is found, an empty string is returned.
+// The value is copied, since the gateways may point the params to the buffers of the
+// request, which are reused.
+func (ps Params) ByName(name string) string {
+	for _, p := range ps {
+		if p.Key == name {
+			return strings.Clone(p.Value)
+		}
+	}
+
+	return ""
+}
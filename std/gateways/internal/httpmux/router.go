@@ -1,3 +1,4 @@
+// Package httpmux is the router of the REST routes of the silverhttp and nethttp gateways.
 package httpmux
 
 import (
@@ -8,20 +9,22 @@ import (
 	"github.com/clubpay/ronykit/kit"
 )
 
-type RouteData struct {
+type RouteData[D any] struct {
 	Method      string
 	Path        string
 	Predicate   string
 	ServiceName string
 	ContractID  string
-	Decoder     DecoderFunc
+	Decoder     D
 	Factory     kit.MessageFactoryFunc
+	Encoding    kit.Encoding
+	Stream      bool
 }
 
 // Mux is a http.Handler which can be used to dispatch requests to different
 // handler functions via configurable routes.
-type Mux struct {
-	trees map[string]*node
+type Mux[D any] struct {
+	trees map[string]*node[D]
 
 	paramsPool sync.Pool
 	maxParams  uint16
@@ -84,51 +87,51 @@ type Mux struct {
 	PanicHandler func(http.ResponseWriter, *http.Request, any)
 }
 
-func (r *Mux) getParams() *Params {
+func (r *Mux[D]) getParams() *Params {
 	ps, _ := r.paramsPool.Get().(*Params)
 	*ps = (*ps)[0:0] // reset slice
 
 	return ps
 }
 
-func (r *Mux) putParams(ps *Params) {
+func (r *Mux[D]) putParams(ps *Params) {
 	if ps != nil {
 		r.paramsPool.Put(ps)
 	}
 }
 
 // GET is a shortcut for httpMux.Handle(http.MethodGet, path, handle).
-func (r *Mux) GET(path string, handle *RouteData) {
+func (r *Mux[D]) GET(path string, handle *RouteData[D]) {
 	r.Handle(http.MethodGet, path, handle)
 }
 
 // HEAD is a shortcut for httpMux.Handle(http.MethodHead, path, handle).
-func (r *Mux) HEAD(path string, handle *RouteData) {
+func (r *Mux[D]) HEAD(path string, handle *RouteData[D]) {
 	r.Handle(http.MethodHead, path, handle)
 }
 
 // OPTIONS is a shortcut for httpMux.Handle(http.MethodOptions, path, handle).
-func (r *Mux) OPTIONS(path string, handle *RouteData) {
+func (r *Mux[D]) OPTIONS(path string, handle *RouteData[D]) {
 	r.Handle(http.MethodOptions, path, handle)
 }
 
 // POST is a shortcut for httpMux.Handle(http.MethodPost, path, handle).
-func (r *Mux) POST(path string, handle *RouteData) {
+func (r *Mux[D]) POST(path string, handle *RouteData[D]) {
 	r.Handle(http.MethodPost, path, handle)
 }
 
 // PUT is a shortcut for httpMux.Handle(http.MethodPut, path, handle).
-func (r *Mux) PUT(path string, handle *RouteData) {
+func (r *Mux[D]) PUT(path string, handle *RouteData[D]) {
 	r.Handle(http.MethodPut, path, handle)
 }
 
 // PATCH is a shortcut for httpMux.Handle(http.MethodPatch, path, handle).
-func (r *Mux) PATCH(path string, handle *RouteData) {
+func (r *Mux[D]) PATCH(path string, handle *RouteData[D]) {
 	r.Handle(http.MethodPatch, path, handle)
 }
 
 // DELETE is a shortcut for httpMux.Handle(http.MethodDelete, path, handle).
-func (r *Mux) DELETE(path string, handle *RouteData) {
+func (r *Mux[D]) DELETE(path string, handle *RouteData[D]) {
 	r.Handle(http.MethodDelete, path, handle)
 }
 
@@ -140,7 +143,7 @@ func (r *Mux) DELETE(path string, handle *RouteData) {
 // This function is intended for bulk loading and to allow the usage of less
 // frequently used, non-standardized or custom methods (e.g. for internal
 // communication with a proxy).
-func (r *Mux) Handle(method, path string, handle *RouteData) {
+func (r *Mux[D]) Handle(method, path string, handle *RouteData[D]) {
 	varsCount := uint16(0)
 
 	if method == "" {
@@ -152,12 +155,12 @@ func (r *Mux) Handle(method, path string, handle *RouteData) {
 	}
 
 	if r.trees == nil {
-		r.trees = make(map[string]*node)
+		r.trees = make(map[string]*node[D])
 	}
 
 	root := r.trees[method]
 	if root == nil {
-		root = new(node)
+		root = new(node[D])
 		r.trees[method] = root
 
 		r.globalAllowed = r.allowed("*", "")
@@ -185,7 +188,7 @@ func (r *Mux) Handle(method, path string, handle *RouteData) {
 // If the path was found, it returns the handle function and the path parameter
 // values. Otherwise, the third return value indicates whether a redirection to
 // the same path with an extra / without the trailing slash should be performed.
-func (r *Mux) Lookup(method, path string) (*RouteData, Params, bool) {
+func (r *Mux[D]) Lookup(method, path string) (*RouteData[D], Params, bool) {
 	if root := r.trees[method]; root != nil {
 		handle, ps, tsr := root.getValue(path, r.getParams)
 		if handle == nil {
@@ -204,7 +207,7 @@ func (r *Mux) Lookup(method, path string) (*RouteData, Params, bool) {
 	return nil, nil, false
 }
 
-func (r *Mux) allowed(path, reqMethod string) (allow string) {
+func (r *Mux[D]) allowed(path, reqMethod string) (allow string) {
 	allowed := make([]string, 0, 9)
 
 	//nolint:nestif
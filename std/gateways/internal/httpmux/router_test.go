@@ -0,0 +1,28 @@
+package httpmux_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/clubpay/ronykit/std/gateways/internal/httpmux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter(t *testing.T) {
+	mux := &httpmux.Mux[any]{}
+	expectedRD := &httpmux.RouteData[any]{}
+	mux.POST("/r1/:p1/something", expectedRD)
+	mux.GET("/r1/:p1/something", expectedRD)
+
+	t.Run("Wildcard route must match with GET", func(t *testing.T) {
+		rd, p, _ := mux.Lookup(http.MethodGet, "/r1/x/something")
+		assert.Equal(t, "x", p.ByName("p1"))
+		assert.Equal(t, expectedRD, rd)
+	})
+
+	t.Run("Wildcard route must match with POST", func(t *testing.T) {
+		rd, p, _ := mux.Lookup(http.MethodPost, "/r1/x/something")
+		assert.Equal(t, "x", p.ByName("p1"))
+		assert.Equal(t, expectedRD, rd)
+	})
+}
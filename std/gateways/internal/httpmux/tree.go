@@ -67,18 +67,18 @@ func countParams(path string) uint16 {
 
 type nodeType uint8
 
-type node struct {
+type node[D any] struct {
 	path      string
 	indices   string
 	wildChild bool
 	nType     nodeType
 	priority  uint32
-	children  []*node
-	handle    *RouteData
+	children  []*node[D]
+	handle    *RouteData[D]
 }
 
 // Increments priority of the given child and reorders if necessary
-func (n *node) incrementChildPrio(pos int) int {
+func (n *node[D]) incrementChildPrio(pos int) int {
 	cs := n.children
 	cs[pos].priority++
 	prio := cs[pos].priority
@@ -104,7 +104,7 @@ func (n *node) incrementChildPrio(pos int) int {
 // Not concurrency-safe!
 //
 //nolint:gocognit,cyclop
-func (n *node) addRoute(path string, handle *RouteData) {
+func (n *node[D]) addRoute(path string, handle *RouteData[D]) {
 	fullPath := path
 	n.priority++
 
@@ -125,7 +125,7 @@ walk:
 
 		// Split edge
 		if i < len(n.path) {
-			child := node{
+			child := node[D]{
 				path:      n.path[i:],
 				wildChild: n.wildChild,
 				nType:     static,
@@ -135,7 +135,7 @@ walk:
 				priority:  n.priority - 1,
 			}
 
-			n.children = []*node{&child}
+			n.children = []*node[D]{&child}
 			// []byte for proper unicode char conversion, see #65
 			n.indices = string([]byte{n.path[i]})
 			n.path = path[:i]
@@ -199,7 +199,7 @@ walk:
 			if idxc != ':' && idxc != '*' {
 				// []byte for proper unicode char conversion, see #65
 				n.indices += string([]byte{idxc})
-				child := &node{}
+				child := &node[D]{}
 				n.children = append(n.children, child)
 				n.incrementChildPrio(len(n.indices) - 1)
 				n = child
@@ -221,7 +221,7 @@ walk:
 	}
 }
 
-func (n *node) insertChild(path, fullPath string, handle *RouteData) {
+func (n *node[D]) insertChild(path, fullPath string, handle *RouteData[D]) {
 	for {
 		// Find prefix until first wildcard
 		wildcard, i, valid := findWildcard(path)
@@ -256,11 +256,11 @@ func (n *node) insertChild(path, fullPath string, handle *RouteData) {
 			}
 
 			n.wildChild = true
-			child := &node{
+			child := &node[D]{
 				nType: param,
 				path:  wildcard,
 			}
-			n.children = []*node{child}
+			n.children = []*node[D]{child}
 			n = child
 			n.priority++
 
@@ -268,10 +268,10 @@ func (n *node) insertChild(path, fullPath string, handle *RouteData) {
 			// will be another non-wildcard subpath starting with '/'
 			if len(wildcard) < len(path) {
 				path = path[len(wildcard):]
-				child := &node{
+				child := &node[D]{
 					priority: 1,
 				}
-				n.children = []*node{child}
+				n.children = []*node[D]{child}
 				n = child
 
 				continue
@@ -303,23 +303,23 @@ func (n *node) insertChild(path, fullPath string, handle *RouteData) {
 		n.path = path[:i]
 
 		// First node: catchAll node with empty path
-		child := &node{
+		child := &node[D]{
 			wildChild: true,
 			nType:     catchAll,
 		}
-		n.children = []*node{child}
+		n.children = []*node[D]{child}
 		n.indices = string('/')
 		n = child
 		n.priority++
 
 		// Second node: node holding the variable
-		child = &node{
+		child = &node[D]{
 			path:     path[i:],
 			nType:    catchAll,
 			handle:   handle,
 			priority: 1,
 		}
-		n.children = []*node{child}
+		n.children = []*node[D]{child}
 
 		return
 	}
@@ -336,7 +336,7 @@ func (n *node) insertChild(path, fullPath string, handle *RouteData) {
 // given path.
 //
 //nolint:gocognit,gocyclo
-func (n *node) getValue(path string, params func() *Params) (handle *RouteData, ps *Params, tsr bool) {
+func (n *node[D]) getValue(path string, params func() *Params) (handle *RouteData[D], ps *Params, tsr bool) {
 walk: // Outer loop for walking the tree
 	for {
 		prefix := n.path
@@ -499,7 +499,7 @@ func shiftNRuneBytes(rb [4]byte, n int) [4]byte {
 // Recursive case-insensitive lookup function used by n.findCaseInsensitivePath
 //
 //nolint:gocognit,gocyclo,maintidx
-func (n *node) findCaseInsensitivePathRec(
+func (n *node[D]) findCaseInsensitivePathRec(
 	path string, ciPath []byte, rb [4]byte, fixTrailingSlash bool,
 ) []byte {
 	npLen := len(n.path)
@@ -0,0 +1,52 @@
+package httprelay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+
+	"github.com/clubpay/ronykit/kit/errors"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+var ErrContentEncodingUnsupported = errors.New("unsupported Content-Encoding")
+
+// DecodeBody returns the body decoded by the Content-Encoding of the request. The same
+// encodings as the fasthttp gateway are accepted: gzip, deflate, br and zstd.
+func DecodeBody(encoding string, body []byte) ([]byte, error) {
+	var (
+		rd  io.ReadCloser
+		err error
+	)
+
+	switch encoding {
+	default:
+		return nil, ErrContentEncodingUnsupported
+	case "":
+		return body, nil
+	case "gzip":
+		rd, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		rd, err = zlib.NewReader(bytes.NewReader(body))
+	case "br":
+		rd = io.NopCloser(brotli.NewReader(bytes.NewReader(body)))
+	case "zstd":
+		var d *zstd.Decoder
+
+		d, err = zstd.NewReader(bytes.NewReader(body), zstd.WithDecoderConcurrency(1))
+		if err == nil {
+			rd = d.IOReadCloser()
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rd.Close()
+
+	return io.ReadAll(rd)
+}
@@ -0,0 +1,55 @@
+package httprelay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBody(t *testing.T) {
+	plain := []byte(`{"msg":"hi"}`)
+
+	encode := func(w io.WriteCloser, buf *bytes.Buffer) []byte {
+		_, _ = w.Write(plain)
+		_ = w.Close()
+
+		return buf.Bytes()
+	}
+
+	gz := &bytes.Buffer{}
+	df := &bytes.Buffer{}
+	br := &bytes.Buffer{}
+	zs := &bytes.Buffer{}
+
+	zw, err := zstd.NewWriter(zs)
+	require.NoError(t, err)
+
+	bodies := map[string][]byte{
+		"":        plain,
+		"gzip":    encode(gzip.NewWriter(gz), gz),
+		"deflate": encode(zlib.NewWriter(df), df),
+		"br":      encode(brotli.NewWriter(br), br),
+		"zstd":    encode(zw, zs),
+	}
+
+	for enc, body := range bodies {
+		out, err := DecodeBody(enc, body)
+		require.NoError(t, err, enc)
+		assert.Equal(t, plain, out, enc)
+	}
+
+	for _, enc := range []string{"identity", "compress", "gzip, br"} {
+		_, err := DecodeBody(enc, plain)
+		assert.ErrorIs(t, err, ErrContentEncodingUnsupported, enc)
+	}
+
+	_, err = DecodeBody("gzip", plain)
+	assert.Error(t, err)
+}
@@ -0,0 +1,280 @@
+// Package httprelay implements kit.RelayConn on net/http for the gateways which have no
+// relay client of their own, i.e., silverhttp and nethttp. The gateways read the request
+// and write the response, and the rest, e.g., the headers, the upstream client and the
+// websocket frames, is shared here.
+package httprelay
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/errors"
+)
+
+const (
+	headerSecWSProtocol = "Sec-Websocket-Protocol"
+	headerXForwardedFor = "X-Forwarded-For"
+)
+
+// hopHeaders are stripped from the relayed requests and responses. They are the same as
+// the ones of the fasthttp gateway.
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// clientNoTLS handles all relays without a custom TLS config. The response bodies are
+// not decompressed, and the redirects are not followed, hence the client receives the
+// response of the upstream as it is.
+var clientNoTLS = newClient(nil)
+
+// clientsByTLS caches one client per *tls.Config pointer. Apps typically reuse a single
+// tls.Config, so this map is bounded by the number of distinct configs.
+var clientsByTLS sync.Map // *tls.Config -> *http.Client
+
+func newClient(tc *tls.Config) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	t.Proxy = nil
+	t.DisableCompression = true
+	if tc != nil {
+		// the transport adds its protocols to the config, hence we do not share it.
+		t.TLSClientConfig = tc.Clone()
+	}
+
+	return &http.Client{
+		Transport: t,
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func clientFor(cfg kit.RelayConfig) *http.Client {
+	if cfg.TLSConfig == nil {
+		return clientNoTLS
+	}
+
+	if v, ok := clientsByTLS.Load(cfg.TLSConfig); ok {
+		return v.(*http.Client) //nolint:forcetypeassert
+	}
+
+	actual, _ := clientsByTLS.LoadOrStore(cfg.TLSConfig, newClient(cfg.TLSConfig))
+
+	return actual.(*http.Client) //nolint:forcetypeassert
+}
+
+// TargetURL parses the targetURL of a relayed request, and drops cfg.DropQueryParams.
+func TargetURL(targetURL string, cfg kit.RelayConfig) (*url.URL, error) {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("targetURL must be absolute")
+	}
+
+	return DropQueryParams(u, cfg.DropQueryParams), nil
+}
+
+func DropQueryParams(u *url.URL, drop []string) *url.URL {
+	if len(drop) == 0 {
+		return u
+	}
+
+	out := *u
+	q := out.Query()
+
+	for _, key := range drop {
+		q.Del(key)
+	}
+
+	out.RawQuery = q.Encode()
+
+	return &out
+}
+
+// RequestHeader returns the headers of the relayed request. The hop-by-hop headers and
+// cfg.DropRequestHeaders are dropped, cfg.ExtraRequestHeaders are set, and the IP of
+// remoteAddr is appended to X-Forwarded-For.
+func RequestHeader(src http.Header, remoteAddr string, cfg kit.RelayConfig) http.Header {
+	hdr := http.Header{}
+	for k, vs := range src {
+		if shouldDropHeader(k, cfg.DropRequestHeaders) {
+			continue
+		}
+
+		for _, v := range vs {
+			hdr.Add(k, v)
+		}
+	}
+
+	for k, v := range cfg.ExtraRequestHeaders {
+		hdr.Set(k, v)
+	}
+
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err == nil {
+		if prior := hdr.Values(headerXForwardedFor); len(prior) > 0 {
+			ip = strings.Join(prior, ", ") + ", " + ip
+		}
+
+		hdr.Set(headerXForwardedFor, ip)
+	}
+
+	return hdr
+}
+
+func shouldDropHeader(name string, extra []string) bool {
+	for _, h := range hopHeaders {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+
+	for _, h := range extra {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Send sends the request to u, and returns the response of the upstream without its
+// hop-by-hop headers. The body is sent as it is received, hence its Content-Encoding is
+// kept; cfg.RewriteRequest receives the same body and headers. The caller closes the
+// body of the response.
+func Send(method string, u *url.URL, header http.Header, body []byte, cfg kit.RelayConfig) (*http.Response, error) {
+	if cfg.RewriteRequest != nil {
+		view := &kit.RelayRequestView{
+			Method: method,
+			URL:    u,
+			Header: header,
+			Body:   append([]byte(nil), body...),
+		}
+		if err := cfg.RewriteRequest(view); err != nil {
+			return nil, err
+		}
+
+		if view.Method != "" {
+			method = view.Method
+		}
+
+		if view.URL != nil {
+			u = view.URL
+		}
+
+		header = view.Header
+		body = view.Body
+	}
+
+	reqCtx, cancel := context.Background(), context.CancelFunc(func() {})
+	if cfg.Timeout > 0 {
+		reqCtx, cancel = context.WithTimeout(reqCtx, cfg.Timeout)
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	if header != nil {
+		req.Header = header
+	}
+
+	req.Host = u.Host
+
+	res, err := clientFor(cfg).Do(req)
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	// the timeout bounds the body too, which is read after Send returns.
+	res.Body = cancelBody{ReadCloser: res.Body, cancel: cancel}
+
+	for _, h := range hopHeaders {
+		res.Header.Del(h)
+	}
+
+	return res, nil
+}
+
+// RewriteResponse reads the whole response of the upstream, and applies
+// cfg.RewriteResponse to it.
+func RewriteResponse(res *http.Response, cfg kit.RelayConfig) (*kit.RelayResponseView, error) {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &kit.RelayResponseView{
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       body,
+	}
+	if err = cfg.RewriteResponse(view); err != nil {
+		return nil, err
+	}
+
+	return view, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	defer b.cancel()
+
+	return b.ReadCloser.Close()
+}
+
+// HeaderHasToken reports whether the comma separated lists of the values contain the
+// token.
+func HeaderHasToken(values []string, token string) bool {
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// SameOrigin is the default origin check of the relayed websockets, which accepts the
+// requests without Origin, and the ones whose Origin host is the Host of the request.
+func SameOrigin(origin, host string) bool {
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, host)
+}
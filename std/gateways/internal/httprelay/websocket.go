@@ -0,0 +1,175 @@
+package httprelay
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/errors"
+
+	"github.com/gobwas/ws"
+)
+
+// wsHandshakeTimeout bounds the dial and the handshake of the relayed websockets.
+const wsHandshakeTimeout = 45 * time.Second
+
+// wsDialStripHeaders are generated by the websocket dialer, hence they are not forwarded.
+// The subprotocols are passed to the dialer separately.
+var wsDialStripHeaders = []string{
+	"Host",
+	"Sec-Websocket-Key",
+	"Sec-Websocket-Version",
+	"Sec-Websocket-Extensions",
+	headerSecWSProtocol,
+}
+
+// WebSocketURL parses the targetURL of a relayed websocket, and drops
+// cfg.DropQueryParams.
+func WebSocketURL(targetURL string, cfg kit.RelayConfig) (*url.URL, error) {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, errors.New("targetURL must use ws:// or wss://")
+	}
+
+	return DropQueryParams(u, cfg.DropQueryParams), nil
+}
+
+// WebSocketHeader applies cfg.RewriteRequest to the headers of the handshake with the
+// upstream.
+func WebSocketHeader(u *url.URL, header http.Header, cfg kit.RelayConfig) (http.Header, error) {
+	if cfg.RewriteRequest == nil {
+		return header, nil
+	}
+
+	view := &kit.RelayRequestView{
+		Method: http.MethodGet,
+		URL:    u,
+		Header: header,
+	}
+	if err := cfg.RewriteRequest(view); err != nil {
+		return nil, err
+	}
+
+	if view.Header == nil {
+		return http.Header{}, nil
+	}
+
+	return view.Header, nil
+}
+
+// DialWebSocket dials the websocket of the upstream. If the upstream rejects the
+// handshake, its response is returned with the error, hence it can be written to the
+// client.
+func DialWebSocket(
+	u *url.URL, header http.Header, cfg kit.RelayConfig,
+) (net.Conn, *bufio.Reader, *kit.RelayResponseView, error) {
+	var protocols []string
+	for _, v := range header.Values(headerSecWSProtocol) {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				protocols = append(protocols, p)
+			}
+		}
+	}
+
+	for _, h := range wsDialStripHeaders {
+		header.Del(h)
+	}
+
+	var rejected *kit.RelayResponseView
+
+	dialer := ws.Dialer{
+		Header:    ws.HandshakeHeaderHTTP(header),
+		Protocols: protocols,
+		TLSConfig: cfg.TLSConfig,
+		Timeout:   wsHandshakeTimeout,
+		OnStatusError: func(_ int, _ []byte, resp io.Reader) {
+			rejected = readStatusError(resp)
+		},
+	}
+
+	backend, br, _, err := dialer.Dial(context.Background(), u.String())
+	if err != nil {
+		return nil, nil, rejected, err
+	}
+
+	return backend, br, nil, nil
+}
+
+// SelectSubprotocol returns the first of the server subprotocols, which is in the
+// Sec-Websocket-Protocol values of the client.
+func SelectSubprotocol(values []string, protocols []string) string {
+	for _, p := range protocols {
+		if HeaderHasToken(values, p) {
+			return p
+		}
+	}
+
+	return ""
+}
+
+// RelayFrames copies the frames of the client to the upstream and vice versa, and
+// closes both connections when either of them is closed. The frames of the client are
+// read from cr, and the ones of the upstream from br if it is set, hence the frames
+// which are already buffered are relayed first.
+func RelayFrames(client net.Conn, cr io.Reader, backend net.Conn, br *bufio.Reader) {
+	var src io.Reader = backend
+	if br != nil {
+		src = br
+	}
+
+	done := make(chan struct{}, 2)
+
+	go func() {
+		_, _ = io.Copy(backend, cr)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		_, _ = io.Copy(client, src)
+		done <- struct{}{}
+	}()
+
+	<-done
+
+	_ = client.Close()
+	_ = backend.Close()
+
+	<-done
+
+	if br != nil {
+		ws.PutReader(br)
+	}
+}
+
+// readStatusError reads the response of the upstream which rejects the websocket
+// handshake. The body is read only if its length is known, since the upstream may keep
+// the connection open.
+func readStatusError(resp io.Reader) *kit.RelayResponseView {
+	res, err := http.ReadResponse(bufio.NewReader(resp), nil)
+	if err != nil {
+		return nil
+	}
+
+	defer res.Body.Close()
+
+	view := &kit.RelayResponseView{
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+	}
+	if res.ContentLength > 0 || len(res.TransferEncoding) > 0 {
+		view.Body, _ = io.ReadAll(res.Body)
+	}
+
+	return view
+}
@@ -0,0 +1,91 @@
+// Package selector is the route selector of the silverhttp and nethttp gateways, whose
+// decoders differ by the request type of their servers.
+package selector
+
+import (
+	"github.com/clubpay/ronykit/kit"
+)
+
+// The keys of Selector.Query, which the gateways use to read the routes.
+const (
+	QueryMethod    = "httpgw.method"
+	QueryPath      = "httpgw.path"
+	QueryDecoder   = "httpgw.decoder"
+	QueryPredicate = "httpgw.predicate"
+	QueryStream    = "httpgw.stream"
+)
+
+// Selector implements kit.RouteSelector and
+// also kit.RPCRouteSelector and kit.RESTRouteSelector
+type Selector[D any] struct {
+	Method    string
+	Path      string
+	Predicate string
+	Decoder   D
+	Encoding  kit.Encoding
+	Stream    bool
+}
+
+var (
+	_ kit.RouteSelector       = (*Selector[any])(nil)
+	_ kit.RESTRouteSelector   = (*Selector[any])(nil)
+	_ kit.StreamRouteSelector = (*Selector[any])(nil)
+	_ kit.RPCRouteSelector    = (*Selector[any])(nil)
+)
+
+func (r Selector[D]) GetEncoding() kit.Encoding {
+	return r.Encoding
+}
+
+func (r Selector[D]) SetEncoding(enc kit.Encoding) Selector[D] {
+	r.Encoding = enc
+
+	return r
+}
+
+func (r Selector[D]) SetDecoder(f D) Selector[D] {
+	r.Decoder = f
+
+	return r
+}
+
+func (r Selector[D]) GetMethod() string {
+	return r.Method
+}
+
+func (r Selector[D]) GetPath() string {
+	return r.Path
+}
+
+func (r Selector[D]) GetPredicate() string {
+	return r.Predicate
+}
+
+func (r Selector[D]) IsStream() bool {
+	return r.Stream
+}
+
+func (r Selector[D]) Query(q string) any {
+	switch q {
+	case QueryDecoder:
+		return r.Decoder
+	case QueryMethod:
+		return r.Method
+	case QueryPath:
+		return r.Path
+	case QueryPredicate:
+		return r.Predicate
+	case QueryStream:
+		return r.Stream
+	}
+
+	return nil
+}
+
+func (r Selector[D]) String() string {
+	if r.Predicate != "" {
+		return r.Predicate
+	}
+
+	return r.Method + " " + r.Path
+}
@@ -0,0 +1,220 @@
+// Package wsconn is the websocket connection of the silverhttp and nethttp gateways,
+// which carries the RPC containers.
+package wsconn
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/utils"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// Conn is a websocket connection whose upgrade, i.e., the handshake, is done by the
+// gateway.
+type Conn struct {
+	utils.SpinLock
+
+	kv            map[string]string
+	id            uint64
+	clientIP      string
+	c             io.ReadWriteCloser
+	rpcOutFactory kit.OutgoingRPCFactory
+	binary        bool
+}
+
+var (
+	_ kit.Conn    = (*Conn)(nil)
+	_ kit.RPCConn = (*Conn)(nil)
+)
+
+// New returns the connection of c. The messages are written as binary frames if binary
+// is set, otherwise as text frames.
+func New(
+	id uint64, clientIP string, c io.ReadWriteCloser, rpcOutFactory kit.OutgoingRPCFactory, binary bool,
+) *Conn {
+	return &Conn{
+		kv:            map[string]string{},
+		id:            id,
+		clientIP:      clientIP,
+		c:             c,
+		rpcOutFactory: rpcOutFactory,
+		binary:        binary,
+	}
+}
+
+// Serve passes the connection and its messages to the delegate until the connection is
+// closed. The messages are read from src, which may buffer the frames which are sent
+// right after the handshake.
+func (w *Conn) Serve(d kit.GatewayDelegate, src io.Reader) {
+	d.OnOpen(w)
+	w.readMessages(
+		src,
+		func(data []byte) {
+			go d.OnMessage(w, data)
+		},
+	)
+	w.Close()
+	d.OnClose(w.id)
+}
+
+func (w *Conn) Close() {
+	w.Lock()
+	if w.c != nil {
+		_ = w.c.Close()
+		w.c = nil
+	}
+	w.Unlock()
+}
+
+func (w *Conn) ConnID() uint64 {
+	return w.id
+}
+
+func (w *Conn) ClientIP() string {
+	return w.clientIP
+}
+
+func (w *Conn) Write(data []byte) (int, error) {
+	op := ws.OpText
+	if w.binary {
+		op = ws.OpBinary
+	}
+
+	var err error
+
+	w.Lock()
+
+	if w.c != nil {
+		err = wsutil.WriteServerMessage(w.c, op, data)
+	} else {
+		err = kit.ErrWriteToClosedConn
+	}
+
+	w.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+func (w *Conn) WriteEnvelope(e *kit.Envelope) error {
+	outC := w.rpcOutFactory()
+	outC.InjectMessage(e.GetMsg())
+	outC.SetID(e.GetID())
+	e.WalkHdr(
+		func(key string, val string) bool {
+			outC.SetHdr(key, val)
+
+			return true
+		},
+	)
+
+	data, err := outC.Marshal()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+
+	outC.Release()
+
+	return err
+}
+
+func (w *Conn) Stream() bool {
+	return true
+}
+
+func (w *Conn) Walk(f func(key string, val string) bool) {
+	w.Lock()
+
+	for k, v := range w.kv {
+		if !f(k, v) {
+			break
+		}
+	}
+
+	w.Unlock()
+}
+
+func (w *Conn) Get(key string) string {
+	w.Lock()
+	v := w.kv[key]
+	w.Unlock()
+
+	return v
+}
+
+func (w *Conn) Set(key string, val string) {
+	w.Lock()
+	w.kv[key] = val
+	w.Unlock()
+}
+
+// handleControl answers the control frames, i.e., ping and close, of the client. The answer
+// is written at once, hence it does not interleave with the messages of the handlers.
+func (w *Conn) handleControl(h ws.Header, r io.Reader) error {
+	frame := bytes.Buffer{}
+	err := wsutil.ControlHandler{
+		Src:                 r,
+		Dst:                 &frame,
+		State:               ws.StateServerSide,
+		DisableSrcCiphering: true,
+	}.Handle(h)
+
+	if frame.Len() > 0 {
+		w.Lock()
+		if w.c != nil {
+			_, _ = w.c.Write(frame.Bytes())
+		}
+		w.Unlock()
+	}
+
+	return err
+}
+
+// readMessages reads the data messages of the client, and calls f for each of them, until
+// the connection is closed.
+func (w *Conn) readMessages(src io.Reader, f func(data []byte)) {
+	rd := &wsutil.Reader{
+		Source:         src,
+		State:          ws.StateServerSide,
+		OnIntermediate: w.handleControl,
+	}
+
+	for {
+		h, err := rd.NextFrame()
+		if err != nil {
+			return
+		}
+
+		if h.OpCode.IsControl() {
+			if err = w.handleControl(h, rd); err != nil {
+				return
+			}
+
+			continue
+		}
+
+		if h.OpCode&(ws.OpText|ws.OpBinary) == 0 {
+			if err = rd.Discard(); err != nil {
+				return
+			}
+
+			continue
+		}
+
+		data, err := io.ReadAll(rd)
+		if err != nil {
+			return
+		}
+
+		f(data)
+	}
+}
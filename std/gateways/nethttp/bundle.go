@@ -0,0 +1,522 @@
+package nethttp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/common"
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/std/gateways/internal/realip"
+	"github.com/clubpay/ronykit/std/gateways/internal/selector"
+	"github.com/clubpay/ronykit/std/gateways/internal/wsconn"
+	"github.com/clubpay/ronykit/std/gateways/nethttp/httpmux"
+
+	"github.com/gobwas/ws"
+	reuse "github.com/libp2p/go-reuseport"
+)
+
+const (
+	queryMethod    = selector.QueryMethod
+	queryPath      = selector.QueryPath
+	queryDecoder   = selector.QueryDecoder
+	queryPredicate = selector.QueryPredicate
+	queryStream    = selector.QueryStream
+)
+
+var noExecuteArg = kit.ExecuteArg{}
+
+// Gateway is a kit.Gateway which is also an http.Handler, hence it can be mounted on any
+// server or router of net/http.
+type Gateway interface {
+	kit.Gateway
+	http.Handler
+}
+
+type bundle struct {
+	listen      string
+	l           kit.Logger
+	d           kit.GatewayDelegate
+	srv         *http.Server
+	srvName     string
+	maxBodySize int64
+	tlsConfig   *tls.Config
+	certFile    string
+	keyFile     string
+	h2c         bool
+	http2       *http.HTTP2Config
+
+	connPool sync.Pool
+	connID   atomic.Uint64
+	// netConnID numbers the network connections, which may carry several requests.
+	netConnID atomic.Uint64
+	cors      *cors
+	httpMux   *httpmux.Mux
+
+	// restRoutes keeps the versions of each route, keyed by "METHOD path". Only the
+	// first version is registered in the httpMux.
+	restRoutes map[string]*kit.VersionedRoutes[*httpmux.RouteData]
+
+	rpcRoutes     map[string]*kit.VersionedRoutes[*httpmux.RouteData]
+	wsEndpoint    string
+	wsConnsMtx    sync.Mutex
+	wsConns       map[*wsconn.Conn]struct{}
+	predicateKey  string
+	rpcInFactory  kit.IncomingRPCFactory
+	rpcOutFactory kit.OutgoingRPCFactory
+	wsBinary      bool
+}
+
+var _ Gateway = (*bundle)(nil)
+
+func New(opts ...Option) (Gateway, error) {
+	r := &bundle{
+		httpMux: &httpmux.Mux{
+			RedirectTrailingSlash:  true,
+			RedirectFixedPath:      true,
+			HandleMethodNotAllowed: true,
+			HandleOPTIONS:          true,
+		},
+		restRoutes:    map[string]*kit.VersionedRoutes[*httpmux.RouteData]{},
+		rpcRoutes:     map[string]*kit.VersionedRoutes[*httpmux.RouteData]{},
+		wsConns:       map[*wsconn.Conn]struct{}{},
+		l:             common.NewNopLogger(),
+		rpcInFactory:  common.SimpleIncomingJSONRPC,
+		rpcOutFactory: common.SimpleOutgoingJSONRPC,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if err := r.loadTLS(); err != nil {
+		return nil, err
+	}
+
+	r.srv = &http.Server{
+		Handler:     r,
+		TLSConfig:   r.tlsConfig,
+		HTTP2:       r.http2,
+		Protocols:   r.protocols(),
+		ConnContext: r.connContext,
+	}
+
+	return r, nil
+}
+
+func MustNew(opts ...Option) Gateway {
+	b, err := New(opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// Start serves the gateway on the Listen address. If the gateway has no address, it is
+// only served by the servers which it is mounted on.
+func (b *bundle) Start(ctx context.Context, cfg kit.GatewayStartConfig) error {
+	if b.listen == "" {
+		return nil
+	}
+
+	var (
+		ln  net.Listener
+		err error
+	)
+	if cfg.ReusePort {
+		ln, err = reuse.Listen("tcp4", b.listen)
+	} else {
+		ln, err = (&net.ListenConfig{}).Listen(ctx, "tcp4", b.listen)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		var err error
+		if b.tlsEnabled() {
+			// the certificates are loaded by New, and ServeTLS advertises HTTP/2 by ALPN.
+			err = b.srv.ServeTLS(ln, "", "")
+		} else {
+			err = b.srv.Serve(ln)
+		}
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			b.l.Errorf("[Gateway][nethttp] got error on serving: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Shutdown stops the server, if the gateway listens, and closes the websockets, which the
+// server does not track once they are hijacked.
+func (b *bundle) Shutdown(ctx context.Context) error {
+	var err error
+	if b.listen != "" {
+		err = b.srv.Shutdown(ctx)
+	}
+
+	b.closeWS()
+
+	return err
+}
+
+func (b *bundle) Register(
+	svcName, contractID string, enc kit.Encoding, sel kit.RouteSelector, input, _ kit.Message,
+) {
+	b.registerRPC(svcName, contractID, sel, input)
+	b.registerREST(svcName, contractID, enc, sel, input)
+}
+
+func (b *bundle) registerRPC(svcName, contractID string, sel kit.RouteSelector, input kit.Message) {
+	rpcSelector, ok := sel.(kit.RPCRouteSelector)
+	if !ok || rpcSelector.GetPredicate() == "" {
+		return
+	}
+
+	rd := &httpmux.RouteData{
+		ServiceName: svcName,
+		ContractID:  contractID,
+		Predicate:   rpcSelector.GetPredicate(),
+		Factory:     kit.CreateMessageFactory(input),
+	}
+
+	vr := b.rpcRoutes[rd.Predicate]
+	if vr == nil {
+		vr = &kit.VersionedRoutes[*httpmux.RouteData]{}
+		b.rpcRoutes[rd.Predicate] = vr
+	}
+
+	vr.Add(kit.NegotiatedVersion(sel, false), rd)
+}
+
+func (b *bundle) registerREST(
+	svcName, contractID string, enc kit.Encoding, sel kit.RouteSelector, input kit.Message,
+) {
+	restSelector, ok := sel.(kit.RESTRouteSelector)
+	if !ok {
+		return
+	}
+
+	if restSelector.GetMethod() == "" || restSelector.GetPath() == "" {
+		return
+	}
+
+	decoder, ok := restSelector.Query(queryDecoder).(DecoderFunc)
+	if !ok || decoder == nil {
+		decoder = reflectDecoder(enc, kit.CreateMessageFactory(input))
+	}
+
+	var methods []string
+	if method := restSelector.GetMethod(); method == MethodWildcard {
+		methods = append(
+			methods,
+			MethodGet, MethodPost, MethodPut, MethodPatch, MethodDelete, MethodOptions,
+			MethodConnect, MethodTrace, MethodHead,
+		)
+	} else {
+		methods = append(methods, method)
+	}
+
+	stream := false
+	if ss, ok := restSelector.(kit.StreamRouteSelector); ok {
+		stream = ss.IsStream()
+	}
+
+	version := kit.NegotiatedVersion(sel, true)
+	for _, method := range methods {
+		rd := &httpmux.RouteData{
+			ServiceName: svcName,
+			ContractID:  contractID,
+			Method:      method,
+			Path:        restSelector.GetPath(),
+			Decoder:     decoder,
+			Encoding:    enc,
+			Stream:      stream,
+		}
+
+		key := method + " " + rd.Path
+		vr := b.restRoutes[key]
+		switch {
+		case vr == nil:
+			vr = &kit.VersionedRoutes[*httpmux.RouteData]{}
+			b.restRoutes[key] = vr
+			b.httpMux.Handle(method, rd.Path, rd)
+		case vr.Has(version):
+			// let the httpMux complain about the duplicate route
+			b.httpMux.Handle(method, rd.Path, rd)
+		}
+
+		vr.Add(version, rd)
+	}
+}
+
+func (b *bundle) Subscribe(d kit.GatewayDelegate) {
+	b.d = d
+}
+
+func (b *bundle) Dispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
+	switch ctx.Conn().(type) {
+	case *httpConn, *sseConn:
+		return b.httpDispatch(ctx, in)
+	case *wsconn.Conn:
+		return b.rpcDispatch(ctx, in)
+	default:
+		panic("BUG!! incorrect connection")
+	}
+}
+
+// ServeHTTP serves the request by the contracts of the gateway. The routes are matched by
+// the URL.Path of the request, hence the gateway can be mounted under a prefix by
+// http.StripPrefix.
+func (b *bundle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if b.srvName != "" {
+		w.Header().Set("Server", b.srvName)
+	}
+
+	if b.wsEndpoint != "" && r.Method == http.MethodGet && r.URL.Path == b.wsEndpoint {
+		b.wsHandler(w, r)
+
+		return
+	}
+
+	httpBody, err := b.readBody(w, r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if _, ok := err.(*http.MaxBytesError); ok { //nolint:errorlint
+			status = http.StatusRequestEntityTooLarge
+		}
+
+		http.Error(w, http.StatusText(status), status)
+
+		return
+	}
+
+	if rd, _, _ := b.httpMux.Lookup(r.Method, r.URL.Path); rd != nil && rd.Stream {
+		b.sseHandler(w, r, httpBody)
+
+		return
+	}
+
+	c, ok := b.connPool.Get().(*httpConn)
+	if !ok {
+		c = &httpConn{}
+	}
+
+	c.w = w
+	c.r = r
+	c.id = b.connID.Add(1)
+	c.body = httpBody
+	c.stream = newStream(r)
+	b.d.OnOpen(c)
+	b.d.OnMessage(c, httpBody)
+	b.d.OnClose(c.ConnID())
+	c.finish()
+
+	c.reset()
+	b.connPool.Put(c)
+}
+
+func (b *bundle) readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body := r.Body
+	if b.maxBodySize > 0 {
+		body = http.MaxBytesReader(w, r.Body, b.maxBodySize)
+	}
+
+	return io.ReadAll(body)
+}
+
+// sseHandler serves the requests of the SSE routes. The events are written until the
+// handlers return, and then the stream is closed.
+func (b *bundle) sseHandler(w http.ResponseWriter, r *http.Request, httpBody []byte) {
+	c := &sseConn{
+		httpConn: httpConn{
+			w:      w,
+			r:      r,
+			id:     b.connID.Add(1),
+			body:   httpBody,
+			stream: newStream(r),
+		},
+		rc: http.NewResponseController(w),
+	}
+
+	setSSEHeaders(w)
+
+	b.d.OnOpen(c)
+	b.d.OnMessage(c, httpBody)
+	c.close()
+	b.d.OnClose(c.ConnID())
+}
+
+func (b *bundle) wsHandler(w http.ResponseWriter, r *http.Request) {
+	if !b.cors.handleWS(r) {
+		http.Error(w, "origin is not allowed", http.StatusForbidden)
+
+		return
+	}
+
+	conn, rw, _, err := ws.UpgradeHTTP(r, w)
+	if err != nil {
+		return
+	}
+
+	wsc := wsconn.New(
+		b.connID.Add(1), realip.FromHeader(r.Header.Get, r.RemoteAddr), conn, b.rpcOutFactory, b.wsBinary,
+	)
+	b.trackWS(wsc)
+
+	// the connection is hijacked, hence we read its messages in another goroutine and
+	// let the server release the request. The frames which are sent right after the
+	// handshake may be buffered in rw.
+	go func() {
+		wsc.Serve(b.d, rw.Reader)
+		b.untrackWS(wsc)
+	}()
+}
+
+// trackWS keeps the hijacked websocket, since the server does not close it on shutdown.
+func (b *bundle) trackWS(c *wsconn.Conn) {
+	b.wsConnsMtx.Lock()
+	b.wsConns[c] = struct{}{}
+	b.wsConnsMtx.Unlock()
+}
+
+func (b *bundle) untrackWS(c *wsconn.Conn) {
+	b.wsConnsMtx.Lock()
+	delete(b.wsConns, c)
+	b.wsConnsMtx.Unlock()
+}
+
+// closeWS closes the hijacked websockets. Their handlers call OnClose once their reads
+// fail.
+func (b *bundle) closeWS() {
+	b.wsConnsMtx.Lock()
+	for c := range b.wsConns {
+		c.Close()
+	}
+	b.wsConnsMtx.Unlock()
+}
+
+func (b *bundle) rpcDispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
+	if len(in) == 0 {
+		return noExecuteArg, kit.ErrDecodeIncomingContainerFailed
+	}
+
+	inputMsgContainer := b.rpcInFactory()
+	defer inputMsgContainer.Release()
+
+	err := inputMsgContainer.Unmarshal(in)
+	if err != nil {
+		return noExecuteArg, err
+	}
+
+	vr := b.rpcRoutes[inputMsgContainer.GetHdr(b.predicateKey)]
+	if vr == nil {
+		return noExecuteArg, kit.ErrNoHandler
+	}
+
+	routeData, ok := vr.Get(inputMsgContainer.GetHdr(kit.HeaderAcceptVersion))
+	if !ok {
+		return noExecuteArg, kit.ErrNoHandler
+	}
+
+	msg := routeData.Factory()
+	if v, ok := msg.(kit.RawMessage); ok {
+		err = inputMsgContainer.ExtractMessage(&v)
+		msg = v
+	} else {
+		err = inputMsgContainer.ExtractMessage(msg)
+	}
+
+	if err != nil {
+		return noExecuteArg, errors.Wrap(kit.ErrDecodeIncomingMessageFailed, err)
+	}
+
+	ctx.In().
+		SetID(inputMsgContainer.GetID()).
+		SetHdrMap(inputMsgContainer.GetHdrMap()).
+		SetMsg(msg)
+
+	return kit.ExecuteArg{
+		ServiceName: routeData.ServiceName,
+		ContractID:  routeData.ContractID,
+		Route:       routeData.Predicate,
+	}, nil
+}
+
+func (b *bundle) httpDispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
+	var conn *httpConn
+
+	switch c := ctx.Conn().(type) {
+	case *httpConn:
+		conn = c
+	case *sseConn:
+		conn = &c.httpConn
+	default:
+		panic("BUG!! incorrect REST connection")
+	}
+
+	routeData, params, _ := b.httpMux.Lookup(conn.GetMethod(), conn.GetPath())
+
+	// check CORS rules before even returning errRouteNotFound. This makes sure that
+	// we handle any CORS even for non-routable requests.
+	b.cors.handle(conn)
+
+	if routeData == nil {
+		if conn.r.Method == http.MethodOptions {
+			return noExecuteArg, kit.ErrPreflight
+		}
+
+		return noExecuteArg, kit.ErrNoHandler
+	}
+
+	if vr := b.restRoutes[routeData.Method+" "+routeData.Path]; vr != nil {
+		var ok bool
+
+		routeData, ok = vr.Get(conn.Get(kit.HeaderAcceptVersion))
+		if !ok {
+			return noExecuteArg, kit.ErrNoHandler
+		}
+	}
+
+	// Walk over all the query params
+	for k, vs := range conn.r.URL.Query() {
+		for _, v := range vs {
+			params = append(
+				params,
+				httpmux.Param{
+					Key:   k,
+					Value: v,
+				},
+			)
+		}
+	}
+
+	m, err := routeData.Decoder(conn.r, params, in)
+	if err != nil {
+		return noExecuteArg, errors.Wrap(kit.ErrDecodeIncomingMessageFailed, err)
+	}
+
+	conn.enc = routeData.Encoding
+	ctx.In().
+		SetHdrWalker(conn).
+		SetMsg(m)
+
+	return kit.ExecuteArg{
+		ServiceName: routeData.ServiceName,
+		ContractID:  routeData.ContractID,
+		Route:       fmt.Sprintf("%s %s", routeData.Method, routeData.Path),
+	}, nil
+}
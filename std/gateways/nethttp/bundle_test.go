@@ -0,0 +1,250 @@
+package nethttp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/desc"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+type echoMsg struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+// startMountedServer starts a kit server whose gateway is mounted under /api on an
+// http.ServeMux.
+func startMountedServer(t *testing.T, opts ...Option) string {
+	t.Helper()
+
+	gw := MustNew(append(opts, WithWebsocketEndpoint("/ws"), WithPredicateKey("cmd"))...)
+
+	svc := desc.NewService("svc").
+		AddContract(
+			desc.NewContract().
+				SetInput(&echoMsg{}).
+				SetOutput(&echoMsg{}).
+				AddRoute(desc.Route("echo", RPC("echo"))).
+				AddRoute(desc.Route("get", GET("/echo/:id"))).
+				AddRoute(desc.Route("post", POST("/echo/:id"))).
+				AddHandler(func(ctx *kit.Context) {
+					in := ctx.In().GetMsg().(*echoMsg) //nolint:forcetypeassert
+					ctx.In().Reply().SetMsg(&echoMsg{ID: in.ID, Value: in.Value + ":" + ctx.Conn().Get("k")}).Send()
+				}),
+			desc.NewContract().
+				SetInput(&echoMsg{}).
+				SetOutput(&echoMsg{}).
+				AddRoute(desc.Route("events", SSE("/events"))).
+				AddRoute(desc.Route("twice", GET("/twice"))).
+				AddHandler(func(ctx *kit.Context) {
+					for _, v := range []string{"a", "b", "c"} {
+						ctx.Out().SetMsg(&echoMsg{Value: v}).Send()
+						if !ctx.Conn().Stream() && v == "b" {
+							ctx.Conn().(kit.RESTConn).Set("X-Last", v) //nolint:forcetypeassert
+
+							return
+						}
+					}
+				}),
+		)
+
+	s := kit.NewServer(
+		kit.WithGateway(gw),
+		kit.WithServiceBuilder(svc),
+	)
+	s.Start(t.Context())
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", gw))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+func TestServeHTTP(t *testing.T) {
+	url := startMountedServer(t)
+
+	resp, err := http.Get(url + "/api/echo/12?value=hi") //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != `{"id":"12","value":"hi:"}` {
+		t.Fatalf("unexpected response: %d %s", resp.StatusCode, body)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, url+"/api/echo/13", strings.NewReader(`{"value":"posted"}`)) //nolint:noctx
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("k", "v")
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != `{"id":"13","value":"posted:v"}` {
+		t.Fatalf("unexpected response: %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestServeHTTPSendTwice(t *testing.T) {
+	url := startMountedServer(t)
+
+	resp, err := http.Get(url + "/api/twice") //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	// the body is written once the handlers return, hence both envelopes and the header
+	// which is set after them are sent.
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Last") != "b" ||
+		string(body) != "{\"id\":\"\",\"value\":\"a\"}\n{\"id\":\"\",\"value\":\"b\"}\n" {
+		t.Fatalf("unexpected response: %d %v %s", resp.StatusCode, resp.Header, body)
+	}
+}
+
+func TestServeHTTPBodyLimit(t *testing.T) {
+	url := startMountedServer(t, WithBufferSize(8))
+
+	resp, err := http.Post(url+"/api/echo/1", "application/json", strings.NewReader(`{"value":"too long"}`)) //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+}
+
+func TestWebsocketRPC(t *testing.T) {
+	url := startMountedServer(t)
+
+	conn, _, _, err := ws.Dial(t.Context(), "ws"+strings.TrimPrefix(url, "http")+"/api/ws")
+	if err != nil {
+		t.Fatalf("ws dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// the ping of the client is answered while the messages are served
+	if err = wsutil.WriteClientMessage(conn, ws.OpPing, []byte("p")); err != nil {
+		t.Fatal(err)
+	}
+
+	err = wsutil.WriteClientText(conn, []byte(`{"id":"1","hdr":{"cmd":"echo"},"payload":{"value":"hi"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var msgs []wsutil.Message
+	for len(msgs) < 2 {
+		var next []wsutil.Message
+
+		next, err = wsutil.ReadServerMessage(conn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msgs = append(msgs, next...)
+	}
+
+	if msgs[0].OpCode != ws.OpPong || string(msgs[0].Payload) != "p" {
+		t.Fatalf("expected pong, got: %v %s", msgs[0].OpCode, msgs[0].Payload)
+	}
+	if msgs[1].OpCode != ws.OpText || !strings.Contains(string(msgs[1].Payload), `"value":"hi:"`) ||
+		!strings.Contains(string(msgs[1].Payload), `"id":"1"`) {
+		t.Fatalf("unexpected response: %s", msgs[1].Payload)
+	}
+}
+
+func TestSSE(t *testing.T) {
+	url := startMountedServer(t)
+
+	resp, err := http.Get(url + "/api/events") //nolint:noctx
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != sseContentType {
+		t.Fatalf("unexpected content type: %s", resp.Header.Get("Content-Type"))
+	}
+
+	var data []string
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+			data = append(data, v)
+		}
+	}
+
+	if len(data) != 3 || data[0] != `{"id":"","value":"a"}` || data[2] != `{"id":"","value":"c"}` {
+		t.Fatalf("unexpected events: %v", data)
+	}
+}
+
+func TestShutdownClosesWebsockets(t *testing.T) {
+	gw := MustNew(WithWebsocketEndpoint("/ws"))
+
+	s := kit.NewServer(
+		kit.WithGateway(gw),
+		kit.WithServiceBuilder(desc.NewService("svc")),
+	)
+	s.Start(t.Context())
+
+	srv := httptest.NewServer(gw)
+	t.Cleanup(srv.Close)
+
+	conn, _, _, err := ws.Dial(t.Context(), "ws"+strings.TrimPrefix(srv.URL, "http")+"/ws")
+	if err != nil {
+		t.Fatalf("ws dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// the gateway is mounted without Listen, hence only its Shutdown closes the websocket.
+	s.Shutdown(t.Context())
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err = wsutil.ReadServerText(conn); err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected the websocket to be closed, got: %v", err)
+	}
+}
+
+func TestStartWithoutListen(t *testing.T) {
+	b := MustNew()
+
+	if err := b.Start(t.Context(), kit.GatewayStartConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Shutdown(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,263 @@
+package nethttp
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/utils"
+	"github.com/clubpay/ronykit/kit/utils/buf"
+	"github.com/clubpay/ronykit/std/gateways/internal/realip"
+)
+
+const (
+	headerHost = "Host"
+
+	// maxPooledBody is the largest buffer of the body, which is kept by the pooled
+	// connections.
+	maxPooledBody = 64 << 10
+)
+
+type httpConn struct {
+	utils.SpinLock
+
+	w    http.ResponseWriter
+	r    *http.Request
+	id   uint64
+	enc  kit.Encoding
+	body []byte
+
+	stream stream
+
+	// the body is buffered and written by finish, as the fasthttp gateway does, hence
+	// the handlers may write several times and set the headers after the body.
+	out      []byte
+	buffered bool
+
+	status      int
+	wroteHeader bool
+}
+
+var _ kit.RESTConn = (*httpConn)(nil)
+
+func (c *httpConn) reset() {
+	c.w = nil
+	c.r = nil
+	c.body = nil
+	c.stream = stream{}
+	c.enc = kit.Undefined
+	c.out = c.out[:0]
+	if cap(c.out) > maxPooledBody {
+		c.out = nil
+	}
+	c.buffered = false
+	c.status = 0
+	c.wroteHeader = false
+}
+
+// Walk calls f for each value of the request headers. The Host header, which net/http
+// keeps out of the request headers, and the stream metadata are walked first.
+func (c *httpConn) Walk(f func(key string, val string) bool) {
+	if !f(headerHost, c.r.Host) {
+		return
+	}
+
+	for _, k := range streamKeys {
+		if v, _ := c.stream.value(k); v != "" && !f(k, v) {
+			return
+		}
+	}
+
+	for k, vs := range c.r.Header {
+		if _, ok := c.stream.value(k); ok {
+			continue
+		}
+
+		for _, v := range vs {
+			if !f(k, v) {
+				return
+			}
+		}
+	}
+}
+
+func (c *httpConn) WalkQueryParams(f func(key string, val string) bool) {
+	for k, vs := range c.r.URL.Query() {
+		for _, v := range vs {
+			if !f(k, v) {
+				return
+			}
+		}
+	}
+}
+
+func (c *httpConn) Get(key string) string {
+	if http.CanonicalHeaderKey(key) == headerHost {
+		return c.r.Host
+	}
+
+	if v, ok := c.stream.value(key); ok {
+		return v
+	}
+
+	return c.r.Header.Get(key)
+}
+
+func (c *httpConn) Set(key string, val string) {
+	c.w.Header().Set(key, val)
+}
+
+func (c *httpConn) SetStatusCode(code int) {
+	c.status = code
+}
+
+func (c *httpConn) writeHeader() {
+	if c.wroteHeader {
+		return
+	}
+
+	c.wroteHeader = true
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+
+	c.w.WriteHeader(c.status)
+}
+
+// finish writes the buffered body once the handlers return. The status code is written
+// even if no body is written, e.g., the answer of the preflight requests. The responses
+// which are already written, e.g., the relayed ones and the redirects, are left as they
+// are.
+func (c *httpConn) finish() {
+	if c.wroteHeader {
+		return
+	}
+
+	if !c.buffered {
+		if c.status != 0 {
+			c.writeHeader()
+		}
+
+		return
+	}
+
+	c.w.Header().Set(headerContentLength, strconv.Itoa(len(c.out)))
+	c.writeHeader()
+	_, _ = c.w.Write(c.out)
+}
+
+func (c *httpConn) ConnID() uint64 {
+	return c.id
+}
+
+func (c *httpConn) ClientIP() string {
+	return realip.FromHeader(c.r.Header.Get, c.r.RemoteAddr)
+}
+
+// Write appends data to the body, which is written by finish.
+func (c *httpConn) Write(data []byte) (int, error) {
+	c.out = append(c.out, data...)
+	c.buffered = true
+
+	return len(data), nil
+}
+
+func (c *httpConn) WriteEnvelope(e *kit.Envelope) error {
+	if enc := c.responseEncoding(); enc != kit.JSON && enc != kit.Undefined {
+		return c.writeEnvelopeAs(enc, e)
+	}
+
+	dataBuf := buf.GetCap(e.SizeHint())
+	defer dataBuf.Release()
+
+	err := kit.EncodeMessage(e.GetMsg(), dataBuf)
+	if err != nil {
+		return err
+	}
+
+	resHdr := c.w.Header()
+
+	e.WalkHdr(
+		func(key string, val string) bool {
+			resHdr.Set(key, val)
+
+			return true
+		},
+	)
+
+	_, err = c.Write(*dataBuf.Bytes())
+
+	return err
+}
+
+// responseEncoding negotiates the encoding of the response by the Accept and Content-Type
+// headers of the request, and the encoding of the contract.
+func (c *httpConn) responseEncoding() kit.Encoding {
+	def := kit.JSON
+	if c.enc != kit.Undefined {
+		def = c.enc
+	}
+
+	return kit.NegotiateEncoding(c.Get(headerAccept), c.Get(headerContentType), def)
+}
+
+func (c *httpConn) writeEnvelopeAs(enc kit.Encoding, e *kit.Envelope) error {
+	data, used, err := kit.MarshalMessageAs(enc, e.GetMsg())
+	if err != nil {
+		return err
+	}
+
+	resHdr := c.w.Header()
+
+	// the raw messages are already encoded, so the handler sets their content type.
+	if _, ok := e.GetMsg().(kit.RawMessage); !ok {
+		resHdr.Set(headerContentType, used.ContentType())
+	}
+
+	e.WalkHdr(
+		func(key string, val string) bool {
+			resHdr.Set(key, val)
+
+			return true
+		},
+	)
+
+	_, err = c.Write(data)
+
+	return err
+}
+
+func (c *httpConn) Stream() bool {
+	return false
+}
+
+func (c *httpConn) GetHost() string {
+	return c.r.Host
+}
+
+func (c *httpConn) GetRequestURI() string {
+	return c.r.RequestURI
+}
+
+func (c *httpConn) GetMethod() string {
+	return c.r.Method
+}
+
+func (c *httpConn) GetPath() string {
+	return c.r.URL.Path
+}
+
+func (c *httpConn) Redirect(statusCode int, url string) {
+	c.wroteHeader = true
+	http.Redirect(c.w, c.r, url, statusCode)
+}
+
+// GetRequest returns the request of net/http, e.g., to read the values of its context,
+// which are set by the middlewares.
+func (c *httpConn) GetRequest() *http.Request {
+	return c.r
+}
+
+func (c *httpConn) GetResponseWriter() http.ResponseWriter {
+	return c.w
+}
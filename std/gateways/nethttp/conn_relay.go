@@ -0,0 +1,178 @@
+package nethttp
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/std/gateways/internal/httprelay"
+
+	"github.com/gobwas/ws"
+)
+
+var _ kit.RelayConn = (*httpConn)(nil)
+
+// RequestBody returns the body of the request, which is decoded by its Content-Encoding:
+// gzip, deflate, br or zstd.
+func (c *httpConn) RequestBody() ([]byte, error) {
+	return httprelay.DecodeBody(c.r.Header.Get(headerContentEncoding), c.body)
+}
+
+func (c *httpConn) IsWebSocketUpgrade() bool {
+	return httprelay.HeaderHasToken(c.r.Header.Values(headerConnection), "upgrade") &&
+		httprelay.HeaderHasToken(c.r.Header.Values(headerUpgrade), "websocket")
+}
+
+// RelayHTTP sends the request to the targetURL, and writes the response of the upstream.
+// The body is relayed as it is received, hence it keeps its Content-Encoding. The
+// response body is streamed to the client, unless cfg.RewriteResponse is set, which
+// needs the whole body.
+func (c *httpConn) RelayHTTP(targetURL string, cfg kit.RelayConfig) error {
+	u, err := httprelay.TargetURL(targetURL, cfg)
+	if err != nil {
+		return err
+	}
+
+	header := httprelay.RequestHeader(c.r.Header, c.r.RemoteAddr, cfg)
+
+	res, err := httprelay.Send(c.GetMethod(), u, header, c.body, cfg)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if cfg.RewriteResponse != nil {
+		view, err := httprelay.RewriteResponse(res, cfg)
+		if err != nil {
+			return err
+		}
+
+		return c.WriteHTTPResponse(view.StatusCode, view.Header, view.Body)
+	}
+
+	c.streamResponse(res)
+
+	return kit.ErrRelayCompleted
+}
+
+// streamResponse writes the response of the upstream while it is read. The bodies of
+// unknown length, e.g., the event streams, are flushed as soon as each chunk is read.
+// If the upstream fails after the headers are written, the connection is closed, hence
+// the client does not take the partial body as complete.
+func (c *httpConn) streamResponse(res *http.Response) {
+	setResponseHeader(c.w, res.Header)
+	c.SetStatusCode(res.StatusCode)
+
+	var err error
+
+	rc := http.NewResponseController(c.w)
+	if res.ContentLength >= 0 {
+		c.w.Header().Set(headerContentLength, strconv.FormatInt(res.ContentLength, 10))
+		c.writeHeader()
+		_, err = io.Copy(c.w, res.Body)
+	} else {
+		c.writeHeader()
+
+		buf := make([]byte, 8<<10)
+		for err == nil {
+			var n int
+
+			n, err = res.Body.Read(buf)
+			if n > 0 {
+				if _, werr := c.w.Write(buf[:n]); werr != nil {
+					err = werr
+
+					break
+				}
+
+				_ = rc.Flush()
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		// only the HTTP/1.x connections can be hijacked, the streams of HTTP/2 are reset
+		// when the body is shorter than its Content-Length.
+		if conn, _, herr := rc.Hijack(); herr == nil {
+			_ = conn.Close()
+		}
+	}
+}
+
+// RelayWebSocket dials the websocket of the targetURL, upgrades the connection of the
+// client, and then relays the frames in both directions until either side is closed.
+// The frames are relayed as they are, hence the control frames, e.g., ping and close,
+// are answered end to end.
+func (c *httpConn) RelayWebSocket(targetURL string, cfg kit.RelayConfig) error {
+	u, err := httprelay.WebSocketURL(targetURL, cfg)
+	if err != nil {
+		return err
+	}
+
+	header, err := httprelay.WebSocketHeader(u, httprelay.RequestHeader(c.r.Header, c.r.RemoteAddr, cfg), cfg)
+	if err != nil {
+		return err
+	}
+
+	backend, br, rejected, err := httprelay.DialWebSocket(u, header, cfg)
+	if err != nil {
+		if rejected != nil {
+			_ = c.WriteHTTPResponse(rejected.StatusCode, rejected.Header, rejected.Body)
+		} else {
+			_ = c.WriteHTTPResponse(
+				http.StatusServiceUnavailable,
+				http.Header{headerContentType: []string{MIMETextPlainCharsetUTF8}},
+				[]byte(err.Error()),
+			)
+		}
+
+		return err
+	}
+
+	origin := c.r.Header.Get(HeaderOrigin)
+	if (cfg.WebSocketCheckOrigin == nil && !httprelay.SameOrigin(origin, c.r.Host)) ||
+		(cfg.WebSocketCheckOrigin != nil && !cfg.WebSocketCheckOrigin(origin)) {
+		_ = backend.Close()
+		_ = c.WriteHTTPResponse(http.StatusForbidden, nil, []byte(http.StatusText(http.StatusForbidden)))
+
+		return errors.New("websocket: request origin not allowed")
+	}
+
+	upgrader := ws.HTTPUpgrader{}
+	protocols := c.r.Header.Values(headerSecWSProtocol)
+	if p := httprelay.SelectSubprotocol(protocols, cfg.WebSocketSubprotocols); p != "" {
+		upgrader.Protocol = func(s string) bool { return s == p }
+	}
+
+	client, rw, _, err := upgrader.Upgrade(c.r, c.w)
+	if err != nil {
+		// the upgrader has already answered the client, and the upstream connection is
+		// closed here to avoid leaking it.
+		c.wroteHeader = true
+		_ = backend.Close()
+
+		return err
+	}
+
+	c.wroteHeader = true
+
+	go httprelay.RelayFrames(client, rw.Reader, backend, br)
+
+	return kit.ErrRelayCompleted
+}
+
+func (c *httpConn) WriteHTTPResponse(status int, header http.Header, body []byte) error {
+	setResponseHeader(c.w, header)
+	c.SetStatusCode(status)
+	c.out = append(c.out[:0], body...)
+	c.buffered = true
+
+	return kit.ErrRelayCompleted
+}
@@ -0,0 +1,74 @@
+package nethttp
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/utils/buf"
+)
+
+// sseConn is the connection of the SSE routes. The events are written to the response
+// until the handlers return, and then the connection is closed.
+type sseConn struct {
+	httpConn
+
+	rc     *http.ResponseController
+	wMtx   sync.Mutex // protects closed
+	closed bool
+}
+
+var (
+	_ kit.RESTConn = (*sseConn)(nil)
+	_ kit.Conn     = (*sseConn)(nil)
+)
+
+func (c *sseConn) Stream() bool {
+	return true
+}
+
+func (c *sseConn) Write(data []byte) (int, error) {
+	err := c.writeEvent(appendSSEEvent(nil, "", data))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+// WriteEnvelope writes the message of the envelope as an event. The headers of the envelope
+// are not sent, since the response headers are already written by the first event.
+func (c *sseConn) WriteEnvelope(e *kit.Envelope) error {
+	dataBuf := buf.GetCap(e.SizeHint())
+	defer dataBuf.Release()
+
+	err := kit.EncodeMessage(e.GetMsg(), dataBuf)
+	if err != nil {
+		return err
+	}
+
+	return c.writeEvent(appendSSEEvent(nil, sseEventMessage, *dataBuf.Bytes()))
+}
+
+func (c *sseConn) writeEvent(event []byte) error {
+	c.wMtx.Lock()
+	defer c.wMtx.Unlock()
+
+	if c.closed {
+		return kit.ErrWriteToClosedConn
+	}
+
+	if _, err := c.w.Write(event); err != nil {
+		return err
+	}
+
+	return c.rc.Flush()
+}
+
+// close makes any further write fail, since the response writer is released after the
+// handlers return.
+func (c *sseConn) close() {
+	c.wMtx.Lock()
+	c.closed = true
+	c.wMtx.Unlock()
+}
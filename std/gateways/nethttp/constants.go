@@ -0,0 +1,44 @@
+package nethttp
+
+import "net/http"
+
+const (
+	maxMimeFormSize = 1 << 24
+
+	headerAccept      = "Accept"
+	headerContentType = "Content-Type"
+)
+
+// HTTP methods of net/http, which are re-exported to build the selectors.
+const (
+	MethodGet      = http.MethodGet
+	MethodHead     = http.MethodHead
+	MethodPost     = http.MethodPost
+	MethodPut      = http.MethodPut
+	MethodPatch    = http.MethodPatch
+	MethodDelete   = http.MethodDelete
+	MethodConnect  = http.MethodConnect
+	MethodOptions  = http.MethodOptions
+	MethodTrace    = http.MethodTrace
+	MethodWildcard = "*"
+)
+
+// MIME types that are commonly used
+const (
+	MIMETextXML               = "text/xml"
+	MIMETextHTML              = "text/html"
+	MIMETextPlain             = "text/plain"
+	MIMEApplicationXML        = "application/xml"
+	MIMEApplicationJSON       = "application/json"
+	MIMEApplicationJavaScript = "application/javascript"
+	MIMEApplicationForm       = "application/x-www-form-urlencoded"
+	MIMEOctetStream           = "application/octet-stream"
+	MIMEMultipartForm         = "multipart/form-data"
+
+	MIMETextXMLCharsetUTF8               = "text/xml; charset=utf-8"
+	MIMETextHTMLCharsetUTF8              = "text/html; charset=utf-8"
+	MIMETextPlainCharsetUTF8             = "text/plain; charset=utf-8"
+	MIMEApplicationXMLCharsetUTF8        = "application/xml; charset=utf-8"
+	MIMEApplicationJSONCharsetUTF8       = "application/json; charset=utf-8"
+	MIMEApplicationJavaScriptCharsetUTF8 = "application/javascript; charset=utf-8"
+)
@@ -0,0 +1,229 @@
+package nethttp
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"unsafe"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/kit/utils"
+	"github.com/clubpay/ronykit/std/gateways/nethttp/httpmux"
+
+	"github.com/goccy/go-reflect"
+)
+
+type (
+	Params      = httpmux.Params
+	DecoderFunc = func(r *http.Request, bag Params, data []byte) (kit.Message, error)
+)
+
+// emptyInterface is the header for an interface{} value.
+type emptyInterface struct {
+	_    uint64
+	word unsafe.Pointer
+}
+
+type paramCaster struct {
+	offset uintptr
+	name   string
+	opt    string
+	typ    reflect.Type
+}
+
+func reflectDecoder(enc kit.Encoding, factory kit.MessageFactoryFunc) DecoderFunc {
+	switch factory().(type) {
+	case kit.MultipartFormMessage:
+		return func(r *http.Request, _ Params, data []byte) (kit.Message, error) {
+			mr, err := multipartReader(r, data)
+			if err != nil {
+				return nil, err
+			}
+
+			frm, err := mr.ReadForm(maxMimeFormSize)
+			if err != nil {
+				return nil, err
+			}
+
+			v := kit.MultipartFormMessage{}
+			v.SetForm(frm)
+
+			return v, nil
+		}
+	case kit.RawMessage:
+		return func(_ *http.Request, _ Params, data []byte) (kit.Message, error) {
+			v := kit.RawMessage{}
+			v.CopyFrom(data)
+
+			return v, nil
+		}
+	default:
+	}
+
+	tagKey := enc.FieldTag()
+
+	rVal := reflect.ValueOf(factory())
+	if rVal.Kind() != reflect.Ptr {
+		panic(fmt.Sprintf("%s must be a pointer to struct", rVal.String()))
+	}
+
+	rVal = rVal.Elem()
+	if rVal.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("%s must be a pointer to struct", rVal.String()))
+	}
+
+	pcs := extractFields(rVal, tagKey)
+
+	return genDecoder(enc, factory, pcs...)
+}
+
+// multipartReader returns the reader of the multipart body, which is already read by the
+// gateway.
+func multipartReader(r *http.Request, data []byte) (*multipart.Reader, error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get(headerContentType))
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, errors.New("request is not multipart: %s", mediaType)
+	}
+
+	return multipart.NewReader(bytes.NewReader(data), params["boundary"]), nil
+}
+
+// bodyEncoding returns the encoding of the request body by its Content-Type header. If
+// the content type is not registered in kit, the encoding of the contract is used.
+func bodyEncoding(r *http.Request, enc kit.Encoding) kit.Encoding {
+	if r == nil {
+		return enc
+	}
+
+	if v, ok := kit.EncodingByContentType(r.Header.Get(headerContentType)); ok {
+		return v
+	}
+
+	return enc
+}
+
+func genDecoder(enc kit.Encoding, factory kit.MessageFactoryFunc, pcs ...paramCaster) DecoderFunc {
+	return func(r *http.Request, bag Params, data []byte) (kit.Message, error) {
+		var (
+			v   = factory()
+			err error
+		)
+
+		if len(data) > 0 {
+			err = kit.UnmarshalMessageAs(bodyEncoding(r, enc), data, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for idx := range pcs {
+			x := bag.ByName(pcs[idx].name)
+			if x == "" {
+				continue
+			}
+
+			ptr := unsafe.Add((*emptyInterface)(unsafe.Pointer(&v)).word, pcs[idx].offset)
+
+			switch pcs[idx].typ.Kind() {
+			default:
+			// ignore
+			case reflect.Ptr:
+				switch pcs[idx].typ.Elem().Kind() {
+				default:
+				// ignore
+				case reflect.Bool:
+					if strings.ToLower(x) == "true" {
+						*(**bool)(ptr) = utils.ValPtr(true)
+					}
+				case reflect.String:
+					*(**string)(ptr) = utils.ValPtr(x)
+				case reflect.Int64:
+					*(**int64)(ptr) = utils.ValPtr(utils.StrToInt64(x))
+				case reflect.Int32:
+					*(**int32)(ptr) = utils.ValPtr(utils.StrToInt32(x))
+				case reflect.Uint64:
+					*(**uint64)(ptr) = utils.ValPtr(utils.StrToUInt64(x))
+				case reflect.Uint32:
+					*(**uint32)(ptr) = utils.ValPtr(utils.StrToUInt32(x))
+				case reflect.Float64:
+					*(**float64)(ptr) = utils.ValPtr(utils.StrToFloat64(x))
+				case reflect.Float32:
+					*(**float32)(ptr) = utils.ValPtr(utils.StrToFloat32(x))
+				case reflect.Int:
+					*(**int)(ptr) = utils.ValPtr(utils.StrToInt(x))
+				case reflect.Uint:
+					*(**uint)(ptr) = utils.ValPtr(utils.StrToUInt(x))
+				}
+			case reflect.Int64:
+				*(*int64)(ptr) = utils.StrToInt64(x)
+			case reflect.Int32:
+				*(*int32)(ptr) = utils.StrToInt32(x)
+			case reflect.Uint64:
+				*(*uint64)(ptr) = utils.StrToUInt64(x)
+			case reflect.Uint32:
+				*(*uint32)(ptr) = utils.StrToUInt32(x)
+			case reflect.Float64:
+				*(*float64)(ptr) = utils.StrToFloat64(x)
+			case reflect.Float32:
+				*(*float32)(ptr) = utils.StrToFloat32(x)
+			case reflect.Int:
+				*(*int)(ptr) = utils.StrToInt(x)
+			case reflect.Uint:
+				*(*uint)(ptr) = utils.StrToUInt(x)
+			case reflect.Slice:
+				switch pcs[idx].typ.Elem().Kind() {
+				default:
+					// ignore
+				case reflect.Uint8:
+					*(*[]byte)(ptr) = utils.S2B(x)
+				}
+			case reflect.String:
+				*(*string)(ptr) = string(utils.S2B(x))
+			case reflect.Bool:
+				if strings.ToLower(x) == "true" {
+					*(*bool)(ptr) = true
+				}
+			}
+		}
+
+		return v.(kit.Message), nil //nolint:forcetypeassert
+	}
+}
+
+func extractFields(rVal reflect.Value, tagKey string) []paramCaster {
+	var pcs []paramCaster
+
+	for i := range rVal.NumField() {
+		f := rVal.Type().Field(i)
+		if f.Type.Kind() == reflect.Struct && f.Anonymous {
+			pcs = append(pcs, extractFields(rVal.Field(i), tagKey)...)
+		} else {
+			if tagValue := f.Tag.Get(tagKey); tagValue != "" {
+				valueParts := strings.Split(tagValue, ",")
+				if len(valueParts) == 1 {
+					valueParts = append(valueParts, "")
+				}
+
+				pcs = append(
+					pcs,
+					paramCaster{
+						offset: f.Offset,
+						name:   valueParts[0],
+						opt:    valueParts[1],
+						typ:    f.Type,
+					},
+				)
+			}
+		}
+	}
+
+	return pcs
+}
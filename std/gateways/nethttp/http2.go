@@ -0,0 +1,135 @@
+package nethttp
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/clubpay/ronykit/kit/errors"
+)
+
+// The keys of the stream metadata, which are exposed by Conn.Get and Conn.Walk of the
+// REST connections. They are set by the gateway, so the request headers of these names
+// are neither walked nor returned by Conn.Get.
+const (
+	// StreamProtocol is the protocol of the request, e.g., "HTTP/1.1" or "HTTP/2.0".
+	StreamProtocol = "X-Stream-Protocol"
+	// StreamConnID is the id of the network connection, which is shared by the streams
+	// multiplexed on it. It is set only if the gateway serves its own server by Listen.
+	StreamConnID = "X-Stream-Conn-Id"
+	// StreamID numbers the requests of a network connection, i.e., the HTTP/2 streams or
+	// the HTTP/1.1 requests which reuse the connection, from 1 in the order they are
+	// served. It is not the id of the HTTP/2 frames, which net/http does not expose. It
+	// is set only if the gateway serves its own server by Listen.
+	StreamID = "X-Stream-Id"
+)
+
+var streamKeys = [...]string{StreamProtocol, StreamConnID, StreamID}
+
+var ErrNoCertificate = errors.New("no certificate is set by WithTLS or WithTLSConfig")
+
+type netConnKey struct{}
+
+// netConn is kept in the context of each network connection, which is inherited by its
+// requests.
+type netConn struct {
+	id      uint64
+	streams atomic.Uint64
+}
+
+// protocols returns the protocols of the server. HTTP/2 is served on the TLS connections
+// which negotiate it, and h2c on the cleartext ones if it is enabled by WithH2C.
+func (b *bundle) protocols() *http.Protocols {
+	p := &http.Protocols{}
+	p.SetHTTP1(true)
+	p.SetHTTP2(true)
+	p.SetUnencryptedHTTP2(b.h2c)
+
+	return p
+}
+
+func (b *bundle) tlsEnabled() bool {
+	return b.tlsConfig != nil
+}
+
+// loadTLS loads the files of WithTLS into the config of the server, unless the config of
+// WithTLSConfig has its own certificates. The files are loaded once, hence their errors
+// are returned by New.
+func (b *bundle) loadTLS() error {
+	switch {
+	case b.tlsConfig != nil && hasCertificate(b.tlsConfig):
+		return nil
+	case b.certFile == "" && b.tlsConfig == nil:
+		return nil
+	case b.certFile == "":
+		return ErrNoCertificate
+	}
+
+	cert, err := tls.LoadX509KeyPair(b.certFile, b.keyFile)
+	if err != nil {
+		return err
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if b.tlsConfig != nil {
+		cfg = b.tlsConfig.Clone()
+	}
+
+	cfg.Certificates = []tls.Certificate{cert}
+	b.tlsConfig = cfg
+
+	return nil
+}
+
+func hasCertificate(cfg *tls.Config) bool {
+	return len(cfg.Certificates) > 0 || cfg.GetCertificate != nil || cfg.GetConfigForClient != nil
+}
+
+// connContext tags the context of each network connection with its id, which is then
+// inherited by the requests, or the HTTP/2 streams, of the connection.
+func (b *bundle) connContext(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, netConnKey{}, &netConn{id: b.netConnID.Add(1)})
+}
+
+// stream is the metadata of a request, which is taken once the request is served.
+type stream struct {
+	proto  string
+	connID uint64
+	id     uint64
+}
+
+func newStream(r *http.Request) stream {
+	s := stream{proto: r.Proto}
+	if nc, ok := r.Context().Value(netConnKey{}).(*netConn); ok {
+		s.connID = nc.id
+		s.id = nc.streams.Add(1)
+	}
+
+	return s
+}
+
+// value returns the metadata of key, and whether key is one of the keys of the stream
+// metadata.
+func (s stream) value(key string) (string, bool) {
+	switch http.CanonicalHeaderKey(key) {
+	case StreamProtocol:
+		return s.proto, true
+	case StreamConnID:
+		return formatID(s.connID), true
+	case StreamID:
+		return formatID(s.id), true
+	}
+
+	return "", false
+}
+
+func formatID(id uint64) string {
+	if id == 0 {
+		return ""
+	}
+
+	return strconv.FormatUint(id, 10)
+}
@@ -0,0 +1,263 @@
+package nethttp
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/desc"
+)
+
+type streamMsg struct {
+	Protocol string `json:"protocol"`
+	ConnID   string `json:"connID"`
+	StreamID string `json:"streamID,omitempty"`
+	Custom   string `json:"custom,omitempty"`
+}
+
+// startListenServer starts a kit server whose gateway listens on its own address.
+func startListenServer(t *testing.T, opts ...Option) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	svc := desc.NewService("svc").
+		AddContract(
+			desc.NewContract().
+				SetInput(&streamMsg{}).
+				SetOutput(&streamMsg{}).
+				AddRoute(desc.Route("meta", GET("/meta"))).
+				AddHandler(func(ctx *kit.Context) {
+					ctx.In().Reply().
+						SetMsg(&streamMsg{
+							Protocol: ctx.Conn().Get(StreamProtocol),
+							ConnID:   ctx.Conn().Get(StreamConnID),
+							StreamID: ctx.Conn().Get(StreamID),
+							Custom:   ctx.Conn().Get("X-Stream-Custom"),
+						}).
+						Send()
+				}),
+			desc.NewContract().
+				SetInput(&streamMsg{}).
+				SetOutput(&streamMsg{}).
+				AddRoute(desc.Route("events", SSE("/events"))).
+				AddHandler(func(ctx *kit.Context) {
+					for range 3 {
+						ctx.Out().SetMsg(&streamMsg{Protocol: ctx.Conn().Get(StreamProtocol)}).Send()
+					}
+				}),
+		)
+
+	s := kit.NewServer(
+		kit.WithGateway(MustNew(append(opts, Listen(addr))...)),
+		kit.WithServiceBuilder(svc),
+	)
+	s.Start(t.Context())
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	for range 50 {
+		c, err := net.Dial("tcp4", addr)
+		if err == nil {
+			_ = c.Close()
+
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return addr
+}
+
+func getBody(t *testing.T, c *http.Client, url string, hdr http.Header) (*http.Response, string) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil) //nolint:noctx
+	for k, vs := range hdr {
+		req.Header[k] = vs
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	return resp, strings.TrimSpace(string(body))
+}
+
+func TestH2C(t *testing.T) {
+	addr := startListenServer(t, WithH2C())
+
+	p := &http.Protocols{}
+	p.SetUnencryptedHTTP2(true)
+
+	c := &http.Client{Transport: &http.Transport{Protocols: p}}
+	defer c.CloseIdleConnections()
+
+	// the clients cannot forge the stream metadata, but their other headers are kept.
+	resp, first := getBody(t, c, "http://"+addr+"/meta", http.Header{
+		StreamConnID:      []string{"forged"},
+		"X-Stream-Custom": []string{"kept"},
+	})
+	if resp.ProtoMajor != 2 || !strings.HasPrefix(first, `{"protocol":"HTTP/2.0","connID":"`) ||
+		strings.Contains(first, "forged") || strings.Contains(first, `"connID":""`) ||
+		!strings.HasSuffix(first, `"streamID":"1","custom":"kept"}`) {
+		t.Fatalf("unexpected response: %s %s", resp.Proto, first)
+	}
+
+	// the streams of the same connection share its id, and are numbered in order.
+	_, body := getBody(t, c, "http://"+addr+"/meta", nil)
+	if expected := strings.Replace(first, `"streamID":"1","custom":"kept"}`, `"streamID":"2"}`, 1); body != expected {
+		t.Fatalf("unexpected response: %s, expected: %s", body, expected)
+	}
+
+	// the HTTP/1.1 requests are still served.
+	resp, body = getBody(t, http.DefaultClient, "http://"+addr+"/meta", nil)
+	if resp.ProtoMajor != 1 || !strings.Contains(body, `"protocol":"HTTP/1.1"`) {
+		t.Fatalf("unexpected response: %s %s", resp.Proto, body)
+	}
+}
+
+func TestH2CSSE(t *testing.T) {
+	addr := startListenServer(t, WithH2C())
+
+	p := &http.Protocols{}
+	p.SetUnencryptedHTTP2(true)
+
+	c := &http.Client{Transport: &http.Transport{Protocols: p}}
+	defer c.CloseIdleConnections()
+
+	resp, err := c.Get("http://" + addr + "/events") //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 || resp.Header.Get("Content-Type") != sseContentType {
+		t.Fatalf("unexpected response: %s %s", resp.Proto, resp.Header.Get("Content-Type"))
+	}
+
+	var data []string
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+			data = append(data, v)
+		}
+	}
+
+	if len(data) != 3 || data[0] != `{"protocol":"HTTP/2.0","connID":""}` {
+		t.Fatalf("unexpected events: %v", data)
+	}
+}
+
+func TestHTTP2OverTLS(t *testing.T) {
+	certFile, keyFile, pool := writeTestCert(t)
+	addr := startListenServer(t, WithTLS(certFile, keyFile))
+
+	c := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			ForceAttemptHTTP2: true,
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	resp, body := getBody(t, c, "https://"+addr+"/meta", nil)
+	if resp.ProtoMajor != 2 || !strings.Contains(body, `"protocol":"HTTP/2.0"`) {
+		t.Fatalf("unexpected response: %s %s", resp.Proto, body)
+	}
+}
+
+func TestTLSLoadError(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := New(Listen("127.0.0.1:0"), WithTLS(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the missing files to fail New, got: %v", err)
+	}
+
+	_, err = New(Listen("127.0.0.1:0"), WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+	if !errors.Is(err, ErrNoCertificate) {
+		t.Fatalf("expected ErrNoCertificate, got: %v", err)
+	}
+}
+
+// writeTestCert writes a self-signed certificate of 127.0.0.1 and its key, and returns
+// their files and the pool which trusts the certificate.
+func writeTestCert(t *testing.T) (string, string, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return certFile, keyFile, pool
+}
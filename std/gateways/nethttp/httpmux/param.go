@@ -0,0 +1,20 @@
+package httpmux
+
+import (
+	"net/http"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/std/gateways/internal/httpmux"
+)
+
+// The router is shared with the silverhttp gateway, and only the decoders differ.
+type (
+	// Param is a single URL parameter, consisting of a key and a value.
+	Param = httpmux.Param
+	// Params is a Param-slice, as returned by the Mux.
+	Params    = httpmux.Params
+	RouteData = httpmux.RouteData[DecoderFunc]
+	Mux       = httpmux.Mux[DecoderFunc]
+)
+
+type DecoderFunc func(r *http.Request, bag Params, data []byte) (kit.Message, error)
@@ -0,0 +1,112 @@
+package nethttp
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/clubpay/ronykit/kit"
+)
+
+type Option func(b *bundle)
+
+// WithServerName sets the Server header of the responses.
+func WithServerName(name string) Option {
+	return func(b *bundle) {
+		b.srvName = name
+	}
+}
+
+// WithBufferSize sets the maximum size of the request bodies. The requests with larger
+// bodies are answered with 413 (Request Entity Too Large).
+func WithBufferSize(size int64) Option {
+	return func(b *bundle) {
+		b.maxBodySize = size
+	}
+}
+
+func WithLogger(l kit.Logger) Option {
+	return func(b *bundle) {
+		b.l = l
+	}
+}
+
+// Listen serves the gateway on its own http.Server, which is started and stopped by the
+// kit server. Without Listen, the gateway only serves the requests which are passed to its
+// ServeHTTP, e.g., when it is mounted on another server.
+func Listen(addr string) Option {
+	return func(b *bundle) {
+		b.listen = addr
+	}
+}
+
+// WithTLS serves the connections of Listen over TLS by the certificate and the key files,
+// which are PEM encoded. HTTP/2 is negotiated by ALPN with the clients which support it,
+// and the others are served over HTTP/1.1. The files are loaded by New, which returns
+// their errors.
+func WithTLS(certFile, keyFile string) Option {
+	return func(b *bundle) {
+		b.certFile = certFile
+		b.keyFile = keyFile
+	}
+}
+
+// WithTLSConfig serves the connections of Listen over TLS by the config. If it has no
+// certificates, the files of WithTLS are loaded.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(b *bundle) {
+		b.tlsConfig = cfg
+	}
+}
+
+// WithH2C serves HTTP/2 over the cleartext connections of Listen, e.g., inside a service
+// mesh. The clients must use HTTP/2 with prior knowledge, since the upgrade of HTTP/1.1
+// connections is not supported. The HTTP/1.1 requests are still served.
+func WithH2C() Option {
+	return func(b *bundle) {
+		b.h2c = true
+	}
+}
+
+// WithHTTP2Config sets the HTTP/2 parameters of the server, e.g., MaxConcurrentStreams.
+func WithHTTP2Config(cfg *http.HTTP2Config) Option {
+	return func(b *bundle) {
+		b.http2 = cfg
+	}
+}
+
+func WithCORS(cfg CORSConfig) Option {
+	return func(b *bundle) {
+		b.cors = newCORS(cfg)
+	}
+}
+
+func WithPredicateKey(key string) Option {
+	return func(b *bundle) {
+		b.predicateKey = key
+	}
+}
+
+// WithWebsocketEndpoint accepts the websocket connections on the endpoint, which send
+// the RPC messages. The contracts are selected by the predicate header of the incoming
+// container. Check WithPredicateKey and WithCustomRPC.
+func WithWebsocketEndpoint(endpoint string) Option {
+	return func(b *bundle) {
+		b.wsEndpoint = endpoint
+	}
+}
+
+func WithCustomRPC(in kit.IncomingRPCFactory, out kit.OutgoingRPCFactory) Option {
+	return func(b *bundle) {
+		b.rpcInFactory = in
+		b.rpcOutFactory = out
+	}
+}
+
+// WithWebsocketBinaryMode writes the websocket messages as binary frames, which is
+// required by the binary RPC containers, e.g., common.SimpleOutgoingProtoRPC.
+// By default, the messages are written as text frames.
+func WithWebsocketBinaryMode() Option {
+	return func(b *bundle) {
+		b.wsBinary = true
+	}
+}
@@ -0,0 +1,35 @@
+package nethttp
+
+import (
+	"net/http"
+
+	"github.com/clubpay/ronykit/std/gateways/internal/httprelay"
+)
+
+const (
+	headerConnection      = "Connection"
+	headerContentEncoding = "Content-Encoding"
+	headerContentLength   = "Content-Length"
+	headerUpgrade         = "Upgrade"
+	headerSecWSProtocol   = "Sec-Websocket-Protocol"
+)
+
+var ErrContentEncodingUnsupported = httprelay.ErrContentEncodingUnsupported
+
+// setResponseHeader replaces the headers of the response by the ones of the upstream, as
+// the fasthttp gateway resets the response. The Content-Length is set by the writer of
+// the body.
+func setResponseHeader(w http.ResponseWriter, header http.Header) {
+	resHdr := w.Header()
+	clear(resHdr)
+
+	for k, vs := range header {
+		if http.CanonicalHeaderKey(k) == headerContentLength {
+			continue
+		}
+
+		for _, v := range vs {
+			resHdr.Add(k, v)
+		}
+	}
+}
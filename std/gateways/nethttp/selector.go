@@ -0,0 +1,77 @@
+package nethttp
+
+import (
+	"net/http"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/std/gateways/internal/selector"
+)
+
+// Selector implements kit.RouteSelector and
+// also kit.RPCRouteSelector and kit.RESTRouteSelector
+type Selector = selector.Selector[DecoderFunc]
+
+// REST returns a Selector which acts on http requests.
+func REST(method, path string) Selector {
+	return Selector{
+		Method: method,
+		Path:   path,
+	}
+}
+
+// POST a shortcut for REST(http.MethodPost, path)
+func POST(path string) Selector {
+	return REST(http.MethodPost, path)
+}
+
+// GET a shortcut for REST(http.MethodGet, path)
+func GET(path string) Selector {
+	return REST(http.MethodGet, path)
+}
+
+// PATCH a shortcut for REST(http.MethodPatch, path)
+func PATCH(path string) Selector {
+	return REST(http.MethodPatch, path)
+}
+
+// PUT a shortcut for REST(http.MethodPut, path)
+func PUT(path string) Selector {
+	return REST(http.MethodPut, path)
+}
+
+// DELETE a shortcut for REST(http.MethodDelete, path)
+func DELETE(path string) Selector {
+	return REST(http.MethodDelete, path)
+}
+
+// SSE returns a Selector for a Server-Sent Events route. The connection implements
+// kit.Conn with Stream() == true so handlers can push multiple envelopes on one request.
+// SSE is a shortcut for SSEMethod(http.MethodGet, path).
+func SSE(path string) Selector {
+	return SSEMethod(http.MethodGet, path)
+}
+
+// SSEMethod returns a streaming SSE Selector for the given HTTP method and path.
+func SSEMethod(method, path string) Selector {
+	s := REST(method, path)
+	s.Stream = true
+
+	return s
+}
+
+// RPC returns a Selector which acts on websocket requests
+func RPC(predicate string) Selector {
+	return Selector{
+		Predicate: predicate,
+	}
+}
+
+// RPCs is a shortcut for multiple RPC selectors
+func RPCs(predicate ...string) []kit.RouteSelector {
+	selectors := make([]kit.RouteSelector, 0, len(predicate))
+	for idx := range predicate {
+		selectors = append(selectors, RPC(predicate[idx]))
+	}
+
+	return selectors
+}
@@ -0,0 +1,32 @@
+package nethttp
+
+import (
+	"net/http"
+)
+
+const (
+	sseContentType  = "text/event-stream"
+	sseEventMessage = "message"
+)
+
+// setSSEHeaders sets the headers of the event stream. The stream is not length-delimited,
+// hence net/http writes it in chunks on HTTP/1.1.
+func setSSEHeaders(w http.ResponseWriter) {
+	resHdr := w.Header()
+	resHdr.Set(headerContentType, sseContentType)
+	resHdr.Set("Cache-Control", "no-cache")
+	resHdr.Set("X-Accel-Buffering", "no")
+}
+
+func appendSSEEvent(b []byte, event string, data []byte) []byte {
+	if event != "" {
+		b = append(b, "event: "...)
+		b = append(b, event...)
+		b = append(b, '\n')
+	}
+
+	b = append(b, "data: "...)
+	b = append(b, data...)
+
+	return append(b, '\n', '\n')
+}
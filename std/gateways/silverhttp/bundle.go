@@ -5,23 +5,29 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
 
 	"github.com/clubpay/ronykit/kit"
 	"github.com/clubpay/ronykit/kit/common"
 	"github.com/clubpay/ronykit/kit/errors"
 	"github.com/clubpay/ronykit/kit/utils"
+	"github.com/clubpay/ronykit/std/gateways/internal/selector"
+	"github.com/clubpay/ronykit/std/gateways/internal/wsconn"
 	"github.com/clubpay/ronykit/std/gateways/silverhttp/httpmux"
+	"github.com/clubpay/ronykit/std/gateways/silverhttp/realip"
 
 	"github.com/go-www/silverlining"
 	"github.com/go-www/silverlining/h1"
+	"github.com/gobwas/ws"
 	reuse "github.com/libp2p/go-reuseport"
 )
 
 const (
-	queryMethod    = "silverhttp.method"
-	queryPath      = "silverhttp.path"
-	queryDecoder   = "silverhttp.decoder"
-	queryPredicate = "silverhttp.predicate"
+	queryMethod    = selector.QueryMethod
+	queryPath      = selector.QueryPath
+	queryDecoder   = selector.QueryDecoder
+	queryPredicate = selector.QueryPredicate
+	queryStream    = selector.QueryStream
 )
 
 var noExecuteArg = kit.ExecuteArg{}
@@ -36,6 +42,18 @@ type bundle struct {
 	connPool sync.Pool
 	cors     *cors
 	httpMux  *httpmux.Mux
+
+	// restRoutes keeps the versions of each route, keyed by "METHOD path". Only the
+	// first version is registered in the httpMux.
+	restRoutes map[string]*kit.VersionedRoutes[*httpmux.RouteData]
+
+	rpcRoutes     map[string]*kit.VersionedRoutes[*httpmux.RouteData]
+	wsEndpoint    string
+	wsNextID      atomic.Uint64
+	predicateKey  string
+	rpcInFactory  kit.IncomingRPCFactory
+	rpcOutFactory kit.OutgoingRPCFactory
+	wsBinary      bool
 }
 
 var _ kit.Gateway = (*bundle)(nil)
@@ -48,8 +66,12 @@ func New(opts ...Option) (kit.Gateway, error) {
 			HandleMethodNotAllowed: true,
 			HandleOPTIONS:          true,
 		},
-		srv: &silverlining.Server{},
-		l:   common.NewNopLogger(),
+		restRoutes:    map[string]*kit.VersionedRoutes[*httpmux.RouteData]{},
+		rpcRoutes:     map[string]*kit.VersionedRoutes[*httpmux.RouteData]{},
+		srv:           &silverlining.Server{},
+		l:             common.NewNopLogger(),
+		rpcInFactory:  common.SimpleIncomingJSONRPC,
+		rpcOutFactory: common.SimpleOutgoingJSONRPC,
 	}
 	for _, opt := range opts {
 		opt(r)
@@ -102,9 +124,32 @@ func (b *bundle) Shutdown(_ context.Context) error {
 func (b *bundle) Register(
 	svcName, contractID string, enc kit.Encoding, sel kit.RouteSelector, input, _ kit.Message,
 ) {
+	b.registerRPC(svcName, contractID, sel, input)
 	b.registerREST(svcName, contractID, enc, sel, input)
 }
 
+func (b *bundle) registerRPC(svcName, contractID string, sel kit.RouteSelector, input kit.Message) {
+	rpcSelector, ok := sel.(kit.RPCRouteSelector)
+	if !ok || rpcSelector.GetPredicate() == "" {
+		return
+	}
+
+	rd := &httpmux.RouteData{
+		ServiceName: svcName,
+		ContractID:  contractID,
+		Predicate:   rpcSelector.GetPredicate(),
+		Factory:     kit.CreateMessageFactory(input),
+	}
+
+	vr := b.rpcRoutes[rd.Predicate]
+	if vr == nil {
+		vr = &kit.VersionedRoutes[*httpmux.RouteData]{}
+		b.rpcRoutes[rd.Predicate] = vr
+	}
+
+	vr.Add(kit.NegotiatedVersion(sel, false), rd)
+}
+
 func (b *bundle) registerREST(
 	svcName, contractID string, enc kit.Encoding, sel kit.RouteSelector, input kit.Message,
 ) {
@@ -133,17 +178,36 @@ func (b *bundle) registerREST(
 		methods = append(methods, method)
 	}
 
+	stream := false
+	if ss, ok := restSelector.(kit.StreamRouteSelector); ok {
+		stream = ss.IsStream()
+	}
+
+	version := kit.NegotiatedVersion(sel, true)
 	for _, method := range methods {
-		b.httpMux.Handle(
-			method, restSelector.GetPath(),
-			&httpmux.RouteData{
-				ServiceName: svcName,
-				ContractID:  contractID,
-				Method:      method,
-				Path:        restSelector.GetPath(),
-				Decoder:     decoder,
-			},
-		)
+		rd := &httpmux.RouteData{
+			ServiceName: svcName,
+			ContractID:  contractID,
+			Method:      method,
+			Path:        restSelector.GetPath(),
+			Decoder:     decoder,
+			Encoding:    enc,
+			Stream:      stream,
+		}
+
+		key := method + " " + rd.Path
+		vr := b.restRoutes[key]
+		switch {
+		case vr == nil:
+			vr = &kit.VersionedRoutes[*httpmux.RouteData]{}
+			b.restRoutes[key] = vr
+			b.httpMux.Handle(method, rd.Path, rd)
+		case vr.Has(version):
+			// let the httpMux complain about the duplicate route
+			b.httpMux.Handle(method, rd.Path, rd)
+		}
+
+		vr.Add(version, rd)
 	}
 }
 
@@ -153,14 +217,28 @@ func (b *bundle) Subscribe(d kit.GatewayDelegate) {
 
 func (b *bundle) Dispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
 	switch ctx.Conn().(type) {
-	case *httpConn:
+	case *httpConn, *sseConn:
 		return b.httpDispatch(ctx, in)
+	case *wsconn.Conn:
+		return b.rpcDispatch(ctx, in)
 	default:
 		panic("BUG!! incorrect connection")
 	}
 }
 
 func (b *bundle) httpHandler(ctx *silverlining.Context) {
+	if b.wsEndpoint != "" && ctx.Method() == h1.MethodGET && utils.B2S(ctx.Path()) == b.wsEndpoint {
+		b.wsHandler(ctx)
+
+		return
+	}
+
+	if rd, _, _ := b.httpMux.Lookup(ctx.Method().String(), utils.B2S(ctx.Path())); rd != nil && rd.Stream {
+		b.sseHandler(ctx)
+
+		return
+	}
+
 	c, ok := b.connPool.Get().(*httpConn)
 	if !ok {
 		c = &httpConn{}
@@ -172,16 +250,120 @@ func (b *bundle) httpHandler(ctx *silverlining.Context) {
 	}
 
 	c.ctx = ctx
+	c.enc = kit.Undefined
+	c.body = httpBody
 	b.d.OnOpen(c)
 	b.d.OnMessage(c, httpBody)
 	b.d.OnClose(c.ConnID())
 
+	c.body = nil
+	c.hdr = c.hdr[:0]
 	b.connPool.Put(c)
 }
 
+// sseHandler serves the requests of the SSE routes. The events are written until the
+// handlers return, and then the stream is closed.
+func (b *bundle) sseHandler(ctx *silverlining.Context) {
+	httpBody, err := ctx.Body()
+	if err != nil {
+		return
+	}
+
+	c := &sseConn{
+		httpConn: httpConn{
+			ctx:  ctx,
+			enc:  kit.Undefined,
+			body: httpBody,
+		},
+	}
+
+	setSSEHeaders(ctx)
+
+	b.d.OnOpen(c)
+	b.d.OnMessage(c, httpBody)
+	c.close()
+	b.d.OnClose(c.ConnID())
+}
+
+func (b *bundle) wsHandler(ctx *silverlining.Context) {
+	if !b.cors.handleWS(ctx) {
+		_ = ctx.WriteFullBodyString(StatusForbidden, "origin is not allowed")
+
+		return
+	}
+
+	clientIP := realip.FromRequest(ctx)
+
+	rwc, err := ctx.UpgradeWebSocket(ws.OpText)
+	if err != nil {
+		return
+	}
+
+	wsc := wsconn.New(b.wsNextID.Add(1), clientIP, rwc, b.rpcOutFactory, b.wsBinary)
+
+	// the connection is hijacked, hence we read its messages in another goroutine and
+	// let the server release the request.
+	go wsc.Serve(b.d, rwc)
+}
+
+func (b *bundle) rpcDispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
+	if len(in) == 0 {
+		return noExecuteArg, kit.ErrDecodeIncomingContainerFailed
+	}
+
+	inputMsgContainer := b.rpcInFactory()
+	defer inputMsgContainer.Release()
+
+	err := inputMsgContainer.Unmarshal(in)
+	if err != nil {
+		return noExecuteArg, err
+	}
+
+	vr := b.rpcRoutes[inputMsgContainer.GetHdr(b.predicateKey)]
+	if vr == nil {
+		return noExecuteArg, kit.ErrNoHandler
+	}
+
+	routeData, ok := vr.Get(inputMsgContainer.GetHdr(kit.HeaderAcceptVersion))
+	if !ok {
+		return noExecuteArg, kit.ErrNoHandler
+	}
+
+	msg := routeData.Factory()
+	if v, ok := msg.(kit.RawMessage); ok {
+		err = inputMsgContainer.ExtractMessage(&v)
+		msg = v
+	} else {
+		err = inputMsgContainer.ExtractMessage(msg)
+	}
+
+	if err != nil {
+		return noExecuteArg, errors.Wrap(kit.ErrDecodeIncomingMessageFailed, err)
+	}
+
+	ctx.In().
+		SetID(inputMsgContainer.GetID()).
+		SetHdrMap(inputMsgContainer.GetHdrMap()).
+		SetMsg(msg)
+
+	return kit.ExecuteArg{
+		ServiceName: routeData.ServiceName,
+		ContractID:  routeData.ContractID,
+		Route:       routeData.Predicate,
+	}, nil
+}
+
 func (b *bundle) httpDispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, error) {
-	//nolint:forcetypeassert
-	conn := ctx.Conn().(*httpConn)
+	var conn *httpConn
+
+	switch c := ctx.Conn().(type) {
+	case *httpConn:
+		conn = c
+	case *sseConn:
+		conn = &c.httpConn
+	default:
+		panic("BUG!! incorrect REST connection")
+	}
 
 	routeData, params, _ := b.httpMux.Lookup(conn.GetMethod(), conn.GetPath())
 
@@ -197,6 +379,15 @@ func (b *bundle) httpDispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, erro
 		return noExecuteArg, kit.ErrNoHandler
 	}
 
+	if vr := b.restRoutes[routeData.Method+" "+routeData.Path]; vr != nil {
+		var ok bool
+
+		routeData, ok = vr.Get(conn.Get(kit.HeaderAcceptVersion))
+		if !ok {
+			return noExecuteArg, kit.ErrNoHandler
+		}
+	}
+
 	// Walk over all the query params
 	for _, p := range conn.ctx.QueryParams() {
 		params = append(
@@ -213,6 +404,7 @@ func (b *bundle) httpDispatch(ctx *kit.Context, in []byte) (kit.ExecuteArg, erro
 		return noExecuteArg, errors.Wrap(kit.ErrDecodeIncomingMessageFailed, err)
 	}
 
+	conn.enc = routeData.Encoding
 	ctx.In().
 		SetHdrWalker(conn).
 		SetMsg(m)
@@ -0,0 +1,216 @@
+package silverhttp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/desc"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+type echoMsg struct {
+	Value string `json:"value"`
+}
+
+func startStreamServer(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	svc := desc.NewService("svc").
+		AddContract(
+			desc.NewContract().
+				SetInput(&echoMsg{}).
+				SetOutput(&echoMsg{}).
+				AddRoute(desc.Route("echo", RPC("echo"))).
+				AddHandler(func(ctx *kit.Context) {
+					in := ctx.In().GetMsg().(*echoMsg) //nolint:forcetypeassert
+					ctx.In().Reply().SetMsg(&echoMsg{Value: in.Value + ":" + ctx.Conn().Get("k")}).Send()
+				}),
+			desc.NewContract().
+				SetInput(&echoMsg{}).
+				SetOutput(&echoMsg{}).
+				AddRoute(desc.Route("events", SSE("/events"))).
+				AddHandler(func(ctx *kit.Context) {
+					for _, v := range []string{"a", "b", "c"} {
+						ctx.Out().SetMsg(&echoMsg{Value: v}).Send()
+					}
+				}),
+		)
+
+	s := kit.NewServer(
+		kit.WithGateway(MustNew(Listen(addr), WithWebsocketEndpoint("/ws"), WithPredicateKey("cmd"))),
+		kit.WithServiceBuilder(svc),
+	)
+	s.Start(t.Context())
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	return addr
+}
+
+func TestWebsocketRPC(t *testing.T) {
+	addr := startStreamServer(t)
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	for range 50 {
+		conn, _, _, err = ws.Dial(t.Context(), "ws://"+addr+"/ws")
+		if err == nil {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err != nil {
+		t.Fatalf("ws dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// the ping of the client is answered while the messages are served
+	if err = wsutil.WriteClientMessage(conn, ws.OpPing, []byte("p")); err != nil {
+		t.Fatal(err)
+	}
+
+	err = wsutil.WriteClientText(conn, []byte(`{"id":"1","hdr":{"cmd":"echo"},"payload":{"value":"hi"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	msgs, err := wsutil.ReadServerMessage(conn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for len(msgs) < 2 {
+		var next []wsutil.Message
+
+		next, err = wsutil.ReadServerMessage(conn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msgs = append(msgs, next...)
+	}
+
+	if msgs[0].OpCode != ws.OpPong || string(msgs[0].Payload) != "p" {
+		t.Fatalf("expected pong, got: %v %s", msgs[0].OpCode, msgs[0].Payload)
+	}
+	if msgs[1].OpCode != ws.OpText || !strings.Contains(string(msgs[1].Payload), `"value":"hi:"`) ||
+		!strings.Contains(string(msgs[1].Payload), `"id":"1"`) {
+		t.Fatalf("unexpected response: %s", msgs[1].Payload)
+	}
+
+	// the unknown commands are not answered, and the connection is kept open.
+	err = wsutil.WriteClientText(conn, []byte(`{"id":"2","hdr":{"cmd":"unknown"},"payload":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = wsutil.WriteClientText(conn, []byte(`{"id":"3","hdr":{"cmd":"echo"},"payload":{"value":"again"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := wsutil.ReadServerText(conn)
+	if err != nil || !strings.Contains(string(data), `"id":"3"`) {
+		t.Fatalf("unexpected response: %s, %v", data, err)
+	}
+}
+
+func TestSSE(t *testing.T) {
+	addr := startStreamServer(t)
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	for range 50 {
+		resp, err = http.Get("http://" + addr + "/events") //nolint:noctx
+		if err == nil {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != sseContentType {
+		t.Fatalf("unexpected content type: %s", resp.Header.Get("Content-Type"))
+	}
+
+	var data []string
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+			data = append(data, v)
+		}
+	}
+
+	if len(data) != 3 || data[0] != `{"value":"a"}` || data[2] != `{"value":"c"}` {
+		t.Fatalf("unexpected events: %v", data)
+	}
+}
+
+func TestSelectorStream(t *testing.T) {
+	sel := SSEMethod(MethodPost, "/events")
+	if !sel.IsStream() || sel.Query(queryStream) != true || sel.GetMethod() != MethodPost {
+		t.Fatalf("unexpected selector: %#v", sel)
+	}
+
+	if RPC("echo").IsStream() || len(RPCs("a", "b")) != 2 {
+		t.Fatal("unexpected RPC selectors")
+	}
+}
+
+// foreignSelector is a selector of another gateway, e.g., the fasthttp selectors of rony.
+type foreignSelector struct {
+	method, path, predicate string
+	stream                  bool
+}
+
+func (s foreignSelector) GetEncoding() kit.Encoding { return kit.JSON }
+func (s foreignSelector) GetMethod() string         { return s.method }
+func (s foreignSelector) GetPath() string           { return s.path }
+func (s foreignSelector) GetPredicate() string      { return s.predicate }
+func (s foreignSelector) IsStream() bool            { return s.stream }
+func (s foreignSelector) Query(string) any          { return nil }
+func (s foreignSelector) String() string            { return s.method + " " + s.path }
+
+func TestRegisterForeignSelectors(t *testing.T) {
+	b := MustNew().(*bundle) //nolint:forcetypeassert
+
+	b.Register("svc", "c1", kit.JSON, foreignSelector{method: MethodGet, path: "/events", stream: true}, &echoMsg{}, nil)
+	b.Register("svc", "c2", kit.JSON, foreignSelector{predicate: "echo"}, &echoMsg{}, nil)
+
+	rd, _, _ := b.httpMux.Lookup(MethodGet, "/events")
+	if rd == nil || !rd.Stream {
+		t.Fatalf("expected a stream route: %#v", rd)
+	}
+
+	if rd, ok := b.rpcRoutes["echo"].Get(""); !ok || rd.ContractID != "c2" {
+		t.Fatalf("expected an rpc route: %#v", rd)
+	}
+}
@@ -12,7 +12,13 @@ import (
 type httpConn struct {
 	utils.SpinLock
 
-	ctx *silverlining.Context
+	ctx  *silverlining.Context
+	enc  kit.Encoding
+	body []byte
+
+	// hdr keeps the names of the response headers, since silverlining cannot list or
+	// reset them.
+	hdr []string
 }
 
 var _ kit.RESTConn = (*httpConn)(nil)
@@ -49,9 +55,20 @@ func (c *httpConn) Get(key string) string {
 }
 
 func (c *httpConn) Set(key string, val string) {
+	c.hdr = append(c.hdr, key)
 	c.ctx.ResponseHeaders().Set(key, val)
 }
 
+// resetHeader removes the response headers which are set by Set.
+func (c *httpConn) resetHeader() {
+	resHdr := c.ctx.ResponseHeaders()
+	for _, k := range c.hdr {
+		resHdr.Del(k)
+	}
+
+	c.hdr = c.hdr[:0]
+}
+
 func (c *httpConn) SetStatusCode(code int) {
 	c.ctx.WriteHeader(code)
 }
@@ -73,6 +90,10 @@ func (c *httpConn) Write(data []byte) (int, error) {
 }
 
 func (c *httpConn) WriteEnvelope(e *kit.Envelope) error {
+	if enc := c.responseEncoding(); enc != kit.JSON && enc != kit.Undefined {
+		return c.writeEnvelopeAs(enc, e)
+	}
+
 	dataBuf := buf.GetCap(e.SizeHint())
 
 	err := kit.EncodeMessage(e.GetMsg(), dataBuf)
@@ -80,11 +101,9 @@ func (c *httpConn) WriteEnvelope(e *kit.Envelope) error {
 		return err
 	}
 
-	resHdr := c.ctx.ResponseHeaders()
-
 	e.WalkHdr(
 		func(key string, val string) bool {
-			resHdr.Set(key, val)
+			c.Set(key, val)
 
 			return true
 		},
@@ -96,6 +115,42 @@ func (c *httpConn) WriteEnvelope(e *kit.Envelope) error {
 	return err
 }
 
+// responseEncoding negotiates the encoding of the response by the Accept and Content-Type
+// headers of the request, and the encoding of the contract.
+func (c *httpConn) responseEncoding() kit.Encoding {
+	def := kit.JSON
+	if c.enc != kit.Undefined {
+		def = c.enc
+	}
+
+	return kit.NegotiateEncoding(c.Get(headerAccept), c.Get(headerContentType), def)
+}
+
+func (c *httpConn) writeEnvelopeAs(enc kit.Encoding, e *kit.Envelope) error {
+	data, used, err := kit.MarshalMessageAs(enc, e.GetMsg())
+	if err != nil {
+		return err
+	}
+
+	// the raw messages are already encoded, so the handler sets their content type.
+	if _, ok := e.GetMsg().(kit.RawMessage); !ok {
+		c.Set(headerContentType, used.ContentType())
+	}
+
+	e.WalkHdr(
+		func(key string, val string) bool {
+			c.Set(key, val)
+
+			return true
+		},
+	)
+
+	c.ctx.SetContentLength(len(data))
+	_, err = c.ctx.Write(data)
+
+	return err
+}
+
 func (c *httpConn) Stream() bool {
 	return false
 }
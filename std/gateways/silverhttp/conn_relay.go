@@ -0,0 +1,188 @@
+package silverhttp
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/errors"
+	"github.com/clubpay/ronykit/std/gateways/internal/httprelay"
+
+	"github.com/gobwas/ws"
+)
+
+var _ kit.RelayConn = (*httpConn)(nil)
+
+// RequestBody returns the body of the request, which is decoded by its Content-Encoding:
+// gzip, deflate, br or zstd.
+func (c *httpConn) RequestBody() ([]byte, error) {
+	enc, _ := c.ctx.RequestHeaders().Get(headerContentEncoding)
+
+	return httprelay.DecodeBody(enc, c.body)
+}
+
+func (c *httpConn) IsWebSocketUpgrade() bool {
+	return httprelay.HeaderHasToken(c.requestHeaderValues(headerConnection), "upgrade") &&
+		httprelay.HeaderHasToken(c.requestHeaderValues(headerUpgrade), "websocket")
+}
+
+func (c *httpConn) requestHeaderValues(name string) []string {
+	v, ok := c.ctx.RequestHeaders().Get(name)
+	if !ok {
+		return nil
+	}
+
+	return []string{v}
+}
+
+// RelayHTTP sends the request to the targetURL, and writes the response of the upstream.
+// The body is relayed as it is received, hence it keeps its Content-Encoding. The
+// response body is streamed to the client, unless cfg.RewriteResponse is set, which
+// needs the whole body.
+func (c *httpConn) RelayHTTP(targetURL string, cfg kit.RelayConfig) error {
+	u, err := httprelay.TargetURL(targetURL, cfg)
+	if err != nil {
+		return err
+	}
+
+	header := httprelay.RequestHeader(requestHeader(c.ctx), c.ctx.RemoteAddr().String(), cfg)
+
+	res, err := httprelay.Send(c.GetMethod(), u, header, c.body, cfg)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if cfg.RewriteResponse != nil {
+		view, err := httprelay.RewriteResponse(res, cfg)
+		if err != nil {
+			return err
+		}
+
+		return c.WriteHTTPResponse(view.StatusCode, view.Header, view.Body)
+	}
+
+	c.streamResponse(res)
+
+	return kit.ErrRelayCompleted
+}
+
+// streamResponse writes the response of the upstream while it is read. The bodies of
+// unknown length, e.g., the event streams, are written in chunks, and each chunk is
+// flushed as soon as it is read. If the upstream fails after the headers are written,
+// the connection is closed, hence the client does not take the partial body as complete.
+func (c *httpConn) streamResponse(res *http.Response) {
+	c.setResponseHeader(res.Header)
+
+	var err error
+
+	switch {
+	case res.ContentLength >= 0:
+		c.ctx.SetContentLength(int(res.ContentLength))
+		c.ctx.WriteHeader(res.StatusCode)
+		_, err = io.Copy(c.ctx, res.Body)
+	case c.GetMethod() == MethodHead ||
+		res.StatusCode == StatusNoContent || res.StatusCode == StatusNotModified:
+		c.ctx.ConnectionClose()
+		c.ctx.WriteHeader(res.StatusCode)
+	default:
+		w := c.ctx.ChunkedBodyWriter()
+		c.ctx.WriteHeader(res.StatusCode)
+
+		buf := make([]byte, 8<<10)
+		for err == nil {
+			var n int
+
+			n, err = res.Body.Read(buf)
+			if n > 0 {
+				if _, werr := w.Write(buf[:n]); werr != nil {
+					err = werr
+
+					break
+				}
+
+				_ = c.ctx.Flush()
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			err = w.Close()
+		}
+	}
+
+	if err != nil {
+		c.ctx.ConnectionClose()
+	}
+}
+
+// RelayWebSocket dials the websocket of the targetURL, upgrades the connection of the
+// client, and then relays the frames in both directions until either side is closed.
+// The frames are relayed as they are, hence the control frames, e.g., ping and close,
+// are answered end to end.
+func (c *httpConn) RelayWebSocket(targetURL string, cfg kit.RelayConfig) error {
+	u, err := httprelay.WebSocketURL(targetURL, cfg)
+	if err != nil {
+		return err
+	}
+
+	header, err := httprelay.WebSocketHeader(
+		u, httprelay.RequestHeader(requestHeader(c.ctx), c.ctx.RemoteAddr().String(), cfg), cfg,
+	)
+	if err != nil {
+		return err
+	}
+
+	backend, br, rejected, err := httprelay.DialWebSocket(u, header, cfg)
+	if err != nil {
+		if rejected != nil {
+			_ = c.WriteHTTPResponse(rejected.StatusCode, rejected.Header, rejected.Body)
+		} else {
+			c.Set(headerContentType, MIMETextPlainCharsetUTF8)
+			_ = c.ctx.WriteFullBodyString(StatusServiceUnavailable, err.Error())
+		}
+
+		return err
+	}
+
+	origin, _ := c.ctx.RequestHeaders().Get(HeaderOrigin)
+	if (cfg.WebSocketCheckOrigin == nil && !httprelay.SameOrigin(origin, c.ctx.Host())) ||
+		(cfg.WebSocketCheckOrigin != nil && !cfg.WebSocketCheckOrigin(origin)) {
+		_ = backend.Close()
+		_ = c.ctx.WriteFullBodyString(StatusForbidden, http.StatusText(StatusForbidden))
+
+		return errors.New("websocket: request origin not allowed")
+	}
+
+	protocols := c.requestHeaderValues(headerSecWSProtocol)
+	if p := httprelay.SelectSubprotocol(protocols, cfg.WebSocketSubprotocols); p != "" {
+		c.Set(headerSecWSProtocol, p)
+	}
+
+	if _, err = c.ctx.UpgradeWebSocket(ws.OpText); err != nil {
+		// the upgrade failed before the connection is hijacked, hence the upstream
+		// connection is closed here to avoid leaking it.
+		_ = backend.Close()
+
+		return err
+	}
+
+	// the reader of the upgraded connection fills the whole buffer of each read, hence the
+	// frames are read from the connection itself, after the ones which are already buffered.
+	bufR, _, client := c.ctx.HijackConn()
+	pending := append([]byte(nil), bufR.Upstream.NextBuffer...)
+
+	go httprelay.RelayFrames(client, io.MultiReader(bytes.NewReader(pending), client), backend, br)
+
+	return kit.ErrRelayCompleted
+}
+
+func (c *httpConn) WriteHTTPResponse(status int, header http.Header, body []byte) error {
+	c.setResponseHeader(header)
+	c.ctx.SetContentLength(len(body))
+	c.ctx.WriteHeader(status)
+	_, _ = c.ctx.Write(body)
+
+	return kit.ErrRelayCompleted
+}
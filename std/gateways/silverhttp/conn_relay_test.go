@@ -0,0 +1,270 @@
+package silverhttp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/kit/desc"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startRelayServer starts a server whose routes relay the requests to the target.
+func startRelayServer(t *testing.T, target string, cfg kit.RelayConfig) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	relay := func(ctx *kit.Context) {
+		rc, ok := ctx.RelayConn()
+		if !ok {
+			t.Error("expected a relay connection")
+
+			return
+		}
+
+		ctx.Conn().(kit.RESTConn).Set("X-Handler", "1") //nolint:forcetypeassert
+
+		err := kit.Relay(ctx, target+ctx.Conn().(kit.RESTConn).GetRequestURI(), cfg) //nolint:forcetypeassert
+		if err != nil && !rc.IsWebSocketUpgrade() {
+			_ = rc.WriteHTTPResponse(http.StatusBadGateway, nil, []byte(err.Error()))
+		}
+	}
+
+	svc := desc.NewService("svc").
+		AddContract(
+			desc.NewContract().
+				SetInput(kit.RawMessage{}).
+				AddRoute(desc.Route("post", POST("/relay/*path"))).
+				AddRoute(desc.Route("get", GET("/relay/*path"))).
+				AddHandler(relay),
+		)
+
+	s := kit.NewServer(
+		kit.WithGateway(MustNew(Listen(addr))),
+		kit.WithServiceBuilder(svc),
+	)
+	s.Start(t.Context())
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	for range 50 {
+		c, err := net.Dial("tcp4", addr)
+		if err == nil {
+			_ = c.Close()
+
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return addr
+}
+
+func TestRelayHTTP(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/relay/echo", r.URL.Path)
+		assert.Equal(t, "keep=1", r.URL.RawQuery)
+		assert.Empty(t, r.Header.Get("X-Secret"))
+		assert.Empty(t, r.Header.Get("Keep-Alive"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "extra", r.Header.Get("X-Extra"))
+		assert.Equal(t, "1.1.1.1, 127.0.0.1", r.Header.Get("X-Forwarded-For"))
+
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+
+		body, _ := io.ReadAll(zr)
+		assert.Equal(t, `{"msg":"hi"}`, string(body))
+
+		http.SetCookie(w, &http.Cookie{Name: "a", Value: "1"})
+		http.SetCookie(w, &http.Cookie{Name: "b", Value: "2"})
+		w.Header().Set("X-Upstream", "yes")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(upstream.Close)
+
+	addr := startRelayServer(
+		t, upstream.URL,
+		kit.RelayConfig{
+			ExtraRequestHeaders: map[string]string{"X-Extra": "extra"},
+			DropRequestHeaders:  []string{"X-Secret"},
+			DropQueryParams:     []string{"token"},
+		},
+	)
+
+	gz := &bytes.Buffer{}
+	zw := gzip.NewWriter(gz)
+	_, _ = zw.Write([]byte(`{"msg":"hi"}`))
+	_ = zw.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "http://"+addr+"/relay/echo?token=t&keep=1", gz) //nolint:noctx
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("X-Secret", "s")
+	req.Header.Set("Keep-Alive", "timeout=5")
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, `{"ok":true}`, string(body))
+	assert.Equal(t, "yes", resp.Header.Get("X-Upstream"))
+	assert.Empty(t, resp.Header.Get("X-Handler"))
+	assert.Len(t, resp.Cookies(), 2)
+}
+
+func TestRelayHTTPStream(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", sseContentType)
+		_, _ = w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush() //nolint:forcetypeassert
+
+		<-release
+
+		_, _ = w.Write([]byte("data: second\n\n"))
+	}))
+	t.Cleanup(upstream.Close)
+
+	addr := startRelayServer(t, upstream.URL, kit.RelayConfig{})
+
+	resp, err := http.Get("http://" + addr + "/relay/events") //nolint:noctx
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, sseContentType, resp.Header.Get("Content-Type"))
+
+	// the first event is received while the upstream is still writing.
+	rd := bufio.NewReader(resp.Body)
+	line, err := rd.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "data: first\n", line)
+
+	close(release)
+
+	rest, err := io.ReadAll(rd)
+	require.NoError(t, err)
+	assert.Equal(t, "\ndata: second\n\n", string(rest))
+}
+
+func TestRelayHTTPRewriteAndTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/relay/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("X-Rewritten")))
+	}))
+	t.Cleanup(upstream.Close)
+
+	addr := startRelayServer(
+		t, upstream.URL,
+		kit.RelayConfig{
+			Timeout: 50 * time.Millisecond,
+			RewriteRequest: func(req *kit.RelayRequestView) error {
+				req.Method = http.MethodPut
+				req.Header.Set("X-Rewritten", "1")
+
+				return nil
+			},
+			RewriteResponse: func(resp *kit.RelayResponseView) error {
+				resp.StatusCode = http.StatusCreated
+				resp.Body = append(resp.Body, '!')
+
+				return nil
+			},
+		},
+	)
+
+	resp, err := http.Get("http://" + addr + "/relay/fast") //nolint:noctx
+	require.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "PUT /relay/fast 1!", string(body))
+	assert.Empty(t, resp.Header.Get("X-Handler"))
+
+	resp, err = http.Get("http://" + addr + "/relay/slow") //nolint:noctx
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+}
+
+func TestRelayWebSocket(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "127.0.0.1", r.Header.Get("X-Forwarded-For"))
+		assert.Equal(t, "extra", r.Header.Get("X-Extra"))
+
+		conn, _, _, err := ws.HTTPUpgrader{Protocol: func(p string) bool { return p == "p1" }}.Upgrade(r, w)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			data, op, err := wsutil.ReadClientData(conn)
+			if err != nil {
+				return
+			}
+
+			if err = wsutil.WriteServerMessage(conn, op, append([]byte("echo:"), data...)); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	addr := startRelayServer(
+		t, "ws"+strings.TrimPrefix(upstream.URL, "http"),
+		kit.RelayConfig{
+			ExtraRequestHeaders:   map[string]string{"X-Extra": "extra"},
+			WebSocketSubprotocols: []string{"p1"},
+			WebSocketCheckOrigin:  func(origin string) bool { return origin != "http://evil.com" },
+		},
+	)
+
+	conn, _, hs, err := ws.Dialer{Protocols: []string{"p0", "p1"}}.Dial(t.Context(), "ws://"+addr+"/relay/ws")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, "p1", hs.Protocol)
+
+	for _, msg := range []string{"a", "b"} {
+		require.NoError(t, wsutil.WriteClientText(conn, []byte(msg)))
+
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		data, err := wsutil.ReadServerText(conn)
+		require.NoError(t, err)
+		assert.Equal(t, "echo:"+msg, string(data))
+	}
+
+	// the origins which are rejected by the config cannot open the websocket.
+	_, _, _, err = ws.Dialer{
+		Header: ws.HandshakeHeaderHTTP(http.Header{"Origin": []string{"http://evil.com"}}),
+	}.Dial(t.Context(), "ws://"+addr+"/relay/ws")
+	assert.Equal(t, ws.StatusError(http.StatusForbidden), err)
+}
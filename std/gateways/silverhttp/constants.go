@@ -2,6 +2,9 @@ package silverhttp
 
 const (
 	maxMimeFormSize = 1 << 24
+
+	headerAccept      = "Accept"
+	headerContentType = "Content-Type"
 )
 
 // HTTP methods were copied from net/http.
@@ -81,24 +81,23 @@ func (cors *cors) handle(rc *httpConn) {
 		return
 	}
 
-	resHdr := rc.ctx.ResponseHeaders()
 	// ByPass cors (Cross Origin Resource Sharing) check
-	resHdr.Set("Vary", HeaderOrigin)
-	resHdr.Set(HeaderAccessControlExposeHeaders, cors.exposedHeaders)
+	rc.Set("Vary", HeaderOrigin)
+	rc.Set(HeaderAccessControlExposeHeaders, cors.exposedHeaders)
 
 	origin := rc.Get(HeaderOrigin)
 	if cors.origins[0] == "*" {
-		resHdr.Set(HeaderAccessControlAllowOrigin, origin)
+		rc.Set(HeaderAccessControlAllowOrigin, origin)
 	} else {
 		for _, allowedOrigin := range cors.origins {
 			if strings.EqualFold(origin, allowedOrigin) {
-				resHdr.Set(HeaderAccessControlAllowOrigin, origin)
+				rc.Set(HeaderAccessControlAllowOrigin, origin)
 			}
 		}
 	}
 
 	if rc.ctx.Method() == h1.MethodOPTIONS {
-		resHdr.Set(
+		rc.Set(
 			"Vary",
 			strings.Join(
 				[]string{
@@ -109,16 +108,16 @@ func (cors *cors) handle(rc *httpConn) {
 			),
 		)
 
-		resHdr.Set(HeaderAccessControlRequestMethod, cors.methods)
+		rc.Set(HeaderAccessControlRequestMethod, cors.methods)
 
 		reqHeaders, _ := rc.ctx.RequestHeaders().GetBytes(utils.S2B(HeaderAccessControlRequestHeaders))
 		if len(reqHeaders) > 0 {
-			resHdr.Set(HeaderAccessControlAllowHeaders, utils.B2S(reqHeaders))
+			rc.Set(HeaderAccessControlAllowHeaders, utils.B2S(reqHeaders))
 		} else {
-			resHdr.Set(HeaderAccessControlAllowHeaders, cors.headers)
+			rc.Set(HeaderAccessControlAllowHeaders, cors.headers)
 		}
 
-		resHdr.Set(HeaderAccessControlAllowMethods, cors.methods)
+		rc.Set(HeaderAccessControlAllowMethods, cors.methods)
 		rc.ctx.WriteHeader(StatusNoContent)
 	}
 }
@@ -60,10 +60,7 @@ func reflectDecoder(enc kit.Encoding, factory kit.MessageFactoryFunc) DecoderFun
 	default:
 	}
 
-	tagKey := enc.Tag()
-	if tagKey == "" {
-		tagKey = kit.JSON.Tag()
-	}
+	tagKey := enc.FieldTag()
 
 	rVal := reflect.ValueOf(factory())
 	if rVal.Kind() != reflect.Ptr {
@@ -77,10 +74,25 @@ func reflectDecoder(enc kit.Encoding, factory kit.MessageFactoryFunc) DecoderFun
 
 	pcs := extractFields(rVal, tagKey)
 
-	return genDecoder(factory, pcs...)
+	return genDecoder(enc, factory, pcs...)
+}
+
+// bodyEncoding returns the encoding of the request body by its Content-Type header. If
+// the content type is not registered in kit, the encoding of the contract is used.
+func bodyEncoding(ctx *silverlining.Context, enc kit.Encoding) kit.Encoding {
+	if ctx == nil {
+		return enc
+	}
+
+	ct, _ := ctx.RequestHeaders().GetBytes(utils.S2B(headerContentType))
+	if v, ok := kit.EncodingByContentType(utils.B2S(ct)); ok {
+		return v
+	}
+
+	return enc
 }
 
-func genDecoder(factory kit.MessageFactoryFunc, pcs ...paramCaster) DecoderFunc {
+func genDecoder(enc kit.Encoding, factory kit.MessageFactoryFunc, pcs ...paramCaster) DecoderFunc {
 	return func(ctx *silverlining.Context, bag Params, data []byte) (kit.Message, error) {
 		var (
 			v   = factory()
@@ -88,7 +100,7 @@ func genDecoder(factory kit.MessageFactoryFunc, pcs ...paramCaster) DecoderFunc
 		)
 
 		if len(data) > 0 {
-			err = kit.UnmarshalMessage(data, v)
+			err = kit.UnmarshalMessageAs(bodyEncoding(ctx, enc), data, v)
 			if err != nil {
 				return nil, err
 			}
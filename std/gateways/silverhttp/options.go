@@ -33,3 +33,34 @@ func WithCORS(cfg CORSConfig) Option {
 		b.cors = newCORS(cfg)
 	}
 }
+
+func WithPredicateKey(key string) Option {
+	return func(b *bundle) {
+		b.predicateKey = key
+	}
+}
+
+// WithWebsocketEndpoint accepts the websocket connections on the endpoint, which send
+// the RPC messages. The contracts are selected by the predicate header of the incoming
+// container. Check WithPredicateKey and WithCustomRPC.
+func WithWebsocketEndpoint(endpoint string) Option {
+	return func(b *bundle) {
+		b.wsEndpoint = endpoint
+	}
+}
+
+func WithCustomRPC(in kit.IncomingRPCFactory, out kit.OutgoingRPCFactory) Option {
+	return func(b *bundle) {
+		b.rpcInFactory = in
+		b.rpcOutFactory = out
+	}
+}
+
+// WithWebsocketBinaryMode writes the websocket messages as binary frames, which is
+// required by the binary RPC containers, e.g., common.SimpleOutgoingProtoRPC.
+// By default, the messages are written as text frames.
+func WithWebsocketBinaryMode() Option {
+	return func(b *bundle) {
+		b.wsBinary = true
+	}
+}
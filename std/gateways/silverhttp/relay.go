@@ -0,0 +1,83 @@
+package silverhttp
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/clubpay/ronykit/kit/utils"
+	"github.com/clubpay/ronykit/std/gateways/internal/httprelay"
+
+	"github.com/go-www/silverlining"
+)
+
+const (
+	headerConnection      = "Connection"
+	headerContentEncoding = "Content-Encoding"
+	headerUpgrade         = "Upgrade"
+	headerSecWSProtocol   = "Sec-Websocket-Protocol"
+	headerSetCookie       = "Set-Cookie"
+)
+
+var ErrContentEncodingUnsupported = httprelay.ErrContentEncodingUnsupported
+
+// serverHeaders are written by silverlining for every response, hence the ones of the
+// upstream are not copied.
+var serverHeaders = []string{
+	"Content-Length",
+	"Date",
+	"Server",
+}
+
+// requestHeader returns the headers of the request in the form which httprelay relays.
+func requestHeader(ctx *silverlining.Context) http.Header {
+	hdr := http.Header{}
+	for _, h := range ctx.RequestHeaders().List() {
+		hdr.Add(utils.B2S(h.Name), string(h.RawValue))
+	}
+
+	return hdr
+}
+
+// setResponseHeader replaces the headers of the response by the ones of the upstream, as
+// the fasthttp gateway resets the response. The server sets one value per header name,
+// hence the values of a header are joined, except the cookies, which cannot be joined and
+// are set under differently cased names.
+func (c *httpConn) setResponseHeader(header http.Header) {
+	c.resetHeader()
+
+	for k, vs := range header {
+		if len(vs) == 0 || utils.Contains(serverHeaders, http.CanonicalHeaderKey(k)) {
+			continue
+		}
+
+		if !strings.EqualFold(k, headerSetCookie) {
+			c.Set(k, strings.Join(vs, ", "))
+
+			continue
+		}
+
+		for i, v := range vs {
+			c.Set(toggleCase(headerSetCookie, i), v)
+		}
+	}
+}
+
+// toggleCase toggles the case of the letters of the name, which are selected by the bits
+// of n. Each n returns a distinct name, which is the same header for the clients.
+func toggleCase(name string, n int) string {
+	b := []byte(name)
+	for i := 0; n > 0 && i < len(b); i++ {
+		c := b[i]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			continue
+		}
+
+		if n&1 == 1 {
+			b[i] = c ^ 0x20
+		}
+
+		n >>= 1
+	}
+
+	return string(b)
+}
@@ -4,23 +4,12 @@ import (
 	"net/http"
 
 	"github.com/clubpay/ronykit/kit"
+	"github.com/clubpay/ronykit/std/gateways/internal/selector"
 )
 
 // Selector implements kit.RouteSelector and
 // also kit.RPCRouteSelector and kit.RESTRouteSelector
-type Selector struct {
-	Method    string
-	Path      string
-	Predicate string
-	Decoder   DecoderFunc
-	Encoding  kit.Encoding
-}
-
-var (
-	_ kit.RouteSelector     = (*Selector)(nil)
-	_ kit.RESTRouteSelector = (*Selector)(nil)
-	_ kit.RPCRouteSelector  = (*Selector)(nil)
-)
+type Selector = selector.Selector[DecoderFunc]
 
 // REST returns a Selector which acts on http requests.
 func REST(method, path string) Selector {
@@ -55,60 +44,34 @@ func DELETE(path string) Selector {
 	return REST(http.MethodDelete, path)
 }
 
-// RPC returns a Selector which acts on websocket requests
-func RPC(predicate string) Selector {
-	return Selector{
-		Predicate: predicate,
-	}
-}
-
-func (r Selector) GetEncoding() kit.Encoding {
-	return r.Encoding
-}
-
-func (r Selector) SetEncoding(enc kit.Encoding) Selector {
-	r.Encoding = enc
-
-	return r
-}
-
-func (r Selector) SetDecoder(f DecoderFunc) Selector {
-	r.Decoder = f
-
-	return r
-}
-
-func (r Selector) GetMethod() string {
-	return r.Method
+// SSE returns a Selector for a Server-Sent Events route. The connection implements
+// kit.Conn with Stream() == true so handlers can push multiple envelopes on one request.
+// SSE is a shortcut for SSEMethod(http.MethodGet, path).
+func SSE(path string) Selector {
+	return SSEMethod(http.MethodGet, path)
 }
 
-func (r Selector) GetPath() string {
-	return r.Path
-}
+// SSEMethod returns a streaming SSE Selector for the given HTTP method and path.
+func SSEMethod(method, path string) Selector {
+	s := REST(method, path)
+	s.Stream = true
 
-func (r Selector) GetPredicate() string {
-	return r.Predicate
+	return s
 }
 
-func (r Selector) Query(q string) any {
-	switch q {
-	case queryDecoder:
-		return r.Decoder
-	case queryMethod:
-		return r.Method
-	case queryPath:
-		return r.Path
-	case queryPredicate:
-		return r.Predicate
+// RPC returns a Selector which acts on websocket requests
+func RPC(predicate string) Selector {
+	return Selector{
+		Predicate: predicate,
 	}
-
-	return nil
 }
 
-func (r Selector) String() string {
-	if r.Predicate != "" {
-		return r.Predicate
+// RPCs is a shortcut for multiple RPC selectors
+func RPCs(predicate ...string) []kit.RouteSelector {
+	selectors := make([]kit.RouteSelector, 0, len(predicate))
+	for idx := range predicate {
+		selectors = append(selectors, RPC(predicate[idx]))
 	}
 
-	return r.Method + " " + r.Path
+	return selectors
 }
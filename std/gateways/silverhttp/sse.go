@@ -0,0 +1,33 @@
+package silverhttp
+
+import (
+	"github.com/go-www/silverlining"
+)
+
+const (
+	sseContentType  = "text/event-stream"
+	sseEventMessage = "message"
+)
+
+// setSSEHeaders sets the headers of the event stream, which is not length-delimited, hence
+// the connection is closed after the stream.
+func setSSEHeaders(ctx *silverlining.Context) {
+	resHdr := ctx.ResponseHeaders()
+	resHdr.Set(headerContentType, sseContentType)
+	resHdr.Set("Cache-Control", "no-cache")
+	resHdr.Set("X-Accel-Buffering", "no")
+	ctx.SetContentLength(-1)
+}
+
+func appendSSEEvent(b []byte, event string, data []byte) []byte {
+	if event != "" {
+		b = append(b, "event: "...)
+		b = append(b, event...)
+		b = append(b, '\n')
+	}
+
+	b = append(b, "data: "...)
+	b = append(b, data...)
+
+	return append(b, '\n', '\n')
+}
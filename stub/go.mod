@@ -10,6 +10,7 @@ require (
 	github.com/stretchr/testify v1.11.1
 	github.com/valyala/fasthttp v1.73.0
 	golang.org/x/net v0.57.0
+	google.golang.org/protobuf v1.36.11
 )
 
 require (
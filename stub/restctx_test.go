@@ -13,6 +13,8 @@ import (
 
 	"github.com/clubpay/ronykit/kit"
 	"github.com/valyala/fasthttp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 type testServer struct {
@@ -364,3 +366,55 @@ func TestRESTCtxGetBodyWriter(t *testing.T) {
 		t.Fatalf("unexpected body: %s", got)
 	}
 }
+
+func TestRESTCtxAutoRunProto(t *testing.T) {
+	srv := &fasthttp.Server{}
+	srv.Handler = func(ctx *fasthttp.RequestCtx) {
+		in := &wrapperspb.StringValue{}
+		if string(ctx.Request.Header.ContentType()) != kit.ContentTypeProto ||
+			string(ctx.Request.Header.Peek(fasthttp.HeaderAccept)) != kit.ContentTypeProto ||
+			proto.Unmarshal(ctx.PostBody(), in) != nil {
+			ctx.SetStatusCode(http.StatusBadRequest)
+
+			return
+		}
+
+		out, _ := proto.Marshal(wrapperspb.String(in.GetValue() + "-ok"))
+		ctx.Response.Header.SetContentType(kit.ContentTypeProto)
+		ctx.SetStatusCode(http.StatusOK)
+		ctx.SetBody(out)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+
+	defer func() {
+		_ = ln.Close()
+		_ = srv.Shutdown()
+	}()
+
+	res := &wrapperspb.StringValue{}
+	rest := New(ln.Addr().String()).REST().
+		SetMethod(http.MethodPost).
+		SetOKHandler(func(_ context.Context, r RESTResponse) *Error {
+			return WrapError(UnmarshalResponse(r, res))
+		}).
+		DefaultResponseHandler(func(_ context.Context, r RESTResponse) *Error {
+			return NewError(r.StatusCode(), "unexpected status")
+		}).
+		AutoRun(context.Background(), "/echo", kit.Proto, wrapperspb.String("hello"))
+	defer rest.Release()
+
+	if rest.Err() != nil {
+		t.Fatalf("unexpected error: %v", rest.Err())
+	}
+	if res.GetValue() != "hello-ok" {
+		t.Fatalf("unexpected response: %v", res)
+	}
+}
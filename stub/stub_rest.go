@@ -25,6 +25,17 @@ type RESTResponse interface {
 	GetHeader(key string) string
 }
 
+// UnmarshalResponse unmarshals the body of the response into m by the encoding of its
+// Content-Type header. If the content type is not registered in kit, JSON is used.
+func UnmarshalResponse(r RESTResponse, m kit.Message) error {
+	enc, ok := kit.EncodingByContentType(r.GetHeader("Content-Type"))
+	if !ok {
+		enc = kit.JSON
+	}
+
+	return kit.UnmarshalMessageAs(enc, r.GetBody(), m)
+}
+
 type RESTPreflightHandler func(r *fasthttp.Request)
 
 type RESTCtx struct {
@@ -485,17 +496,21 @@ func (hc *RESTCtx) DumpRequestTo(w io.Writer) *RESTCtx {
 func (hc *RESTCtx) AutoRun(
 	ctx context.Context, route string, enc kit.Encoding, m kit.Message,
 ) *RESTCtx {
-	switch enc.Tag() {
+	switch enc {
 	default:
-	case kit.JSON.Tag():
-		hc.SetHeader("Content-Type", "application/json")
-	case kit.Proto.Tag():
-		hc.SetHeader("Content-Type", "application/protobuf")
+	case kit.JSON:
+		hc.SetHeader("Content-Type", kit.ContentTypeJSON)
+	case kit.Proto, kit.MSG:
+		hc.SetHeader("Content-Type", enc.ContentType())
+		hc.SetHeader("Accept", enc.ContentType())
 	}
 
-	ref := hc.r.Load(m, enc.Tag())
+	// the params are named as the gateways name them.
+	tagKey := enc.FieldTag()
 
-	fields, ok := ref.ByTag(enc.Tag())
+	ref := hc.r.Load(m, tagKey)
+
+	fields, ok := ref.ByTag(tagKey)
 	if !ok {
 		fields = ref.Obj()
 	}
@@ -560,6 +575,13 @@ func (hc *RESTCtx) AutoRun(
 			},
 		)
 	default:
+		if enc == kit.Proto || enc == kit.MSG {
+			reqBody, _, err := kit.MarshalMessageAs(enc, m)
+			hc.SetBodyErr(reqBody, err)
+
+			break
+		}
+
 		reqBody, _ := hc.codec.Marshal(m) //nolint:errcheck
 		hc.SetBody(reqBody)
 	}
@@ -95,6 +95,32 @@ func TestGolangGenerator(t *testing.T) {
 	fmt.Println(string(files[0].Data))
 }
 
+func TestGolangGeneratorVersions(t *testing.T) {
+	svc := desc.ServiceDescFunc(func() *desc.Service {
+		contract := func(version string) *desc.Contract {
+			return desc.NewContract().
+				SetVersion(version).
+				AddRoute(desc.Route("GetUser", newREST(kit.JSON, "/users", "GET"))).
+				SetInput(&SimpleObject{}).
+				SetOutput(&SimpleObject{}).
+				SetHandler(nil)
+		}
+
+		return desc.NewService("testService").
+			AddContract(contract("v1"), contract("v1.2"))
+	})
+
+	files, err := stubgen.NewGolangEngine(stubgen.GolangConfig{PkgName: "test"}).
+		Generate(stubgen.NewInput("test", svc))
+	require.NoError(t, err)
+	require.Greater(t, len(files), 0)
+
+	code := string(files[0].Data)
+	assert.Contains(t, code, "GetUserV1(")
+	assert.Contains(t, code, "GetUserV1_2(")
+	assert.Contains(t, code, `SetHeader("Accept-Version", "v1.2")`)
+}
+
 func TestTypeScriptGenerator(t *testing.T) {
 	svc := desc.ServiceDescFunc(func() *desc.Service {
 		return desc.NewService("testService").
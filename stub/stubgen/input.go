@@ -2,6 +2,7 @@ package stubgen
 
 import (
 	"go/build"
+	"strings"
 
 	"github.com/clubpay/ronykit/kit"
 	"github.com/clubpay/ronykit/kit/desc"
@@ -45,9 +46,17 @@ func (in *Input) Tags() []string {
 }
 
 func (in *Input) addContract(c desc.ParsedContract) {
+	name := versionedName(c.Name, c.Version)
+
 	if c.Method != "" && c.Path != "" {
+		var version string
+		if !c.VersionInPath {
+			version = c.Version
+		}
+
 		in.restMethods = append(in.restMethods, RESTMethod{
-			Name:                 c.Name,
+			Name:                 name,
+			Version:              version,
 			Method:               c.Method,
 			Path:                 c.Path,
 			PathParams:           c.PathParams,
@@ -60,7 +69,8 @@ func (in *Input) addContract(c desc.ParsedContract) {
 
 	if c.Predicate != "" {
 		in.rpcMethods = append(in.rpcMethods, RPCMethod{
-			Name:      c.Name,
+			Name:      name,
+			Version:   c.Version,
 			Predicate: c.Predicate,
 			Request:   c.Request,
 			Responses: c.Responses,
@@ -69,6 +79,16 @@ func (in *Input) addContract(c desc.ParsedContract) {
 	}
 }
 
+// versionedName appends the version to the name of the method, e.g., GetUser and v1.2
+// makes GetUserV1_2, hence the different versions of a contract get distinct methods.
+func versionedName(name, version string) string {
+	if name == "" || version == "" {
+		return name
+	}
+
+	return name + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(version))
+}
+
 func (in *Input) RESTMethods() []RESTMethod {
 	return in.restMethods
 }
@@ -160,6 +180,9 @@ type RESTMethod struct {
 	Request              desc.ParsedRequest
 	Responses            []desc.ParsedResponse
 	DefaultErrorResponse *desc.ParsedResponse
+	// Version is set if the server selects the version of the contract by
+	// kit.HeaderAcceptVersion header.
+	Version string
 }
 
 func (rm *RESTMethod) HasOKResponse() bool {
@@ -200,6 +223,7 @@ type RPCMethod struct {
 	kit.OutgoingRPCContainer
 
 	Name      string
+	Version   string
 	Predicate string
 	Request   desc.ParsedRequest
 	Responses []desc.ParsedResponse
@@ -21,10 +21,11 @@ import (
 )
 
 type Generator struct {
-	tagName string
-	title   string
-	version string
-	desc    string
+	tagName    string
+	title      string
+	version    string
+	desc       string
+	apiVersion string
 }
 
 func New(title, ver, desc string) *Generator {
@@ -43,6 +44,18 @@ func (sg *Generator) WithTag(tagName string) *Generator {
 	return sg
 }
 
+// WithVersion limits the generated documents to the unversioned contracts and the
+// contracts of the given version.
+func (sg *Generator) WithVersion(version string) *Generator {
+	sg.apiVersion = version
+
+	return sg
+}
+
+func (sg *Generator) includes(c desc.ParsedContract) bool {
+	return sg.apiVersion == "" || c.Version == "" || kit.CompareVersions(c.Version, sg.apiVersion) == 0
+}
+
 func (sg *Generator) WriteSwagToFile(filename string, services ...desc.ServiceDesc) error {
 	f, err := os.Create(filename)
 	if err != nil {
@@ -79,6 +92,10 @@ func (sg *Generator) WriteSwagTo(w io.Writer, descs ...desc.ServiceDesc) error {
 		}
 
 		for _, c := range ps.Contracts {
+			if !sg.includes(c) {
+				continue
+			}
+
 			addSwagOp(swag, ps.Origin.Name, c)
 		}
 	}
@@ -169,6 +186,9 @@ func addSwagOp(swag *spec.Swagger, serviceName string, c desc.ParsedContract) {
 	}
 
 	opID := definitionName(serviceName, c.SelectorName)
+	if c.Version != "" {
+		opID += strings.ToUpper(c.Version)
+	}
 
 	op := spec.NewOperation(opID).
 		WithProduces(contentType).
@@ -238,6 +258,10 @@ func addSwagOp(swag *spec.Swagger, serviceName string, c desc.ParsedContract) {
 	restPath := fixPathForSwag(c.Path)
 	pathItem := swag.Paths.Paths[restPath]
 
+	if c.Version != "" && !c.VersionInPath {
+		op = mergeVersionedOp(pathItem, c, op)
+	}
+
 	switch strings.ToUpper(c.Method) {
 	case http.MethodGet:
 		pathItem.Get = op
@@ -287,6 +311,71 @@ func addSwagOp(swag *spec.Swagger, serviceName string, c desc.ParsedContract) {
 	swag.Paths.Paths[restPath] = pathItem
 }
 
+// mergeVersionedOp merges the versions of a contract which share the same path and
+// are selected by the kit.HeaderAcceptVersion header. The operation of the latest
+// version is kept, and the header lists all the versions.
+func mergeVersionedOp(pathItem spec.PathItem, c desc.ParsedContract, op *spec.Operation) *spec.Operation {
+	var existing *spec.Operation
+
+	switch strings.ToUpper(c.Method) {
+	case http.MethodGet:
+		existing = pathItem.Get
+	case http.MethodDelete:
+		existing = pathItem.Delete
+	case http.MethodPost:
+		existing = pathItem.Post
+	case http.MethodPut:
+		existing = pathItem.Put
+	case http.MethodPatch:
+		existing = pathItem.Patch
+	}
+
+	versions := []any{c.Version}
+	if existing != nil {
+		if p := findParam(existing, kit.HeaderAcceptVersion); p != nil {
+			versions = append(slices.Clone(p.Enum), c.Version)
+		}
+
+		if v, _ := existing.Extensions.GetString(extVersion); kit.CompareVersions(v, c.Version) > 0 {
+			op = existing
+		}
+	}
+
+	slices.SortFunc(versions, func(a, b any) int {
+		return kit.CompareVersions(fmt.Sprint(a), fmt.Sprint(b))
+	})
+
+	if op != existing {
+		op.AddExtension(extVersion, c.Version)
+	}
+
+	if p := findParam(op, kit.HeaderAcceptVersion); p != nil {
+		p.WithEnum(versions...)
+	} else {
+		op.AddParam(
+			spec.HeaderParam(kit.HeaderAcceptVersion).
+				Typed("string", "").
+				WithEnum(versions...).
+				AsOptional(),
+		)
+	}
+
+	return op
+}
+
+// extVersion is the vendor extension which keeps the version of the operation.
+const extVersion = "x-version"
+
+func findParam(op *spec.Operation, name string) *spec.Parameter {
+	for i := range op.Parameters {
+		if op.Parameters[i].In == "header" && op.Parameters[i].Name == name {
+			return &op.Parameters[i]
+		}
+	}
+
+	return nil
+}
+
 func setSwagInput(op *spec.Operation, c desc.ParsedContract) {
 	if len(c.Request.Message.Fields) == 0 {
 		return
@@ -396,6 +485,9 @@ func toSwagDefinition(svcName string, m desc.ParsedMessage) spec.Schema {
 		}
 
 		name, kind, wrapFuncChain := getWrapFunc(p, m.Meta.Fields[p.GoName])
+		if !p.Embedded && (p.Tag.Required || m.Meta.Fields[p.GoName].Required) {
+			def.AddRequired(p.Name)
+		}
 
 		switch kind {
 		default:
@@ -504,7 +596,7 @@ Loop:
 		)
 	}
 
-	if p.Tag.Optional || p.Optional || meta.Optional {
+	if (p.Tag.Optional || p.Optional || meta.Optional) && !p.Tag.Required && !meta.Required {
 		wrapFuncChain = wrapFuncChain.Add(
 			func(schema *spec.Schema) *spec.Schema {
 				spacer := ""
@@ -534,9 +626,57 @@ Loop:
 		)
 	}
 
+	p.Meta = meta
+	if rules := p.Rules(); !rules.IsEmpty() {
+		wrapFuncChain = wrapFuncChain.Add(
+			func(schema *spec.Schema) *spec.Schema {
+				setSchemaRules(schema, rules)
+
+				return schema
+			},
+		)
+	}
+
 	return name, kind, wrapFuncChain
 }
 
+// setSchemaRules sets the validation rules, except enum, on the schema. The length rules are
+// applied to the schema itself, and the others to its elements if it is an array or a map.
+func setSchemaRules(schema *spec.Schema, rules desc.FieldRules) {
+	minLen, maxLen := toInt64Ptr(rules.MinLength), toInt64Ptr(rules.MaxLength)
+
+	switch {
+	case schema.Type.Contains("array"):
+		schema.MinItems, schema.MaxItems = minLen, maxLen
+	case schema.Type.Contains("object"):
+		schema.MinProperties, schema.MaxProperties = minLen, maxLen
+	default:
+		schema.MinLength, schema.MaxLength = minLen, maxLen
+	}
+
+	for {
+		if schema.Items != nil && schema.Items.Schema != nil {
+			schema = schema.Items.Schema
+		} else if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+			schema = schema.AdditionalProperties.Schema
+		} else {
+			break
+		}
+	}
+
+	schema.Minimum = rules.Min
+	schema.Maximum = rules.Max
+	schema.Pattern = rules.Pattern
+}
+
+func toInt64Ptr(v *int) *int64 {
+	if v == nil {
+		return nil
+	}
+
+	return utils.ValPtr(int64(*v))
+}
+
 func (sg *Generator) WritePostmanToFile(filename string, services ...desc.ServiceDesc) error {
 	f, err := os.Create(filename)
 	if err != nil {
@@ -563,6 +703,10 @@ func (sg *Generator) WritePostmanTo(w io.Writer, descs ...desc.ServiceDesc) erro
 		colItems := col.AddItemGroup(ps.Origin.Name)
 
 		for _, c := range ps.Contracts {
+			if !sg.includes(c) {
+				continue
+			}
+
 			switch c.Type {
 			case desc.REST:
 				colItems.AddItem(toPostmanItem(c))
@@ -646,6 +790,16 @@ func toPostmanItem(c desc.ParsedContract) *postman.Items {
 		},
 	}
 
+	if c.Version != "" && !c.VersionInPath {
+		itm.Request.Header = append(
+			itm.Request.Header,
+			&postman.Header{
+				Key:   kit.HeaderAcceptVersion,
+				Value: c.Version,
+			},
+		)
+	}
+
 	for _, hdr := range c.Request.Headers {
 		itm.Request.Header = append(
 			itm.Request.Header,
@@ -695,15 +849,31 @@ func setSwaggerParam(p *spec.Parameter, pp desc.ParsedField) *spec.Parameter {
 		p.Typed("string", "")
 	}
 
-	if len(pp.Tag.PossibleValues) > 0 {
+	rules := pp.Rules()
+	if rules.Required {
+		p.AsRequired()
+	}
+
+	if len(rules.Enum) > 0 {
 		p.WithEnum(
 			utils.Map(
 				func(src string) any { return src },
-				pp.Tag.PossibleValues,
+				rules.Enum,
 			)...,
 		)
 	}
 
+	minLen, maxLen := toInt64Ptr(rules.MinLength), toInt64Ptr(rules.MaxLength)
+	if kind == desc.Array {
+		p.MinItems, p.MaxItems = minLen, maxLen
+	} else {
+		p.MinLength, p.MaxLength = minLen, maxLen
+	}
+
+	p.Minimum = rules.Min
+	p.Maximum = rules.Max
+	p.Pattern = rules.Pattern
+
 	return p
 }
 
@@ -1,6 +1,8 @@
 package apidoc_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"testing"
@@ -8,10 +10,12 @@ import (
 
 	"github.com/clubpay/ronykit/kit"
 	"github.com/clubpay/ronykit/kit/desc"
+	"github.com/clubpay/ronykit/kit/utils"
 	"github.com/clubpay/ronykit/std/gateways/fasthttp"
 	"github.com/clubpay/ronykit/x/apidoc"
 	"github.com/clubpay/ronykit/x/apidoc/internal/testdata/a"
 	"github.com/clubpay/ronykit/x/apidoc/internal/testdata/b"
+	"github.com/go-openapi/spec"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -269,3 +273,122 @@ func (t testService2) Desc() *desc.Service {
 				SetHandler(nil),
 		)
 }
+
+type validatedReq struct {
+	Name  string         `json:"name" swag:"required;minLen:3;maxLen:8;pattern:^[a-z]+$"`
+	Age   *int           `json:"age" swag:"required;min:18;max:120"`
+	Tags  []string       `json:"tags" swag:"maxLen:2;pattern:^#"`
+	Score float64        `json:"score"`
+	Attrs map[string]int `json:"attrs" swag:"min:1"`
+}
+
+type testService3 struct{}
+
+func (t testService3) Desc() *desc.Service {
+	return (&desc.Service{
+		Name: "testService",
+	}).
+		AddContract(
+			desc.NewContract().
+				SetName("validated").
+				AddRoute(desc.Route("", fasthttp.GET("/validated/:name"))).
+				SetInput(
+					&validatedReq{},
+					desc.WithField("Score", desc.FieldMeta{Max: utils.ValPtr(1.0)}),
+				).
+				SetOutput(kit.RawMessage{}).
+				SetHandler(nil),
+		)
+}
+
+func TestSwaggerValidationRules(t *testing.T) {
+	ps := desc.Parse(testService3{})
+
+	d := apidoc.ToSwaggerDefinition(ps.Origin.Name, ps.Contracts[0].Request.Message)
+	assert.Equal(t, []string{"name", "age"}, d.Required)
+
+	props := d.Properties.ToOrderedSchemaItems()
+	assert.Equal(t, "name", props[0].Name)
+	assert.EqualValues(t, 3, *props[0].MinLength)
+	assert.EqualValues(t, 8, *props[0].MaxLength)
+	assert.Equal(t, "^[a-z]+$", props[0].Pattern)
+	assert.Equal(t, "age", props[1].Name)
+	assert.Equal(t, 18.0, *props[1].Minimum)
+	assert.Equal(t, 120.0, *props[1].Maximum)
+	assert.Empty(t, props[1].Description)
+	assert.Equal(t, "tags", props[2].Name)
+	assert.EqualValues(t, 2, *props[2].MaxItems)
+	assert.Equal(t, "^#", props[2].Items.Schema.Pattern)
+	assert.Equal(t, "score", props[3].Name)
+	assert.Equal(t, 1.0, *props[3].Maximum)
+	assert.Equal(t, "attrs", props[4].Name)
+	assert.Equal(t, 1.0, *props[4].AdditionalProperties.Schema.Minimum)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, apidoc.New("Test3", "", "").WriteSwagTo(buf, testService3{}))
+
+	swag := spec.Swagger{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &swag))
+
+	params := swag.Paths.Paths["/validated/{name}"].Get.Parameters
+	assert.Equal(t, "name", params[0].Name)
+	assert.True(t, params[0].Required)
+	assert.EqualValues(t, 3, *params[0].MinLength)
+	assert.Equal(t, "^[a-z]+$", params[0].Pattern)
+	assert.Equal(t, "age", params[1].Name)
+	assert.True(t, params[1].Required)
+	assert.Equal(t, 18.0, *params[1].Minimum)
+}
+
+type testService4 struct{}
+
+func (t testService4) Desc() *desc.Service {
+	contract := func(version, selName string) *desc.Contract {
+		return desc.NewContract().
+			SetName("getUser").
+			SetVersion(version).
+			AddRoute(desc.Route(selName, fasthttp.GET("/users/:id"))).
+			SetInput(&validatedReq{}).
+			SetOutput(kit.RawMessage{}).
+			SetHandler(nil)
+	}
+
+	return (&desc.Service{
+		Name: "testService",
+	}).
+		AddContract(
+			contract("v2", "GetUser"),
+			contract("v1", "GetUser"),
+			contract("v10", "GetUser").Deprecate(time.Time{}),
+		)
+}
+
+func TestSwaggerVersions(t *testing.T) {
+	getOp := func(g *apidoc.Generator) *spec.Operation {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, g.WriteSwagTo(buf, testService4{}))
+
+		swag := spec.Swagger{}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &swag))
+
+		return swag.Paths.Paths["/users/{id}"].Get
+	}
+
+	op := getOp(apidoc.New("Test4", "", ""))
+	assert.Equal(t, "testService.GetUserV10", op.ID)
+	assert.True(t, op.Deprecated)
+
+	var versions []any
+	for _, p := range op.Parameters {
+		if p.Name == kit.HeaderAcceptVersion {
+			assert.Equal(t, "header", p.In)
+			assert.False(t, p.Required)
+			versions = p.Enum
+		}
+	}
+	assert.Equal(t, []any{"v1", "v2", "v10"}, versions)
+
+	op = getOp(apidoc.New("Test4", "", "").WithVersion("v2"))
+	assert.Equal(t, "testService.GetUserV2", op.ID)
+	assert.False(t, op.Deprecated)
+}
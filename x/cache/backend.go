@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+)
+
+// Backend stores the cached responses of the ResponseCache.
+type Backend interface {
+	// Get returns the value of the key, or nil if it does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// DeletePrefix deletes all the keys which start with the prefix.
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// NewLocalBackend returns a Backend which keeps the responses in c. The responses are
+// not shared with the other instances of the cluster.
+func NewLocalBackend(c *Cache) Backend {
+	return &localBackend{
+		c:    c,
+		keys: map[string]time.Time{},
+	}
+}
+
+type localBackend struct {
+	c *Cache
+
+	// ristretto cannot iterate over the keys, hence we keep them to support DeletePrefix.
+	mtx       sync.Mutex
+	keys      map[string]time.Time
+	lastSweep time.Time
+}
+
+func (b *localBackend) Get(_ context.Context, key string) ([]byte, error) {
+	v, ok := b.c.Get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	data, _ := v.([]byte) //nolint:errcheck
+
+	return data, nil
+}
+
+func (b *localBackend) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	b.c.SetTTL(key, value, ttl)
+	// make sure the value is visible to the next Get
+	b.c.c.Wait()
+
+	now := time.Now()
+
+	b.mtx.Lock()
+	b.keys[key] = now.Add(ttl)
+	if now.Sub(b.lastSweep) > time.Minute {
+		b.lastSweep = now
+		for k, expiresAt := range b.keys {
+			if expiresAt.Before(now) {
+				delete(b.keys, k)
+			}
+		}
+	}
+	b.mtx.Unlock()
+
+	return nil
+}
+
+func (b *localBackend) DeletePrefix(_ context.Context, prefix string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	for k := range b.keys {
+		if strings.HasPrefix(k, prefix) {
+			b.c.Purge(k)
+			delete(b.keys, k)
+		}
+	}
+
+	return nil
+}
+
+// NewStoreBackend returns a Backend which keeps the responses in the kit.ClusterStore, hence
+// they are shared between the instances of the cluster.
+func NewStoreBackend(cs kit.ClusterStore) Backend {
+	return &storeBackend{cs: cs}
+}
+
+type storeBackend struct {
+	cs kit.ClusterStore
+}
+
+func (b *storeBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	v, err := b.cs.Get(ctx, key)
+	if err != nil || v == "" {
+		// the stores report the missing keys differently, hence we treat all
+		// the errors as a miss.
+		return nil, nil //nolint:nilerr
+	}
+
+	return []byte(v), nil
+}
+
+func (b *storeBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return b.cs.Set(ctx, key, string(value), ttl)
+}
+
+func (b *storeBackend) DeletePrefix(ctx context.Context, prefix string) error {
+	var keys []string
+
+	err := b.cs.Scan(
+		ctx, prefix,
+		func(key string) bool {
+			// some stores return the keys with their own namespace.
+			if idx := strings.Index(key, prefix); idx > 0 {
+				key = key[idx:]
+			}
+
+			keys = append(keys, key)
+
+			return true
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err = b.cs.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
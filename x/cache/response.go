@@ -0,0 +1,400 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+)
+
+const (
+	HeaderETag         = "ETag"
+	HeaderIfNoneMatch  = "If-None-Match"
+	HeaderCacheControl = "Cache-Control"
+	// HeaderCacheStatus is set to HIT on the cached responses, and MISS on the others.
+	HeaderCacheStatus = "X-Cache"
+)
+
+type responseCacheConfig struct {
+	prefix          string
+	ttl             time.Duration
+	queryParams     []string
+	allQueryParams  bool
+	headers         []string
+	cacheControl    string
+	hasCacheControl bool
+}
+
+type ResponseCacheOption func(cfg *responseCacheConfig)
+
+// WithTTL sets how long the responses are cached. Default is 1 minute.
+func WithTTL(ttl time.Duration) ResponseCacheOption {
+	return func(cfg *responseCacheConfig) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithResponseKeyPrefix sets the prefix of the keys in the Backend. Default is "cache:resp:".
+func WithResponseKeyPrefix(prefix string) ResponseCacheOption {
+	return func(cfg *responseCacheConfig) {
+		cfg.prefix = prefix
+	}
+}
+
+// WithQueryParams sets the query params which are part of the cache key. By default,
+// all the query params are part of the key.
+func WithQueryParams(names ...string) ResponseCacheOption {
+	return func(cfg *responseCacheConfig) {
+		cfg.queryParams = names
+		cfg.allQueryParams = false
+	}
+}
+
+// WithHeaders sets the request headers which are part of the cache key, e.g., Accept-Language.
+// By default, the headers are not part of the key.
+func WithHeaders(names ...string) ResponseCacheOption {
+	return func(cfg *responseCacheConfig) {
+		cfg.headers = make([]string, 0, len(names))
+		for _, name := range names {
+			cfg.headers = append(cfg.headers, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of the responses. Default is
+// "max-age=<ttl in seconds>", and an empty value omits the header.
+func WithCacheControl(value string) ResponseCacheOption {
+	return func(cfg *responseCacheConfig) {
+		cfg.cacheControl = value
+		cfg.hasCacheControl = true
+	}
+}
+
+// ResponseCache caches the encoded responses of the REST GET and HEAD requests. The
+// responses are keyed by the contract, the method, the request path (hence the path params),
+// the query params, the selected headers, and the encoding which is negotiated by the
+// Accept header, hence they are replayed with their own Content-Type. Only the successful
+// responses with a single envelope are cached. The requests carrying credentials (i.e.,
+// Authorization or Cookie headers) are not cached, unless the header is selected by
+// WithHeaders, hence the responses are not shared between the users.
+//
+// The cache runs after the other handlers of the contract (e.g., authentication), right
+// before its main handler, and the response is captured after the modifiers of the
+// contract.
+//
+// The responses carry an ETag header, and the requests with a matching If-None-Match
+// header get 304 status code without the body. Use Invalidate or InvalidatePrefix in the
+// write handlers to drop the stale responses.
+type ResponseCache struct {
+	b   Backend
+	cfg responseCacheConfig
+}
+
+var _ kit.ContractWrapper = (*ResponseCache)(nil)
+
+func NewResponseCache(b Backend, opts ...ResponseCacheOption) *ResponseCache {
+	rc := &ResponseCache{
+		b: b,
+		cfg: responseCacheConfig{
+			prefix:         "cache:resp:",
+			ttl:            time.Minute,
+			allQueryParams: true,
+		},
+	}
+	for _, opt := range opts {
+		opt(&rc.cfg)
+	}
+
+	if !rc.cfg.hasCacheControl {
+		rc.cfg.cacheControl = "max-age=" + strconv.Itoa(int(rc.cfg.ttl/time.Second))
+	}
+
+	return rc
+}
+
+// Wrap implements kit.ContractWrapper.
+func (rc *ResponseCache) Wrap(c kit.Contract) kit.Contract {
+	return &cachedContract{
+		Contract: c,
+		h: func(ctx *kit.Context) {
+			rc.handle(ctx, c.Encoding())
+		},
+		m: rc.capture,
+	}
+}
+
+// Invalidate drops the cached responses of the request paths, e.g., "/users/42", for all
+// the contracts, query params, and headers.
+func (rc *ResponseCache) Invalidate(ctx context.Context, paths ...string) error {
+	for _, path := range paths {
+		if err := rc.b.DeletePrefix(ctx, rc.cfg.prefix+path+"|"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// InvalidatePrefix drops the cached responses of all the request paths which start with
+// the pathPrefix, e.g., "/users/". An empty pathPrefix drops all the responses.
+func (rc *ResponseCache) InvalidatePrefix(ctx context.Context, pathPrefix string) error {
+	return rc.b.DeletePrefix(ctx, rc.cfg.prefix+pathPrefix)
+}
+
+// credentialHeaders are the request headers which make the responses user specific.
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// captureKey is the key of the responseCapture in the Context.
+const captureKey = "__x_cache_capture"
+
+// headerAccept and headerContentType select the encoding of the response, as the gateways
+// negotiate it.
+const (
+	headerAccept      = "Accept"
+	headerContentType = "Content-Type"
+)
+
+type cachedResponse struct {
+	ETag       string            `json:"etag"`
+	StatusCode int               `json:"status,omitempty"`
+	Hdr        map[string]string `json:"hdr,omitempty"`
+	Msg        []byte            `json:"msg,omitempty"`
+}
+
+func (rc *ResponseCache) handle(ctx *kit.Context, contractEnc kit.Encoding) {
+	if !ctx.IsREST() {
+		return
+	}
+
+	conn := ctx.RESTConn()
+	switch conn.GetMethod() {
+	case http.MethodGet, http.MethodHead:
+	default:
+		return
+	}
+
+	for _, name := range credentialHeaders {
+		if conn.Get(name) != "" && !slices.Contains(rc.cfg.headers, name) {
+			return
+		}
+	}
+
+	def := kit.JSON
+	if contractEnc != kit.Undefined {
+		def = contractEnc
+	}
+
+	enc := kit.NegotiateEncoding(conn.Get(headerAccept), conn.Get(headerContentType), def)
+	key := rc.key(ctx, conn, enc)
+
+	data, err := rc.b.Get(ctx.Context(), key)
+	if err != nil {
+		ctx.Error(fmt.Errorf("cache: %w", err))
+	}
+
+	if len(data) > 0 {
+		res := &cachedResponse{}
+		if err = kit.UnmarshalMessage(data, res); err == nil {
+			rc.replay(ctx, conn, res)
+
+			return
+		}
+	}
+
+	rc.execute(ctx, key, enc)
+}
+
+func (rc *ResponseCache) key(ctx *kit.Context, conn kit.RESTConn, enc kit.Encoding) string {
+	var query []string
+
+	conn.WalkQueryParams(
+		func(key string, val string) bool {
+			if rc.cfg.allQueryParams || slices.Contains(rc.cfg.queryParams, key) {
+				query = append(query, key+"="+val)
+			}
+
+			return true
+		},
+	)
+	slices.Sort(query)
+
+	h := sha256.New()
+	for _, q := range query {
+		_, _ = h.Write([]byte(q))
+		_, _ = h.Write([]byte{0})
+	}
+
+	for _, name := range rc.cfg.headers {
+		_, _ = h.Write([]byte(name + ":" + conn.Get(name)))
+		_, _ = h.Write([]byte{0})
+	}
+
+	return rc.cfg.prefix + conn.GetPath() + "|" +
+		ctx.ServiceName() + "." + ctx.ContractID() + "|" +
+		conn.GetMethod() + "|" +
+		enc.Tag() + "|" +
+		hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// responseCapture holds the response of a request while the handlers run.
+type responseCapture struct {
+	enc    kit.Encoding
+	res    *cachedResponse
+	count  int
+	failed bool
+}
+
+// execute runs the rest of the handlers, and stores the response if it is cacheable.
+func (rc *ResponseCache) execute(ctx *kit.Context, key string, enc kit.Encoding) {
+	rcp := &responseCapture{enc: enc}
+	ctx.Set(captureKey, rcp)
+
+	ctx.Next()
+
+	if rcp.failed || rcp.count != 1 || rcp.res == nil {
+		return
+	}
+
+	res := rcp.res
+	res.StatusCode = ctx.GetStatusCode()
+	if res.StatusCode != 0 && res.StatusCode != http.StatusOK {
+		return
+	}
+
+	data, err := kit.MarshalMessage(res)
+	if err == nil {
+		err = rc.b.Set(ctx.Context(), key, data, rc.cfg.ttl)
+	}
+
+	if err != nil {
+		ctx.Error(fmt.Errorf("cache: %w", err))
+	}
+}
+
+// capture is the modifier which records the outgoing envelope of a cache miss. It is the
+// first modifier of the contract, hence it runs after the others and sees the final response.
+// The message is encoded by the negotiated encoding, and is sent as it is stored, hence the
+// ETag is of the body which the client gets.
+func (rc *ResponseCache) capture(e *kit.Envelope) {
+	rcp, ok := e.GetContext().Get(captureKey).(*responseCapture)
+	if !ok {
+		return
+	}
+
+	rcp.count++
+
+	msg, used, err := kit.MarshalMessageAs(rcp.enc, e.GetMsg())
+	if err != nil {
+		rcp.failed = true
+
+		return
+	}
+
+	// the raw messages are already encoded, so the handler sets their content type.
+	// The others are sent as they are stored, with the content type of their encoding,
+	// which is then replayed by the headers.
+	if _, ok := e.GetMsg().(kit.RawMessage); !ok {
+		e.SetHdr(headerContentType, used.ContentType())
+		e.SetMsg(kit.RawMessage(msg))
+	}
+
+	etag := newETag(msg)
+	e.SetHdr(HeaderETag, etag)
+	if rc.cfg.cacheControl != "" {
+		e.SetHdr(HeaderCacheControl, rc.cfg.cacheControl)
+	}
+
+	headers := map[string]string{}
+	e.WalkHdr(
+		func(key string, val string) bool {
+			headers[key] = val
+
+			return true
+		},
+	)
+	e.SetHdr(HeaderCacheStatus, "MISS")
+
+	rcp.res = &cachedResponse{
+		ETag: etag,
+		Hdr:  headers,
+		Msg:  append([]byte(nil), msg...),
+	}
+}
+
+func (rc *ResponseCache) replay(ctx *kit.Context, conn kit.RESTConn, res *cachedResponse) {
+	if etagMatch(conn.Get(HeaderIfNoneMatch), res.ETag) {
+		ctx.SetStatusCode(http.StatusNotModified)
+
+		out := ctx.Out().
+			SetHdr(HeaderETag, res.ETag).
+			SetHdr(HeaderCacheStatus, "HIT")
+		if cc, ok := res.Hdr[HeaderCacheControl]; ok {
+			out.SetHdr(HeaderCacheControl, cc)
+		}
+
+		out.SetMsg(kit.RawMessage{}).Send()
+		ctx.StopExecution()
+
+		return
+	}
+
+	if res.StatusCode != 0 {
+		ctx.SetStatusCode(res.StatusCode)
+	}
+
+	ctx.Out().
+		SetHdrMap(res.Hdr).
+		SetHdr(HeaderCacheStatus, "HIT").
+		SetMsg(kit.RawMessage(res.Msg)).
+		Send()
+	ctx.StopExecution()
+}
+
+func newETag(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatch reports whether the If-None-Match header matches the etag. The weak
+// comparison is used, as RFC 9110 requires for If-None-Match.
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+type cachedContract struct {
+	kit.Contract
+
+	h kit.HandlerFunc
+	m kit.ModifierFunc
+}
+
+func (c *cachedContract) Handlers() []kit.HandlerFunc {
+	return kit.InnerHandlers(c.Contract, c.h)
+}
+
+// Modifiers puts the capture modifier first, since the modifiers run in LIFO order.
+func (c *cachedContract) Modifiers() []kit.ModifierFunc {
+	m := make([]kit.ModifierFunc, 0, len(c.Contract.Modifiers())+1)
+	m = append(m, c.m)
+
+	return append(m, c.Contract.Modifiers()...)
+}
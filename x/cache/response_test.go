@@ -0,0 +1,401 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+)
+
+type testRESTConn struct {
+	method string
+	path   string
+	query  map[string]string
+	reqHdr map[string]string
+
+	status int
+	resHdr map[string]string
+	body   string
+}
+
+var _ kit.RESTConn = (*testRESTConn)(nil)
+
+func (c *testRESTConn) ConnID() uint64                   { return 1 }
+func (c *testRESTConn) ClientIP() string                 { return "127.0.0.1" }
+func (c *testRESTConn) Write(data []byte) (int, error)   { return len(data), nil }
+func (c *testRESTConn) Stream() bool                     { return false }
+func (c *testRESTConn) Walk(_ func(string, string) bool) {}
+func (c *testRESTConn) Get(key string) string            { return c.reqHdr[key] }
+func (c *testRESTConn) Set(key, val string)              { c.resHdr[key] = val }
+func (c *testRESTConn) Keys() []string                   { return nil }
+func (c *testRESTConn) GetMethod() string                { return c.method }
+func (c *testRESTConn) GetHost() string                  { return "" }
+func (c *testRESTConn) GetRequestURI() string            { return c.path }
+func (c *testRESTConn) GetPath() string                  { return c.path }
+func (c *testRESTConn) SetStatusCode(code int)           { c.status = code }
+func (c *testRESTConn) Redirect(_ int, _ string)         {}
+
+func (c *testRESTConn) WalkQueryParams(f func(key string, val string) bool) {
+	for k, v := range c.query {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+func (c *testRESTConn) WriteEnvelope(e *kit.Envelope) error {
+	e.WalkHdr(
+		func(key string, val string) bool {
+			c.resHdr[key] = val
+
+			return true
+		},
+	)
+
+	// the response is encoded as the gateways negotiate it.
+	data, _, err := kit.MarshalMessageAs(kit.NegotiateEncoding(c.reqHdr["Accept"], "", kit.JSON), e.GetMsg())
+	c.body = string(data)
+
+	return err
+}
+
+type testContract struct {
+	kit.Contract
+
+	h []kit.HandlerFunc
+	m []kit.ModifierFunc
+}
+
+func (c *testContract) Encoding() kit.Encoding        { return kit.JSON }
+func (c *testContract) Handlers() []kit.HandlerFunc   { return c.h }
+func (c *testContract) Modifiers() []kit.ModifierFunc { return c.m }
+
+// runContract runs the contract as the EdgeServer does: the modifiers are added before the
+// handlers.
+func runContract(c kit.Contract, conn kit.Conn) error {
+	h := make([]kit.HandlerFunc, 0, len(c.Handlers())+1)
+	h = append(h, func(ctx *kit.Context) { ctx.AddModifier(c.Modifiers()...) })
+
+	return kit.NewTestContext().
+		SetHandler(append(h, c.Handlers()...)...).
+		RunWithConn(conn)
+}
+
+type memStore struct {
+	kit.ClusterStore
+
+	mtx sync.Mutex
+	kv  map[string]string
+}
+
+func (s *memStore) Get(_ context.Context, key string) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.kv[key], nil
+}
+
+func (s *memStore) Set(_ context.Context, key, value string, _ time.Duration) error {
+	s.mtx.Lock()
+	s.kv[key] = value
+	s.mtx.Unlock()
+
+	return nil
+}
+
+func (s *memStore) Delete(_ context.Context, key string) error {
+	s.mtx.Lock()
+	delete(s.kv, key)
+	s.mtx.Unlock()
+
+	return nil
+}
+
+func (s *memStore) Scan(_ context.Context, prefix string, cb func(string) bool) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for k := range s.kv {
+		if len(k) >= len(prefix) && k[:len(prefix)] == prefix && !cb(k) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func TestResponseCache(t *testing.T) {
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("new cache error: %v", err)
+	}
+
+	for name, b := range map[string]Backend{
+		"local": NewLocalBackend(c),
+		"store": NewStoreBackend(&memStore{kv: map[string]string{}}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			testResponseCache(t, b)
+		})
+	}
+}
+
+func testResponseCache(t *testing.T, b Backend) {
+	t.Helper()
+
+	calls := 0
+	rc := NewResponseCache(b, WithTTL(time.Minute), WithQueryParams("page"), WithHeaders("accept-language"))
+	c := rc.Wrap(&testContract{
+		h: []kit.HandlerFunc{
+			func(ctx *kit.Context) {
+				calls++
+				ctx.Out().SetMsg(kit.RawMessage(`{"n":1}`)).Send()
+			},
+		},
+	})
+
+	run := func(method, path string, query, hdr map[string]string) *testRESTConn {
+		conn := &testRESTConn{
+			method: method,
+			path:   path,
+			query:  query,
+			reqHdr: hdr,
+			resHdr: map[string]string{},
+		}
+
+		if err := runContract(c, conn); err != nil {
+			t.Fatal(err)
+		}
+
+		return conn
+	}
+
+	res := run(http.MethodGet, "/users/1", nil, nil)
+	etag := res.resHdr[HeaderETag]
+	if calls != 1 || res.body != `{"n":1}` || etag == "" || res.resHdr[HeaderCacheStatus] != "MISS" {
+		t.Fatalf("unexpected response: %d, %+v", calls, res)
+	}
+	if res.resHdr[HeaderCacheControl] != "max-age=60" {
+		t.Fatalf("unexpected cache control: %s", res.resHdr[HeaderCacheControl])
+	}
+
+	// the unselected query params are not part of the key
+	res = run(http.MethodGet, "/users/1", map[string]string{"debug": "1"}, nil)
+	if calls != 1 || res.body != `{"n":1}` || res.resHdr[HeaderCacheStatus] != "HIT" || res.resHdr[HeaderETag] != etag {
+		t.Fatalf("expected cached response: %d, %+v", calls, res)
+	}
+
+	res = run(http.MethodGet, "/users/1", nil, map[string]string{HeaderIfNoneMatch: "W/" + etag})
+	if calls != 1 || res.status != http.StatusNotModified || res.body != "" || res.resHdr[HeaderETag] != etag {
+		t.Fatalf("expected not modified response: %d, %+v", calls, res)
+	}
+
+	run(http.MethodGet, "/users/1", map[string]string{"page": "2"}, nil)
+	run(http.MethodGet, "/users/1", nil, map[string]string{"Accept-Language": "fa"})
+	run(http.MethodGet, "/users/2", nil, nil)
+	run(http.MethodPost, "/users/1", nil, nil)
+	run(http.MethodHead, "/users/1", nil, nil)
+	if calls != 6 {
+		t.Fatalf("unexpected handler calls: %d", calls)
+	}
+
+	if err := rc.Invalidate(context.Background(), "/users/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	run(http.MethodGet, "/users/1", nil, nil)
+	run(http.MethodGet, "/users/2", nil, nil)
+	if calls != 7 {
+		t.Fatalf("unexpected handler calls after invalidate: %d", calls)
+	}
+
+	if err := rc.InvalidatePrefix(context.Background(), "/users/"); err != nil {
+		t.Fatal(err)
+	}
+
+	run(http.MethodGet, "/users/2", nil, nil)
+	if calls != 8 {
+		t.Fatalf("unexpected handler calls after invalidate prefix: %d", calls)
+	}
+}
+
+func TestResponseCacheSkipsErrors(t *testing.T) {
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("new cache error: %v", err)
+	}
+
+	calls := 0
+	rc := NewResponseCache(NewLocalBackend(c), WithCacheControl(""))
+	wc := rc.Wrap(&testContract{
+		h: []kit.HandlerFunc{
+			func(ctx *kit.Context) {
+				calls++
+				ctx.SetStatusCode(http.StatusNotFound)
+				ctx.Out().SetMsg(kit.NewError(http.StatusNotFound, "NOT_FOUND")).Send()
+			},
+		},
+	})
+
+	for range 2 {
+		conn := &testRESTConn{method: http.MethodGet, path: "/missing", resHdr: map[string]string{}}
+		if err = runContract(wc, conn); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := conn.resHdr[HeaderCacheControl]; ok {
+			t.Fatal("unexpected cache control header")
+		}
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected the error responses not to be cached: %d", calls)
+	}
+}
+
+func TestResponseCacheAfterAuth(t *testing.T) {
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("new cache error: %v", err)
+	}
+
+	calls := 0
+	rc := NewResponseCache(NewLocalBackend(c))
+	wc := rc.Wrap(&testContract{
+		h: []kit.HandlerFunc{
+			func(ctx *kit.Context) {
+				if ctx.Conn().Get("X-Api-Key") != "secret" {
+					ctx.SetStatusCode(http.StatusUnauthorized)
+					ctx.Out().SetMsg(kit.NewError(http.StatusUnauthorized, "UNAUTHORIZED")).Send()
+					ctx.StopExecution()
+				}
+			},
+			func(ctx *kit.Context) {
+				calls++
+				ctx.Out().SetMsg(kit.RawMessage(`{"n":1}`)).Send()
+			},
+		},
+		m: []kit.ModifierFunc{
+			func(e *kit.Envelope) {
+				e.SetHdr("X-Modified", "1")
+				e.SetMsg(kit.RawMessage(`{"n":2}`))
+			},
+		},
+	})
+
+	run := func(hdr map[string]string) *testRESTConn {
+		conn := &testRESTConn{method: http.MethodGet, path: "/users/1", reqHdr: hdr, resHdr: map[string]string{}}
+		if err := runContract(wc, conn); err != nil {
+			t.Fatal(err)
+		}
+
+		return conn
+	}
+
+	res := run(map[string]string{"X-Api-Key": "secret"})
+	if calls != 1 || res.body != `{"n":2}` || res.resHdr[HeaderCacheStatus] != "MISS" {
+		t.Fatalf("unexpected response: %d, %+v", calls, res)
+	}
+
+	// the cached response is the one written to the client, after the contract's modifiers.
+	res = run(map[string]string{"X-Api-Key": "secret"})
+	if calls != 1 || res.body != `{"n":2}` || res.resHdr["X-Modified"] != "1" ||
+		res.resHdr[HeaderCacheStatus] != "HIT" {
+		t.Fatalf("expected the modified response from cache: %d, %+v", calls, res)
+	}
+
+	res = run(nil)
+	if res.status != http.StatusUnauthorized || res.resHdr[HeaderCacheStatus] == "HIT" {
+		t.Fatalf("expected the cache to run after auth: %+v", res)
+	}
+
+	// the requests with credentials are not cached
+	for range 2 {
+		run(map[string]string{"X-Api-Key": "secret", "Authorization": "Bearer t"})
+		run(map[string]string{"X-Api-Key": "secret", "Cookie": "sid=1"})
+	}
+	if calls != 5 {
+		t.Fatalf("expected the requests with credentials not to be cached: %d", calls)
+	}
+}
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestResponseCacheEncoding(t *testing.T) {
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("new cache error: %v", err)
+	}
+
+	calls := 0
+	rc := NewResponseCache(NewLocalBackend(c))
+	wc := rc.Wrap(&testContract{
+		h: []kit.HandlerFunc{
+			func(ctx *kit.Context) {
+				calls++
+				ctx.Out().SetMsg(&user{ID: 1, Name: "a"}).Send()
+			},
+		},
+	})
+
+	run := func(accept string) *testRESTConn {
+		conn := &testRESTConn{
+			method: http.MethodGet,
+			path:   "/users/1",
+			reqHdr: map[string]string{"Accept": accept},
+			resHdr: map[string]string{},
+		}
+		if err := runContract(wc, conn); err != nil {
+			t.Fatal(err)
+		}
+
+		return conn
+	}
+
+	mp := kit.MSG.ContentType()
+	miss := run(mp)
+	hit := run(mp)
+	if calls != 1 || hit.resHdr[HeaderCacheStatus] != "HIT" || hit.resHdr["Content-Type"] != mp ||
+		hit.body != miss.body || hit.resHdr[HeaderETag] != newETag([]byte(miss.body)) {
+		t.Fatalf("expected the msgpack response from cache: %d, %+v", calls, hit)
+	}
+
+	// the other encodings are cached apart.
+	res := run("application/json")
+	if calls != 2 || res.resHdr[HeaderCacheStatus] != "MISS" || res.body != `{"id":1,"name":"a"}` ||
+		res.resHdr["Content-Type"] != "application/json" {
+		t.Fatalf("expected the JSON response: %d, %+v", calls, res)
+	}
+}
+
+func TestResponseCacheHeadersOption(t *testing.T) {
+	names := []string{"accept-language"}
+	NewResponseCache(NewLocalBackend(nil), WithHeaders(names...))
+
+	if names[0] != "accept-language" {
+		t.Fatalf("expected the names to be kept: %v", names)
+	}
+}
+
+func TestETagMatch(t *testing.T) {
+	for _, tc := range []struct {
+		hdr   string
+		match bool
+	}{
+		{"", false},
+		{`"a"`, true},
+		{`W/"a"`, true},
+		{`"b", "a"`, true},
+		{`"b"`, false},
+		{"*", true},
+	} {
+		if etagMatch(tc.hdr, `"a"`) != tc.match {
+			t.Fatalf("unexpected result for %q", tc.hdr)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Backend is the storage of the rate limiter. Limiter keeps the state in Redis
+// which is shared between instances, and MemoryLimiter keeps it in the process memory.
+type Backend interface {
+	AllowN(ctx context.Context, key string, limit Limit, n int) (*Result, error)
+	AllowAtMost(ctx context.Context, key string, limit Limit, n int) (*Result, error)
+	Reset(ctx context.Context, key string) error
+}
+
+var (
+	_ Backend = (*Limiter)(nil)
+	_ Backend = (*MemoryLimiter)(nil)
+)
+
+const (
+	memoryGCPeriod = time.Minute
+	// memoryEpsilon absorbs the rounding errors of the float arithmetic, otherwise an
+	// exact remaining, e.g., 2, could be computed as 1.999… and truncated to 1.
+	memoryEpsilon = 1e-9
+)
+
+// MemoryLimiter runs the same GCRA algorithm as Limiter, but keeps the state in the
+// process memory. It is useful for single-node deployments and tests.
+type MemoryLimiter struct {
+	mtx    sync.Mutex
+	base   time.Time
+	tat    map[string]float64
+	lastGC float64
+}
+
+// NewMemoryLimiter returns a new MemoryLimiter.
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		base: time.Now(),
+		tat:  map[string]float64{},
+	}
+}
+
+// Allow is a shortcut for AllowN(ctx, key, limit, 1).
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	return l.AllowN(ctx, key, limit, 1)
+}
+
+// AllowN reports whether n events may happen at time now.
+func (l *MemoryLimiter) AllowN(_ context.Context, key string, limit Limit, n int) (*Result, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	emissionInterval := limit.Period.Seconds() / float64(limit.Rate)
+	burstOffset := emissionInterval * float64(limit.Burst)
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.now()
+	tat := l.getTAT(key, now)
+
+	newTAT := tat + emissionInterval*float64(n)
+	diff := now - (newTAT - burstOffset) + memoryEpsilon
+	remaining := diff / emissionInterval
+
+	if remaining < 0 {
+		return &Result{
+			Limit:      limit,
+			Allowed:    0,
+			Remaining:  0,
+			RetryAfter: dur(-diff),
+			ResetAfter: dur(tat - now),
+		}, nil
+	}
+
+	resetAfter := newTAT - now
+	if resetAfter > 0 {
+		l.tat[key] = newTAT
+	}
+
+	return &Result{
+		Limit:      limit,
+		Allowed:    n,
+		Remaining:  int(remaining),
+		RetryAfter: -1,
+		ResetAfter: dur(resetAfter),
+	}, nil
+}
+
+// AllowAtMost reports whether at most n events may happen at time now.
+// It returns the number of allowed events that is less than or equal to n.
+func (l *MemoryLimiter) AllowAtMost(_ context.Context, key string, limit Limit, n int) (*Result, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	emissionInterval := limit.Period.Seconds() / float64(limit.Rate)
+	burstOffset := emissionInterval * float64(limit.Burst)
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.now()
+	tat := l.getTAT(key, now)
+
+	diff := now - (tat - burstOffset) + memoryEpsilon
+	remaining := diff / emissionInterval
+
+	if remaining < 1 {
+		return &Result{
+			Limit:      limit,
+			Allowed:    0,
+			Remaining:  0,
+			RetryAfter: dur(emissionInterval - diff),
+			ResetAfter: dur(tat - now),
+		}, nil
+	}
+
+	cost := float64(n)
+	if remaining < cost {
+		cost = math.Floor(remaining)
+		remaining = 0
+	} else {
+		remaining -= cost
+	}
+
+	newTAT := tat + emissionInterval*cost
+
+	resetAfter := newTAT - now
+	if resetAfter > 0 {
+		l.tat[key] = newTAT
+	}
+
+	return &Result{
+		Limit:      limit,
+		Allowed:    int(cost),
+		Remaining:  int(remaining),
+		RetryAfter: -1,
+		ResetAfter: dur(resetAfter),
+	}, nil
+}
+
+// Reset gets a key and reset all limitations and previous usages
+func (l *MemoryLimiter) Reset(_ context.Context, key string) error {
+	l.mtx.Lock()
+	delete(l.tat, key)
+	l.mtx.Unlock()
+
+	return nil
+}
+
+// now returns the seconds since the creation of the limiter, and removes the
+// expired keys periodically. The caller must hold the lock.
+func (l *MemoryLimiter) now() float64 {
+	now := time.Since(l.base).Seconds()
+	if now-l.lastGC < memoryGCPeriod.Seconds() {
+		return now
+	}
+
+	l.lastGC = now
+	for k, tat := range l.tat {
+		if tat <= now {
+			delete(l.tat, k)
+		}
+	}
+
+	return now
+}
+
+func (l *MemoryLimiter) getTAT(key string, now float64) float64 {
+	tat, ok := l.tat[key]
+	if !ok {
+		return now
+	}
+
+	return math.Max(tat, now)
+}
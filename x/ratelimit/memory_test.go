@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiterAllowN(t *testing.T) {
+	l := NewMemoryLimiter()
+	limit := PerMinute(3)
+
+	for i := 2; i >= 0; i-- {
+		res, err := l.Allow(t.Context(), "k", limit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.Allowed != 1 || res.Remaining != i || res.RetryAfter != -1 {
+			t.Fatalf("unexpected result: %+v", res)
+		}
+	}
+
+	res, err := l.Allow(t.Context(), "k", limit)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Allowed != 0 || res.Remaining != 0 {
+		t.Fatalf("expected to be limited: %+v", res)
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > 20*time.Second {
+		t.Fatalf("unexpected retry after: %v", res.RetryAfter)
+	}
+	if res.ResetAfter <= 40*time.Second || res.ResetAfter > time.Minute {
+		t.Fatalf("unexpected reset after: %v", res.ResetAfter)
+	}
+
+	// other keys are not affected
+	if res, _ = l.Allow(t.Context(), "other", limit); res.Allowed != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	if err = l.Reset(t.Context(), "k"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res, _ = l.Allow(t.Context(), "k", limit); res.Allowed != 1 || res.Remaining != 2 {
+		t.Fatalf("unexpected result after reset: %+v", res)
+	}
+}
+
+func TestMemoryLimiterAllowAtMost(t *testing.T) {
+	l := NewMemoryLimiter()
+	limit := PerMinute(5)
+
+	res, err := l.AllowAtMost(t.Context(), "k", limit, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Allowed != 3 || res.Remaining != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	res, _ = l.AllowAtMost(t.Context(), "k", limit, 3)
+	if res.Allowed != 2 || res.Remaining != 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	res, _ = l.AllowAtMost(t.Context(), "k", limit, 1)
+	if res.Allowed != 0 || res.RetryAfter <= 0 {
+		t.Fatalf("expected to be limited: %+v", res)
+	}
+}
+
+func TestMemoryLimiterRefill(t *testing.T) {
+	l := NewMemoryLimiter()
+	limit := Limit{Rate: 1, Burst: 1, Period: 20 * time.Millisecond}
+
+	if res, _ := l.Allow(t.Context(), "k", limit); res.Allowed != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	res, _ := l.Allow(t.Context(), "k", limit)
+	if res.Allowed != 0 {
+		t.Fatalf("expected to be limited: %+v", res)
+	}
+
+	time.Sleep(res.RetryAfter + time.Millisecond)
+
+	if res, _ = l.Allow(t.Context(), "k", limit); res.Allowed != 1 {
+		t.Fatalf("expected to be refilled: %+v", res)
+	}
+}
+
+func TestMemoryLimiterInvalidLimit(t *testing.T) {
+	l := NewMemoryLimiter()
+
+	for _, limit := range []Limit{PerSecond(0), PerMinute(-1), {Rate: 1, Burst: 1}} {
+		if _, err := l.Allow(t.Context(), "k", limit); !errors.Is(err, ErrInvalidLimit) {
+			t.Fatalf("AllowN(%s): expected ErrInvalidLimit, got %v", limit, err)
+		}
+		if _, err := l.AllowAtMost(t.Context(), "k", limit, 1); !errors.Is(err, ErrInvalidLimit) {
+			t.Fatalf("AllowAtMost(%s): expected ErrInvalidLimit, got %v", limit, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/clubpay/ronykit/kit"
+)
+
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+const (
+	HeaderLimit      = "RateLimit-Limit"
+	HeaderRemaining  = "RateLimit-Remaining"
+	HeaderReset      = "RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+)
+
+// ExceededError is recorded by kit.Context.Error, when Middleware rejects a request.
+// It wraps ErrLimitExceeded.
+type ExceededError struct {
+	Key    string
+	Result *Result
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%v: key=%s, limit=%s, retry after %s", ErrLimitExceeded, e.Key, e.Result.Limit, e.Result.RetryAfter)
+}
+
+func (e *ExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// KeyFunc extracts the key of the request which the limit is applied to. If it returns
+// an empty string, the request is not limited.
+type KeyFunc func(ctx *kit.LimitedContext) string
+
+// ByClientIP limits the requests per client IP.
+func ByClientIP() KeyFunc {
+	return func(ctx *kit.LimitedContext) string {
+		return ctx.Conn().ClientIP()
+	}
+}
+
+// ByConnID limits the requests per connection. It is useful for stream connections.
+func ByConnID() KeyFunc {
+	return func(ctx *kit.LimitedContext) string {
+		return strconv.FormatUint(ctx.Conn().ConnID(), 10)
+	}
+}
+
+// ByHeader limits the requests per value of the header. It first looks into the headers
+// of the incoming envelope, and then the connection's (e.g., HTTP headers).
+func ByHeader(name string) KeyFunc {
+	return func(ctx *kit.LimitedContext) string {
+		if v := ctx.In().GetHdr(name); v != "" {
+			return v
+		}
+
+		return ctx.Conn().Get(name)
+	}
+}
+
+type middlewareConfig struct {
+	key    KeyFunc
+	prefix string
+	cost   int
+	msg    kit.Message
+}
+
+type MiddlewareOption func(cfg *middlewareConfig)
+
+// WithKey sets the KeyFunc of the Middleware. Default is ByClientIP.
+func WithKey(f KeyFunc) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.key = f
+	}
+}
+
+// WithKeyPrefix sets the prefix of the keys in the Backend. Default is "ratelimit:".
+func WithKeyPrefix(prefix string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.prefix = prefix
+	}
+}
+
+// WithCost sets the number of events that each request consumes. Default is 1.
+func WithCost(n int) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.cost = n
+	}
+}
+
+// WithExceededMessage sets the message which is sent to the client when the request is
+// rejected. Default is a kit.Error with 429 code.
+func WithExceededMessage(msg kit.Message) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.msg = msg
+	}
+}
+
+// Middleware limits the requests by the Backend. It could be used as a global handler
+// (Handler), which shares the limit between all the contracts, or as a kit.ContractWrapper
+// (Wrap), which applies the limit per contract.
+// If the Backend fails, the request is allowed, and the error is recorded by kit.Context.Error.
+type Middleware struct {
+	b     Backend
+	limit Limit
+	cfg   middlewareConfig
+}
+
+var _ kit.ContractWrapper = (*Middleware)(nil)
+
+// NewMiddleware returns a new Middleware. It panics if the limit is not valid, check Limit.Validate.
+func NewMiddleware(b Backend, limit Limit, opts ...MiddlewareOption) *Middleware {
+	if err := limit.Validate(); err != nil {
+		panic(err)
+	}
+
+	m := &Middleware{
+		b:     b,
+		limit: limit,
+		cfg: middlewareConfig{
+			key:    ByClientIP(),
+			prefix: "ratelimit:",
+			cost:   1,
+			msg:    kit.NewError(http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED"),
+		},
+	}
+	for _, opt := range opts {
+		opt(&m.cfg)
+	}
+
+	return m
+}
+
+// Handler returns a kit.HandlerFunc which shares the limit between all the contracts.
+func (m *Middleware) Handler() kit.HandlerFunc {
+	return func(ctx *kit.Context) {
+		m.handle(ctx, "")
+	}
+}
+
+// Wrap implements kit.ContractWrapper. The limit is applied per contract.
+func (m *Middleware) Wrap(c kit.Contract) kit.Contract {
+	return &limitedContract{
+		Contract: c,
+		h: func(ctx *kit.Context) {
+			m.handle(ctx, ctx.ServiceName()+"."+ctx.ContractID()+":")
+		},
+	}
+}
+
+func (m *Middleware) handle(ctx *kit.Context, scope string) {
+	key := m.cfg.key(ctx.Limited())
+	if key == "" {
+		return
+	}
+
+	key = m.cfg.prefix + scope + key
+
+	res, err := m.b.AllowN(ctx.Context(), key, m.limit, m.cfg.cost)
+	if err != nil {
+		ctx.Error(fmt.Errorf("ratelimit: %w", err))
+
+		return
+	}
+
+	if ctx.IsREST() {
+		// the limit is the configured rate, the burst only lets the quota be spent at once.
+		ctx.PresetHdr(HeaderLimit, strconv.Itoa(m.limit.Rate))
+		ctx.PresetHdr(HeaderRemaining, strconv.Itoa(res.Remaining))
+		ctx.PresetHdr(HeaderReset, seconds(res.ResetAfter))
+	}
+
+	if res.Allowed > 0 {
+		return
+	}
+
+	if ctx.IsREST() {
+		ctx.PresetHdr(HeaderRetryAfter, seconds(res.RetryAfter))
+	}
+
+	ctx.Error(&ExceededError{Key: key, Result: res})
+	ctx.SetStatusCode(http.StatusTooManyRequests)
+	ctx.Out().SetMsg(m.cfg.msg).Send()
+	ctx.StopExecution()
+}
+
+// seconds formats d in seconds, rounded up, as the rate limit headers expect.
+func seconds(d time.Duration) string {
+	if d < 0 {
+		return "0"
+	}
+
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+type limitedContract struct {
+	kit.Contract
+
+	h kit.HandlerFunc
+}
+
+func (c *limitedContract) Handlers() []kit.HandlerFunc {
+	h := make([]kit.HandlerFunc, 0, len(c.Contract.Handlers())+1)
+	h = append(h, c.h)
+	h = append(h, c.Contract.Handlers()...)
+
+	return h
+}
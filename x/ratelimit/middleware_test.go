@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/clubpay/ronykit/kit"
+)
+
+type failingBackend struct {
+	*MemoryLimiter
+}
+
+var errBackend = errors.New("backend is down")
+
+func (*failingBackend) AllowN(context.Context, string, Limit, int) (*Result, error) {
+	return nil, errBackend
+}
+
+func runLimited(t *testing.T, h kit.HandlerFunc, hdr kit.EnvelopeHdr) *kit.Envelope {
+	t.Helper()
+
+	var out *kit.Envelope
+
+	err := kit.NewTestContext().
+		SetClientIP("1.2.3.4").
+		SetHandler(h, func(ctx *kit.Context) {
+			ctx.Out().SetMsg(kit.RawMessage("ok")).Send()
+		}).
+		Input(kit.RawMessage{}, hdr).
+		Receiver(func(envelopes ...*kit.Envelope) error {
+			if len(envelopes) != 1 {
+				t.Fatalf("expected one envelope, got: %d", len(envelopes))
+			}
+
+			out = envelopes[0]
+
+			return nil
+		}).
+		RunREST()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return out
+}
+
+func TestMiddlewareHandler(t *testing.T) {
+	m := NewMiddleware(NewMemoryLimiter(), PerMinute(2))
+
+	for _, remaining := range []string{"1", "0"} {
+		out := runLimited(t, m.Handler(), nil)
+		if _, ok := out.GetMsg().(kit.RawMessage); !ok {
+			t.Fatalf("unexpected message: %#v", out.GetMsg())
+		}
+		if out.GetHdr(HeaderLimit) != "2" || out.GetHdr(HeaderRemaining) != remaining {
+			t.Fatalf("unexpected headers: %s, %s", out.GetHdr(HeaderLimit), out.GetHdr(HeaderRemaining))
+		}
+		if out.GetHdr(HeaderRetryAfter) != "" {
+			t.Fatalf("unexpected retry after: %s", out.GetHdr(HeaderRetryAfter))
+		}
+	}
+
+	out := runLimited(t, m.Handler(), nil)
+	if msg, ok := out.GetMsg().(*kit.Error); !ok || msg.Code != 429 {
+		t.Fatalf("unexpected message: %#v", out.GetMsg())
+	}
+	if out.GetHdr(HeaderRetryAfter) != "30" || out.GetHdr(HeaderReset) != "60" {
+		t.Fatalf("unexpected headers: %s, %s", out.GetHdr(HeaderRetryAfter), out.GetHdr(HeaderReset))
+	}
+}
+
+func TestMiddlewareLimitHeader(t *testing.T) {
+	limit := PerMinute(2)
+	limit.Burst = 5
+
+	out := runLimited(t, NewMiddleware(NewMemoryLimiter(), limit).Handler(), nil)
+	if out.GetHdr(HeaderLimit) != "2" || out.GetHdr(HeaderRemaining) != "4" {
+		t.Fatalf("unexpected headers: %s, %s", out.GetHdr(HeaderLimit), out.GetHdr(HeaderRemaining))
+	}
+}
+
+func TestMiddlewareByHeader(t *testing.T) {
+	m := NewMiddleware(NewMemoryLimiter(), PerMinute(1), WithKey(ByHeader("X-Api-Key")))
+
+	runLimited(t, m.Handler(), kit.EnvelopeHdr{"X-Api-Key": "a"})
+	runLimited(t, m.Handler(), kit.EnvelopeHdr{"X-Api-Key": "b"})
+
+	// requests without the header are not limited
+	runLimited(t, m.Handler(), nil)
+	runLimited(t, m.Handler(), nil)
+
+	out := runLimited(t, m.Handler(), kit.EnvelopeHdr{"X-Api-Key": "a"})
+	if _, ok := out.GetMsg().(*kit.Error); !ok {
+		t.Fatalf("expected to be limited: %#v", out.GetMsg())
+	}
+}
+
+func TestMiddlewareFailOpen(t *testing.T) {
+	m := NewMiddleware(&failingBackend{MemoryLimiter: NewMemoryLimiter()}, PerMinute(1))
+
+	for range 2 {
+		out := runLimited(t, m.Handler(), nil)
+		if _, ok := out.GetMsg().(kit.RawMessage); !ok {
+			t.Fatalf("unexpected message: %#v", out.GetMsg())
+		}
+	}
+}
+
+func TestExceededError(t *testing.T) {
+	err := error(&ExceededError{Key: "k", Result: &Result{Limit: PerSecond(1), RetryAfter: 1}})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded, got: %v", err)
+	}
+
+	var c kit.Contract = &limitedContract{h: func(*kit.Context) {}, Contract: nilContract{}}
+	if len(c.Handlers()) != 2 {
+		t.Fatalf("expected the limiter to be prepended: %d", len(c.Handlers()))
+	}
+}
+
+type nilContract struct {
+	kit.Contract
+}
+
+func (nilContract) Handlers() []kit.HandlerFunc {
+	return []kit.HandlerFunc{func(*kit.Context) {}}
+}
+
+func TestNewMiddlewareInvalidLimit(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrInvalidLimit) {
+			t.Fatalf("expected ErrInvalidLimit panic, got %v", err)
+		}
+	}()
+
+	NewMiddleware(NewMemoryLimiter(), PerSecond(0))
+}
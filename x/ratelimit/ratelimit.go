@@ -2,6 +2,7 @@ package ratelimit
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -9,6 +10,9 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrInvalidLimit is returned by the backends, when the Limit has no positive Rate or Period.
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
 type Limit struct {
 	Rate   int
 	Burst  int
@@ -23,6 +27,16 @@ func (l Limit) IsZero() bool {
 	return l == Limit{}
 }
 
+// Validate returns ErrInvalidLimit if the Rate or the Period is not positive, since no
+// emission interval could be derived from it.
+func (l Limit) Validate() error {
+	if l.Rate <= 0 || l.Period <= 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidLimit, l)
+	}
+
+	return nil
+}
+
 func fmtDur(d time.Duration) string {
 	switch d {
 	case time.Second:
@@ -86,6 +100,10 @@ func (l Limiter) AllowN(
 	limit Limit,
 	n int,
 ) (*Result, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
 	values := []any{limit.Burst, limit.Rate, limit.Period.Seconds(), n}
 
 	v, err := luaAllowN.Run(ctx, l.rdb, []string{key}, values...).Result()
@@ -109,6 +127,10 @@ func (l Limiter) AllowAtMost(
 	limit Limit,
 	n int,
 ) (*Result, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
 	values := []any{limit.Burst, limit.Rate, limit.Period.Seconds(), n}
 
 	v, err := luaAllowAtMost.Run(ctx, l.rdb, []string{key}, values...).Result()